@@ -0,0 +1,10 @@
+-- Per-weekday calorie cycling schedule (e.g. higher budget on training days,
+-- lower on rest days). Shape: [{ weekday, label, calorie_budget, protein_target_g,
+-- carbs_target_g, fat_target_g }] where weekday is 0=Mon … 6=Sun and any
+-- null target inherits the base value from calorie_log_user_settings.
+-- NULL = no schedule; every day uses the base budget.
+ALTER TABLE calorie_log_user_settings ADD COLUMN budget_schedule JSONB;
+
+-- Snapshot of the schedule that was in effect up to valid_until. NULL means no
+-- schedule was active (rows written before this column existed had none).
+ALTER TABLE calorie_config_history ADD COLUMN budget_schedule JSONB;
@@ -0,0 +1,29 @@
+-- Macro target modes. Each macro is derived one of three ways:
+--   grams    — fixed *_target_g (the original behaviour)
+--   percent  — *_target_value percent of the day's calorie budget
+--   g_per_lb — *_target_value grams per lb of body weight (latest weight_log entry)
+-- For non-gram modes *_target_g holds the most recently resolved value so older
+-- clients still see a sensible number; the API resolves per day dynamically.
+ALTER TABLE calorie_log_user_settings
+  ADD COLUMN protein_target_mode  varchar(10) NOT NULL DEFAULT 'grams'
+    CHECK (protein_target_mode IN ('grams', 'percent', 'g_per_lb')),
+  ADD COLUMN protein_target_value numeric(6,2),
+  ADD COLUMN carbs_target_mode    varchar(10) NOT NULL DEFAULT 'grams'
+    CHECK (carbs_target_mode IN ('grams', 'percent', 'g_per_lb')),
+  ADD COLUMN carbs_target_value   numeric(6,2),
+  ADD COLUMN fat_target_mode      varchar(10) NOT NULL DEFAULT 'grams'
+    CHECK (fat_target_mode IN ('grams', 'percent', 'g_per_lb')),
+  ADD COLUMN fat_target_value     numeric(6,2);
+
+-- Macro targets in effect up to valid_until. All nullable: NULL = inherit from
+-- current settings (rows written before this migration didn't record macros).
+ALTER TABLE calorie_config_history
+  ADD COLUMN protein_target_g     int,
+  ADD COLUMN carbs_target_g       int,
+  ADD COLUMN fat_target_g         int,
+  ADD COLUMN protein_target_mode  varchar(10),
+  ADD COLUMN protein_target_value numeric(6,2),
+  ADD COLUMN carbs_target_mode    varchar(10),
+  ADD COLUMN carbs_target_value   numeric(6,2),
+  ADD COLUMN fat_target_mode      varchar(10),
+  ADD COLUMN fat_target_value     numeric(6,2);
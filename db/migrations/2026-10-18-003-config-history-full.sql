@@ -0,0 +1,10 @@
+-- Capture the full target config in calorie_config_history, not just the budget,
+-- activity level, schedule, and macros. NULL = inherit from current settings
+-- (rows written before this migration didn't record these values).
+ALTER TABLE calorie_config_history
+  ADD COLUMN breakfast_budget         int,
+  ADD COLUMN lunch_budget             int,
+  ADD COLUMN dinner_budget            int,
+  ADD COLUMN snack_budget             int,
+  ADD COLUMN exercise_target_calories int,
+  ADD COLUMN updated_at               timestamptz;
@@ -0,0 +1,4 @@
+-- Activities used to translate calorie overages into exercise minutes
+-- (exercise catalog keys, e.g. {walking,jogging,cycling}). NULL = app default.
+ALTER TABLE calorie_log_user_settings
+  ADD COLUMN offset_activities text[];
@@ -0,0 +1,11 @@
+-- Record where each calorie log item's numbers came from and how much to trust
+-- them, so the accuracy endpoint can weight calories by confidence.
+-- confidence is 1-5 (5 = exact known data, 1 = very uncertain).
+ALTER TABLE calorie_log_items
+  ADD COLUMN source     varchar(20) NOT NULL DEFAULT 'manual'
+    CHECK (source IN ('manual', 'ai', 'food_db', 'recipe', 'favorite', 'meal_plan', 'catalog')),
+  ADD COLUMN confidence smallint CHECK (confidence BETWEEN 1 AND 5);
+
+-- Backfill sources that can be inferred from existing links.
+UPDATE calorie_log_items SET source = 'meal_plan' WHERE meal_plan_entry_id IS NOT NULL;
+UPDATE calorie_log_items SET source = 'recipe'    WHERE recipe_id IS NOT NULL AND meal_plan_entry_id IS NULL;
@@ -0,0 +1,18 @@
+-- Body measurements other than weight (body fat %, waist, ...), populated by
+-- imports from other trackers. Weight stays in weight_log.
+CREATE TABLE body_measurements (
+  id         SERIAL PRIMARY KEY,
+  user_id    INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date       DATE NOT NULL,
+  metric     VARCHAR(40) NOT NULL,  -- e.g. body_fat_pct, waist
+  value      NUMERIC(8,2) NOT NULL,
+  unit       VARCHAR(10),           -- e.g. %, in, cm; NULL when unitless
+  source     VARCHAR(30) NOT NULL DEFAULT 'manual',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, date, metric)
+);
+
+-- Items imported from another tracker get their own source marker.
+ALTER TABLE calorie_log_items DROP CONSTRAINT calorie_log_items_source_check;
+ALTER TABLE calorie_log_items ADD CONSTRAINT calorie_log_items_source_check
+  CHECK (source IN ('manual', 'ai', 'food_db', 'recipe', 'favorite', 'meal_plan', 'catalog', 'import'));
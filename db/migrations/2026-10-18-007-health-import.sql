@@ -0,0 +1,15 @@
+-- Apple Health / Google Fit export ingestion.
+
+-- Stable identifier from the source export (e.g. a workout's type + start time)
+-- so re-uploading the same export doesn't duplicate items.
+ALTER TABLE calorie_log_items ADD COLUMN external_id VARCHAR(200);
+CREATE UNIQUE INDEX calorie_log_items_external_id
+  ON calorie_log_items (user_id, external_id) WHERE external_id IS NOT NULL;
+
+ALTER TABLE calorie_log_items DROP CONSTRAINT calorie_log_items_source_check;
+ALTER TABLE calorie_log_items ADD CONSTRAINT calorie_log_items_source_check
+  CHECK (source IN ('manual', 'ai', 'food_db', 'recipe', 'favorite', 'meal_plan', 'catalog', 'import',
+                    'apple_health', 'google_fit'));
+
+-- Where a weigh-in came from: manual, import, apple_health, google_fit.
+ALTER TABLE weight_log ADD COLUMN source VARCHAR(30) NOT NULL DEFAULT 'manual';
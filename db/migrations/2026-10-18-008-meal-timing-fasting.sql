@@ -0,0 +1,16 @@
+-- Optional time of day an item was eaten. Local wall-clock time on the item's
+-- date; NULL for items logged without a time.
+ALTER TABLE calorie_log_items ADD COLUMN consumed_at TIME;
+
+-- Day-level annotations ("ate out, estimates rough"). One note per user per day.
+CREATE TABLE calorie_log_days (
+  user_id    INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date       DATE NOT NULL,
+  note       TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, date)
+);
+
+-- Intermittent fasting target: hours between the previous day's last meal and
+-- the day's first meal. NULL = not tracking fasting.
+ALTER TABLE calorie_log_user_settings ADD COLUMN fasting_target_hours NUMERIC(4,1);
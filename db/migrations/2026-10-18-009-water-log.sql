@@ -0,0 +1,17 @@
+-- Water / beverage intake. Amounts are stored in ml; the API converts to and
+-- from fl oz for users with units = 'us'.
+CREATE TABLE water_log (
+  id          SERIAL PRIMARY KEY,
+  user_id     INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date        DATE NOT NULL,
+  amount_ml   INT NOT NULL CHECK (amount_ml > 0),
+  consumed_at TIME,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX water_log_user_date ON water_log (user_id, date);
+
+-- Beverages logged as calorie items can count toward hydration. NULL = doesn't count.
+ALTER TABLE calorie_log_items ADD COLUMN hydration_ml INT CHECK (hydration_ml > 0);
+
+-- Daily water target in ml. NULL = no target.
+ALTER TABLE calorie_log_user_settings ADD COLUMN water_target_ml INT;
@@ -0,0 +1,13 @@
+-- Explicit "day complete" marks live alongside day notes, so a row may now
+-- carry a mark without a note.
+ALTER TABLE calorie_log_days ALTER COLUMN note DROP NOT NULL;
+ALTER TABLE calorie_log_days ADD COLUMN completed BOOLEAN NOT NULL DEFAULT false;
+
+-- What counts as a fully logged day for logging streaks:
+--   'meals' — every meal in complete_day_meals has at least one item
+--             (NULL = breakfast, lunch, and dinner)
+--   'mark'  — the user marked the day complete
+ALTER TABLE calorie_log_user_settings
+  ADD COLUMN complete_day_rule VARCHAR(10) NOT NULL DEFAULT 'meals'
+    CHECK (complete_day_rule IN ('meals', 'mark')),
+  ADD COLUMN complete_day_meals text[];
@@ -0,0 +1,36 @@
+-- Saved foods: nutrition for one serving of a food the user logs or cooks with.
+-- serving_grams is the weight of one serving, when known, so amounts can be
+-- given in grams.
+CREATE TABLE foods (
+  id            SERIAL PRIMARY KEY,
+  user_id       INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name          TEXT NOT NULL,
+  brand         TEXT,
+  serving_qty   NUMERIC(10,2) NOT NULL DEFAULT 1 CHECK (serving_qty > 0),
+  serving_uom   calorie_log_item_uom NOT NULL DEFAULT 'serving',
+  serving_grams NUMERIC(10,2) CHECK (serving_grams > 0),
+  calories      INT NOT NULL,
+  protein_g     NUMERIC(6,1),
+  carbs_g       NUMERIC(6,1),
+  fat_g         NUMERIC(6,1),
+  created_at    TIMESTAMPTZ DEFAULT now(),
+  updated_at    TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_foods_user_id ON foods (user_id);
+
+-- Favorite usage for ranking, and an optional link to the recipe or food the
+-- favorite's nutrition is kept in sync with.
+ALTER TABLE calorie_log_favorites
+  ADD COLUMN use_count    INT NOT NULL DEFAULT 0,
+  ADD COLUMN last_used_at TIMESTAMPTZ,
+  ADD COLUMN recipe_id    INT REFERENCES recipes(id) ON DELETE SET NULL,
+  ADD COLUMN food_id      INT REFERENCES foods(id) ON DELETE SET NULL,
+  ADD CONSTRAINT calorie_log_favorites_one_source CHECK (recipe_id IS NULL OR food_id IS NULL);
+
+-- Links a calorie log item to the favorite it was logged from.
+ALTER TABLE calorie_log_items
+  ADD COLUMN favorite_id INT REFERENCES calorie_log_favorites(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_calorie_log_items_favorite_id
+  ON calorie_log_items (favorite_id) WHERE favorite_id IS NOT NULL;
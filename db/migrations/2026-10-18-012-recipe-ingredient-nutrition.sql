@@ -0,0 +1,13 @@
+-- Ingredients can link a saved food so recipe nutrition is computed rather
+-- than typed in or estimated. grams is the weight of the ingredient's qty/uom,
+-- for units that can't be converted automatically (cups, pinches, "1 large").
+ALTER TABLE recipe_ingredients
+  ADD COLUMN food_id INT REFERENCES foods(id) ON DELETE SET NULL,
+  ADD COLUMN grams   NUMERIC(10,2) CHECK (grams > 0);
+
+CREATE INDEX idx_recipe_ingredients_food_id
+  ON recipe_ingredients (food_id) WHERE food_id IS NOT NULL;
+
+-- True when calories/macros were computed from linked ingredients (and are
+-- recomputed on change); false when entered by hand or estimated.
+ALTER TABLE recipes ADD COLUMN nutrition_computed BOOLEAN NOT NULL DEFAULT false;
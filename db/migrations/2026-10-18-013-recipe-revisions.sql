@@ -0,0 +1,35 @@
+-- Immutable history of recipe edits. Each row is a full snapshot of the
+-- recipe (the GET /api/recipes/:id shape) as it stood after a change, so an
+-- AI edit that goes wrong can be diffed and restored.
+CREATE TABLE recipe_revisions (
+  id            SERIAL PRIMARY KEY,
+  recipe_id     INT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+  revision      INT NOT NULL,
+  snapshot      JSONB NOT NULL,
+  author        TEXT NOT NULL CHECK (author IN ('user', 'ai')),
+  -- The prompt behind an AI revision; null for user edits.
+  ai_prompt     TEXT,
+  -- Set when this revision was made by restoring an earlier one.
+  restored_from INT,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (recipe_id, revision)
+);
+
+-- Existing recipes start with their current state as revision 1.
+INSERT INTO recipe_revisions (recipe_id, revision, snapshot, author, created_at)
+SELECT r.id, 1,
+       to_jsonb(r) || jsonb_build_object(
+         'ingredients', COALESCE((SELECT jsonb_agg(to_jsonb(i) ORDER BY i.sort_order)
+                                  FROM recipe_ingredients i WHERE i.recipe_id = r.id), '[]'::jsonb),
+         'tools',       COALESCE((SELECT jsonb_agg(to_jsonb(t) ORDER BY t.sort_order)
+                                  FROM recipe_tools t WHERE t.recipe_id = r.id), '[]'::jsonb),
+         'steps',       COALESCE((SELECT jsonb_agg(to_jsonb(s) ORDER BY s.sort_order)
+                                  FROM recipe_steps s WHERE s.recipe_id = r.id), '[]'::jsonb),
+         'nutrition',   NULL),
+       'user', COALESCE(r.updated_at, r.created_at, now())
+FROM recipes r;
+
+-- Logged recipe items record the revision they were logged from, so past
+-- days keep pointing at what was actually eaten after the recipe changes.
+ALTER TABLE calorie_log_items
+  ADD COLUMN recipe_revision_id INT REFERENCES recipe_revisions(id) ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+-- Free-form recipe tags ("weeknight", "freezer-friendly"). Stored trimmed and
+-- lowercased by the API so filtering is case-insensitive.
+CREATE TABLE recipe_tags (
+  recipe_id INT  NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+  tag       TEXT NOT NULL,
+  PRIMARY KEY (recipe_id, tag)
+);
+
+-- Index on tag alone for filtering recipes by tag; the PK covers recipe_id.
+CREATE INDEX idx_recipe_tags_tag ON recipe_tags (tag);
+
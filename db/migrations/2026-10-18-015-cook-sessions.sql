@@ -0,0 +1,24 @@
+-- Server-side state for cook (execution) mode, so a session survives a dead
+-- phone and can be resumed or mirrored from another device. Timers store an
+-- absolute ends_at rather than a countdown, so any device can render them.
+CREATE TABLE cook_sessions (
+  id                 SERIAL PRIMARY KEY,
+  user_id            INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  recipe_id          INT  NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+  -- The recipe as it stood when cooking started; step numbers refer to it.
+  recipe_revision_id INT  REFERENCES recipe_revisions(id) ON DELETE SET NULL,
+  status             TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'finished', 'abandoned')),
+  current_step       INT  NOT NULL DEFAULT 0,
+  completed_steps    INT[] NOT NULL DEFAULT '{}',
+  timers             JSONB NOT NULL DEFAULT '[]',
+  -- Bumped on every change so clients can drop stale updates.
+  version            INT  NOT NULL DEFAULT 1,
+  logged_item_id     INT  REFERENCES calorie_log_items(id) ON DELETE SET NULL,
+  started_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at        TIMESTAMPTZ
+);
+
+-- One active session per recipe; starting again resumes it.
+CREATE UNIQUE INDEX idx_cook_sessions_active
+  ON cook_sessions (user_id, recipe_id) WHERE status = 'active';
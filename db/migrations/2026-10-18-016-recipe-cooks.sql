@@ -0,0 +1,18 @@
+-- Cooking history: one row per time a recipe was made, recorded when a cook
+-- session finishes or added by hand, with how it turned out.
+CREATE TABLE recipe_cooks (
+  id                 SERIAL PRIMARY KEY,
+  user_id            INT  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  recipe_id          INT  NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+  -- The version of the recipe that was made.
+  recipe_revision_id INT  REFERENCES recipe_revisions(id) ON DELETE SET NULL,
+  cook_session_id    INT  UNIQUE REFERENCES cook_sessions(id) ON DELETE SET NULL,
+  cooked_on          DATE NOT NULL DEFAULT CURRENT_DATE,
+  servings           NUMERIC(6,2) CHECK (servings > 0),  -- servings made
+  rating             SMALLINT CHECK (rating BETWEEN 1 AND 5),
+  notes              TEXT,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_recipe_cooks_recipe ON recipe_cooks (recipe_id, cooked_on DESC);
@@ -0,0 +1,18 @@
+-- Ingredients can reference another of the user's recipes ("our bolognese"
+-- in a lasagna). qty/uom give the amount in servings of that recipe, or a
+-- weight, which needs the sub-recipe's yield weight to convert.
+ALTER TABLE recipe_ingredients
+  ADD COLUMN sub_recipe_id INT REFERENCES recipes(id) ON DELETE SET NULL,
+  ADD CONSTRAINT recipe_ingredients_one_link CHECK (food_id IS NULL OR sub_recipe_id IS NULL),
+  ADD CONSTRAINT recipe_ingredients_not_self CHECK (sub_recipe_id <> recipe_id);
+
+CREATE INDEX idx_recipe_ingredients_sub_recipe ON recipe_ingredients (sub_recipe_id)
+  WHERE sub_recipe_id IS NOT NULL;
+
+-- Total cooked weight of a recipe's yield, for using it by weight.
+ALTER TABLE recipes
+  ADD COLUMN yield_grams NUMERIC(10,2) CHECK (yield_grams > 0);
+
+-- Whether a cook session shows sub-recipe steps inline before the recipe's own.
+ALTER TABLE cook_sessions
+  ADD COLUMN inline_steps BOOLEAN NOT NULL DEFAULT false;
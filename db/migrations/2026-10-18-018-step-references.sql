@@ -0,0 +1,18 @@
+-- Which ingredients and tools each step uses, so cook mode can show
+-- "you need: 200 g flour" per step. qty/uom are the amount used in that
+-- step; NULL qty means the whole ingredient or an unspecified amount.
+CREATE TABLE recipe_step_ingredients (
+  step_id       INT NOT NULL REFERENCES recipe_steps(id) ON DELETE CASCADE,
+  ingredient_id INT NOT NULL REFERENCES recipe_ingredients(id) ON DELETE CASCADE,
+  qty           NUMERIC(10,2) CHECK (qty > 0),
+  uom           TEXT,
+  sort_order    INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (step_id, ingredient_id)
+);
+
+CREATE TABLE recipe_step_tools (
+  step_id    INT NOT NULL REFERENCES recipe_steps(id) ON DELETE CASCADE,
+  tool_id    INT NOT NULL REFERENCES recipe_tools(id) ON DELETE CASCADE,
+  sort_order INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (step_id, tool_id)
+);
@@ -0,0 +1,78 @@
+-- Stored full-text documents for recipe search, so GET /api/recipes?q= can use
+-- a GIN index instead of building every recipe's tsvector per query. Kept in
+-- their own table (recipes rows are scanned with SELECT *) and maintained by
+-- triggers on recipes and the tables the document draws from.
+CREATE TABLE recipe_search_documents (
+  recipe_id INT      PRIMARY KEY REFERENCES recipes(id) ON DELETE CASCADE,
+  document  tsvector NOT NULL
+);
+
+CREATE INDEX idx_recipe_search_documents_document ON recipe_search_documents USING GIN (document);
+
+-- The document: name and tags weigh most, then ingredients, notes, and step text.
+CREATE FUNCTION recipe_search_document(p_recipe_id INT) RETURNS tsvector
+LANGUAGE sql STABLE AS $$
+  SELECT
+    setweight(to_tsvector('english', r.name), 'A') ||
+    setweight(to_tsvector('english', COALESCE((SELECT string_agg(tag, ' ') FROM recipe_tags WHERE recipe_id = r.id), '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE((SELECT string_agg(name, ' ') FROM recipe_ingredients WHERE recipe_id = r.id), '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(r.notes, '')), 'C') ||
+    setweight(to_tsvector('english', COALESCE((SELECT string_agg(text || ' ' || COALESCE(meanwhile_text, ''), ' ')
+                                               FROM recipe_steps WHERE recipe_id = r.id), '')), 'D')
+  FROM recipes r
+  WHERE r.id = p_recipe_id
+$$;
+
+-- Rebuilds one recipe's document. A recipe that no longer exists (its
+-- sub-rows are being removed by ON DELETE CASCADE) is skipped.
+CREATE FUNCTION refresh_recipe_search_document(p_recipe_id INT) RETURNS void
+LANGUAGE sql AS $$
+  INSERT INTO recipe_search_documents (recipe_id, document)
+  SELECT id, recipe_search_document(id) FROM recipes WHERE id = p_recipe_id
+  ON CONFLICT (recipe_id) DO UPDATE SET document = EXCLUDED.document
+$$;
+
+CREATE FUNCTION recipes_search_document_trigger() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  PERFORM refresh_recipe_search_document(NEW.id);
+  RETURN NULL;
+END
+$$;
+
+-- For recipe_tags, recipe_ingredients, and recipe_steps: refresh the recipe a
+-- row belongs (or belonged) to.
+CREATE FUNCTION recipe_rows_search_document_trigger() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    PERFORM refresh_recipe_search_document(NEW.recipe_id);
+  ELSE
+    PERFORM refresh_recipe_search_document(OLD.recipe_id);
+    IF TG_OP = 'UPDATE' AND NEW.recipe_id <> OLD.recipe_id THEN
+      PERFORM refresh_recipe_search_document(NEW.recipe_id);
+    END IF;
+  END IF;
+  RETURN NULL;
+END
+$$;
+
+CREATE TRIGGER recipes_search_document
+  AFTER INSERT OR UPDATE OF name, notes ON recipes
+  FOR EACH ROW EXECUTE FUNCTION recipes_search_document_trigger();
+
+CREATE TRIGGER recipe_tags_search_document
+  AFTER INSERT OR UPDATE OR DELETE ON recipe_tags
+  FOR EACH ROW EXECUTE FUNCTION recipe_rows_search_document_trigger();
+
+CREATE TRIGGER recipe_ingredients_search_document
+  AFTER INSERT OR UPDATE OF recipe_id, name OR DELETE ON recipe_ingredients
+  FOR EACH ROW EXECUTE FUNCTION recipe_rows_search_document_trigger();
+
+CREATE TRIGGER recipe_steps_search_document
+  AFTER INSERT OR UPDATE OF recipe_id, text, meanwhile_text OR DELETE ON recipe_steps
+  FOR EACH ROW EXECUTE FUNCTION recipe_rows_search_document_trigger();
+
+-- Backfill existing recipes.
+INSERT INTO recipe_search_documents (recipe_id, document)
+SELECT id, recipe_search_document(id) FROM recipes;
@@ -17,13 +17,19 @@ async function apiLogin(request: APIRequestContext): Promise<string> {
   return body.token as string
 }
 
-// Delete all recipes owned by the test user so tests start clean.
+// Delete all recipes owned by the test user so tests start clean. The list is
+// paged ({ recipes, has_more }), so collect every page before deleting.
 async function cleanupRecipes(request: APIRequestContext, token: string) {
   const headers = { Authorization: `Bearer ${token}` }
-  const res     = await request.get('/api/recipes', { headers })
-  const recipes = await res.json() as Array<{ id: number }>
-  for (const r of recipes) {
-    await request.delete(`/api/recipes/${r.id}`, { headers })
+  const ids: number[] = []
+  for (let offset = 0; ; offset += 200) {
+    const res  = await request.get(`/api/recipes?limit=200&offset=${offset}`, { headers })
+    const page = await res.json() as { recipes: Array<{ id: number }>, has_more: boolean }
+    ids.push(...page.recipes.map(r => r.id))
+    if (!page.has_more) break
+  }
+  for (const id of ids) {
+    await request.delete(`/api/recipes/${id}`, { headers })
   }
 }
 
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// validItemSources is the set of allowed calorie_log_items.source values.
+// Mirrors the CHECK constraint on the column.
+var validItemSources = map[string]bool{
+	"manual":       true,
+	"ai":           true,
+	"food_db":      true,
+	"recipe":       true,
+	"favorite":     true,
+	"meal_plan":    true,
+	"catalog":      true,
+	"import":       true,
+	"apple_health": true,
+	"google_fit":   true,
+}
+
+// invalidItemSourceMsg is the error for a source not in validItemSources.
+var invalidItemSourceMsg = "source must be one of: " + strings.Join(slices.Sorted(maps.Keys(validItemSources)), ", ")
+
+// defaultSourceConfidence is the confidence recorded when the client doesn't
+// send one. AI items should always carry the suggestion's own confidence.
+var defaultSourceConfidence = map[string]int{
+	"manual":       3,
+	"ai":           3,
+	"food_db":      5,
+	"recipe":       4,
+	"favorite":     4,
+	"meal_plan":    4,
+	"catalog":      4,
+	"import":       3,
+	"apple_health": 4,
+	"google_fit":   4,
+}
+
+// unknownConfidence is assumed for rows logged before confidence was recorded.
+const unknownConfidence = 3
+
+// guessConfidence is the highest confidence still treated as a guess.
+const guessConfidence = 2
+
+// resolveItemSource validates a new item's source/confidence and fills defaults:
+// the source is inferred from meal plan / recipe links when omitted, and the
+// confidence defaults per source.
+func resolveItemSource(source string, confidence *int, recipeID, mealPlanEntryID *int) (string, int, error) {
+	if source == "" {
+		switch {
+		case mealPlanEntryID != nil:
+			source = "meal_plan"
+		case recipeID != nil:
+			source = "recipe"
+		default:
+			source = "manual"
+		}
+	}
+	if !validItemSources[source] {
+		return "", 0, errors.New(invalidItemSourceMsg)
+	}
+	if confidence == nil {
+		return source, defaultSourceConfidence[source], nil
+	}
+	if *confidence < 1 || *confidence > 5 {
+		return "", 0, fmt.Errorf("confidence must be between 1 and 5")
+	}
+	return source, *confidence, nil
+}
+
+// accuracyDBRow is one (date, source, confidence) group of calorie log items.
+type accuracyDBRow struct {
+	Date       DateOnly `db:"date"`
+	Source     string   `db:"source"`
+	Confidence int      `db:"confidence"`
+	Calories   int      `db:"calories"`
+	Items      int      `db:"items"`
+}
+
+// sourceCalories is the calories and item count logged from one source.
+type sourceCalories struct {
+	Source   string `json:"source"`
+	Calories int    `json:"calories"`
+	Items    int    `json:"items"`
+}
+
+// confidenceSummary is the confidence-weighted accuracy of a set of items.
+// Score is 0-100: calories weighted by confidence relative to all-exact (5/5)
+// data; nil when nothing with calories was logged. GuessPct is the share of
+// calories with confidence <= 2, and MostlyGuesses is set when it's at least half.
+type confidenceSummary struct {
+	Score         *int             `json:"score"`
+	Calories      int              `json:"calories"`
+	GuessCalories int              `json:"guess_calories"`
+	GuessPct      int              `json:"guess_pct"`
+	MostlyGuesses bool             `json:"mostly_guesses"`
+	BySource      []sourceCalories `json:"by_source"`
+}
+
+// accuracyDay is one day's confidence summary.
+type accuracyDay struct {
+	Date DateOnly `json:"date"`
+	confidenceSummary
+}
+
+// loggingAccuracy compares the logged calorie deficit to measured weight change
+// over a rolling window (see design/features/app-improvements). AccuracyPct is
+// nil with Status "insufficient_data" until enough days and weigh-ins exist.
+type loggingAccuracy struct {
+	Status            string   `json:"status"` // ok | insufficient_data
+	WindowStart       DateOnly `json:"window_start"`
+	WindowEnd         DateOnly `json:"window_end"`
+	DaysLogged        int      `json:"days_logged"`
+	WeightCheckins    int      `json:"weight_checkins"`
+	ExpectedChangeLbs *float64 `json:"expected_change_lbs,omitempty"`
+	ActualChangeLbs   *float64 `json:"actual_change_lbs,omitempty"`
+	AccuracyPct       *int     `json:"accuracy_pct,omitempty"`
+}
+
+// accuracyResponse is the response for GET /api/calorie-log/accuracy.
+type accuracyResponse struct {
+	Period  string            `json:"period"` // day | week
+	Start   DateOnly          `json:"start"`
+	End     DateOnly          `json:"end"`
+	Overall confidenceSummary `json:"overall"`
+	Days    []accuracyDay     `json:"days"`
+	Logging loggingAccuracy   `json:"logging_accuracy"`
+}
+
+// Minimum data for the weight-based logging accuracy, per the design doc.
+const (
+	loggingAccuracyWindowDays = 30
+	loggingAccuracyMinDays    = 20
+	loggingAccuracyMinWeighIn = 2
+)
+
+// summarizeConfidence weights calories by confidence across rows. Exercise is
+// included: a guessed burn makes the net number just as uncertain as guessed food.
+func summarizeConfidence(rows []accuracyDBRow) confidenceSummary {
+	var s confidenceSummary
+	var weighted int
+	bySource := map[string]*sourceCalories{}
+	var order []string
+	for _, r := range rows {
+		s.Calories += r.Calories
+		weighted += r.Calories * r.Confidence
+		if r.Confidence <= guessConfidence {
+			s.GuessCalories += r.Calories
+		}
+		sc, ok := bySource[r.Source]
+		if !ok {
+			sc = &sourceCalories{Source: r.Source}
+			bySource[r.Source] = sc
+			order = append(order, r.Source)
+		}
+		sc.Calories += r.Calories
+		sc.Items += r.Items
+	}
+	s.BySource = make([]sourceCalories, 0, len(order))
+	for _, src := range order {
+		s.BySource = append(s.BySource, *bySource[src])
+	}
+	if s.Calories > 0 {
+		score := int(math.Round(float64(weighted) / float64(s.Calories*5) * 100))
+		s.Score = &score
+		s.GuessPct = int(math.Round(float64(s.GuessCalories) / float64(s.Calories) * 100))
+		s.MostlyGuesses = s.GuessPct >= 50
+	}
+	return s
+}
+
+// computeLoggingAccuracy turns a window's logged deficit (TDEE minus net, summed
+// over logged days) and its weigh-ins into the design doc's accuracy percentage:
+// actual weight change / expected weight change. weights must be sorted by date.
+func computeLoggingAccuracy(deficit float64, daysLogged int, weights []weightEntry) loggingAccuracy {
+	a := loggingAccuracy{Status: "insufficient_data", DaysLogged: daysLogged, WeightCheckins: len(weights)}
+	if daysLogged < loggingAccuracyMinDays || len(weights) < loggingAccuracyMinWeighIn {
+		return a
+	}
+	expected := -deficit / 3500 // deficit → loss, so expected change is negative
+	actual := weights[len(weights)-1].WeightLBS - weights[0].WeightLBS
+	a.ExpectedChangeLbs = &expected
+	a.ActualChangeLbs = &actual
+	if math.Abs(expected) < 0.1 {
+		// Maintenance: no meaningful expected change to compare against.
+		return a
+	}
+	pct := int(math.Round(actual / expected * 100))
+	a.AccuracyPct = &pct
+	a.Status = "ok"
+	return a
+}
+
+// getAccuracy returns the confidence-weighted accuracy score for a day or a week,
+// per day and overall, plus the weight-based logging accuracy for the 30 days
+// ending on the period's last day.
+// GET /api/calorie-log/accuracy?period=day|week&date=YYYY-MM-DD. period defaults
+// to week; date defaults to today (for week, any date in the Mon–Sun week).
+func (h *Handler) getAccuracy(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	period := c.DefaultQuery("period", "week")
+	if period != "day" && period != "week" {
+		apiError(c, http.StatusBadRequest, "period must be day or week")
+		return
+	}
+	date, err := time.Parse("2006-01-02", c.DefaultQuery("date", time.Now().Format("2006-01-02")))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+	start, end := date, date
+	if period == "week" {
+		start = date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7)) // back to Monday
+		end = start.AddDate(0, 0, 6)
+	}
+
+	rows, err := queryMany[accuracyDBRow](h.db, c,
+		`SELECT date, source, COALESCE(confidence, @unknown)::int AS confidence,
+		        SUM(calories)::int AS calories, COUNT(*)::int AS items
+		 FROM calorie_log_items
+		 WHERE user_id = @userID AND date >= @start AND date <= @end
+		 GROUP BY date, source, COALESCE(confidence, @unknown)
+		 ORDER BY date, source`,
+		pgx.NamedArgs{
+			"userID":  userID,
+			"unknown": unknownConfidence,
+			"start":   start.Format("2006-01-02"),
+			"end":     end.Format("2006-01-02"),
+		})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch items")
+		return
+	}
+
+	byDate := map[string][]accuracyDBRow{}
+	for _, r := range rows {
+		k := r.Date.Format("2006-01-02")
+		byDate[k] = append(byDate[k], r)
+	}
+	days := []accuracyDay{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if dayRows, ok := byDate[d.Format("2006-01-02")]; ok {
+			days = append(days, accuracyDay{Date: DateOnly{d}, confidenceSummary: summarizeConfidence(dayRows)})
+		}
+	}
+
+	logging, err := h.loggingAccuracyFor(c, userID, end)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to compute logging accuracy")
+		return
+	}
+
+	c.JSON(http.StatusOK, accuracyResponse{
+		Period:  period,
+		Start:   DateOnly{start},
+		End:     DateOnly{end},
+		Overall: summarizeConfidence(rows),
+		Days:    days,
+		Logging: logging,
+	})
+}
+
+// loggingAccuracyFor computes the weight-based logging accuracy for the rolling
+// window ending on end, resolving each logged day's TDEE the same way the
+// progress endpoint does (config history + weight in effect that day).
+func (h *Handler) loggingAccuracyFor(c *gin.Context, userID int, end time.Time) (loggingAccuracy, error) {
+	start := end.AddDate(0, 0, -(loggingAccuracyWindowDays - 1))
+	startStr, endStr := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	settings, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		return loggingAccuracy{}, err
+	}
+	configHistory, _ := queryMany[calorieConfigHistory](h.db, c,
+		`SELECT * FROM calorie_config_history WHERE user_id = @userID ORDER BY valid_until ASC`,
+		pgx.NamedArgs{"userID": userID})
+	allWeights, _ := queryMany[weightEntry](h.db, c,
+		`SELECT * FROM weight_log WHERE user_id = @userID AND date <= @end ORDER BY date ASC`,
+		pgx.NamedArgs{"userID": userID, "end": endStr})
+	days, err := queryMany[weekDayDBRow](h.db, c,
+		`SELECT
+			date,
+			SUM(CASE WHEN type != 'exercise' THEN calories ELSE 0 END) AS calories_food,
+			SUM(CASE WHEN type  = 'exercise' THEN calories ELSE 0 END) AS calories_exercise,
+			COALESCE(SUM(protein_g), 0) AS protein_g,
+			COALESCE(SUM(carbs_g),   0) AS carbs_g,
+			COALESCE(SUM(fat_g),     0) AS fat_g,
+			SUM(CASE WHEN type = 'breakfast' THEN calories ELSE 0 END) AS calories_breakfast,
+			SUM(CASE WHEN type = 'lunch'     THEN calories ELSE 0 END) AS calories_lunch,
+			SUM(CASE WHEN type = 'dinner'    THEN calories ELSE 0 END) AS calories_dinner,
+			SUM(CASE WHEN type = 'snack'     THEN calories ELSE 0 END) AS calories_snack
+		 FROM calorie_log_items
+		 WHERE user_id = @userID AND date >= @start AND date <= @end
+		 GROUP BY date
+		 ORDER BY date ASC`,
+		pgx.NamedArgs{"userID": userID, "start": startStr, "end": endStr})
+	if err != nil {
+		return loggingAccuracy{}, err
+	}
+
+	var fallbackWeight float64
+	if settings.WeightLBS != nil {
+		fallbackWeight = *settings.WeightLBS
+	}
+	var deficit float64
+	for _, row := range days {
+		dateStr := row.Date.Format("2006-01-02")
+		w := weightAtOrBefore(allWeights, dateStr, fallbackWeight)
+		targets := configForDate(configHistory, &settings, dateStr)
+		dayTDEE, ok := tdeeForDay(&settings, w, targets.ActivityLevel, row.Date.Time)
+		if !ok {
+			// Incomplete TDEE profile — no expected change to compare against.
+			a := loggingAccuracy{Status: "insufficient_data", DaysLogged: len(days)}
+			a.WindowStart, a.WindowEnd = DateOnly{start}, DateOnly{end}
+			return a, nil
+		}
+		deficit += dayTDEE - float64(row.CaloriesFood-row.CaloriesExercise)
+	}
+
+	var windowWeights []weightEntry
+	for _, e := range allWeights {
+		if d := e.Date.Format("2006-01-02"); d >= startStr && d <= endStr {
+			windowWeights = append(windowWeights, e)
+		}
+	}
+	a := computeLoggingAccuracy(deficit, len(days), windowWeights)
+	a.WindowStart, a.WindowEnd = DateOnly{start}, DateOnly{end}
+	return a, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestResolveItemSource verifies source inference from links and per-source
+// default confidence, and that an explicit AI confidence is kept.
+func TestResolveItemSource(t *testing.T) {
+	cases := []struct {
+		name       string
+		source     string
+		confidence *int
+		recipeID   *int
+		mealPlanID *int
+		wantSource string
+		wantConf   int
+		wantErr    bool
+	}{
+		{"manual default", "", nil, nil, nil, "manual", 3, false},
+		{"inferred recipe", "", nil, intPtr(7), nil, "recipe", 4, false},
+		{"inferred meal plan wins", "", nil, intPtr(7), intPtr(9), "meal_plan", 4, false},
+		{"ai keeps confidence", "ai", intPtr(2), nil, nil, "ai", 2, false},
+		{"unknown source", "guess", nil, nil, nil, "", 0, true},
+		{"confidence out of range", "ai", intPtr(6), nil, nil, "", 0, true},
+	}
+	for _, tc := range cases {
+		src, conf, err := resolveItemSource(tc.source, tc.confidence, tc.recipeID, tc.mealPlanID)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: wantErr=%v, got %v", tc.name, tc.wantErr, err)
+			continue
+		}
+		if !tc.wantErr && (src != tc.wantSource || conf != tc.wantConf) {
+			t.Errorf("%s: want %s/%d, got %s/%d", tc.name, tc.wantSource, tc.wantConf, src, conf)
+		}
+	}
+}
+
+// TestSummarizeConfidence verifies calories are weighted by confidence and a
+// period dominated by low-confidence calories is flagged as mostly guesses.
+func TestSummarizeConfidence(t *testing.T) {
+	s := summarizeConfidence([]accuracyDBRow{
+		{Source: "food_db", Confidence: 5, Calories: 500, Items: 1},
+		{Source: "ai", Confidence: 2, Calories: 1000, Items: 2},
+		{Source: "ai", Confidence: 4, Calories: 500, Items: 1},
+	})
+	// (500×5 + 1000×2 + 500×4) / (2000×5) = 6500/10000
+	if s.Score == nil || *s.Score != 65 {
+		t.Errorf("want score 65, got %v", s.Score)
+	}
+	if s.GuessPct != 50 || !s.MostlyGuesses {
+		t.Errorf("want 50%% guesses flagged, got %d%% mostly=%v", s.GuessPct, s.MostlyGuesses)
+	}
+	if len(s.BySource) != 2 || s.BySource[1].Source != "ai" || s.BySource[1].Items != 3 {
+		t.Errorf("want food_db then ai (3 items), got %+v", s.BySource)
+	}
+
+	if empty := summarizeConfidence(nil); empty.Score != nil {
+		t.Errorf("want nil score with no items, got %d", *empty.Score)
+	}
+}
+
+// TestComputeLoggingAccuracy verifies the design doc's example and the minimum
+// data requirements.
+func TestComputeLoggingAccuracy(t *testing.T) {
+	day := func(s string) DateOnly { d, _ := time.Parse("2006-01-02", s); return DateOnly{d} }
+	weights := []weightEntry{
+		{Date: day("2024-02-01"), WeightLBS: 185.5},
+		{Date: day("2024-02-22"), WeightLBS: 183.7},
+	}
+	// 9,000 kcal deficit → 2.57 lbs expected; 1.8 lbs actual → 70%.
+	a := computeLoggingAccuracy(9000, 25, weights)
+	if a.Status != "ok" || a.AccuracyPct == nil || *a.AccuracyPct != 70 {
+		t.Errorf("want ok / 70%%, got %s / %v", a.Status, a.AccuracyPct)
+	}
+
+	if a := computeLoggingAccuracy(9000, 19, weights); a.Status != "insufficient_data" {
+		t.Errorf("want insufficient_data with 19 logged days, got %s", a.Status)
+	}
+	if a := computeLoggingAccuracy(9000, 25, weights[:1]); a.Status != "insufficient_data" {
+		t.Errorf("want insufficient_data with 1 weigh-in, got %s", a.Status)
+	}
+}
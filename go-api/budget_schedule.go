@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// parseBudgetSchedule decodes a budget_schedule JSONB value. A nil or empty
+// value yields a nil schedule (no overrides).
+func parseBudgetSchedule(raw *json.RawMessage) ([]budgetScheduleDay, error) {
+	if raw == nil || len(*raw) == 0 || string(*raw) == "null" {
+		return nil, nil
+	}
+	var days []budgetScheduleDay
+	if err := json.Unmarshal(*raw, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// validateBudgetSchedule checks that each weekday appears at most once and that
+// all targets are non-negative. Returns a user-facing error message on failure.
+func validateBudgetSchedule(days []budgetScheduleDay) error {
+	seen := make(map[int]bool, len(days))
+	for _, d := range days {
+		if d.Weekday < 0 || d.Weekday > 6 {
+			return fmt.Errorf("budget_schedule weekday must be 0 (Mon) through 6 (Sun)")
+		}
+		if seen[d.Weekday] {
+			return fmt.Errorf("budget_schedule has more than one entry for weekday %d", d.Weekday)
+		}
+		seen[d.Weekday] = true
+		if d.CalorieBudget != nil && *d.CalorieBudget <= 0 {
+			return fmt.Errorf("budget_schedule calorie_budget must be positive")
+		}
+		for _, v := range []*int{d.ProteinTargetG, d.CarbsTargetG, d.FatTargetG} {
+			if v != nil && *v < 0 {
+				return fmt.Errorf("budget_schedule macro targets must not be negative")
+			}
+		}
+	}
+	return nil
+}
+
+// scheduleDayFor returns the schedule entry for the weekday of t, if any.
+func scheduleDayFor(days []budgetScheduleDay, t time.Time) (budgetScheduleDay, bool) {
+	weekday := (int(t.Weekday()) + 6) % 7 // time.Weekday is Sun=0; schedule is Mon=0
+	for _, d := range days {
+		if d.Weekday == weekday {
+			return d, true
+		}
+	}
+	return budgetScheduleDay{}, false
+}
+
+// applyScheduleDay overwrites the targets in t with any non-nil overrides from d.
+// Scheduled macro targets are fixed grams, so they replace the macro's mode too.
+func applyScheduleDay(t *dayTargets, d budgetScheduleDay) {
+	if d.CalorieBudget != nil {
+		t.CalorieBudget = *d.CalorieBudget
+	}
+	if d.ProteinTargetG != nil {
+		t.ProteinTargetG = *d.ProteinTargetG
+		t.Protein = macroTargetSpec{Mode: "grams", Grams: *d.ProteinTargetG}
+	}
+	if d.CarbsTargetG != nil {
+		t.CarbsTargetG = *d.CarbsTargetG
+		t.Carbs = macroTargetSpec{Mode: "grams", Grams: *d.CarbsTargetG}
+	}
+	if d.FatTargetG != nil {
+		t.FatTargetG = *d.FatTargetG
+		t.Fat = macroTargetSpec{Mode: "grams", Grams: *d.FatTargetG}
+	}
+	t.ScheduleLabel = d.Label
+}
+
+// sameBudgetSchedule reports whether two schedule JSON values decode to the same
+// schedule. Used to skip history records when a patch re-sends the current one.
+func sameBudgetSchedule(a, b *json.RawMessage) bool {
+	da, errA := parseBudgetSchedule(a)
+	db, errB := parseBudgetSchedule(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if len(da) != len(db) {
+		return false
+	}
+	ja, _ := json.Marshal(normalizeSchedule(da))
+	jb, _ := json.Marshal(normalizeSchedule(db))
+	return string(ja) == string(jb)
+}
+
+// normalizeSchedule returns the schedule indexed by weekday so entry order
+// doesn't affect equality checks.
+func normalizeSchedule(days []budgetScheduleDay) [7]*budgetScheduleDay {
+	var out [7]*budgetScheduleDay
+	for i := range days {
+		if days[i].Weekday >= 0 && days[i].Weekday <= 6 {
+			out[days[i].Weekday] = &days[i]
+		}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,33 +9,94 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-// configForDate returns the calorie_budget and activity_level effective on the given
-// date by scanning the history slice (sorted ascending by valid_until).
+// dayTargets is the full target config in effect on a single date (calorie budget,
+// macro targets, per-meal budgets, exercise target, and activity level), after
+// config history and the weekday budget schedule are applied.
+// The *TargetG fields hold fixed gram targets until resolveMacros derives them
+// from the per-macro specs (percent of budget / grams per lb of body weight).
+type dayTargets struct {
+	CalorieBudget  int
+	ProteinTargetG int
+	CarbsTargetG   int
+	FatTargetG     int
+	ActivityLevel  string
+	ScheduleLabel  *string // label of the schedule day that applied, if any
+
+	BreakfastBudget        int
+	LunchBudget            int
+	DinnerBudget           int
+	SnackBudget            int
+	ExerciseTargetCalories int
+
+	Protein, Carbs, Fat macroTargetSpec
+}
+
+// configForDate returns the targets effective on the given date by scanning the
+// history slice (sorted ascending by valid_until).
 // The first history row whose valid_until >= dateStr covers that date.
 // Falls back to current settings when no history row covers the date (i.e. the date
 // is after all history records, so current settings apply).
+// The weekday budget schedule in effect for that period (history row or settings)
+// is then applied on top, so calorie cycling days resolve to their scheduled values.
 func configForDate(
 	history []calorieConfigHistory,
 	settings *calorieLogUserSettings,
 	dateStr string,
-) (calorieBudget int, activityLevel string) {
+) dayTargets {
+	t := dayTargets{
+		CalorieBudget:          settings.CalorieBudget,
+		BreakfastBudget:        settings.BreakfastBudget,
+		LunchBudget:            settings.LunchBudget,
+		DinnerBudget:           settings.DinnerBudget,
+		SnackBudget:            settings.SnackBudget,
+		ExerciseTargetCalories: settings.ExerciseTargetCalories,
+	}
+	t.Protein, t.Carbs, t.Fat = settingsMacroSpecs(settings)
+	if settings.ActivityLevel != nil {
+		t.ActivityLevel = *settings.ActivityLevel
+	}
+	schedule := settings.BudgetSchedule
 	for _, h := range history {
 		if h.ValidUntil.Format("2006-01-02") >= dateStr {
-			al := ""
+			t.CalorieBudget = h.CalorieBudget
 			if h.ActivityLevel != nil {
-				al = *h.ActivityLevel
-			} else if settings.ActivityLevel != nil {
-				al = *settings.ActivityLevel
+				t.ActivityLevel = *h.ActivityLevel
 			}
-			return h.CalorieBudget, al
+			schedule = h.BudgetSchedule
+			t.Protein = historyMacroSpec(t.Protein, h.ProteinTargetG, h.ProteinTargetMode, h.ProteinTargetValue)
+			t.Carbs = historyMacroSpec(t.Carbs, h.CarbsTargetG, h.CarbsTargetMode, h.CarbsTargetValue)
+			t.Fat = historyMacroSpec(t.Fat, h.FatTargetG, h.FatTargetMode, h.FatTargetValue)
+			inheritInt(&t.BreakfastBudget, h.BreakfastBudget)
+			inheritInt(&t.LunchBudget, h.LunchBudget)
+			inheritInt(&t.DinnerBudget, h.DinnerBudget)
+			inheritInt(&t.SnackBudget, h.SnackBudget)
+			inheritInt(&t.ExerciseTargetCalories, h.ExerciseTargetCalories)
+			break
 		}
 	}
-	// No history covers this date — use current settings.
-	al := ""
-	if settings.ActivityLevel != nil {
-		al = *settings.ActivityLevel
+	t.ProteinTargetG, t.CarbsTargetG, t.FatTargetG = t.Protein.Grams, t.Carbs.Grams, t.Fat.Grams
+
+	// A malformed schedule is ignored rather than failing the whole summary —
+	// patchUserSettings validates schedules before they are stored.
+	days, err := parseBudgetSchedule(schedule)
+	if err != nil || len(days) == 0 {
+		return t
+	}
+	d, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return t
+	}
+	if sd, ok := scheduleDayFor(days, d); ok {
+		applyScheduleDay(&t, sd)
+	}
+	return t
+}
+
+// inheritInt overwrites *dst with *v when the history column is non-null.
+func inheritInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
 	}
-	return settings.CalorieBudget, al
 }
 
 // validItemTypes is the set of allowed values for the calorie_log_item_type enum.
@@ -59,14 +121,25 @@ func (h *Handler) getDailySummary(c *gin.Context) {
 		return
 	}
 
+	summary, _, err := h.buildDailySummary(c, userID, date)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// buildDailySummary loads a day's items and resolves the targets in effect on
+// that date into a dailySummary. Also returns the body weight in effect on the
+// date (0 when unknown). Errors carry a user-facing message.
+func (h *Handler) buildDailySummary(c *gin.Context, userID int, date string) (dailySummary, float64, error) {
 	args := pgx.NamedArgs{"userID": userID, "date": date}
 	items, err := queryMany[calorieLogItem](h.db, c,
 		`SELECT * FROM calorie_log_items
 		 WHERE user_id = @userID AND date = @date
 		 ORDER BY created_at`, args)
 	if err != nil {
-		apiError(c, http.StatusInternalServerError, "failed to fetch items")
-		return
+		return dailySummary{}, 0, errors.New("failed to fetch items")
 	}
 	// Ensure items is an empty array (not null) in JSON
 	if items == nil {
@@ -77,8 +150,7 @@ func (h *Handler) getDailySummary(c *gin.Context) {
 		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
 		pgx.NamedArgs{"userID": userID})
 	if err != nil {
-		apiError(c, http.StatusInternalServerError, "failed to fetch settings")
-		return
+		return dailySummary{}, 0, errors.New("failed to fetch settings")
 	}
 
 	// Fetch config history and weight log so we can resolve the historically
@@ -90,22 +162,35 @@ func (h *Handler) getDailySummary(c *gin.Context) {
 		`SELECT * FROM weight_log WHERE user_id = @userID AND date <= @date ORDER BY date ASC`,
 		pgx.NamedArgs{"userID": userID, "date": date})
 
-	// Override budget with historically correct value for the requested date.
-	historicalBudget, actLevel := configForDate(configHistory, &settings, date)
-	settings.CalorieBudget = historicalBudget
+	var fallbackWeight float64
+	if settings.WeightLBS != nil {
+		fallbackWeight = *settings.WeightLBS
+	}
+	w := weightAtOrBefore(weightEntries, date, fallbackWeight)
+
+	// Override budget and macro targets with the historically correct (and
+	// weekday-scheduled) values for the requested date. Derived macro targets
+	// use that day's budget and weight.
+	targets := configForDate(configHistory, &settings, date)
+	targets.resolveMacros(w)
+	settings.CalorieBudget = targets.CalorieBudget
+	settings.ProteinTargetG = targets.ProteinTargetG
+	settings.CarbsTargetG = targets.CarbsTargetG
+	settings.FatTargetG = targets.FatTargetG
+	settings.BreakfastBudget = targets.BreakfastBudget
+	settings.LunchBudget = targets.LunchBudget
+	settings.DinnerBudget = targets.DinnerBudget
+	settings.SnackBudget = targets.SnackBudget
+	settings.ExerciseTargetCalories = targets.ExerciseTargetCalories
 
 	// Populate computed TDEE fields (BMR, budget, pace) using today's profile —
 	// then override ComputedTDEE with the historically accurate value so the
 	// frontend's daily weight impact card reflects actual TDEE at that date.
 	populateComputedTDEE(&settings)
+	populateWaterTarget(&settings)
 
-	var fallbackWeight float64
-	if settings.WeightLBS != nil {
-		fallbackWeight = *settings.WeightLBS
-	}
-	w := weightAtOrBefore(weightEntries, date, fallbackWeight)
 	asOf, _ := time.Parse("2006-01-02", date)
-	if dayTDEE, ok := tdeeForDay(&settings, w, actLevel, asOf); ok {
+	if dayTDEE, ok := tdeeForDay(&settings, w, targets.ActivityLevel, asOf); ok {
 		tdeeInt := int(dayTDEE)
 		settings.ComputedTDEE = &tdeeInt
 	}
@@ -135,7 +220,13 @@ func (h *Handler) getDailySummary(c *gin.Context) {
 	net := caloriesFood - caloriesExercise
 	left := settings.CalorieBudget - net
 
-	c.JSON(http.StatusOK, dailySummary{
+	meals := mealBreakdowns(targets, mealCaloriesFromItems(items))
+	mealsOver := false
+	for _, m := range meals {
+		mealsOver = mealsOver || m.OverBudget
+	}
+
+	summary := dailySummary{
 		Date:             date,
 		CalorieBudget:    settings.CalorieBudget,
 		CaloriesFood:     caloriesFood,
@@ -145,9 +236,41 @@ func (h *Handler) getDailySummary(c *gin.Context) {
 		ProteinG:         proteinG,
 		CarbsG:           carbsG,
 		FatG:             fatG,
+		ScheduleLabel:    targets.ScheduleLabel,
+		Meals:            meals,
+		MealsOverBudget:  mealsOver,
 		Items:            items,
 		Settings:         settings,
-	})
+	}
+	// Offsets use the current weight, as the offsets and what-if endpoints do
+	// (currentWeightLBS), so a day shows the same suggestions on every screen.
+	offsetWeight, _ := h.latestWeightLBS(c, userID, settings.WeightLBS)
+	summary.ExerciseOffsets = offsetsForSummary(summary, offsetActivities(&settings), offsetWeight)
+
+	note, marked, err := h.logDay(c, userID, date)
+	if err != nil {
+		return dailySummary{}, 0, errors.New("failed to fetch note")
+	}
+	summary.Note = note
+	summary.Completeness = newDayCompleteness(itemTypes(items), marked, completeDayRule(&settings), completeDayMeals(&settings))
+
+	// Fasting needs the previous day's last meal for the overnight gap.
+	first, last := mealTimesFromItems(items)
+	var prevLast *ClockTime
+	if first != nil {
+		prev := dayBefore(date)
+		if rows, err := h.fetchMealTimes(c, userID, prev, prev); err == nil && len(rows) == 1 {
+			prevLast = rows[0].LastMeal
+		}
+	}
+	summary.Fasting = newFastingDay(first, last, prevLast, settings.FastingTargetHours)
+
+	water, err := h.waterTotalsByDate(c, userID, date, date)
+	if err != nil {
+		return dailySummary{}, 0, errors.New("failed to fetch water totals")
+	}
+	summary.Water = newWaterTotals(water[date].LoggedML, water[date].FromItemsML, settings.WaterTargetML, settings.Units)
+	return summary, w, nil
 }
 
 // currentMonday is defined in tdee.go.
@@ -204,7 +327,11 @@ func (h *Handler) getWeekSummary(c *gin.Context) {
 			SUM(CASE WHEN type  = 'exercise' THEN calories ELSE 0 END) AS calories_exercise,
 			COALESCE(SUM(protein_g), 0) AS protein_g,
 			COALESCE(SUM(carbs_g),   0) AS carbs_g,
-			COALESCE(SUM(fat_g),     0) AS fat_g
+			COALESCE(SUM(fat_g),     0) AS fat_g,
+			SUM(CASE WHEN type = 'breakfast' THEN calories ELSE 0 END) AS calories_breakfast,
+			SUM(CASE WHEN type = 'lunch'     THEN calories ELSE 0 END) AS calories_lunch,
+			SUM(CASE WHEN type = 'dinner'    THEN calories ELSE 0 END) AS calories_dinner,
+			SUM(CASE WHEN type = 'snack'     THEN calories ELSE 0 END) AS calories_snack
 		 FROM calorie_log_items
 		 WHERE user_id = @userID AND date >= @weekStart AND date <= @weekEnd
 		 GROUP BY date`,
@@ -218,6 +345,12 @@ func (h *Handler) getWeekSummary(c *gin.Context) {
 		return
 	}
 
+	water, err := h.waterTotalsByDate(c, userID, weekStart.Format("2006-01-02"), weekEnd.Format("2006-01-02"))
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch water totals")
+		return
+	}
+
 	// Index DB rows by date string for O(1) merge.
 	rowByDate := make(map[string]weekDayDBRow, len(rows))
 	for _, r := range rows {
@@ -232,12 +365,21 @@ func (h *Handler) getWeekSummary(c *gin.Context) {
 	for i := 0; i < 7; i++ {
 		d := weekStart.AddDate(0, 0, i)
 		dateStr := d.Format("2006-01-02")
-		budget, actLevel := configForDate(configHistory, &settings, dateStr)
+		w := weightAtOrBefore(weightEntries, dateStr, fallbackWeight)
+		targets := configForDate(configHistory, &settings, dateStr)
+		targets.resolveMacros(w)
+		budget := targets.CalorieBudget
 		day := weekDaySummary{
-			Date:          DateOnly{d},
-			CalorieBudget: budget,
+			Date:           DateOnly{d},
+			CalorieBudget:  budget,
+			ScheduleLabel:  targets.ScheduleLabel,
+			ProteinTargetG: targets.ProteinTargetG,
+			CarbsTargetG:   targets.CarbsTargetG,
+			FatTargetG:     targets.FatTargetG,
 		}
+		consumed := map[string]int{}
 		if row, ok := rowByDate[dateStr]; ok {
+			consumed = row.mealCalories()
 			day.HasData = true
 			day.CaloriesFood = row.CaloriesFood
 			day.CaloriesExercise = row.CaloriesExercise
@@ -247,12 +389,13 @@ func (h *Handler) getWeekSummary(c *gin.Context) {
 		}
 		day.NetCalories = day.CaloriesFood - day.CaloriesExercise
 		day.CaloriesLeft = budget - day.NetCalories
+		day.Meals = mealBreakdowns(targets, consumed)
+		day.WaterML = water[dateStr].LoggedML + water[dateStr].FromItemsML
 
 		// Accumulate TDEE-based deficit only for days with logged data.
 		if tdeeAvailable && day.HasData {
-			w := weightAtOrBefore(weightEntries, dateStr, fallbackWeight)
 			asOf, _ := time.Parse("2006-01-02", dateStr)
-			if dayTDEE, ok := tdeeForDay(&settings, w, actLevel, asOf); ok {
+			if dayTDEE, ok := tdeeForDay(&settings, w, targets.ActivityLevel, asOf); ok {
 				totalDeficit += dayTDEE - float64(day.NetCalories)
 			} else {
 				tdeeAvailable = false
@@ -275,7 +418,7 @@ func (h *Handler) getWeekSummary(c *gin.Context) {
 		estimatedWC = &wc
 	}
 
-	c.JSON(http.StatusOK, weekSummaryResponse{Days: result, EstimatedWeightChangeLbs: estimatedWC})
+	c.JSON(http.StatusOK, weekSummaryResponse{Days: result, EstimatedWeightChangeLbs: estimatedWC, WaterTargetML: settings.WaterTargetML})
 }
 
 // getProgress returns per-day calorie totals and aggregate stats for an arbitrary date range.
@@ -283,34 +426,55 @@ func (h *Handler) getWeekSummary(c *gin.Context) {
 // Only days with logged items are returned (no gap-filling — the frontend handles that).
 func (h *Handler) getProgress(c *gin.Context) {
 	userID := c.GetInt("user_id")
-	start := c.Query("start")
-	end := c.Query("end")
+	start, end, ok := progressRange(c)
+	if !ok {
+		return
+	}
+
+	resp, _, err := h.buildProgress(c, userID, start, end)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// progressRange reads and validates the required start/end query params.
+// On failure it writes a 400 and returns ok=false.
+func progressRange(c *gin.Context) (start, end string, ok bool) {
+	start = c.Query("start")
+	end = c.Query("end")
 
 	if start == "" || end == "" {
 		apiError(c, http.StatusBadRequest, "start and end query params are required")
-		return
+		return "", "", false
 	}
 	if _, err := time.Parse("2006-01-02", start); err != nil {
 		apiError(c, http.StatusBadRequest, "invalid start, expected YYYY-MM-DD")
-		return
+		return "", "", false
 	}
 	if _, err := time.Parse("2006-01-02", end); err != nil {
 		apiError(c, http.StatusBadRequest, "invalid end, expected YYYY-MM-DD")
-		return
+		return "", "", false
 	}
 	if start > end {
 		apiError(c, http.StatusBadRequest, "start must not be after end")
-		return
+		return "", "", false
 	}
+	return start, end, true
+}
 
+// buildProgress computes the per-day totals and aggregate stats behind
+// getProgress for [start, end]. Also returns the weight log up to end, which
+// the report uses for the weight trend. Errors carry a user-facing message.
+func (h *Handler) buildProgress(c *gin.Context, userID int, start, end string) (progressResponse, []weightEntry, error) {
 	// Get the user's settings, config history, and weight log in parallel via
 	// separate queries — all are needed for per-day TDEE and budget resolution.
 	settings, err := queryOne[calorieLogUserSettings](h.db, c,
 		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
 		pgx.NamedArgs{"userID": userID})
 	if err != nil {
-		apiError(c, http.StatusInternalServerError, "failed to fetch settings")
-		return
+		return progressResponse{}, nil, errors.New("failed to fetch settings")
 	}
 
 	// Fetch all config history sorted ascending — configForDate scans from oldest
@@ -340,15 +504,18 @@ func (h *Handler) getProgress(c *gin.Context) {
 			SUM(CASE WHEN type  = 'exercise' THEN calories ELSE 0 END) AS calories_exercise,
 			COALESCE(SUM(protein_g), 0) AS protein_g,
 			COALESCE(SUM(carbs_g),   0) AS carbs_g,
-			COALESCE(SUM(fat_g),     0) AS fat_g
+			COALESCE(SUM(fat_g),     0) AS fat_g,
+			SUM(CASE WHEN type = 'breakfast' THEN calories ELSE 0 END) AS calories_breakfast,
+			SUM(CASE WHEN type = 'lunch'     THEN calories ELSE 0 END) AS calories_lunch,
+			SUM(CASE WHEN type = 'dinner'    THEN calories ELSE 0 END) AS calories_dinner,
+			SUM(CASE WHEN type = 'snack'     THEN calories ELSE 0 END) AS calories_snack
 		 FROM calorie_log_items
 		 WHERE user_id = @userID AND date >= @start AND date <= @end
 		 GROUP BY date
 		 ORDER BY date ASC`,
 		pgx.NamedArgs{"userID": userID, "start": start, "end": end})
 	if err != nil {
-		apiError(c, http.StatusInternalServerError, "failed to fetch progress data")
-		return
+		return progressResponse{}, nil, errors.New("failed to fetch progress data")
 	}
 
 	// Build weekDaySummary slice (same shape as getWeekSummary) and compute stats.
@@ -360,9 +527,13 @@ func (h *Handler) getProgress(c *gin.Context) {
 	tdeeAvailable := true
 	for _, row := range rows {
 		dateStr := row.Date.Format("2006-01-02")
-		budget, actLevel := configForDate(configHistory, &settings, dateStr)
+		w := weightAtOrBefore(weightEntries, dateStr, fallbackWeight)
+		targets := configForDate(configHistory, &settings, dateStr)
+		targets.resolveMacros(w)
+		budget := targets.CalorieBudget
 		net := row.CaloriesFood - row.CaloriesExercise
 		left := budget - net
+		meals := mealBreakdowns(targets, row.mealCalories())
 		days = append(days, weekDaySummary{
 			Date:             row.Date,
 			CalorieBudget:    budget,
@@ -374,11 +545,26 @@ func (h *Handler) getProgress(c *gin.Context) {
 			CarbsG:           row.CarbsG,
 			FatG:             row.FatG,
 			HasData:          true,
+			ScheduleLabel:    targets.ScheduleLabel,
+			ProteinTargetG:   targets.ProteinTargetG,
+			CarbsTargetG:     targets.CarbsTargetG,
+			FatTargetG:       targets.FatTargetG,
+			Meals:            meals,
 		})
 		stats.DaysTracked++
+		stats.MealAdherence = addMealAdherence(stats.MealAdherence, meals)
 		if net <= budget {
 			stats.DaysOnBudget++
 		}
+		if row.ProteinG >= float64(targets.ProteinTargetG) {
+			stats.DaysProteinOnTarget++
+		}
+		if row.CarbsG <= float64(targets.CarbsTargetG) {
+			stats.DaysCarbsOnTarget++
+		}
+		if row.FatG <= float64(targets.FatTargetG) {
+			stats.DaysFatOnTarget++
+		}
 		stats.AvgCaloriesFood += row.CaloriesFood
 		stats.AvgCaloriesExercise += row.CaloriesExercise
 		stats.AvgNetCalories += net
@@ -387,8 +573,7 @@ func (h *Handler) getProgress(c *gin.Context) {
 		// Per-day TDEE using historical weight and age at that date.
 		if tdeeAvailable {
 			asOf, _ := time.Parse("2006-01-02", dateStr)
-			w := weightAtOrBefore(weightEntries, dateStr, fallbackWeight)
-			if dayTDEE, ok := tdeeForDay(&settings, w, actLevel, asOf); ok {
+			if dayTDEE, ok := tdeeForDay(&settings, w, targets.ActivityLevel, asOf); ok {
 				totalDeficit += dayTDEE - float64(net)
 			} else {
 				tdeeAvailable = false
@@ -396,12 +581,38 @@ func (h *Handler) getProgress(c *gin.Context) {
 		}
 	}
 
+	// Fully logged days; marked days count even without items under the 'mark' rule.
+	logged, err := h.fetchLoggedDays(c, userID, start, end)
+	if err != nil {
+		return progressResponse{}, nil, errors.New("failed to fetch logged days")
+	}
+	rule, required := completeDayRule(&settings), completeDayMeals(&settings)
+	for _, d := range logged {
+		if newDayCompleteness(d.Types, d.Marked, rule, required).Complete {
+			stats.DaysComplete++
+		}
+	}
+
 	// Convert totals to averages.
 	if stats.DaysTracked > 0 {
 		stats.AvgCaloriesFood /= stats.DaysTracked
 		stats.AvgCaloriesExercise /= stats.DaysTracked
 		stats.AvgNetCalories /= stats.DaysTracked
 	}
+	stats.MealAdherence = finishMealAdherence(stats.MealAdherence)
+	if stats.MealAdherence == nil {
+		stats.MealAdherence = []mealAdherence{}
+	}
+
+	// Fasting adherence; the day before start is included so start's fast can be measured.
+	if settings.FastingTargetHours != nil {
+		mealTimes, err := h.fetchMealTimes(c, userID, dayBefore(start), end)
+		if err != nil {
+			return progressResponse{}, nil, errors.New("failed to fetch meal times")
+		}
+		fs := computeFastingStats(mealTimes, start, end, *settings.FastingTargetHours)
+		stats.Fasting = &fs
+	}
 
 	// Set TDEE-based weight change estimate when profile is complete.
 	// Positive = calorie surplus (gaining), negative = deficit (losing).
@@ -412,7 +623,7 @@ func (h *Handler) getProgress(c *gin.Context) {
 		stats.EstimatedWeightChangeLbs = &wc
 	}
 
-	c.JSON(http.StatusOK, progressResponse{Days: days, Stats: stats})
+	return progressResponse{Days: days, Stats: stats}, weightEntries, nil
 }
 
 // getEarliestLogDate returns the earliest date the user has a calorie log entry.
@@ -474,10 +685,36 @@ func (h *Handler) createCalorieLogItem(c *gin.Context) {
 	if body.Date == "" {
 		body.Date = time.Now().Format("2006-01-02")
 	}
+	if body.Source == "" && body.FavoriteID != nil && body.MealPlanEntryID == nil && body.RecipeID == nil {
+		body.Source = "favorite"
+	}
+	source, confidence, err := resolveItemSource(body.Source, body.Confidence, body.RecipeID, body.MealPlanEntryID)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.ConsumedAt != nil {
+		if _, err := parseClockTime(*body.ConsumedAt); err != nil {
+			apiError(c, http.StatusBadRequest, "invalid consumed_at, expected HH:MM")
+			return
+		}
+	}
+	if body.HydrationML != nil && *body.HydrationML <= 0 {
+		apiError(c, http.StatusBadRequest, "hydration_ml must be positive")
+		return
+	}
 
+	// Logging from a favorite bumps its usage; the CTE also drops a favorite_id
+	// that isn't the user's.
 	item, err := queryOne[calorieLogItem](h.db, c,
-		`INSERT INTO calorie_log_items (user_id, date, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g, recipe_id, meal_plan_entry_id)
-		 VALUES (@userID, @date, @itemName, @type, @qty, @uom, @calories, @proteinG, @carbsG, @fatG, @recipeID, @mealPlanEntryID)
+		`WITH used AS (
+			UPDATE calorie_log_favorites SET use_count = use_count + 1, last_used_at = now()
+			WHERE id = @favoriteID AND user_id = @userID
+			RETURNING id
+		 )
+		 INSERT INTO calorie_log_items (user_id, date, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g, recipe_id, meal_plan_entry_id, source, confidence, consumed_at, hydration_ml, favorite_id, recipe_revision_id)
+		 VALUES (@userID, @date, @itemName, @type, @qty, @uom, @calories, @proteinG, @carbsG, @fatG, @recipeID, @mealPlanEntryID, @source, @confidence, @consumedAt::time, @hydrationML, (SELECT id FROM used),
+		         (SELECT MAX(id) FROM recipe_revisions WHERE recipe_id = @recipeID))
 		 RETURNING *`,
 		pgx.NamedArgs{
 			"userID": userID, "date": body.Date, "itemName": body.ItemName,
@@ -485,6 +722,8 @@ func (h *Handler) createCalorieLogItem(c *gin.Context) {
 			"calories": body.Calories, "proteinG": body.ProteinG,
 			"carbsG": body.CarbsG, "fatG": body.FatG,
 			"recipeID": body.RecipeID, "mealPlanEntryID": body.MealPlanEntryID,
+			"source": source, "confidence": confidence, "consumedAt": body.ConsumedAt,
+			"hydrationML": body.HydrationML, "favoriteID": body.FavoriteID,
 		})
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to create item")
@@ -512,11 +751,33 @@ func (h *Handler) updateCalorieLogItem(c *gin.Context) {
 		FatG            *float64 `json:"fat_g"`
 		RecipeID        *int     `json:"recipe_id"`
 		MealPlanEntryID *int     `json:"meal_plan_entry_id"`
+		Source          *string  `json:"source"`
+		Confidence      *int     `json:"confidence"`
+		ConsumedAt      *string  `json:"consumed_at"`  // HH:MM; "" clears it
+		HydrationML     *int     `json:"hydration_ml"` // 0 stops counting toward water
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		apiError(c, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if body.Source != nil && !validItemSources[*body.Source] {
+		apiError(c, http.StatusBadRequest, invalidItemSourceMsg)
+		return
+	}
+	if body.Confidence != nil && (*body.Confidence < 1 || *body.Confidence > 5) {
+		apiError(c, http.StatusBadRequest, "confidence must be between 1 and 5")
+		return
+	}
+	if body.HydrationML != nil && *body.HydrationML < 0 {
+		apiError(c, http.StatusBadRequest, "hydration_ml must not be negative")
+		return
+	}
+	if body.ConsumedAt != nil && *body.ConsumedAt != "" {
+		if _, err := parseClockTime(*body.ConsumedAt); err != nil {
+			apiError(c, http.StatusBadRequest, "invalid consumed_at, expected HH:MM")
+			return
+		}
+	}
 
 	item, err := queryOne[calorieLogItem](h.db, c,
 		`UPDATE calorie_log_items SET
@@ -530,7 +791,15 @@ func (h *Handler) updateCalorieLogItem(c *gin.Context) {
 			carbs_g = COALESCE(@carbsG, carbs_g),
 			fat_g = COALESCE(@fatG, fat_g),
 			recipe_id = COALESCE(@recipeID, recipe_id),
+			recipe_revision_id = CASE WHEN @recipeID::int IS NULL OR @recipeID::int = recipe_id THEN recipe_revision_id
+			                          ELSE (SELECT MAX(id) FROM recipe_revisions WHERE recipe_id = @recipeID::int) END,
 			meal_plan_entry_id = COALESCE(@mealPlanEntryID, meal_plan_entry_id),
+			source = COALESCE(@source, source),
+			confidence = COALESCE(@confidence, confidence),
+			consumed_at = CASE WHEN @consumedAt::text IS NULL THEN consumed_at
+			                   ELSE NULLIF(@consumedAt::text, '')::time END,
+			hydration_ml = CASE WHEN @hydrationML::int IS NULL THEN hydration_ml
+			                    ELSE NULLIF(@hydrationML::int, 0) END,
 			updated_at = now()
 		 WHERE id = @id AND user_id = @userID
 		 RETURNING *`,
@@ -540,6 +809,8 @@ func (h *Handler) updateCalorieLogItem(c *gin.Context) {
 			"qty": body.Qty, "uom": body.Uom, "calories": body.Calories,
 			"proteinG": body.ProteinG, "carbsG": body.CarbsG, "fatG": body.FatG,
 			"recipeID": body.RecipeID, "mealPlanEntryID": body.MealPlanEntryID,
+			"source": body.Source, "confidence": body.Confidence, "consumedAt": body.ConsumedAt,
+			"hydrationML": body.HydrationML,
 		})
 	if err != nil {
 		apiError(c, http.StatusNotFound, "item not found")
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// copyCalorieLogItemsRequest is the body for POST /api/calorie-log/items/copy.
+// MealType limits the copy to one meal; TargetType re-files the copies under a
+// different meal (e.g. yesterday's lunch as today's dinner).
+type copyCalorieLogItemsRequest struct {
+	SourceDate string  `json:"source_date" binding:"required"`
+	TargetDate string  `json:"target_date" binding:"required"`
+	MealType   *string `json:"meal_type"`
+	TargetType *string `json:"target_type"`
+}
+
+// moveCalorieLogItemsRequest is the body for POST /api/calorie-log/items/move.
+// At least one of TargetDate or TargetType must be set.
+type moveCalorieLogItemsRequest struct {
+	IDs        []int   `json:"ids" binding:"required"`
+	TargetDate *string `json:"target_date"`
+	TargetType *string `json:"target_type"`
+}
+
+// deleteCalorieLogItemsRequest is the body for POST /api/calorie-log/items/bulk-delete.
+type deleteCalorieLogItemsRequest struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// bulkItemsResponse is returned by the bulk item endpoints: how many items were
+// affected and the refreshed daily summary for every date that changed.
+type bulkItemsResponse struct {
+	Affected int            `json:"affected"`
+	Days     []dailySummary `json:"days"`
+}
+
+// validateBulkType checks an optional meal/item type from a bulk request.
+func validateBulkType(c *gin.Context, field string, t *string) bool {
+	if t != nil && !validItemTypes[*t] {
+		apiError(c, http.StatusBadRequest, field+" must be one of: breakfast, lunch, dinner, snack, exercise")
+		return false
+	}
+	return true
+}
+
+// validateBulkDate checks a YYYY-MM-DD date from a bulk request.
+func validateBulkDate(c *gin.Context, field, date string) bool {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid "+field+", expected YYYY-MM-DD")
+		return false
+	}
+	return true
+}
+
+// respondWithSummaries sends a bulkItemsResponse with the daily summary for each
+// affected date, oldest first. It runs after the commit, so a summary that fails
+// to load is logged and left out rather than reported as a failed write.
+func (h *Handler) respondWithSummaries(c *gin.Context, userID, affected int, dates map[string]bool) {
+	sorted := make([]string, 0, len(dates))
+	for d := range dates {
+		sorted = append(sorted, d)
+	}
+	sort.Strings(sorted)
+
+	days := make([]dailySummary, 0, len(sorted))
+	for _, d := range sorted {
+		summary, _, err := h.buildDailySummary(c, userID, d)
+		if err != nil {
+			log.Printf("[calorie-log/bulk] summary for %s: %v", d, err)
+			continue
+		}
+		days = append(days, summary)
+	}
+	c.JSON(http.StatusOK, bulkItemsResponse{Affected: affected, Days: days})
+}
+
+// copyCalorieLogItems copies all items (or one meal's items) from one date to
+// another in a single transaction. Existing items on the target date are kept —
+// copies are added alongside them, same as copyMealPlanWeek. Copies drop the
+// meal plan link so the plan entry isn't shown as logged twice, and a copied
+// meal_plan item is recorded as manual.
+// POST /api/calorie-log/items/copy.
+func (h *Handler) copyCalorieLogItems(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var body copyCalorieLogItemsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "source_date and target_date are required")
+		return
+	}
+	if !validateBulkDate(c, "source_date", body.SourceDate) || !validateBulkDate(c, "target_date", body.TargetDate) ||
+		!validateBulkType(c, "meal_type", body.MealType) || !validateBulkType(c, "target_type", body.TargetType) {
+		return
+	}
+	// Re-filing only makes sense for a single meal, and never across food/exercise.
+	if body.TargetType != nil {
+		if body.MealType == nil {
+			apiError(c, http.StatusBadRequest, "target_type requires meal_type")
+			return
+		}
+		if (*body.MealType == "exercise") != (*body.TargetType == "exercise") {
+			apiError(c, http.StatusBadRequest, "cannot copy between exercise and food types")
+			return
+		}
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	tag, err := tx.Exec(c,
+		`INSERT INTO calorie_log_items
+		   (user_id, date, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g, recipe_id, source, confidence, consumed_at, hydration_ml, recipe_revision_id)
+		 SELECT user_id, @targetDate, item_name, COALESCE(@targetType::calorie_log_item_type, type),
+		        qty, uom, calories, protein_g, carbs_g, fat_g, recipe_id,
+		        CASE WHEN source = 'meal_plan' THEN 'manual' ELSE source END, confidence, consumed_at, hydration_ml, recipe_revision_id
+		 FROM calorie_log_items
+		 WHERE user_id = @userID AND date = @sourceDate
+		   AND (@mealType::calorie_log_item_type IS NULL OR type = @mealType::calorie_log_item_type)
+		 ORDER BY created_at`,
+		pgx.NamedArgs{
+			"userID":     userID,
+			"sourceDate": body.SourceDate,
+			"targetDate": body.TargetDate,
+			"mealType":   body.MealType,
+			"targetType": body.TargetType,
+		})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to copy items")
+		return
+	}
+
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	h.respondWithSummaries(c, userID, int(tag.RowsAffected()), map[string]bool{body.TargetDate: true})
+}
+
+// moveCalorieLogItems moves a set of items to another date and/or meal type in a
+// single transaction. Fails with 404 if any ID isn't one of the user's items, so
+// a partial move never happens.
+// POST /api/calorie-log/items/move.
+func (h *Handler) moveCalorieLogItems(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var body moveCalorieLogItemsRequest
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.IDs) == 0 {
+		apiError(c, http.StatusBadRequest, "ids is required")
+		return
+	}
+	if body.TargetDate == nil && body.TargetType == nil {
+		apiError(c, http.StatusBadRequest, "target_date or target_type is required")
+		return
+	}
+	if body.TargetDate != nil && !validateBulkDate(c, "target_date", *body.TargetDate) {
+		return
+	}
+	if !validateBulkType(c, "target_type", body.TargetType) {
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	// Lock the items and collect their current dates/types for validation and
+	// for the list of affected days.
+	rows, err := tx.Query(c,
+		`SELECT * FROM calorie_log_items WHERE user_id = @userID AND id = ANY(@ids) FOR UPDATE`,
+		pgx.NamedArgs{"userID": userID, "ids": body.IDs})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch items")
+		return
+	}
+	items, err := pgx.CollectRows(rows, pgx.RowToStructByName[calorieLogItem])
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch items")
+		return
+	}
+	if len(items) != len(uniqueInts(body.IDs)) {
+		apiError(c, http.StatusNotFound, "item not found")
+		return
+	}
+
+	dates := map[string]bool{}
+	for _, it := range items {
+		dates[it.Date.Format("2006-01-02")] = true
+		if body.TargetType != nil && (it.Type == "exercise") != (*body.TargetType == "exercise") {
+			apiError(c, http.StatusBadRequest, "cannot move items between exercise and food types")
+			return
+		}
+	}
+	if body.TargetDate != nil {
+		dates[*body.TargetDate] = true
+	}
+
+	tag, err := tx.Exec(c,
+		`UPDATE calorie_log_items SET
+			date = COALESCE(@targetDate::date, date),
+			type = COALESCE(@targetType::calorie_log_item_type, type),
+			updated_at = now()
+		 WHERE user_id = @userID AND id = ANY(@ids)`,
+		pgx.NamedArgs{
+			"userID":     userID,
+			"ids":        body.IDs,
+			"targetDate": body.TargetDate,
+			"targetType": body.TargetType,
+		})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to move items")
+		return
+	}
+
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	h.respondWithSummaries(c, userID, int(tag.RowsAffected()), dates)
+}
+
+// deleteCalorieLogItems deletes a set of items in a single transaction. Fails
+// with 404 (deleting nothing) if any ID isn't one of the user's items.
+// POST /api/calorie-log/items/bulk-delete.
+func (h *Handler) deleteCalorieLogItems(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var body deleteCalorieLogItemsRequest
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.IDs) == 0 {
+		apiError(c, http.StatusBadRequest, "ids is required")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	rows, err := tx.Query(c,
+		`DELETE FROM calorie_log_items WHERE user_id = @userID AND id = ANY(@ids) RETURNING date`,
+		pgx.NamedArgs{"userID": userID, "ids": body.IDs})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete items")
+		return
+	}
+	deleted, err := pgx.CollectRows(rows, pgx.RowTo[DateOnly])
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete items")
+		return
+	}
+	if len(deleted) != len(uniqueInts(body.IDs)) {
+		apiError(c, http.StatusNotFound, "item not found")
+		return
+	}
+
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	dates := map[string]bool{}
+	for _, d := range deleted {
+		dates[d.Format("2006-01-02")] = true
+	}
+	h.respondWithSummaries(c, userID, len(deleted), dates)
+}
+
+// uniqueInts returns ids with duplicates removed, preserving order.
+func uniqueInts(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doBulkRequest runs a bulk endpoint with no DB — only request validation,
+// which happens before any query, can be exercised this way.
+func doBulkRequest(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/bulk", func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	}, handler)
+	req := httptest.NewRequest("POST", "/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBulkItems_Validation(t *testing.T) {
+	h := Handler{}
+	cases := []struct {
+		name    string
+		handler gin.HandlerFunc
+		body    string
+	}{
+		{"copy missing target", h.copyCalorieLogItems, `{"source_date":"2024-01-01"}`},
+		{"copy bad date", h.copyCalorieLogItems, `{"source_date":"2024-01-01","target_date":"tomorrow"}`},
+		{"copy target_type without meal_type", h.copyCalorieLogItems, `{"source_date":"2024-01-01","target_date":"2024-01-02","target_type":"dinner"}`},
+		{"copy food into exercise", h.copyCalorieLogItems, `{"source_date":"2024-01-01","target_date":"2024-01-02","meal_type":"lunch","target_type":"exercise"}`},
+		{"move without target", h.moveCalorieLogItems, `{"ids":[1,2]}`},
+		{"move empty ids", h.moveCalorieLogItems, `{"ids":[],"target_date":"2024-01-02"}`},
+		{"move bad type", h.moveCalorieLogItems, `{"ids":[1],"target_type":"brunch"}`},
+		{"delete empty ids", h.deleteCalorieLogItems, `{"ids":[]}`},
+	}
+	for _, tc := range cases {
+		if w := doBulkRequest(tc.handler, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", tc.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestUniqueInts(t *testing.T) {
+	got := uniqueInts([]int{3, 1, 3, 2, 1})
+	if len(got) != 3 || got[0] != 3 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("want [3 1 2], got %v", got)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxDayNoteLength caps a day note; notes are short annotations, not a journal.
+const maxDayNoteLength = 2000
+
+// getDayNotes returns the user's day notes within [start, end], oldest first.
+// GET /api/calorie-log/days/notes?start=YYYY-MM-DD&end=YYYY-MM-DD.
+func (h *Handler) getDayNotes(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	start := c.Query("start")
+	end := c.Query("end")
+
+	if start == "" || end == "" {
+		apiError(c, http.StatusBadRequest, "start and end query params are required")
+		return
+	}
+	if _, err := time.Parse("2006-01-02", start); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid start, expected YYYY-MM-DD")
+		return
+	}
+	if _, err := time.Parse("2006-01-02", end); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid end, expected YYYY-MM-DD")
+		return
+	}
+
+	notes, err := queryMany[calorieLogDay](h.db, c,
+		`SELECT * FROM calorie_log_days
+		 WHERE user_id = @userID AND date >= @start AND date <= @end AND note IS NOT NULL
+		 ORDER BY date ASC`,
+		pgx.NamedArgs{"userID": userID, "start": start, "end": end})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch notes")
+		return
+	}
+	if notes == nil {
+		notes = []calorieLogDay{}
+	}
+	c.JSON(http.StatusOK, notes)
+}
+
+// putDayNote sets the note for a day. A blank note clears it (204).
+// PUT /api/calorie-log/days/:date/note. Body: { "note": "ate out, estimates rough" }.
+func (h *Handler) putDayNote(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	var body struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	note := strings.TrimSpace(body.Note)
+	if utf8.RuneCountInString(note) > maxDayNoteLength {
+		apiError(c, http.StatusBadRequest, "note must be at most 2000 characters")
+		return
+	}
+
+	args := pgx.NamedArgs{"userID": userID, "date": date, "note": note}
+	if note == "" {
+		// Keep the row if the day is still marked complete.
+		if err := h.clearLogDay(c, args,
+			"DELETE FROM calorie_log_days WHERE user_id = @userID AND date = @date AND NOT completed",
+			"UPDATE calorie_log_days SET note = NULL, updated_at = now() WHERE user_id = @userID AND date = @date"); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to delete note")
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	day, err := queryOne[calorieLogDay](h.db, c,
+		`INSERT INTO calorie_log_days (user_id, date, note)
+		 VALUES (@userID, @date, @note)
+		 ON CONFLICT (user_id, date) DO UPDATE SET note = EXCLUDED.note, updated_at = now()
+		 RETURNING *`, args)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to save note")
+		return
+	}
+	c.JSON(http.StatusOK, day)
+}
+
+// putDayComplete marks a day complete or not, for the 'mark' streak rule.
+// PUT /api/calorie-log/days/:date/complete. Body: { "complete": true }.
+// Unmarking a day without a note removes its row (204).
+func (h *Handler) putDayComplete(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	var body struct {
+		Complete *bool `json:"complete"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Complete == nil {
+		apiError(c, http.StatusBadRequest, "complete is required")
+		return
+	}
+
+	args := pgx.NamedArgs{"userID": userID, "date": date}
+	if !*body.Complete {
+		if err := h.clearLogDay(c, args,
+			"DELETE FROM calorie_log_days WHERE user_id = @userID AND date = @date AND note IS NULL",
+			"UPDATE calorie_log_days SET completed = false, updated_at = now() WHERE user_id = @userID AND date = @date"); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to unmark day")
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	day, err := queryOne[calorieLogDay](h.db, c,
+		`INSERT INTO calorie_log_days (user_id, date, completed)
+		 VALUES (@userID, @date, true)
+		 ON CONFLICT (user_id, date) DO UPDATE SET completed = true, updated_at = now()
+		 RETURNING *`, args)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to mark day complete")
+		return
+	}
+	c.JSON(http.StatusOK, day)
+}
+
+// clearLogDay clears one field of a calorie_log_days row: deleteSQL removes the
+// row when the other field is empty too, otherwise updateSQL clears the field.
+func (h *Handler) clearLogDay(c *gin.Context, args pgx.NamedArgs, deleteSQL, updateSQL string) error {
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+	if _, err := tx.Exec(c, deleteSQL, args); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(c, updateSQL, args); err != nil {
+		return err
+	}
+	return tx.Commit(c)
+}
+
+// logDay returns the note and completion mark for a day; nil and false when
+// the day has neither.
+func (h *Handler) logDay(c *gin.Context, userID int, date string) (note *string, completed bool, err error) {
+	err = h.db.QueryRow(c,
+		`SELECT (SELECT note FROM calorie_log_days WHERE user_id = @userID AND date = @date),
+		        EXISTS (SELECT 1 FROM calorie_log_days WHERE user_id = @userID AND date = @date AND completed)`,
+		pgx.NamedArgs{"userID": userID, "date": date}).Scan(&note, &completed)
+	return note, completed, err
+}
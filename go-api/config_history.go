@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// withHistoryRanges pairs each history row (sorted by valid_until ascending) with
+// the first date it covers: the day after the previous row's valid_until.
+func withHistoryRanges(rows []calorieConfigHistory) []configHistoryRange {
+	out := make([]configHistoryRange, len(rows))
+	for i, r := range rows {
+		out[i] = configHistoryRange{calorieConfigHistory: r}
+		if i > 0 {
+			from := DateOnly{rows[i-1].ValidUntil.AddDate(0, 0, 1)}
+			out[i].ValidFrom = &from
+		}
+	}
+	return out
+}
+
+// historyBoundaryRow returns the row that must be inserted so a history range
+// ends exactly on date, splitting whichever range currently covers it. The new
+// row copies the covering row's targets (or the current settings, when date is
+// past the newest row). Returns false when a row already ends on date.
+// rows must be sorted by valid_until ascending.
+func historyBoundaryRow(rows []calorieConfigHistory, settings *calorieLogUserSettings, date time.Time) (calorieConfigHistory, bool) {
+	dateStr := date.Format("2006-01-02")
+	for _, r := range rows {
+		until := r.ValidUntil.Format("2006-01-02")
+		if until == dateStr {
+			return calorieConfigHistory{}, false
+		}
+		if until > dateStr {
+			r.ID = 0
+			r.ValidUntil = DateOnly{date}
+			r.CreatedAt, r.UpdatedAt = nil, nil
+			return r, true
+		}
+	}
+	row, _ := historySnapshot(settings.UserID, dateStr, settings)
+	return row, true
+}
+
+// validateConfigHistoryPatch applies the same rules patchUserSettings uses for
+// the matching settings fields. Returns a user-facing error message on failure.
+func validateConfigHistoryPatch(p configHistoryPatch) error {
+	if p.CalorieBudget != nil && *p.CalorieBudget <= 0 {
+		return fmt.Errorf("calorie_budget must be positive")
+	}
+	if p.ActivityLevel != nil {
+		if _, ok := activityMultipliers[*p.ActivityLevel]; !ok {
+			return fmt.Errorf("activity_level must be one of: sedentary, light, moderate, active, very_active")
+		}
+	}
+	if p.BudgetSchedule != nil {
+		days, err := parseBudgetSchedule(p.BudgetSchedule)
+		if err != nil {
+			return fmt.Errorf("budget_schedule must be an array of weekday entries")
+		}
+		if err := validateBudgetSchedule(days); err != nil {
+			return err
+		}
+	}
+	for _, m := range []struct {
+		name  string
+		mode  *string
+		value *float64
+	}{
+		{"protein", p.ProteinTargetMode, p.ProteinTargetValue},
+		{"carbs", p.CarbsTargetMode, p.CarbsTargetValue},
+		{"fat", p.FatTargetMode, p.FatTargetValue},
+	} {
+		if err := validateMacroTarget(m.name, m.mode, m.value); err != nil {
+			return err
+		}
+		if m.mode != nil && *m.mode != "grams" && m.value == nil {
+			return fmt.Errorf("%s_target_value is required for %s mode", m.name, *m.mode)
+		}
+	}
+	for _, v := range []*int{p.ProteinTargetG, p.CarbsTargetG, p.FatTargetG,
+		p.BreakfastBudget, p.LunchBudget, p.DinnerBudget, p.SnackBudget, p.ExerciseTargetCalories} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("targets must not be negative")
+		}
+	}
+	return nil
+}
+
+// configHistorySetClauses builds the SET clause for the fields present in p.
+// Returns nil when p has no fields. An empty budget_schedule array clears it.
+func configHistorySetClauses(p configHistoryPatch, args pgx.NamedArgs) []string {
+	clauses := []string{}
+	add := func(col, arg string, v interface{}) {
+		clauses = append(clauses, col+" = @"+arg)
+		args[arg] = v
+	}
+	if p.CalorieBudget != nil {
+		add("calorie_budget", "calorieBudget", *p.CalorieBudget)
+	}
+	if p.ActivityLevel != nil {
+		add("activity_level", "activityLevel", *p.ActivityLevel)
+	}
+	if p.BudgetSchedule != nil {
+		days, _ := parseBudgetSchedule(p.BudgetSchedule)
+		var schedule interface{}
+		if len(days) > 0 {
+			schedule = string(*p.BudgetSchedule)
+		}
+		clauses = append(clauses, "budget_schedule = @budgetSchedule::jsonb")
+		args["budgetSchedule"] = schedule
+	}
+	if p.ProteinTargetG != nil {
+		add("protein_target_g", "proteinTargetG", *p.ProteinTargetG)
+	}
+	if p.CarbsTargetG != nil {
+		add("carbs_target_g", "carbsTargetG", *p.CarbsTargetG)
+	}
+	if p.FatTargetG != nil {
+		add("fat_target_g", "fatTargetG", *p.FatTargetG)
+	}
+	if p.ProteinTargetMode != nil {
+		add("protein_target_mode", "proteinTargetMode", *p.ProteinTargetMode)
+	}
+	if p.ProteinTargetValue != nil {
+		add("protein_target_value", "proteinTargetValue", *p.ProteinTargetValue)
+	}
+	if p.CarbsTargetMode != nil {
+		add("carbs_target_mode", "carbsTargetMode", *p.CarbsTargetMode)
+	}
+	if p.CarbsTargetValue != nil {
+		add("carbs_target_value", "carbsTargetValue", *p.CarbsTargetValue)
+	}
+	if p.FatTargetMode != nil {
+		add("fat_target_mode", "fatTargetMode", *p.FatTargetMode)
+	}
+	if p.FatTargetValue != nil {
+		add("fat_target_value", "fatTargetValue", *p.FatTargetValue)
+	}
+	if p.BreakfastBudget != nil {
+		add("breakfast_budget", "breakfastBudget", *p.BreakfastBudget)
+	}
+	if p.LunchBudget != nil {
+		add("lunch_budget", "lunchBudget", *p.LunchBudget)
+	}
+	if p.DinnerBudget != nil {
+		add("dinner_budget", "dinnerBudget", *p.DinnerBudget)
+	}
+	if p.SnackBudget != nil {
+		add("snack_budget", "snackBudget", *p.SnackBudget)
+	}
+	if p.ExerciseTargetCalories != nil {
+		add("exercise_target_calories", "exerciseTargetCalories", *p.ExerciseTargetCalories)
+	}
+	if len(clauses) == 0 {
+		return nil
+	}
+	return append(clauses, "updated_at = NOW()")
+}
+
+// listConfigHistory returns the user's config history ranges, oldest first.
+// Dates after the newest range use the current settings.
+// GET /api/calorie-log/config-history.
+func (h *Handler) listConfigHistory(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	rows, err := queryMany[calorieConfigHistory](h.db, c,
+		"SELECT * FROM calorie_config_history WHERE user_id = @userID ORDER BY valid_until ASC",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch config history")
+		return
+	}
+	c.JSON(http.StatusOK, withHistoryRanges(rows))
+}
+
+// updateConfigHistoryRange overwrites the provided target fields for every day
+// from start through end, e.g. to fix a budget that was mis-set last month.
+// Existing ranges that straddle start or end are split first so days outside
+// the range keep their targets. end must be before today — today onward is
+// governed by the current settings.
+// PUT /api/calorie-log/config-history/range.
+func (h *Handler) updateConfigHistoryRange(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req configHistoryRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, "start and end are required")
+		return
+	}
+	start, err := time.Parse("2006-01-02", req.Start)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid start date")
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.End)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid end date")
+		return
+	}
+	if end.Before(start) {
+		apiError(c, http.StatusBadRequest, "end must not be before start")
+		return
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if !end.Before(today) {
+		apiError(c, http.StatusBadRequest, "end must be before today; update settings to change current targets")
+		return
+	}
+	if err := validateConfigHistoryPatch(req.configHistoryPatch); err != nil {
+		apiError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	args := pgx.NamedArgs{"userID": userID, "start": req.Start, "end": req.End}
+	setClauses := configHistorySetClauses(req.configHistoryPatch, args)
+	if setClauses == nil {
+		apiError(c, http.StatusBadRequest, "no fields to update")
+		return
+	}
+
+	settings, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusNotFound, "settings not found")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	rows, err := tx.Query(c,
+		"SELECT * FROM calorie_config_history WHERE user_id = @userID ORDER BY valid_until ASC FOR UPDATE",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch config history")
+		return
+	}
+	history, err := pgx.CollectRows(rows, pgx.RowToStructByName[calorieConfigHistory])
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch config history")
+		return
+	}
+
+	// Split ranges at the day before start and at end, so the rows ending inside
+	// [start, end] cover exactly that span.
+	for _, boundary := range []time.Time{start.AddDate(0, 0, -1), end} {
+		row, ok := historyBoundaryRow(history, &settings, boundary)
+		if !ok {
+			continue
+		}
+		if err := upsertConfigHistory(c, tx, row); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to split config history")
+			return
+		}
+		history = append(history, row)
+		sort.Slice(history, func(i, j int) bool { return history[i].ValidUntil.Before(history[j].ValidUntil.Time) })
+	}
+
+	_, err = tx.Exec(c,
+		"UPDATE calorie_config_history SET "+strings.Join(setClauses, ", ")+
+			" WHERE user_id = @userID AND valid_until BETWEEN @start AND @end",
+		args)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update config history")
+		return
+	}
+
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	h.listConfigHistory(c)
+}
+
+// updateConfigHistoryEntry overwrites the provided target fields on a single
+// history row. The row's date range is unchanged.
+// PUT /api/calorie-log/config-history/:id.
+func (h *Handler) updateConfigHistoryEntry(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var p configHistoryPatch
+	if err := c.ShouldBindJSON(&p); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := validateConfigHistoryPatch(p); err != nil {
+		apiError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	args := pgx.NamedArgs{"id": id, "userID": userID}
+	setClauses := configHistorySetClauses(p, args)
+	if setClauses == nil {
+		apiError(c, http.StatusBadRequest, "no fields to update")
+		return
+	}
+
+	row, err := queryOne[calorieConfigHistory](h.db, c,
+		"UPDATE calorie_config_history SET "+strings.Join(setClauses, ", ")+
+			" WHERE id = @id AND user_id = @userID RETURNING *",
+		args)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "config history entry not found")
+		return
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// deleteConfigHistoryEntry removes a history row. Its dates fall through to the
+// next newer range (or the current settings, if it was the newest).
+// DELETE /api/calorie-log/config-history/:id.
+func (h *Handler) deleteConfigHistoryEntry(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	tag, err := h.db.Exec(c,
+		"DELETE FROM calorie_config_history WHERE id = @id AND user_id = @userID",
+		pgx.NamedArgs{"id": id, "userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete config history entry")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		apiError(c, http.StatusNotFound, "config history entry not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWithHistoryRanges verifies each range starts the day after the previous
+// one ends, and the oldest range has no start.
+func TestWithHistoryRanges(t *testing.T) {
+	ranges := withHistoryRanges(makeConfigHistory("2024-01-31", 2200, "2024-02-28", 2000))
+	if ranges[0].ValidFrom != nil {
+		t.Errorf("want nil valid_from on oldest range, got %v", ranges[0].ValidFrom)
+	}
+	if ranges[1].ValidFrom == nil || ranges[1].ValidFrom.Format("2006-01-02") != "2024-02-01" {
+		t.Errorf("want valid_from 2024-02-01, got %v", ranges[1].ValidFrom)
+	}
+}
+
+// TestHistoryBoundaryRow verifies splitting copies the covering range's targets,
+// falls back to current settings past the newest range, and is skipped when a
+// range already ends on the date.
+func TestHistoryBoundaryRow(t *testing.T) {
+	history := makeConfigHistory("2024-01-31", 2200, "2024-02-28", 2000)
+	settings := settingsWithBudget(1800)
+	day := func(s string) time.Time { d, _ := time.Parse("2006-01-02", s); return d }
+
+	row, ok := historyBoundaryRow(history, settings, day("2024-02-10"))
+	if !ok || row.CalorieBudget != 2000 || row.ValidUntil.Format("2006-01-02") != "2024-02-10" {
+		t.Errorf("want split of Feb range at 2024-02-10, got ok=%v %+v", ok, row)
+	}
+	row, ok = historyBoundaryRow(history, settings, day("2024-03-10"))
+	if !ok || row.CalorieBudget != 1800 {
+		t.Errorf("want current-settings snapshot past newest range, got ok=%v budget=%d", ok, row.CalorieBudget)
+	}
+	if _, ok := historyBoundaryRow(history, settings, day("2024-01-31")); ok {
+		t.Error("want no split when a range already ends on the date")
+	}
+}
+
+// TestValidateConfigHistoryPatch verifies history edits use the same rules as
+// settings patches.
+func TestValidateConfigHistoryPatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		patch   configHistoryPatch
+		wantErr bool
+	}{
+		{"budget ok", configHistoryPatch{CalorieBudget: intPtr(1900)}, false},
+		{"zero budget", configHistoryPatch{CalorieBudget: intPtr(0)}, true},
+		{"bad activity level", configHistoryPatch{ActivityLevel: strPtr("couch")}, true},
+		{"percent without value", configHistoryPatch{FatTargetMode: strPtr("percent")}, true},
+		{"negative meal budget", configHistoryPatch{LunchBudget: intPtr(-1)}, true},
+	}
+	for _, tc := range cases {
+		if err := validateConfigHistoryPatch(tc.patch); (err != nil) != tc.wantErr {
+			t.Errorf("%s: wantErr=%v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
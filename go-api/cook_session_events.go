@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cookSessionHub fans session updates out to the devices streaming them.
+// It's in-process, which holds while the API runs as a single instance.
+type cookSessionHub struct {
+	mu   sync.Mutex
+	subs map[int]map[chan cookSession]struct{}
+}
+
+func newCookSessionHub() *cookSessionHub {
+	return &cookSessionHub{subs: map[int]map[chan cookSession]struct{}{}}
+}
+
+// subscribe returns a channel of updates to a session and a func that stops
+// them.
+func (hub *cookSessionHub) subscribe(id int) (<-chan cookSession, func()) {
+	ch := make(chan cookSession, 4)
+	hub.mu.Lock()
+	if hub.subs[id] == nil {
+		hub.subs[id] = map[chan cookSession]struct{}{}
+	}
+	hub.subs[id][ch] = struct{}{}
+	hub.mu.Unlock()
+
+	return ch, func() {
+		hub.mu.Lock()
+		delete(hub.subs[id], ch)
+		if len(hub.subs[id]) == 0 {
+			delete(hub.subs, id)
+		}
+		hub.mu.Unlock()
+	}
+}
+
+// publish sends a session's new state to its subscribers without blocking.
+// A subscriber that has fallen behind drops its oldest update: each update
+// is the full state, so only the latest matters. A nil hub is a no-op, for
+// handlers built without one in tests.
+func (hub *cookSessionHub) publish(s cookSession) {
+	if hub == nil {
+		return
+	}
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for ch := range hub.subs[s.ID] {
+		select {
+		case ch <- s:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- s:
+			default:
+			}
+		}
+	}
+}
+
+// cookHeartbeat keeps idle streams from being closed by proxies.
+const cookHeartbeat = 25 * time.Second
+
+// streamCookSession streams a session as server-sent events so another
+// device (a kitchen tablet) mirrors progress. Each "session" event carries
+// the full state, starting with the current one; the stream ends once the
+// session is finished or abandoned. Clients must send the Authorization
+// header, so they read the stream with fetch rather than EventSource.
+// GET /api/cook-sessions/:id/events
+func (h *Handler) streamCookSession(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid session id")
+		return
+	}
+
+	// Subscribe before loading so no update lands in between.
+	updates, unsubscribe := h.cookEvents.subscribe(id)
+	defer unsubscribe()
+
+	s, err := loadCookSession(c, h.db, userID, id, false)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "cook session not found")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	version := s.Version
+	send := func(s cookSession) {
+		c.SSEvent("session", sessionState(s, time.Now()))
+		c.Writer.Flush()
+	}
+	send(s)
+
+	heartbeat := time.NewTicker(cookHeartbeat)
+	defer heartbeat.Stop()
+	for s.Status == "active" {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case s = <-updates:
+			if s.Version <= version {
+				continue
+			}
+			version = s.Version
+			send(s)
+		}
+	}
+}
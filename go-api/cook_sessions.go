@@ -0,0 +1,609 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+/* ─── Session state ──────────────────────────────────────────────────── */
+
+// cookAction is the body for POST /api/cook-sessions/:id/actions. Step
+// defaults to the current step; Seconds overrides or extends a timer.
+type cookAction struct {
+	Action  string `json:"action" binding:"required"`
+	Step    *int   `json:"step"`
+	Seconds *int   `json:"seconds"`
+}
+
+// cookSessionState is a session as served to clients. ServerTime lets a
+// device with a skewed clock render timers from ends_at correctly.
+type cookSessionState struct {
+	cookSession
+	ServerTime time.Time `json:"server_time"`
+}
+
+// cookSessionDetail adds the recipe being cooked, as it stood when the
+// session started, for a device joining or resuming the session.
+type cookSessionDetail struct {
+	cookSessionState
+	Recipe recipeDetail `json:"recipe"`
+}
+
+// cookSessionListItem is one entry in GET /api/cook-sessions.
+type cookSessionListItem struct {
+	cookSession
+	RecipeName  string  `json:"recipe_name"  db:"recipe_name"`
+	RecipeEmoji *string `json:"recipe_emoji" db:"recipe_emoji"`
+}
+
+// timerRemaining is the whole seconds left on a timer, rounded up.
+func timerRemaining(t cookTimer, now time.Time) int {
+	if t.EndsAt == nil {
+		return t.RemainingSeconds
+	}
+	return max(0, int(math.Ceil(t.EndsAt.Sub(now).Seconds())))
+}
+
+// sessionState refreshes running timers' remaining seconds as of now.
+func sessionState(s cookSession, now time.Time) cookSessionState {
+	timers := make([]cookTimer, len(s.Timers))
+	for i, t := range s.Timers {
+		t.RemainingSeconds = timerRemaining(t, now)
+		timers[i] = t
+	}
+	s.Timers = timers
+	if s.CompletedSteps == nil {
+		s.CompletedSteps = []int{}
+	}
+	return cookSessionState{cookSession: s, ServerTime: now}
+}
+
+// applyCookAction changes a session's step or timers. steps are the steps of
+// the recipe revision being cooked. Returns a message for an invalid action.
+func applyCookAction(s *cookSession, a cookAction, steps []recipeStep, now time.Time) string {
+	step := s.CurrentStep
+	if a.Step != nil {
+		step = *a.Step
+	}
+	if step < 0 || step >= len(steps) {
+		return "step out of range"
+	}
+	timer := slices.IndexFunc(s.Timers, func(t cookTimer) bool { return t.Step == step })
+
+	switch a.Action {
+	case "goto":
+		s.CurrentStep = step
+	case "next":
+		if !slices.Contains(s.CompletedSteps, s.CurrentStep) {
+			s.CompletedSteps = append(s.CompletedSteps, s.CurrentStep)
+		}
+		s.CurrentStep = min(s.CurrentStep+1, len(steps)-1)
+	case "prev":
+		s.CurrentStep = max(s.CurrentStep-1, 0)
+	case "complete_step":
+		if !slices.Contains(s.CompletedSteps, step) {
+			s.CompletedSteps = append(s.CompletedSteps, step)
+		}
+	case "uncomplete_step":
+		s.CompletedSteps = slices.DeleteFunc(s.CompletedSteps, func(n int) bool { return n == step })
+	case "start_timer":
+		seconds := steps[step].TimerSeconds
+		if a.Seconds != nil {
+			seconds = a.Seconds
+		}
+		if seconds == nil || *seconds <= 0 {
+			return "step has no timer; pass seconds"
+		}
+		if timer >= 0 {
+			s.Timers = slices.Delete(s.Timers, timer, timer+1)
+		}
+		endsAt := now.Add(time.Duration(*seconds) * time.Second)
+		s.Timers = append(s.Timers, cookTimer{Step: step, DurationSeconds: *seconds, EndsAt: &endsAt, RemainingSeconds: *seconds})
+	case "pause_timer", "resume_timer", "extend_timer", "cancel_timer":
+		if timer < 0 {
+			return "no timer for this step"
+		}
+		t := &s.Timers[timer]
+		switch a.Action {
+		case "pause_timer":
+			if t.EndsAt == nil {
+				return "timer is already paused"
+			}
+			t.RemainingSeconds = timerRemaining(*t, now)
+			t.EndsAt = nil
+		case "resume_timer":
+			if t.EndsAt != nil {
+				return "timer is not paused"
+			}
+			endsAt := now.Add(time.Duration(t.RemainingSeconds) * time.Second)
+			t.EndsAt = &endsAt
+		case "extend_timer":
+			if a.Seconds == nil || *a.Seconds <= 0 {
+				return "seconds must be positive"
+			}
+			extra := *a.Seconds
+			t.DurationSeconds += extra
+			if t.EndsAt != nil {
+				// A timer that already rang restarts from now.
+				endsAt := t.EndsAt.Add(time.Duration(extra) * time.Second)
+				if t.EndsAt.Before(now) {
+					endsAt = now.Add(time.Duration(extra) * time.Second)
+				}
+				t.EndsAt = &endsAt
+			}
+			t.RemainingSeconds = timerRemaining(*t, now)
+			if t.EndsAt == nil {
+				t.RemainingSeconds += extra
+			}
+		case "cancel_timer":
+			s.Timers = slices.Delete(s.Timers, timer, timer+1)
+		}
+	default:
+		return "invalid action; expected goto|next|prev|complete_step|uncomplete_step|start_timer|pause_timer|resume_timer|extend_timer|cancel_timer"
+	}
+
+	slices.Sort(s.CompletedSteps)
+	slices.SortFunc(s.Timers, func(a, b cookTimer) int { return a.Step - b.Step })
+	return ""
+}
+
+/* ─── Persistence ────────────────────────────────────────────────────── */
+
+// loadCookSession returns one of the user's sessions; within a transaction it
+// locks the row so concurrent actions from two devices apply in turn.
+func loadCookSession(ctx context.Context, q querier, userID, id int, lock bool) (cookSession, error) {
+	sql := `SELECT * FROM cook_sessions WHERE id = @id AND user_id = @userID`
+	if lock {
+		sql += ` FOR UPDATE`
+	}
+	rows, err := q.Query(ctx, sql, pgx.NamedArgs{"id": id, "userID": userID})
+	if err != nil {
+		return cookSession{}, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[cookSession])
+}
+
+// loadCookRecipe returns the recipe a session is cooking: its revision
+// snapshot, so step numbers hold even if the recipe is edited mid-cook, or
+// the current recipe when there's no revision. With InlineSteps, sub-recipe
+// steps come first (see inlineSubRecipeSteps).
+func loadCookRecipe(ctx context.Context, q querier, s cookSession) (recipeDetail, error) {
+	d, err := loadCookRevision(ctx, q, s)
+	if err != nil || !s.InlineSteps {
+		return d, err
+	}
+	subs, err := loadSubRecipes(ctx, q, d)
+	if err != nil {
+		return recipeDetail{}, err
+	}
+	d.Steps = inlineSubRecipeSteps(d, subs)
+	return d, nil
+}
+
+// loadCookRevision returns the recipe revision a session started from.
+func loadCookRevision(ctx context.Context, q querier, s cookSession) (recipeDetail, error) {
+	if s.RecipeRevisionID != nil {
+		rows, err := q.Query(ctx, `SELECT snapshot FROM recipe_revisions WHERE id = @id`,
+			pgx.NamedArgs{"id": *s.RecipeRevisionID})
+		if err != nil {
+			return recipeDetail{}, err
+		}
+		d, err := pgx.CollectOneRow(rows, pgx.RowTo[recipeDetail])
+		if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+			return d, err
+		}
+	}
+	return loadRecipeDetail(ctx, q, s.RecipeID)
+}
+
+// saveCookSession writes a session's state and bumps its version.
+func saveCookSession(ctx context.Context, tx pgx.Tx, s cookSession) (cookSession, error) {
+	args, err := cookSessionArgs(s)
+	if err != nil {
+		return cookSession{}, err
+	}
+	rows, err := tx.Query(ctx,
+		`UPDATE cook_sessions SET
+			status = @status, current_step = @currentStep, completed_steps = @completedSteps,
+			timers = @timers::jsonb, logged_item_id = @loggedItemID, finished_at = @finishedAt,
+			version = version + 1, updated_at = now()
+		 WHERE id = @id
+		 RETURNING *`,
+		args)
+	if err != nil {
+		return cookSession{}, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[cookSession])
+}
+
+// cookSessionArgs builds the update args for saveCookSession. Timers are
+// passed as JSON text (cast with ::jsonb) since the simple query protocol
+// can't encode a struct slice.
+func cookSessionArgs(s cookSession) (pgx.NamedArgs, error) {
+	if s.CompletedSteps == nil {
+		s.CompletedSteps = []int{}
+	}
+	if s.Timers == nil {
+		s.Timers = []cookTimer{}
+	}
+	timers, err := json.Marshal(s.Timers)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.NamedArgs{
+		"id": s.ID, "status": s.Status, "currentStep": s.CurrentStep,
+		"completedSteps": s.CompletedSteps, "timers": string(timers),
+		"loggedItemID": s.LoggedItemID, "finishedAt": s.FinishedAt,
+	}, nil
+}
+
+/* ─── Handlers ───────────────────────────────────────────────────────── */
+
+// startCookSession starts cooking a recipe, or resumes the active session for
+// it (200 instead of 201). Returns the session with the recipe.
+// ?inline_steps=true cooks sub-recipes from the same step list; otherwise
+// the client links to them.
+// POST /api/recipes/:id/cook
+func (h *Handler) startCookSession(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+
+	src, err := fetchRecipeDetail(h, c, id)
+	if err != nil || src.UserID != userID {
+		apiError(c, http.StatusNotFound, "recipe not found")
+		return
+	}
+	inline := c.Query("inline_steps") == "true"
+	if len(src.Steps) == 0 && (!inline || len(subRecipeRefs(src)) == 0) {
+		apiError(c, http.StatusBadRequest, "recipe has no steps")
+		return
+	}
+
+	status := http.StatusCreated
+	s, err := queryOne[cookSession](h.db, c,
+		`INSERT INTO cook_sessions (user_id, recipe_id, recipe_revision_id, inline_steps)
+		 VALUES (@userID, @recipeID, (SELECT MAX(id) FROM recipe_revisions WHERE recipe_id = @recipeID), @inline)
+		 ON CONFLICT (user_id, recipe_id) WHERE status = 'active' DO NOTHING
+		 RETURNING *`,
+		pgx.NamedArgs{"userID": userID, "recipeID": id, "inline": inline})
+	if errors.Is(err, pgx.ErrNoRows) {
+		status = http.StatusOK
+		s, err = queryOne[cookSession](h.db, c,
+			`SELECT * FROM cook_sessions WHERE user_id = @userID AND recipe_id = @recipeID AND status = 'active'`,
+			pgx.NamedArgs{"userID": userID, "recipeID": id})
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start cook session")
+		return
+	}
+
+	recipe, err := loadCookRecipe(c, h.db, s)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+	c.JSON(status, cookSessionDetail{cookSessionState: sessionState(s, time.Now()), Recipe: recipe})
+}
+
+// listCookSessions returns the user's active sessions, most recent first, so
+// any device can offer to resume one.
+// GET /api/cook-sessions
+func (h *Handler) listCookSessions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	items, err := queryMany[cookSessionListItem](h.db, c,
+		`SELECT s.*, r.name AS recipe_name, r.emoji AS recipe_emoji
+		 FROM cook_sessions s
+		 JOIN recipes r ON r.id = s.recipe_id
+		 WHERE s.user_id = @userID AND s.status = 'active'
+		 ORDER BY s.updated_at DESC`,
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch cook sessions")
+		return
+	}
+	now := time.Now()
+	for i := range items {
+		items[i].cookSession = sessionState(items[i].cookSession, now).cookSession
+	}
+	if items == nil {
+		items = []cookSessionListItem{}
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// getCookSession returns a session with the recipe being cooked.
+// GET /api/cook-sessions/:id
+func (h *Handler) getCookSession(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid session id")
+		return
+	}
+
+	s, err := loadCookSession(c, h.db, userID, id, false)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "cook session not found")
+		return
+	}
+	recipe, err := loadCookRecipe(c, h.db, s)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+	c.JSON(http.StatusOK, cookSessionDetail{cookSessionState: sessionState(s, time.Now()), Recipe: recipe})
+}
+
+// applyCookSessionAction moves between steps, marks steps done, and starts,
+// pauses, resumes, extends, or cancels step timers. The new state is
+// streamed to every device watching the session.
+// POST /api/cook-sessions/:id/actions
+func (h *Handler) applyCookSessionAction(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	var body cookAction
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "action is required")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	s, err := loadCookSession(c, tx, userID, id, true)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "cook session not found")
+		return
+	}
+	if s.Status != "active" {
+		apiError(c, http.StatusConflict, "cook session is "+s.Status)
+		return
+	}
+	recipe, err := loadCookRecipe(c, tx, s)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+
+	now := time.Now()
+	if msg := applyCookAction(&s, body, recipe.Steps, now); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+	s, err = saveCookSession(c, tx, s)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to save cook session")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	h.cookEvents.publish(s)
+	c.JSON(http.StatusOK, sessionState(s, now))
+}
+
+// cookLogRequest logs servings of the cooked recipe to the calorie log.
+type cookLogRequest struct {
+	Servings float64 `json:"servings"` // defaults to 1
+	Date     string  `json:"date"`     // YYYY-MM-DD, defaults to today
+	Type     string  `json:"type"`     // breakfast|lunch|dinner|snack
+}
+
+// cookLogSuggestion is what finishing offers to log: one serving of the
+// recipe at its per-serving nutrition.
+type cookLogSuggestion struct {
+	ItemName string   `json:"item_name"`
+	Servings float64  `json:"servings"`
+	Calories *int     `json:"calories"`
+	ProteinG *float64 `json:"protein_g"`
+	CarbsG   *float64 `json:"carbs_g"`
+	FatG     *float64 `json:"fat_g"`
+}
+
+// cookFinishResponse is the response for POST /api/cook-sessions/:id/finish.
+// Exactly one of LoggedItem and SuggestedLog is set. Cook is the history
+// entry recorded when this call finished the session.
+type cookFinishResponse struct {
+	Session      cookSessionState   `json:"session"`
+	Cook         *recipeCook        `json:"cook"`
+	LoggedItem   *calorieLogItem    `json:"logged_item"`
+	SuggestedLog *cookLogSuggestion `json:"suggested_log"`
+}
+
+// finishCookSession ends a session and records it in the recipe's cooking
+// history; the optional cook body sets servings made, rating, and notes
+// (its recipe_revision_id is ignored — it's the session's). With a log body,
+// servings of the recipe are logged to the calorie log (linked to the recipe
+// revision cooked); without one the response suggests what to log, and
+// calling finish again with a log body logs it.
+// POST /api/cook-sessions/:id/finish
+func (h *Handler) finishCookSession(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	var body struct {
+		Cook *createRecipeCookRequest `json:"cook"`
+		Log  *cookLogRequest          `json:"log"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	cook := createRecipeCookRequest{}
+	if body.Cook != nil {
+		cook = *body.Cook
+		cook.RecipeRevisionID = nil
+	}
+	if msg := validateRecipeCook(cook.CookedOn, cook.Servings, cook.Rating); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+	if l := body.Log; l != nil {
+		if l.Servings == 0 {
+			l.Servings = 1
+		}
+		if l.Date == "" {
+			l.Date = time.Now().Format("2006-01-02")
+		}
+		switch {
+		case l.Servings < 0:
+			apiError(c, http.StatusBadRequest, "servings must be positive")
+			return
+		case !validItemTypes[l.Type] || l.Type == "exercise":
+			apiError(c, http.StatusBadRequest, "type must be one of: breakfast, lunch, dinner, snack")
+			return
+		}
+		if _, err := time.Parse("2006-01-02", l.Date); err != nil {
+			apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	s, err := loadCookSession(c, tx, userID, id, true)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "cook session not found")
+		return
+	}
+	switch {
+	case s.Status == "abandoned":
+		apiError(c, http.StatusConflict, "cook session is abandoned")
+		return
+	case s.Status == "finished" && (body.Log == nil || s.LoggedItemID != nil):
+		apiError(c, http.StatusConflict, "cook session is already finished")
+		return
+	}
+	recipe, err := loadCookRecipe(c, tx, s)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+
+	now := time.Now()
+	resp := cookFinishResponse{}
+	if l := body.Log; l != nil {
+		if recipe.Calories == nil {
+			apiError(c, http.StatusBadRequest, "recipe has no calories; log it manually")
+			return
+		}
+		scale := func(v *float64) *float64 {
+			if v == nil {
+				return nil
+			}
+			scaled := round1(*v * l.Servings)
+			return &scaled
+		}
+		rows, err := tx.Query(c,
+			`INSERT INTO calorie_log_items
+			   (user_id, date, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g,
+			    recipe_id, recipe_revision_id, source, confidence)
+			 VALUES (@userID, @date, @itemName, @type, @qty, 'serving', @calories, @proteinG, @carbsG, @fatG,
+			         @recipeID, @revisionID, 'recipe', @confidence)
+			 RETURNING *`,
+			pgx.NamedArgs{
+				"userID": userID, "date": l.Date, "itemName": recipe.Name, "type": l.Type,
+				"qty": l.Servings, "calories": int(math.Round(float64(*recipe.Calories) * l.Servings)),
+				"proteinG": scale(recipe.ProteinG), "carbsG": scale(recipe.CarbsG), "fatG": scale(recipe.FatG),
+				"recipeID": s.RecipeID, "revisionID": s.RecipeRevisionID,
+				"confidence": defaultSourceConfidence["recipe"],
+			})
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to log servings")
+			return
+		}
+		item, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[calorieLogItem])
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to log servings")
+			return
+		}
+		resp.LoggedItem = &item
+		s.LoggedItemID = &item.ID
+	} else {
+		resp.SuggestedLog = &cookLogSuggestion{
+			ItemName: recipe.Name, Servings: 1,
+			Calories: recipe.Calories, ProteinG: recipe.ProteinG, CarbsG: recipe.CarbsG, FatG: recipe.FatG,
+		}
+	}
+	if s.Status == "active" {
+		s.Status, s.FinishedAt, s.Timers = "finished", &now, nil
+		cook.RecipeRevisionID = s.RecipeRevisionID
+		recorded, err := insertRecipeCook(c, tx, userID, s.RecipeID, &s.ID, cook)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to record cook")
+			return
+		}
+		resp.Cook = &recorded
+	}
+
+	s, err = saveCookSession(c, tx, s)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to save cook session")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	h.cookEvents.publish(s)
+	resp.Session = sessionState(s, now)
+	c.JSON(http.StatusOK, resp)
+}
+
+// abandonCookSession stops a session without logging anything.
+// DELETE /api/cook-sessions/:id
+func (h *Handler) abandonCookSession(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid session id")
+		return
+	}
+
+	s, err := queryOne[cookSession](h.db, c,
+		`UPDATE cook_sessions SET
+			status = 'abandoned', timers = '[]', finished_at = now(),
+			version = version + 1, updated_at = now()
+		 WHERE id = @id AND user_id = @userID AND status = 'active'
+		 RETURNING *`,
+		pgx.NamedArgs{"id": id, "userID": userID})
+	if err != nil {
+		apiError(c, http.StatusNotFound, "active cook session not found")
+		return
+	}
+	h.cookEvents.publish(s)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func cookSteps() []recipeStep {
+	timer := 600
+	return []recipeStep{
+		{Type: "instruction", Text: "Chop the onion."},
+		{Type: "timer", Text: "Simmer.", TimerSeconds: &timer},
+		{Type: "instruction", Text: "Serve."},
+	}
+}
+
+func TestApplyCookAction_Steps(t *testing.T) {
+	steps, now := cookSteps(), time.Now()
+	s := cookSession{}
+	step := func(n int) *int { return &n }
+
+	for _, a := range []cookAction{{Action: "next"}, {Action: "next"}, {Action: "next"}} {
+		if msg := applyCookAction(&s, a, steps, now); msg != "" {
+			t.Fatal(msg)
+		}
+	}
+	if s.CurrentStep != 2 || len(s.CompletedSteps) != 3 {
+		t.Errorf("after next x3: got step %d completed %v", s.CurrentStep, s.CompletedSteps)
+	}
+
+	applyCookAction(&s, cookAction{Action: "uncomplete_step", Step: step(1)}, steps, now)
+	applyCookAction(&s, cookAction{Action: "goto", Step: step(0)}, steps, now)
+	applyCookAction(&s, cookAction{Action: "prev"}, steps, now)
+	if s.CurrentStep != 0 || len(s.CompletedSteps) != 2 || s.CompletedSteps[1] != 2 {
+		t.Errorf("got step %d completed %v", s.CurrentStep, s.CompletedSteps)
+	}
+
+	if msg := applyCookAction(&s, cookAction{Action: "goto", Step: step(3)}, steps, now); msg == "" {
+		t.Error("expected error for step out of range")
+	}
+	if msg := applyCookAction(&s, cookAction{Action: "skip"}, steps, now); msg == "" {
+		t.Error("expected error for invalid action")
+	}
+}
+
+func TestApplyCookAction_Timers(t *testing.T) {
+	steps, now := cookSteps(), time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+	s := cookSession{CurrentStep: 1}
+	secs := func(n int) *int { return &n }
+
+	if msg := applyCookAction(&s, cookAction{Action: "start_timer"}, steps, now); msg != "" {
+		t.Fatal(msg)
+	}
+	if len(s.Timers) != 1 || !s.Timers[0].EndsAt.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("start: got %+v", s.Timers)
+	}
+
+	now = now.Add(4 * time.Minute)
+	applyCookAction(&s, cookAction{Action: "pause_timer"}, steps, now)
+	if tm := s.Timers[0]; tm.EndsAt != nil || tm.RemainingSeconds != 360 {
+		t.Errorf("pause: got %+v", tm)
+	}
+	if msg := applyCookAction(&s, cookAction{Action: "pause_timer"}, steps, now); msg == "" {
+		t.Error("expected error pausing a paused timer")
+	}
+
+	now = now.Add(time.Hour)
+	applyCookAction(&s, cookAction{Action: "extend_timer", Seconds: secs(60)}, steps, now)
+	applyCookAction(&s, cookAction{Action: "resume_timer"}, steps, now)
+	if tm := s.Timers[0]; !tm.EndsAt.Equal(now.Add(7*time.Minute)) || tm.DurationSeconds != 660 {
+		t.Errorf("resume: got %+v", tm)
+	}
+
+	// A timer that already rang restarts from now when extended.
+	now = now.Add(time.Hour)
+	applyCookAction(&s, cookAction{Action: "extend_timer", Seconds: secs(30)}, steps, now)
+	if tm := s.Timers[0]; !tm.EndsAt.Equal(now.Add(30 * time.Second)) {
+		t.Errorf("extend after ring: got %v", tm.EndsAt)
+	}
+
+	if msg := applyCookAction(&s, cookAction{Action: "start_timer", Step: secs(0)}, steps, now); msg == "" {
+		t.Error("expected error for a step without a timer")
+	}
+	applyCookAction(&s, cookAction{Action: "start_timer", Step: secs(0), Seconds: secs(90)}, steps, now)
+	if len(s.Timers) != 2 || s.Timers[0].Step != 0 {
+		t.Errorf("timers sorted by step: got %+v", s.Timers)
+	}
+	applyCookAction(&s, cookAction{Action: "cancel_timer"}, steps, now)
+	if len(s.Timers) != 1 || s.Timers[0].Step != 0 {
+		t.Errorf("cancel: got %+v", s.Timers)
+	}
+}
+
+func TestCookSessionArgs(t *testing.T) {
+	steps, now := cookSteps(), time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+	s := cookSession{ID: 4, Status: "active", CurrentStep: 1}
+	applyCookAction(&s, cookAction{Action: "start_timer"}, steps, now)
+	args, err := cookSessionArgs(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSimpleProtocolArgs(t, args)
+	if !strings.Contains(args["timers"].(string), `"duration_seconds":600`) {
+		t.Errorf("timers: got %v", args["timers"])
+	}
+
+	if args, _ := cookSessionArgs(cookSession{}); args["timers"] != "[]" {
+		t.Errorf("no timers: got %v", args["timers"])
+	}
+}
+
+func TestSessionStateRemaining(t *testing.T) {
+	now := time.Now()
+	ends, rang := now.Add(90*time.Second+time.Millisecond), now.Add(-time.Second)
+	got := sessionState(cookSession{Timers: []cookTimer{
+		{Step: 0, EndsAt: &ends},
+		{Step: 1, EndsAt: &rang},
+		{Step: 2, RemainingSeconds: 45},
+	}}, now)
+	if got.Timers[0].RemainingSeconds != 91 || got.Timers[1].RemainingSeconds != 0 || got.Timers[2].RemainingSeconds != 45 {
+		t.Errorf("got %+v", got.Timers)
+	}
+	if got.CompletedSteps == nil {
+		t.Error("completed_steps should encode as []")
+	}
+}
+
+func TestCookSessionHub(t *testing.T) {
+	hub := newCookSessionHub()
+	a, stopA := hub.subscribe(1)
+	b, stopB := hub.subscribe(1)
+	other, stopOther := hub.subscribe(2)
+	defer stopOther()
+
+	hub.publish(cookSession{ID: 1, Version: 2})
+	if (<-a).Version != 2 || (<-b).Version != 2 {
+		t.Error("expected both subscribers to get the update")
+	}
+	select {
+	case s := <-other:
+		t.Errorf("unexpected update for session 2: %+v", s)
+	default:
+	}
+
+	// A subscriber that stops reading keeps only the latest updates.
+	for v := 3; v <= 10; v++ {
+		hub.publish(cookSession{ID: 1, Version: v})
+	}
+	var last int
+	for len(a) > 0 {
+		last = (<-a).Version
+	}
+	if last != 10 {
+		t.Errorf("latest update: got %d", last)
+	}
+
+	stopA()
+	stopB()
+	if len(hub.subs[1]) != 0 {
+		t.Error("expected no subscribers after unsubscribe")
+	}
+	var nilHub *cookSessionHub
+	nilHub.publish(cookSession{ID: 1})
+}
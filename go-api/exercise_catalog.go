@@ -0,0 +1,271 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// exerciseActivity is one entry in the built-in MET catalog. MET values are from
+// the Compendium of Physical Activities. SpeedMPH is the typical pace used to
+// convert a distance into a duration; 0 means the activity is duration-only.
+type exerciseActivity struct {
+	Key      string   `json:"key"`
+	Name     string   `json:"name"`
+	MET      float64  `json:"met"`
+	SpeedMPH float64  `json:"speed_mph,omitempty"`
+	Aliases  []string `json:"-"`
+}
+
+// exerciseCatalog is the built-in activity list, matched against suggest
+// descriptions before falling back to the AI estimate.
+var exerciseCatalog = []exerciseActivity{
+	{Key: "walking", Name: "Walking", MET: 3.5, SpeedMPH: 3.0, Aliases: []string{"walk", "walking", "walked"}},
+	{Key: "brisk_walking", Name: "Brisk Walking", MET: 4.3, SpeedMPH: 3.5, Aliases: []string{"brisk walk", "brisk walking", "power walk", "power walking"}},
+	{Key: "hiking", Name: "Hiking", MET: 6.0, SpeedMPH: 2.5, Aliases: []string{"hike", "hiking", "hiked"}},
+	{Key: "jogging", Name: "Jogging", MET: 7.0, SpeedMPH: 5.0, Aliases: []string{"jog", "jogging", "jogged"}},
+	{Key: "running", Name: "Running", MET: 9.8, SpeedMPH: 6.0, Aliases: []string{"run", "running", "ran"}},
+	{Key: "cycling", Name: "Cycling", MET: 7.5, SpeedMPH: 12.0, Aliases: []string{"bike", "biking", "biked", "bike ride", "cycle", "cycling", "cycled"}},
+	{Key: "stationary_bike", Name: "Stationary Bike", MET: 6.8, Aliases: []string{"stationary bike", "spin", "spin class", "spinning", "peloton"}},
+	{Key: "swimming", Name: "Swimming", MET: 6.0, Aliases: []string{"swim", "swimming", "swam", "laps"}},
+	{Key: "rowing", Name: "Rowing", MET: 7.0, Aliases: []string{"row", "rowing", "rowed", "rower", "erg"}},
+	{Key: "elliptical", Name: "Elliptical", MET: 5.0, Aliases: []string{"elliptical"}},
+	{Key: "stair_climbing", Name: "Stair Climbing", MET: 9.0, Aliases: []string{"stairs", "stair climbing", "stairmaster", "stair climber"}},
+	{Key: "weight_training", Name: "Weight Training", MET: 3.5, Aliases: []string{"weights", "weight training", "weightlifting", "lifting", "lifted", "strength training"}},
+	{Key: "hiit", Name: "HIIT", MET: 8.0, Aliases: []string{"hiit", "interval training", "circuit training", "crossfit"}},
+	{Key: "yoga", Name: "Yoga", MET: 2.5, Aliases: []string{"yoga"}},
+	{Key: "pilates", Name: "Pilates", MET: 3.0, Aliases: []string{"pilates"}},
+	{Key: "dancing", Name: "Dancing", MET: 5.0, Aliases: []string{"dance", "dancing", "danced", "zumba"}},
+	{Key: "jump_rope", Name: "Jump Rope", MET: 11.0, Aliases: []string{"jump rope", "jumping rope", "skipping rope"}},
+}
+
+// defaultWeightLBS is used for MET math when the user has no logged or saved weight.
+const defaultWeightLBS = 160.0
+
+// catalogActivity looks up a catalog entry by key.
+func catalogActivity(key string) (exerciseActivity, bool) {
+	for _, a := range exerciseCatalog {
+		if a.Key == key {
+			return a, true
+		}
+	}
+	return exerciseActivity{}, false
+}
+
+// metCalories returns calories burned: MET × body weight (kg) × hours.
+func metCalories(met, weightLBS, minutes float64) int {
+	kg := weightLBS / 2.20462
+	return int(math.Round(met * kg * minutes / 60))
+}
+
+// minutesForDistance converts a distance to minutes at the activity's typical
+// pace. ok=false for duration-only activities.
+func (a exerciseActivity) minutesForDistance(distance float64, unit string) (float64, bool) {
+	if a.SpeedMPH <= 0 {
+		return 0, false
+	}
+	miles := distance
+	if unit == "km" {
+		miles = distance / 1.60934
+	}
+	return miles / a.SpeedMPH * 60, true
+}
+
+// aliasPatterns are the catalog aliases compiled to whole-word patterns, longest
+// first so "brisk walk" wins over "walk".
+var aliasPatterns = func() []struct {
+	re  *regexp.Regexp
+	key string
+} {
+	type entry struct{ alias, key string }
+	var entries []entry
+	for _, a := range exerciseCatalog {
+		for _, alias := range a.Aliases {
+			entries = append(entries, entry{alias, a.Key})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return len(entries[i].alias) > len(entries[j].alias) })
+	out := make([]struct {
+		re  *regexp.Regexp
+		key string
+	}, len(entries))
+	for i, e := range entries {
+		out[i].re = regexp.MustCompile(`\b` + regexp.QuoteMeta(e.alias) + `\b`)
+		out[i].key = e.key
+	}
+	return out
+}()
+
+// quantityPattern matches "30 min", "1.5 hours", "5k", "3 miles", etc.
+var quantityPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(minutes|minute|mins|min|hours|hour|hrs|hr|h|miles|mile|mi|kilometers|kilometres|km|k)\b`)
+
+// parsedExercise is a free-text exercise description resolved against the catalog.
+type parsedExercise struct {
+	Activity exerciseActivity
+	Qty      float64 // in Uom
+	Uom      string  // minutes | miles | km
+	Minutes  float64
+}
+
+// parseExerciseDescription matches a description like "30 minute jog" or "ran 5k"
+// against the catalog. ok=false when no activity matches or no usable duration
+// or distance is found, in which case the caller falls back to the AI estimate.
+func parseExerciseDescription(desc string) (parsedExercise, bool) {
+	text := strings.ToLower(desc)
+	var activity exerciseActivity
+	found := false
+	for _, p := range aliasPatterns {
+		if p.re.MatchString(text) {
+			activity, found = catalogActivity(p.key)
+			break
+		}
+	}
+	if !found {
+		return parsedExercise{}, false
+	}
+
+	m := quantityPattern.FindStringSubmatch(text)
+	if m == nil {
+		return parsedExercise{}, false
+	}
+	qty, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || qty <= 0 {
+		return parsedExercise{}, false
+	}
+	p := parsedExercise{Activity: activity, Qty: qty}
+	switch m[2] {
+	case "hours", "hour", "hrs", "hr", "h":
+		p.Uom, p.Qty, p.Minutes = "minutes", qty*60, qty*60
+	case "miles", "mile", "mi":
+		p.Uom = "miles"
+	case "kilometers", "kilometres", "km", "k":
+		p.Uom = "km"
+	default:
+		p.Uom, p.Minutes = "minutes", qty
+	}
+	if p.Uom != "minutes" {
+		minutes, ok := activity.minutesForDistance(qty, p.Uom)
+		if !ok {
+			return parsedExercise{}, false
+		}
+		p.Minutes = minutes
+	}
+	return p, true
+}
+
+// catalogSuggestion builds a suggest response from a parsed catalog match.
+func catalogSuggestion(p parsedExercise, weightLBS float64) suggestionResponse {
+	return suggestionResponse{
+		ItemName:   p.Activity.Name,
+		Qty:        p.Qty,
+		Uom:        p.Uom,
+		Calories:   metCalories(p.Activity.MET, weightLBS, p.Minutes),
+		Confidence: 4,
+		Source:     "catalog",
+	}
+}
+
+// currentWeightLBS returns the user's latest logged weight (or settings weight)
+// for MET math, falling back to defaultWeightLBS. estimated=true when the
+// default was used.
+func (h *Handler) currentWeightLBS(c *gin.Context) (weight float64, estimated bool) {
+	if h.db == nil {
+		return defaultWeightLBS, true
+	}
+	userID := c.GetInt("user_id")
+	var fallback *float64
+	if s, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID}); err == nil {
+		fallback = s.WeightLBS
+	}
+	if w, ok := h.latestWeightLBS(c, userID, fallback); ok {
+		return w, false
+	}
+	return defaultWeightLBS, true
+}
+
+// listExerciseCatalog returns the built-in activity catalog.
+// GET /api/calorie-log/exercise-catalog.
+func (h *Handler) listExerciseCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, exerciseCatalog)
+}
+
+// exerciseCalcRequest is the body for POST /api/calorie-log/exercise-calc.
+// Exactly one of DurationMin or Distance must be set.
+type exerciseCalcRequest struct {
+	Activity     string   `json:"activity" binding:"required"` // catalog key
+	DurationMin  *float64 `json:"duration_min"`
+	Distance     *float64 `json:"distance"`
+	DistanceUnit string   `json:"distance_unit"` // miles (default) | km
+}
+
+// exerciseCalcResponse is the computed burn for an exercise-calc request.
+type exerciseCalcResponse struct {
+	Activity        string  `json:"activity"`
+	ItemName        string  `json:"item_name"`
+	MET             float64 `json:"met"`
+	DurationMin     float64 `json:"duration_min"`
+	WeightLBS       float64 `json:"weight_lbs"`
+	WeightEstimated bool    `json:"weight_estimated"`
+	Calories        int     `json:"calories"`
+}
+
+// calcExerciseCalories computes calories burned for a catalog activity from a
+// duration or distance and the user's current weight.
+// POST /api/calorie-log/exercise-calc.
+func (h *Handler) calcExerciseCalories(c *gin.Context) {
+	var req exerciseCalcRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, "activity is required")
+		return
+	}
+	activity, ok := catalogActivity(req.Activity)
+	if !ok {
+		apiError(c, http.StatusBadRequest, "unknown activity")
+		return
+	}
+	if (req.DurationMin == nil) == (req.Distance == nil) {
+		apiError(c, http.StatusBadRequest, "provide exactly one of duration_min or distance")
+		return
+	}
+
+	var minutes float64
+	if req.DurationMin != nil {
+		minutes = *req.DurationMin
+	} else {
+		unit := req.DistanceUnit
+		if unit == "" {
+			unit = "miles"
+		}
+		if unit != "miles" && unit != "km" {
+			apiError(c, http.StatusBadRequest, "distance_unit must be miles or km")
+			return
+		}
+		if minutes, ok = activity.minutesForDistance(*req.Distance, unit); !ok {
+			apiError(c, http.StatusBadRequest, activity.Name+" does not support distance; use duration_min")
+			return
+		}
+	}
+	if minutes <= 0 {
+		apiError(c, http.StatusBadRequest, "duration or distance must be positive")
+		return
+	}
+
+	weight, estimated := h.currentWeightLBS(c)
+	c.JSON(http.StatusOK, exerciseCalcResponse{
+		Activity:        activity.Key,
+		ItemName:        activity.Name,
+		MET:             activity.MET,
+		DurationMin:     math.Round(minutes*10) / 10,
+		WeightLBS:       weight,
+		WeightEstimated: estimated,
+		Calories:        metCalories(activity.MET, weight, minutes),
+	})
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultOffsetActivities are used when the user hasn't chosen offset activities:
+// an easy, a moderate, and a hard option.
+var defaultOffsetActivities = []string{"walking", "jogging", "cycling"}
+
+// exerciseOffset translates one overage (the whole day, or a single meal) into
+// the minutes of each offset activity needed to burn it off.
+type exerciseOffset struct {
+	Scope   string         `json:"scope"` // day | breakfast | lunch | dinner | snack
+	OverBy  int            `json:"over_by"`
+	Options []offsetOption `json:"options"`
+}
+
+// offsetOption is the time needed to burn an overage with one activity.
+type offsetOption struct {
+	Activity string `json:"activity"` // catalog key
+	Name     string `json:"name"`
+	Minutes  int    `json:"minutes"`
+}
+
+// offsetActivities resolves the user's offset activity keys against the catalog.
+// Unknown keys (e.g. removed from the catalog) are skipped.
+func offsetActivities(s *calorieLogUserSettings) []exerciseActivity {
+	keys := s.OffsetActivities
+	if len(keys) == 0 {
+		keys = defaultOffsetActivities
+	}
+	out := make([]exerciseActivity, 0, len(keys))
+	for _, k := range keys {
+		if a, ok := catalogActivity(k); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// minutesToBurn returns the whole minutes of an activity needed to burn calories,
+// rounded up so the offset is never understated.
+func minutesToBurn(calories int, met, weightLBS float64) int {
+	perMinute := met * (weightLBS / 2.20462) / 60
+	if perMinute <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(calories) / perMinute))
+}
+
+// offsetFor builds the offset options for one overage.
+func offsetFor(scope string, overBy int, activities []exerciseActivity, weightLBS float64) exerciseOffset {
+	o := exerciseOffset{Scope: scope, OverBy: overBy, Options: make([]offsetOption, 0, len(activities))}
+	for _, a := range activities {
+		o.Options = append(o.Options, offsetOption{
+			Activity: a.Key,
+			Name:     a.Name,
+			Minutes:  minutesToBurn(overBy, a.MET, weightLBS),
+		})
+	}
+	return o
+}
+
+// offsetsForSummary returns exercise offsets for the day's overage (net calories
+// over budget) and for each meal over its budget. Returns nil when nothing is
+// over. weightLBS <= 0 falls back to defaultWeightLBS.
+func offsetsForSummary(ds dailySummary, activities []exerciseActivity, weightLBS float64) []exerciseOffset {
+	if weightLBS <= 0 {
+		weightLBS = defaultWeightLBS
+	}
+	var out []exerciseOffset
+	if ds.CaloriesLeft < 0 {
+		out = append(out, offsetFor("day", -ds.CaloriesLeft, activities, weightLBS))
+	}
+	for _, m := range ds.Meals {
+		if m.OverBudget {
+			out = append(out, offsetFor(m.Meal, m.OverBy, activities, weightLBS))
+		}
+	}
+	return out
+}
+
+// withCandidateItem returns a copy of ds with a not-yet-saved item added to its
+// totals and meal breakdown. Used by the what-if mode.
+func withCandidateItem(ds dailySummary, itemType string, calories int) dailySummary {
+	ds.Meals = append([]mealBreakdown(nil), ds.Meals...)
+	if itemType == "exercise" {
+		ds.CaloriesExercise += calories
+	} else {
+		ds.CaloriesFood += calories
+		for i, m := range ds.Meals {
+			if m.Meal == itemType {
+				ds.Meals[i] = newMealBreakdown(m.Meal, m.Budget, m.Consumed+calories)
+			}
+		}
+	}
+	ds.NetCalories = ds.CaloriesFood - ds.CaloriesExercise
+	ds.CaloriesLeft = ds.CalorieBudget - ds.NetCalories
+	ds.MealsOverBudget = false
+	for _, m := range ds.Meals {
+		ds.MealsOverBudget = ds.MealsOverBudget || m.OverBudget
+	}
+	return ds
+}
+
+// exerciseOffsetsResponse is the response for GET /api/calorie-log/exercise-offsets.
+type exerciseOffsetsResponse struct {
+	Date            string           `json:"date"`
+	WeightLBS       float64          `json:"weight_lbs"`
+	WeightEstimated bool             `json:"weight_estimated"`
+	Offsets         []exerciseOffset `json:"offsets"`
+}
+
+// getExerciseOffsets returns the exercise needed to offset the day's overage and
+// each over-budget meal, using the user's current weight and offset activities.
+// GET /api/calorie-log/exercise-offsets?date=YYYY-MM-DD (defaults to today).
+func (h *Handler) getExerciseOffsets(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	ds, _, err := h.buildDailySummary(c, userID, date)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	weight, estimated := h.currentWeightLBS(c)
+	offsets := offsetsForSummary(ds, offsetActivities(&ds.Settings), weight)
+	if offsets == nil {
+		offsets = []exerciseOffset{}
+	}
+	c.JSON(http.StatusOK, exerciseOffsetsResponse{
+		Date:            date,
+		WeightLBS:       weight,
+		WeightEstimated: estimated,
+		Offsets:         offsets,
+	})
+}
+
+// exerciseOffsetWhatIfRequest is the body for POST /api/calorie-log/exercise-offsets/what-if:
+// a candidate item the user is about to log.
+type exerciseOffsetWhatIfRequest struct {
+	Date     string `json:"date"` // YYYY-MM-DD; defaults to today
+	Type     string `json:"type"     binding:"required"`
+	Calories int    `json:"calories"`
+}
+
+// exerciseOffsetWhatIfResponse describes the day after the candidate is added.
+// Meal is the candidate's meal breakdown (nil for exercise); *Added fields are
+// how much of the resulting overage the candidate itself causes.
+type exerciseOffsetWhatIfResponse struct {
+	Date            string           `json:"date"`
+	CaloriesLeft    int              `json:"calories_left"`
+	Meal            *mealBreakdown   `json:"meal,omitempty"`
+	DayOverByAdded  int              `json:"day_over_by_added"`
+	MealOverByAdded int              `json:"meal_over_by_added"`
+	WeightLBS       float64          `json:"weight_lbs"`
+	WeightEstimated bool             `json:"weight_estimated"`
+	Offsets         []exerciseOffset `json:"offsets"`
+}
+
+// overBy returns how far left is below zero (0 when not over).
+func overBy(left int) int {
+	if left < 0 {
+		return -left
+	}
+	return 0
+}
+
+// whatIfExerciseOffsets previews the overage and exercise offsets that logging a
+// candidate item would cause, without saving it.
+// POST /api/calorie-log/exercise-offsets/what-if.
+func (h *Handler) whatIfExerciseOffsets(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req exerciseOffsetWhatIfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, "type is required")
+		return
+	}
+	if !validItemTypes[req.Type] {
+		apiError(c, http.StatusBadRequest, "type must be one of: breakfast, lunch, dinner, snack, exercise")
+		return
+	}
+	if req.Calories < 0 {
+		apiError(c, http.StatusBadRequest, "calories must not be negative")
+		return
+	}
+	if req.Date == "" {
+		req.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	before, _, err := h.buildDailySummary(c, userID, req.Date)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	after := withCandidateItem(before, req.Type, req.Calories)
+
+	resp := exerciseOffsetWhatIfResponse{
+		Date:           req.Date,
+		CaloriesLeft:   after.CaloriesLeft,
+		DayOverByAdded: overBy(after.CaloriesLeft) - overBy(before.CaloriesLeft),
+	}
+	for i, m := range after.Meals {
+		if m.Meal == req.Type {
+			resp.Meal = &after.Meals[i]
+			resp.MealOverByAdded = m.OverBy - before.Meals[i].OverBy
+		}
+	}
+	resp.WeightLBS, resp.WeightEstimated = h.currentWeightLBS(c)
+	resp.Offsets = offsetsForSummary(after, offsetActivities(&after.Settings), resp.WeightLBS)
+	if resp.Offsets == nil {
+		resp.Offsets = []exerciseOffset{}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+// TestMinutesToBurn verifies the MET math rounds up so offsets are never understated.
+func TestMinutesToBurn(t *testing.T) {
+	// Walking 3.5 MET at 160 lb ≈ 4.23 kcal/min → 120 kcal needs 28.4 → 29 min.
+	if got := minutesToBurn(120, 3.5, 160); got != 29 {
+		t.Errorf("want 29 min, got %d", got)
+	}
+}
+
+// TestOffsetsForSummary verifies offsets are produced for the day's overage and
+// each over-budget meal, using the configured activities.
+func TestOffsetsForSummary(t *testing.T) {
+	ds := dailySummary{
+		CaloriesLeft: -300,
+		Meals:        mealBreakdowns(dayTargets{BreakfastBudget: 500, LunchBudget: 700}, map[string]int{"breakfast": 620, "lunch": 600}),
+	}
+	settings := calorieLogUserSettings{OffsetActivities: []string{"jogging", "not_an_activity"}}
+	offsets := offsetsForSummary(ds, offsetActivities(&settings), 160)
+	if len(offsets) != 2 || offsets[0].Scope != "day" || offsets[1].Scope != "breakfast" {
+		t.Fatalf("want day and breakfast offsets, got %+v", offsets)
+	}
+	if offsets[1].OverBy != 120 || len(offsets[1].Options) != 1 || offsets[1].Options[0].Activity != "jogging" {
+		t.Errorf("want 120 kcal breakfast offset by jogging only, got %+v", offsets[1])
+	}
+
+	if got := offsetsForSummary(dailySummary{CaloriesLeft: 50}, offsetActivities(&calorieLogUserSettings{}), 160); got != nil {
+		t.Errorf("want no offsets when under budget, got %+v", got)
+	}
+}
+
+// TestWithCandidateItem verifies the what-if preview updates day totals and the
+// candidate's meal without touching the original summary.
+func TestWithCandidateItem(t *testing.T) {
+	before := dailySummary{
+		CalorieBudget: 2000,
+		CaloriesFood:  1900,
+		Meals:         mealBreakdowns(dayTargets{DinnerBudget: 700}, map[string]int{"dinner": 600}),
+	}
+	before.NetCalories, before.CaloriesLeft = 1900, 100
+
+	after := withCandidateItem(before, "dinner", 300)
+	if after.CaloriesLeft != -200 || !after.MealsOverBudget {
+		t.Errorf("want 200 over and a meal over budget, got left=%d over=%v", after.CaloriesLeft, after.MealsOverBudget)
+	}
+	if d := after.Meals[2]; d.OverBy != 200 {
+		t.Errorf("want dinner 200 over, got %+v", d)
+	}
+	if before.Meals[2].Consumed != 600 {
+		t.Errorf("original summary was modified: %+v", before.Meals[2])
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// fastingDay is the intermittent-fasting view of one day, derived from the
+// consumed_at times of food items. The fast is the overnight gap from the
+// previous day's last meal to this day's first meal.
+type fastingDay struct {
+	FirstMealAt       *ClockTime `json:"first_meal_at"`
+	LastMealAt        *ClockTime `json:"last_meal_at"`
+	EatingWindowHours *float64   `json:"eating_window_hours"`
+	FastHours         *float64   `json:"fast_hours"` // nil when either day has no timed meals
+	TargetHours       *float64   `json:"target_hours"`
+	MetTarget         *bool      `json:"met_target"` // nil when no target or no fast
+}
+
+// fastingStats is fasting target adherence over a progress range. A day counts
+// when it and the previous day both have timed meals.
+type fastingStats struct {
+	TargetHours   float64 `json:"target_hours"`
+	DaysMeasured  int     `json:"days_measured"`
+	DaysMet       int     `json:"days_met"`
+	AvgFastHours  float64 `json:"avg_fast_hours"`
+	CurrentStreak int     `json:"current_streak"`
+	LongestStreak int     `json:"longest_streak"`
+}
+
+// mealTimesRow is the first and last timed meal of a day.
+type mealTimesRow struct {
+	Date      DateOnly   `db:"date"`
+	FirstMeal *ClockTime `db:"first_meal"`
+	LastMeal  *ClockTime `db:"last_meal"`
+}
+
+// mealTimesFromItems returns the earliest and latest consumed_at among food
+// items; both nil when none are timed.
+func mealTimesFromItems(items []calorieLogItem) (first, last *ClockTime) {
+	for _, item := range items {
+		if item.Type == "exercise" || item.ConsumedAt == nil {
+			continue
+		}
+		if first == nil || item.ConsumedAt.Minutes < first.Minutes {
+			first = item.ConsumedAt
+		}
+		if last == nil || item.ConsumedAt.Minutes > last.Minutes {
+			last = item.ConsumedAt
+		}
+	}
+	return first, last
+}
+
+// fastHours is the overnight gap from prevLast (on the previous day) to first.
+func fastHours(prevLast, first ClockTime) float64 {
+	return roundTenth(float64(24*60-prevLast.Minutes+first.Minutes) / 60)
+}
+
+// roundTenth rounds hours to one decimal place.
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// newFastingDay builds the fasting view for a day. Returns nil when there's
+// nothing to show: no timed meals and no target.
+func newFastingDay(first, last, prevLast *ClockTime, target *float64) *fastingDay {
+	if first == nil && target == nil {
+		return nil
+	}
+	fd := &fastingDay{FirstMealAt: first, LastMealAt: last, TargetHours: target}
+	if first != nil {
+		window := roundTenth(float64(last.Minutes-first.Minutes) / 60)
+		fd.EatingWindowHours = &window
+		if prevLast != nil {
+			fast := fastHours(*prevLast, *first)
+			fd.FastHours = &fast
+			if target != nil {
+				met := fast >= *target
+				fd.MetTarget = &met
+			}
+		}
+	}
+	return fd
+}
+
+// computeFastingStats scores each day in [start, end] against target. rows
+// must include the day before start so the first day's fast can be measured.
+// Streaks count consecutive days meeting the target, ending at end (or the day
+// before, if end hasn't been met yet — same rule as habit streaks).
+func computeFastingStats(rows []mealTimesRow, start, end string, target float64) fastingStats {
+	byDate := make(map[string]mealTimesRow, len(rows))
+	for _, r := range rows {
+		byDate[r.Date.Format("2006-01-02")] = r
+	}
+
+	stats := fastingStats{TargetHours: target}
+	metDates := map[string]bool{}
+	var total float64
+	for _, r := range rows {
+		date := r.Date.Format("2006-01-02")
+		if date < start || date > end || r.FirstMeal == nil {
+			continue
+		}
+		prev, ok := byDate[r.Date.AddDate(0, 0, -1).Format("2006-01-02")]
+		if !ok || prev.LastMeal == nil {
+			continue
+		}
+		fast := fastHours(*prev.LastMeal, *r.FirstMeal)
+		stats.DaysMeasured++
+		total += fast
+		if fast >= target {
+			stats.DaysMet++
+			metDates[date] = true
+		}
+	}
+	if stats.DaysMeasured > 0 {
+		stats.AvgFastHours = roundTenth(total / float64(stats.DaysMeasured))
+	}
+	if endDate, err := time.Parse("2006-01-02", end); err == nil {
+		stats.CurrentStreak, stats.LongestStreak = computeDailyStreak(metDates, endDate)
+	}
+	return stats
+}
+
+// fetchMealTimes returns per-day first/last timed meals for [start, end].
+func (h *Handler) fetchMealTimes(c *gin.Context, userID int, start, end string) ([]mealTimesRow, error) {
+	return queryMany[mealTimesRow](h.db, c,
+		`SELECT date, MIN(consumed_at) AS first_meal, MAX(consumed_at) AS last_meal
+		 FROM calorie_log_items
+		 WHERE user_id = @userID AND date >= @start AND date <= @end
+		   AND type != 'exercise' AND consumed_at IS NOT NULL
+		 GROUP BY date
+		 ORDER BY date ASC`,
+		pgx.NamedArgs{"userID": userID, "start": start, "end": end})
+}
+
+// dayBefore returns the YYYY-MM-DD date before date (date if unparseable).
+func dayBefore(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, -1).Format("2006-01-02")
+}
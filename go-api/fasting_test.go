@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func clock(s string) *ClockTime {
+	t, err := parseClockTime(s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestClockTime_JSON(t *testing.T) {
+	b, err := json.Marshal(clock("07:05"))
+	if err != nil || string(b) != `"07:05"` {
+		t.Errorf("marshal: got %s, %v", b, err)
+	}
+	var ct ClockTime
+	if err := json.Unmarshal([]byte(`"19:45"`), &ct); err != nil || ct.Minutes != 19*60+45 {
+		t.Errorf("unmarshal: got %+v, %v", ct, err)
+	}
+	if err := json.Unmarshal([]byte(`"25:00"`), &ct); err == nil {
+		t.Error("expected invalid time to fail")
+	}
+}
+
+func TestMealTimesFromItems(t *testing.T) {
+	items := []calorieLogItem{
+		{Type: "lunch", ConsumedAt: clock("12:30")},
+		{Type: "exercise", ConsumedAt: clock("06:00")},
+		{Type: "snack"},
+		{Type: "dinner", ConsumedAt: clock("19:15")},
+		{Type: "breakfast", ConsumedAt: clock("09:00")},
+	}
+	first, last := mealTimesFromItems(items)
+	if first == nil || first.String() != "09:00" || last == nil || last.String() != "19:15" {
+		t.Errorf("got %v–%v, want 09:00–19:15 (exercise ignored)", first, last)
+	}
+	if f, l := mealTimesFromItems([]calorieLogItem{{Type: "snack"}}); f != nil || l != nil {
+		t.Error("untimed items should yield no meal times")
+	}
+}
+
+func TestNewFastingDay(t *testing.T) {
+	target := 16.0
+	fd := newFastingDay(clock("12:00"), clock("20:00"), clock("20:00"), &target)
+	if fd == nil || *fd.EatingWindowHours != 8 || *fd.FastHours != 16 || !*fd.MetTarget {
+		t.Fatalf("16:8 day: got %+v", fd)
+	}
+
+	fd = newFastingDay(clock("07:30"), clock("21:00"), clock("22:00"), &target)
+	if *fd.FastHours != 9.5 || *fd.MetTarget {
+		t.Errorf("short fast: got %+v", fd)
+	}
+
+	fd = newFastingDay(clock("12:00"), clock("12:00"), nil, &target)
+	if fd.FastHours != nil || fd.MetTarget != nil {
+		t.Errorf("no previous-day meals should leave fast unknown: %+v", fd)
+	}
+
+	if fd := newFastingDay(nil, nil, nil, nil); fd != nil {
+		t.Errorf("no timed meals and no target should omit fasting, got %+v", fd)
+	}
+	if fd := newFastingDay(clock("09:00"), clock("18:00"), clock("20:00"), nil); fd.MetTarget != nil || *fd.FastHours != 13 {
+		t.Errorf("without a target, fast is shown but not scored: %+v", fd)
+	}
+}
+
+func TestComputeFastingStats(t *testing.T) {
+	day := func(d, first, last string) mealTimesRow {
+		date, _ := time.Parse("2006-01-02", d)
+		return mealTimesRow{Date: DateOnly{date}, FirstMeal: clock(first), LastMeal: clock(last)}
+	}
+	rows := []mealTimesRow{
+		day("2024-03-01", "08:00", "20:00"), // before range: only provides the previous-day last meal
+		day("2024-03-02", "12:00", "20:00"), // 16h ✓
+		day("2024-03-03", "10:00", "21:00"), // 14h ✗
+		day("2024-03-04", "13:00", "20:00"), // 16h ✓
+		day("2024-03-05", "12:30", "19:00"), // 16.5h ✓
+		// 03-06 missing → 03-07 unmeasured
+		day("2024-03-07", "11:00", "19:00"),
+	}
+	stats := computeFastingStats(rows, "2024-03-02", "2024-03-07", 16)
+
+	if stats.DaysMeasured != 4 || stats.DaysMet != 3 {
+		t.Errorf("measured/met: got %d/%d, want 4/3", stats.DaysMeasured, stats.DaysMet)
+	}
+	if stats.AvgFastHours != 15.6 {
+		t.Errorf("avg: got %v, want 15.6", stats.AvgFastHours)
+	}
+	// 03-07 isn't met and 03-06 has no data, so the current streak is broken.
+	if stats.CurrentStreak != 0 || stats.LongestStreak != 2 {
+		t.Errorf("streaks: got current %d longest %d, want 0/2", stats.CurrentStreak, stats.LongestStreak)
+	}
+
+	stats = computeFastingStats(rows, "2024-03-02", "2024-03-05", 16)
+	if stats.CurrentStreak != 2 {
+		t.Errorf("streak ending on range end: got %d, want 2", stats.CurrentStreak)
+	}
+}
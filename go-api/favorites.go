@@ -1,33 +1,124 @@
 package main
 
 import (
+	"context"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 )
 
-// listFavorites returns all favorites for the current user, newest first.
+// favoriteTimeWindowMinutes is how close to the requested time a past use must
+// be to count toward time-of-day ranking.
+const favoriteTimeWindowMinutes = 90
+
+// listFavorites returns the current user's favorites, most used first.
+// GET /api/calorie-log/favorites?type=lunch&time=HH:MM. Both params are optional:
+// type ranks favorites logged as that meal type higher, time ranks those logged
+// near that time of day higher (and picks a meal type when type is omitted).
 func (h *Handler) listFavorites(c *gin.Context) {
 	userID := c.GetInt("user_id")
-	favs, err := queryMany[calorieLogFavorite](h.db, c, `
-		SELECT * FROM calorie_log_favorites
-		WHERE user_id = @userID
-		ORDER BY id DESC
-	`, pgx.NamedArgs{"userID": userID})
+	mealType := c.Query("type")
+	if mealType != "" && !validItemTypes[mealType] {
+		apiError(c, http.StatusBadRequest, "type must be one of: breakfast, lunch, dinner, snack, exercise")
+		return
+	}
+	var at *ClockTime
+	if raw := c.Query("time"); raw != "" {
+		t, err := parseClockTime(raw)
+		if err != nil {
+			apiError(c, http.StatusBadRequest, "invalid time, expected HH:MM")
+			return
+		}
+		at = &t
+		if mealType == "" {
+			mealType = mealTypeForTime(t)
+		}
+	}
+
+	var timeArg *string
+	if at != nil {
+		s := at.String()
+		timeArg = &s
+	}
+	favs, err := queryMany[rankedFavorite](h.db, c, `
+		SELECT f.*,
+			COUNT(i.id) FILTER (WHERE i.type::text = @type) AS type_uses,
+			COUNT(i.id) FILTER (
+				WHERE i.consumed_at IS NOT NULL AND @time::time IS NOT NULL
+				  AND abs(extract(epoch FROM i.consumed_at - @time::time)) <= @window * 60
+			) AS time_uses,
+			mode() WITHIN GROUP (ORDER BY i.type::text) AS top_type
+		FROM calorie_log_favorites f
+		LEFT JOIN calorie_log_items i ON i.favorite_id = f.id
+		WHERE f.user_id = @userID
+		GROUP BY f.id
+	`, pgx.NamedArgs{"userID": userID, "type": mealType, "time": timeArg, "window": favoriteTimeWindowMinutes})
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to fetch favorites")
 		return
 	}
 	// Return empty array instead of null when no favorites exist.
 	if favs == nil {
-		favs = []calorieLogFavorite{}
+		favs = []rankedFavorite{}
 	}
+	rankFavorites(favs, mealType, at, time.Now())
 	c.JSON(http.StatusOK, favs)
 }
 
+// mealTypeForTime is the meal usually eaten at a time of day.
+func mealTypeForTime(t ClockTime) string {
+	switch {
+	case t.Minutes < 10*60+30:
+		return "breakfast"
+	case t.Minutes < 14*60+30:
+		return "lunch"
+	case t.Minutes >= 17*60 && t.Minutes < 21*60+30:
+		return "dinner"
+	default:
+		return "snack"
+	}
+}
+
+// rankFavorites scores and sorts favs in place, best first. Uses as the
+// requested meal type and near the requested time weigh most, then overall
+// use, with a boost for favorites used in the last week. Ties go to the newest.
+// Also fills SuggestedType: the meal type a favorite is usually logged as,
+// else the requested one, else its own type.
+func rankFavorites(favs []rankedFavorite, mealType string, at *ClockTime, now time.Time) {
+	for i := range favs {
+		f := &favs[i]
+		f.Score = 3*float64(f.TypeUses) + 2*float64(f.TimeUses) + float64(f.UseCount)
+		if f.LastUsedAt != nil && now.Sub(*f.LastUsedAt) < 7*24*time.Hour {
+			f.Score += 2
+		}
+		f.Score = math.Round(f.Score*10) / 10
+
+		switch {
+		case f.Type == "exercise":
+			f.SuggestedType = "exercise"
+		case f.TopType != nil && *f.TopType != "exercise":
+			f.SuggestedType = *f.TopType
+		case mealType != "" && mealType != "exercise":
+			f.SuggestedType = mealType
+		default:
+			f.SuggestedType = f.Type
+		}
+	}
+	sort.SliceStable(favs, func(a, b int) bool {
+		if favs[a].Score != favs[b].Score {
+			return favs[a].Score > favs[b].Score
+		}
+		return favs[a].ID > favs[b].ID
+	})
+}
+
 // createFavorite saves a new favorite template for the current user.
+// With recipe_id or food_id, nutrition is taken from (and kept in sync with) that source.
 func (h *Handler) createFavorite(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	var body createFavoriteRequest
@@ -35,12 +126,37 @@ func (h *Handler) createFavorite(c *gin.Context) {
 		apiError(c, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	fav, err := queryOne[calorieLogFavorite](h.db, c, `
+	if !validItemTypes[body.Type] {
+		apiError(c, http.StatusBadRequest, "type must be one of: breakfast, lunch, dinner, snack, exercise")
+		return
+	}
+	if body.RecipeID != nil && body.FoodID != nil {
+		apiError(c, http.StatusBadRequest, "a favorite can link a recipe or a food, not both")
+		return
+	}
+	if body.Calories == 0 && body.RecipeID == nil && body.FoodID == nil {
+		apiError(c, http.StatusBadRequest, "calories is required")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	if msg := checkFavoriteLinks(c, tx, userID, body.RecipeID, body.FoodID); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+	var id int
+	err = tx.QueryRow(c, `
 		INSERT INTO calorie_log_favorites
-			(user_id, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g)
+			(user_id, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g, recipe_id, food_id)
 		VALUES
-			(@userID, @itemName, @type, @qty, @uom, @calories, @proteinG, @carbsG, @fatG)
-		RETURNING *
+			(@userID, @itemName, @type, @qty, @uom, @calories, @proteinG, @carbsG, @fatG, @recipeID, @foodID)
+		RETURNING id
 	`, pgx.NamedArgs{
 		"userID":   userID,
 		"itemName": body.ItemName,
@@ -51,7 +167,14 @@ func (h *Handler) createFavorite(c *gin.Context) {
 		"proteinG": body.ProteinG,
 		"carbsG":   body.CarbsG,
 		"fatG":     body.FatG,
-	})
+		"recipeID": body.RecipeID,
+		"foodID":   body.FoodID,
+	}).Scan(&id)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to create favorite")
+		return
+	}
+	fav, err := h.finishFavoriteWrite(c, tx, userID, id)
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to create favorite")
 		return
@@ -59,6 +182,164 @@ func (h *Handler) createFavorite(c *gin.Context) {
 	c.JSON(http.StatusCreated, fav)
 }
 
+// updateFavorite edits a favorite. Omitted fields keep their value; a
+// recipe_id or food_id of 0 removes the link (nutrition stays as it was).
+// PUT /api/calorie-log/favorites/:id
+func (h *Handler) updateFavorite(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var body updateFavoriteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Type != nil && !validItemTypes[*body.Type] {
+		apiError(c, http.StatusBadRequest, "type must be one of: breakfast, lunch, dinner, snack, exercise")
+		return
+	}
+	if body.ItemName != nil && *body.ItemName == "" {
+		apiError(c, http.StatusBadRequest, "item_name must not be empty")
+		return
+	}
+	if body.RecipeID != nil && body.FoodID != nil && *body.RecipeID != 0 && *body.FoodID != 0 {
+		apiError(c, http.StatusBadRequest, "a favorite can link a recipe or a food, not both")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	if msg := checkFavoriteLinks(c, tx, userID, nonZero(body.RecipeID), nonZero(body.FoodID)); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+	// Linking one source unlinks the other so the one-source constraint holds.
+	tag, err := tx.Exec(c, `
+		UPDATE calorie_log_favorites SET
+			item_name = COALESCE(@itemName, item_name),
+			type      = COALESCE(@type::calorie_log_item_type, type),
+			qty       = COALESCE(@qty, qty),
+			uom       = COALESCE(@uom::calorie_log_item_uom, uom),
+			calories  = COALESCE(@calories, calories),
+			protein_g = COALESCE(@proteinG, protein_g),
+			carbs_g   = COALESCE(@carbsG, carbs_g),
+			fat_g     = COALESCE(@fatG, fat_g),
+			recipe_id = CASE
+				WHEN @recipeID::int IS NOT NULL THEN NULLIF(@recipeID::int, 0)
+				WHEN NULLIF(@foodID::int, 0) IS NOT NULL THEN NULL
+				ELSE recipe_id END,
+			food_id = CASE
+				WHEN @foodID::int IS NOT NULL THEN NULLIF(@foodID::int, 0)
+				WHEN NULLIF(@recipeID::int, 0) IS NOT NULL THEN NULL
+				ELSE food_id END
+		WHERE id = @id AND user_id = @userID
+	`, pgx.NamedArgs{
+		"id": id, "userID": userID,
+		"itemName": body.ItemName, "type": body.Type, "qty": body.Qty, "uom": body.Uom,
+		"calories": body.Calories, "proteinG": body.ProteinG, "carbsG": body.CarbsG, "fatG": body.FatG,
+		"recipeID": body.RecipeID, "foodID": body.FoodID,
+	})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update favorite")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		apiError(c, http.StatusNotFound, "favorite not found")
+		return
+	}
+	fav, err := h.finishFavoriteWrite(c, tx, userID, id)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update favorite")
+		return
+	}
+	c.JSON(http.StatusOK, fav)
+}
+
+// nonZero returns nil for a nil or zero id, so "unlink" values skip link checks.
+func nonZero(id *int) *int {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
+
+// checkFavoriteLinks verifies that a linked recipe or food belongs to the user.
+// Returns a user-facing message, or "" when the links are valid.
+func checkFavoriteLinks(c *gin.Context, tx pgx.Tx, userID int, recipeID, foodID *int) string {
+	var ok bool
+	if recipeID != nil {
+		err := tx.QueryRow(c, `SELECT EXISTS (SELECT 1 FROM recipes WHERE id = @id AND user_id = @userID)`,
+			pgx.NamedArgs{"id": *recipeID, "userID": userID}).Scan(&ok)
+		if err != nil || !ok {
+			return "recipe not found"
+		}
+	}
+	if foodID != nil {
+		err := tx.QueryRow(c, `SELECT EXISTS (SELECT 1 FROM foods WHERE id = @id AND user_id = @userID)`,
+			pgx.NamedArgs{"id": *foodID, "userID": userID}).Scan(&ok)
+		if err != nil || !ok {
+			return "food not found"
+		}
+	}
+	return ""
+}
+
+// finishFavoriteWrite syncs linked nutrition, commits, and returns the saved favorite.
+func (h *Handler) finishFavoriteWrite(c *gin.Context, tx pgx.Tx, userID, id int) (calorieLogFavorite, error) {
+	if err := syncLinkedFavorites(c, tx, userID); err != nil {
+		return calorieLogFavorite{}, err
+	}
+	rows, err := tx.Query(c, `SELECT * FROM calorie_log_favorites WHERE id = @id`, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return calorieLogFavorite{}, err
+	}
+	fav, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[calorieLogFavorite])
+	if err != nil {
+		return calorieLogFavorite{}, err
+	}
+	return fav, tx.Commit(c)
+}
+
+// syncLinkedFavorites recomputes the nutrition of the user's favorites that
+// link a recipe or food. A recipe favorite's qty is servings of the recipe's
+// per-serving numbers; a food favorite's qty is in the food's serving unit, or
+// in grams (uom 'g') when the food's serving weight is known. Run after any
+// write that changes a favorite's link or qty, or a source's nutrition.
+func syncLinkedFavorites(ctx context.Context, q execer, userID int) error {
+	_, err := q.Exec(ctx, `
+		UPDATE calorie_log_favorites f SET
+			calories  = ROUND(s.calories * s.factor),
+			protein_g = ROUND(s.protein_g * s.factor, 1),
+			carbs_g   = ROUND(s.carbs_g * s.factor, 1),
+			fat_g     = ROUND(s.fat_g * s.factor, 1)
+		FROM (
+			SELECT fav.id, r.calories, r.protein_g, r.carbs_g, r.fat_g,
+				COALESCE(fav.qty, 1) AS factor
+			FROM calorie_log_favorites fav
+			JOIN recipes r ON r.id = fav.recipe_id
+			WHERE fav.user_id = @userID AND r.calories IS NOT NULL
+			UNION ALL
+			SELECT fav.id, fd.calories, fd.protein_g, fd.carbs_g, fd.fat_g,
+				CASE WHEN fav.uom = 'g' AND fd.serving_grams IS NOT NULL
+					THEN COALESCE(fav.qty, 1) / fd.serving_grams
+					ELSE COALESCE(fav.qty, 1) / fd.serving_qty END
+			FROM calorie_log_favorites fav
+			JOIN foods fd ON fd.id = fav.food_id
+			WHERE fav.user_id = @userID
+		) s
+		WHERE f.id = s.id
+	`, pgx.NamedArgs{"userID": userID})
+	return err
+}
+
 // deleteFavorite removes a favorite by id, scoped to the current user.
 func (h *Handler) deleteFavorite(c *gin.Context) {
 	userID := c.GetInt("user_id")
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMealTypeForTime(t *testing.T) {
+	cases := map[string]string{
+		"07:00": "breakfast",
+		"10:29": "breakfast",
+		"12:15": "lunch",
+		"15:30": "snack",
+		"18:45": "dinner",
+		"22:30": "snack",
+	}
+	for at, want := range cases {
+		if got := mealTypeForTime(*clock(at)); got != want {
+			t.Errorf("%s: got %s, want %s", at, got, want)
+		}
+	}
+}
+
+func TestRankFavorites(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	lunch, dinner := "lunch", "dinner"
+	favs := []rankedFavorite{
+		// Used a lot, but always at dinner.
+		{calorieLogFavorite: calorieLogFavorite{ID: 1, Type: "dinner", UseCount: 6}, TopType: &dinner},
+		// Used less overall, but as lunch and around now.
+		{calorieLogFavorite: calorieLogFavorite{ID: 2, Type: "snack", UseCount: 3, LastUsedAt: &recent}, TypeUses: 3, TimeUses: 2, TopType: &lunch},
+		// Never used; newest favorite.
+		{calorieLogFavorite: calorieLogFavorite{ID: 3, Type: "breakfast"}},
+		{calorieLogFavorite: calorieLogFavorite{ID: 4, Type: "exercise", UseCount: 1}},
+	}
+	rankFavorites(favs, "lunch", clock("12:00"), now)
+
+	order := []int{favs[0].ID, favs[1].ID, favs[2].ID, favs[3].ID}
+	if order[0] != 2 || order[1] != 1 || order[2] != 4 || order[3] != 3 {
+		t.Errorf("order: got %v, want [2 1 4 3]", order)
+	}
+	// 3×3 type + 2×2 time + 3 uses + 2 recent.
+	if favs[0].Score != 18 {
+		t.Errorf("score: got %v, want 18", favs[0].Score)
+	}
+	suggested := map[int]string{}
+	for _, f := range favs {
+		suggested[f.ID] = f.SuggestedType
+	}
+	if suggested[1] != "dinner" || suggested[2] != "lunch" || suggested[3] != "lunch" || suggested[4] != "exercise" {
+		t.Errorf("suggested types: got %v", suggested)
+	}
+
+	// Without a requested meal type, unused favorites keep their own type.
+	unused := []rankedFavorite{{calorieLogFavorite: calorieLogFavorite{ID: 5, Type: "snack"}}}
+	rankFavorites(unused, "", nil, now)
+	if unused[0].SuggestedType != "snack" {
+		t.Errorf("got %s, want snack", unused[0].SuggestedType)
+	}
+}
+
+func TestCreateFavorite_Validation(t *testing.T) {
+	h := Handler{}
+	cases := []struct{ name, body string }{
+		{"bad type", `{"item_name":"Oats","type":"brunch","calories":300}`},
+		{"no calories", `{"item_name":"Oats","type":"breakfast"}`},
+		{"two links", `{"item_name":"Oats","type":"breakfast","recipe_id":1,"food_id":2}`},
+	}
+	for _, tc := range cases {
+		if w := doBulkRequest(h.createFavorite, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", tc.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidateFoodRequest(t *testing.T) {
+	name, cal, zero, grams := "Oats", 150, 0.0, "cups"
+	if msg := validateFoodRequest(foodRequest{Name: &name}, true); msg == "" {
+		t.Error("create without calories should fail")
+	}
+	if msg := validateFoodRequest(foodRequest{Name: &name, Calories: &cal}, true); msg != "" {
+		t.Errorf("valid create: got %q", msg)
+	}
+	if msg := validateFoodRequest(foodRequest{ServingQty: &zero}, false); msg == "" {
+		t.Error("zero serving_qty should fail")
+	}
+	if msg := validateFoodRequest(foodRequest{ServingUom: &grams}, false); msg == "" {
+		t.Error("unknown serving_uom should fail")
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// validFoodUoms are the calorie_log_item_uom values that make sense for a
+// food serving (the rest are exercise units).
+var validFoodUoms = map[string]bool{"each": true, "g": true, "serving": true}
+
+// validateFoodRequest checks the fields present in body. On create, name and
+// calories are required.
+func validateFoodRequest(body foodRequest, create bool) string {
+	if create && (body.Name == nil || body.Calories == nil) {
+		return "name and calories are required"
+	}
+	switch {
+	case body.Name != nil && strings.TrimSpace(*body.Name) == "":
+		return "name must not be empty"
+	case body.Calories != nil && *body.Calories < 0:
+		return "calories must not be negative"
+	case body.ServingQty != nil && *body.ServingQty <= 0:
+		return "serving_qty must be positive"
+	case body.ServingUom != nil && !validFoodUoms[*body.ServingUom]:
+		return "serving_uom must be one of: each, g, serving"
+	case body.ServingGrams != nil && *body.ServingGrams <= 0:
+		return "serving_grams must be positive"
+	}
+	return ""
+}
+
+// listFoods returns the user's saved foods by name, optionally filtered.
+// GET /api/foods?q=oat
+func (h *Handler) listFoods(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	foods, err := queryMany[food](h.db, c,
+		`SELECT * FROM foods
+		 WHERE user_id = @userID
+		   AND (@q = '' OR name ILIKE '%' || @q || '%' OR brand ILIKE '%' || @q || '%')
+		 ORDER BY lower(name), id`,
+		pgx.NamedArgs{"userID": userID, "q": strings.TrimSpace(c.Query("q"))})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch foods")
+		return
+	}
+	if foods == nil {
+		foods = []food{}
+	}
+	c.JSON(http.StatusOK, foods)
+}
+
+// createFood saves a food.
+// POST /api/foods
+func (h *Handler) createFood(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	var body foodRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := validateFoodRequest(body, true); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	f, err := queryOne[food](h.db, c,
+		`INSERT INTO foods (user_id, name, brand, serving_qty, serving_uom, serving_grams, calories, protein_g, carbs_g, fat_g)
+		 VALUES (@userID, @name, @brand, COALESCE(@servingQty, 1), COALESCE(@servingUom::calorie_log_item_uom, 'serving'),
+		         @servingGrams, @calories, @proteinG, @carbsG, @fatG)
+		 RETURNING *`,
+		pgx.NamedArgs{
+			"userID": userID, "name": strings.TrimSpace(*body.Name), "brand": body.Brand,
+			"servingQty": body.ServingQty, "servingUom": body.ServingUom, "servingGrams": body.ServingGrams,
+			"calories": body.Calories, "proteinG": body.ProteinG, "carbsG": body.CarbsG, "fatG": body.FatG,
+		})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to create food")
+		return
+	}
+	c.JSON(http.StatusCreated, f)
+}
+
+// updateFood edits a food, recomputes recipes whose ingredients use it, and
+// re-syncs linked favorites.
+// PUT /api/foods/:id. Omitted fields keep their value.
+func (h *Handler) updateFood(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var body foodRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := validateFoodRequest(body, false); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		body.Name = &name
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	rows, err := tx.Query(c,
+		`UPDATE foods SET
+			name          = COALESCE(@name, name),
+			brand         = COALESCE(@brand, brand),
+			serving_qty   = COALESCE(@servingQty, serving_qty),
+			serving_uom   = COALESCE(@servingUom::calorie_log_item_uom, serving_uom),
+			serving_grams = COALESCE(@servingGrams, serving_grams),
+			calories      = COALESCE(@calories, calories),
+			protein_g     = COALESCE(@proteinG, protein_g),
+			carbs_g       = COALESCE(@carbsG, carbs_g),
+			fat_g         = COALESCE(@fatG, fat_g),
+			updated_at    = now()
+		 WHERE id = @id AND user_id = @userID
+		 RETURNING *`,
+		pgx.NamedArgs{
+			"id": id, "userID": userID, "name": body.Name, "brand": body.Brand,
+			"servingQty": body.ServingQty, "servingUom": body.ServingUom, "servingGrams": body.ServingGrams,
+			"calories": body.Calories, "proteinG": body.ProteinG, "carbsG": body.CarbsG, "fatG": body.FatG,
+		})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update food")
+		return
+	}
+	f, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[food])
+	if errors.Is(err, pgx.ErrNoRows) {
+		apiError(c, http.StatusNotFound, "food not found")
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update food")
+		return
+	}
+	recipeIDs, err := recipesUsingFood(c, tx, id)
+	if err == nil {
+		err = recomputeRecipes(c, tx, recipeIDs)
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to recompute recipes")
+		return
+	}
+	if err := syncLinkedFavorites(c, tx, userID); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to sync favorites")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// deleteFood removes a food. Linked favorites keep their last nutrition;
+// recipe ingredients lose the link and their recipes are recomputed.
+// DELETE /api/foods/:id
+func (h *Handler) deleteFood(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	// Collect affected recipes before ON DELETE SET NULL clears the links.
+	recipeIDs, err := recipesUsingFood(c, tx, id)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete food")
+		return
+	}
+	tag, err := tx.Exec(c,
+		"DELETE FROM foods WHERE id = @id AND user_id = @userID",
+		pgx.NamedArgs{"id": id, "userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete food")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		apiError(c, http.StatusNotFound, "food not found")
+		return
+	}
+	if err := recomputeRecipes(c, tx, recipeIDs); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to recompute recipes")
+		return
+	}
+	if err := syncLinkedFavorites(c, tx, userID); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to sync favorites")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
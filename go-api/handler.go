@@ -15,6 +15,7 @@ import (
 type Handler struct {
 	db            *pgxpool.Pool
 	openAIBaseURL string // Base URL for OpenAI API (overridable for tests)
+	cookEvents    *cookSessionHub
 }
 
 /* ─── Database helpers ────────────────────────────────────────────────── */
@@ -88,29 +89,83 @@ func (h *Handler) registerRoutes(router *gin.Engine) {
 	api.POST("/calorie-log/items", h.createCalorieLogItem)
 	api.PUT("/calorie-log/items/:id", h.updateCalorieLogItem)
 	api.DELETE("/calorie-log/items/:id", h.deleteCalorieLogItem)
+	api.POST("/calorie-log/items/copy", h.copyCalorieLogItems)
+	api.POST("/calorie-log/items/move", h.moveCalorieLogItems)
+	api.POST("/calorie-log/items/bulk-delete", h.deleteCalorieLogItems)
+	api.POST("/calorie-log/import/preview", h.previewImport)
+	api.POST("/calorie-log/import/commit", h.commitImport)
+	api.POST("/calorie-log/import/health/preview", h.previewHealthImport)
+	api.POST("/calorie-log/import/health/commit", h.commitHealthImport)
 	api.GET("/calorie-log/user-settings", h.getUserSettings)
 	api.PATCH("/calorie-log/user-settings", h.patchUserSettings)
 	api.POST("/calorie-log/suggest", h.suggestCalorieLogItem)
+	api.GET("/calorie-log/exercise-catalog", h.listExerciseCatalog)
+	api.POST("/calorie-log/exercise-calc", h.calcExerciseCalories)
+	api.GET("/calorie-log/exercise-offsets", h.getExerciseOffsets)
+	api.POST("/calorie-log/exercise-offsets/what-if", h.whatIfExerciseOffsets)
 	api.GET("/calorie-log/progress", h.getProgress)
+	api.GET("/calorie-log/report", h.getReport)
+	api.GET("/calorie-log/config-history", h.listConfigHistory)
+	api.PUT("/calorie-log/config-history/range", h.updateConfigHistoryRange)
+	api.PUT("/calorie-log/config-history/:id", h.updateConfigHistoryEntry)
+	api.DELETE("/calorie-log/config-history/:id", h.deleteConfigHistoryEntry)
 	api.GET("/calorie-log/earliest-date", h.getEarliestLogDate)
+	api.GET("/calorie-log/days/notes", h.getDayNotes)
+	api.PUT("/calorie-log/days/:date/note", h.putDayNote)
+	api.PUT("/calorie-log/days/:date/complete", h.putDayComplete)
+	api.GET("/calorie-log/streak", h.getLoggingStreak)
+	api.GET("/calorie-log/accuracy", h.getAccuracy)
 	api.GET("/calorie-log/favorites", h.listFavorites)
 	api.POST("/calorie-log/favorites", h.createFavorite)
+	api.PUT("/calorie-log/favorites/:id", h.updateFavorite)
 	api.DELETE("/calorie-log/favorites/:id", h.deleteFavorite)
 	api.GET("/weight-log", h.getWeightLog)
 	api.POST("/weight-log", h.upsertWeightEntry)
 	api.PUT("/weight-log/:id", h.updateWeightEntry)
 	api.DELETE("/weight-log/:id", h.deleteWeightEntry)
+	api.GET("/water-log", h.getWaterLog)
+	api.POST("/water-log", h.createWaterEntry)
+	api.DELETE("/water-log/:id", h.deleteWaterEntry)
+	// Food library routes
+	api.GET("/foods", h.listFoods)
+	api.POST("/foods", h.createFood)
+	api.PUT("/foods/:id", h.updateFood)
+	api.DELETE("/foods/:id", h.deleteFood)
 	// Recipe routes — /generate must be registered before /:id to avoid being swallowed as an id param
 	api.POST("/recipes/generate", h.generateRecipe)
+	api.POST("/recipes/import", h.importRecipe)
+	api.POST("/recipes/import/file/preview", h.previewRecipeFileImport)
+	api.POST("/recipes/import/file/commit", h.commitRecipeFileImport)
+	api.GET("/recipes/export", h.exportRecipes)
+	api.GET("/recipes/tags", h.listRecipeTags)
 	api.GET("/recipes", h.listRecipes)
 	api.POST("/recipes", h.createRecipe)
 	api.GET("/recipes/:id", h.getRecipe)
 	api.PUT("/recipes/:id", h.updateRecipe)
 	api.DELETE("/recipes/:id", h.deleteRecipe)
 	api.POST("/recipes/:id/duplicate", h.duplicateRecipe)
+	api.GET("/recipes/:id/export", h.exportRecipe)
+	api.GET("/recipes/:id/shopping-list", h.getShoppingList)
+	api.GET("/recipes/:id/scaled", h.getScaledRecipe)
+	api.POST("/recipes/:id/scaled", h.saveScaledRecipe)
+	api.GET("/recipes/:id/revisions", h.listRecipeRevisions)
+	api.GET("/recipes/:id/revisions/diff", h.diffRecipeRevisions)
+	api.GET("/recipes/:id/revisions/:rev", h.getRecipeRevision)
+	api.POST("/recipes/:id/revisions/:rev/restore", h.restoreRecipeRevision)
+	api.POST("/recipes/:id/cook", h.startCookSession)
+	api.GET("/recipes/:id/cooks", h.listRecipeCooks)
+	api.POST("/recipes/:id/cooks", h.createRecipeCook)
+	api.PUT("/recipes/:id/cooks/:cook", h.updateRecipeCook)
+	api.DELETE("/recipes/:id/cooks/:cook", h.deleteRecipeCook)
 	api.POST("/recipes/:id/ai-modify", h.aiModifyRecipe)
 	api.POST("/recipes/:id/ai-copy", h.aiCopyRecipe)
 	api.POST("/recipes/:id/ai-nutrition", h.aiNutrition)
+	api.GET("/cook-sessions", h.listCookSessions)
+	api.GET("/cook-sessions/:id", h.getCookSession)
+	api.GET("/cook-sessions/:id/events", h.streamCookSession)
+	api.POST("/cook-sessions/:id/actions", h.applyCookSessionAction)
+	api.POST("/cook-sessions/:id/finish", h.finishCookSession)
+	api.DELETE("/cook-sessions/:id", h.abandonCookSession)
 	// Journal routes — static paths (/calendar, /summary, /tag-days) must be registered
 	// before /:id to avoid Gin treating them as ID params.
 	api.GET("/journal", h.getJournalEntries)
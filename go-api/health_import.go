@@ -0,0 +1,626 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Apple Health (export.zip / export.xml) and Google Takeout Fit (Takeout zip or
+// individual session / data-point JSON files) ingestion. Both are parsed as
+// streams — Apple exports run to hundreds of MB — into the importer's records:
+//   - workouts        → exercise items, deduped by external_id
+//   - active energy   → body_measurements "active_energy_kcal" (daily total, upserted)
+//   - steps           → body_measurements "steps" (daily total, upserted)
+//   - weight          → weight_log
+//   - body fat        → body_measurements "body_fat_pct"
+//
+// Daily totals only go to measurements, not the log: TDEE already includes
+// everyday movement, so only workouts count as exercise calories.
+
+// maxHealthImportBytes caps uploaded health exports.
+const maxHealthImportBytes = 1 << 30
+
+// healthWorkoutActivities maps Apple workout types (without the
+// HKWorkoutActivityType prefix) and Google Fit activity names to catalog keys,
+// used for the item name and for MET estimates when the export has no energy.
+var healthWorkoutActivities = map[string]string{
+	"walking":                          "walking",
+	"hiking":                           "hiking",
+	"running":                          "running",
+	"running.jogging":                  "jogging",
+	"cycling":                          "cycling",
+	"biking":                           "cycling",
+	"biking.stationary":                "stationary_bike",
+	"biking.spinning":                  "stationary_bike",
+	"swimming":                         "swimming",
+	"swimming.pool":                    "swimming",
+	"swimming.open_water":              "swimming",
+	"rowing":                           "rowing",
+	"rowing.machine":                   "rowing",
+	"elliptical":                       "elliptical",
+	"stairclimbing":                    "stair_climbing",
+	"stairs":                           "stair_climbing",
+	"stair_climbing":                   "stair_climbing",
+	"traditionalstrengthtraining":      "weight_training",
+	"functionalstrengthtraining":       "weight_training",
+	"strength_training":                "weight_training",
+	"weightlifting":                    "weight_training",
+	"highintensityintervaltraining":    "hiit",
+	"interval_training.high_intensity": "hiit",
+	"crossfit":                         "hiit",
+	"yoga":                             "yoga",
+	"pilates":                          "pilates",
+	"dance":                            "dancing",
+	"dancing":                          "dancing",
+	"jumprope":                         "jump_rope",
+	"jump_rope":                        "jump_rope",
+}
+
+// camelBoundary splits Apple's CamelCase workout types into words.
+var camelBoundary = regexp.MustCompile(`([a-z])([A-Z])`)
+
+// healthWorkoutName resolves a raw workout type to a catalog activity (if any)
+// and a display name.
+func healthWorkoutName(raw string) (exerciseActivity, bool, string) {
+	raw = strings.TrimPrefix(raw, "HKWorkoutActivityType")
+	if a, ok := catalogActivity(healthWorkoutActivities[strings.ToLower(raw)]); ok {
+		return a, true, a.Name
+	}
+	words := strings.Fields(strings.NewReplacer("_", " ", ".", " ").Replace(camelBoundary.ReplaceAllString(raw, "$1 $2")))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	if len(words) == 0 {
+		return exerciseActivity{}, false, "Workout"
+	}
+	return exerciseActivity{}, false, strings.Join(words, " ")
+}
+
+// energyToKcal converts an energy reading to kcal. Apple uses "Cal" for kcal.
+func energyToKcal(v float64, unit string) float64 {
+	if strings.EqualFold(unit, "kj") {
+		return v / 4.184
+	}
+	return v
+}
+
+/* ─── Aggregation ────────────────────────────────────────────────────── */
+
+// healthAggregator collects workouts and per-day readings from one export.
+// Steps and active energy are summed per day per source device and the
+// largest source wins, since a phone and a watch both record the same walk.
+type healthAggregator struct {
+	since     string  // drop readings before this date ("" = keep all)
+	weightLBS float64 // for MET estimates of workouts without energy
+	workouts  []importRecord
+	steps     map[string]map[string]float64 // date → source → total
+	energy    map[string]map[string]float64
+	weights   map[string]timedReading // date → latest reading
+	bodyFat   map[string]timedReading
+	issues    []importIssue
+}
+
+// timedReading keeps the latest reading of the day.
+type timedReading struct {
+	at    time.Time
+	value float64
+}
+
+func newHealthAggregator(since string, weightLBS float64) *healthAggregator {
+	return &healthAggregator{
+		since:     since,
+		weightLBS: weightLBS,
+		steps:     map[string]map[string]float64{},
+		energy:    map[string]map[string]float64{},
+		weights:   map[string]timedReading{},
+		bodyFat:   map[string]timedReading{},
+		issues:    []importIssue{},
+	}
+}
+
+// skip reports whether a reading dated date is before the since cutoff.
+func (a *healthAggregator) skip(date string) bool {
+	return a.since != "" && date < a.since
+}
+
+// addDaily adds to a per-day, per-source total.
+func addDaily(m map[string]map[string]float64, date, source string, v float64) {
+	if m[date] == nil {
+		m[date] = map[string]float64{}
+	}
+	m[date][source] += v
+}
+
+// setLatest keeps the reading with the latest timestamp for its day.
+func setLatest(m map[string]timedReading, at time.Time, v float64) {
+	date := at.Format("2006-01-02")
+	if cur, ok := m[date]; !ok || !at.Before(cur.at) {
+		m[date] = timedReading{at: at, value: v}
+	}
+}
+
+// addWorkout records one workout as an exercise item. When the export has no
+// energy, catalog activities are estimated from duration; others are skipped.
+func (a *healthAggregator) addWorkout(externalID, rawType string, start time.Time, minutes, kcal float64) {
+	date := start.Format("2006-01-02")
+	if a.skip(date) {
+		return
+	}
+	activity, known, name := healthWorkoutName(rawType)
+	calories := int(math.Round(kcal))
+	if calories <= 0 {
+		if !known || minutes <= 0 {
+			a.issues = append(a.issues, importIssue{Message: fmt.Sprintf("%s on %s has no energy burned; skipped", name, date)})
+			return
+		}
+		calories = metCalories(activity.MET, a.weightLBS, minutes)
+	}
+	rec := importRecord{Kind: "exercise", Date: date, ItemName: name, Type: "exercise", Calories: calories, ExternalID: externalID}
+	if minutes > 0 {
+		qty := math.Round(minutes*10) / 10
+		uom := "minutes"
+		rec.Qty, rec.Uom = &qty, &uom
+	}
+	a.workouts = append(a.workouts, rec)
+}
+
+// records flattens the aggregate into importer records: workouts in export
+// order, then daily readings by date.
+func (a *healthAggregator) records() []importRecord {
+	out := append([]importRecord{}, a.workouts...)
+
+	var daily []importRecord
+	for date, bySource := range a.steps {
+		daily = append(daily, dailyMeasurement(date, "steps", "count", maxSource(bySource)))
+	}
+	for date, bySource := range a.energy {
+		daily = append(daily, dailyMeasurement(date, "active_energy_kcal", "kcal", maxSource(bySource)))
+	}
+	for date, r := range a.weights {
+		daily = append(daily, importRecord{Kind: "weight", Date: date, Value: math.Round(r.value*10) / 10})
+	}
+	for date, r := range a.bodyFat {
+		daily = append(daily, bodyFatRecord(date, math.Round(r.value*10)/10))
+	}
+	sort.SliceStable(daily, func(i, j int) bool {
+		if daily[i].Date != daily[j].Date {
+			return daily[i].Date < daily[j].Date
+		}
+		return daily[i].Kind+daily[i].Metric < daily[j].Kind+daily[j].Metric
+	})
+	out = append(out, daily...)
+
+	for i := range out {
+		out[i].Line = i + 1
+	}
+	return out
+}
+
+// maxSource returns the largest per-source total.
+func maxSource(bySource map[string]float64) float64 {
+	best := 0.0
+	for _, v := range bySource {
+		best = math.Max(best, v)
+	}
+	return best
+}
+
+// dailyMeasurement is a rounded per-day total.
+func dailyMeasurement(date, metric, unit string, v float64) importRecord {
+	return importRecord{Kind: "measurement", Date: date, Metric: metric, Value: math.Round(v), Unit: &unit}
+}
+
+/* ─── Apple Health ───────────────────────────────────────────────────── */
+
+// appleDateLayout is the timestamp format in export.xml; the offset is the
+// device's, so the date part is already the user's local date.
+const appleDateLayout = "2006-01-02 15:04:05 -0700"
+
+// appleRecord is a <Record> element (a single quantity sample).
+type appleRecord struct {
+	Type       string `xml:"type,attr"`
+	SourceName string `xml:"sourceName,attr"`
+	Unit       string `xml:"unit,attr"`
+	Value      string `xml:"value,attr"`
+	StartDate  string `xml:"startDate,attr"`
+}
+
+// appleWorkout is a <Workout> element. Older exports carry energy as
+// attributes; newer ones in <WorkoutStatistics> children.
+type appleWorkout struct {
+	ActivityType          string `xml:"workoutActivityType,attr"`
+	Duration              string `xml:"duration,attr"`
+	DurationUnit          string `xml:"durationUnit,attr"`
+	TotalEnergyBurned     string `xml:"totalEnergyBurned,attr"`
+	TotalEnergyBurnedUnit string `xml:"totalEnergyBurnedUnit,attr"`
+	StartDate             string `xml:"startDate,attr"`
+	Statistics            []struct {
+		Type string `xml:"type,attr"`
+		Sum  string `xml:"sum,attr"`
+		Unit string `xml:"unit,attr"`
+	} `xml:"WorkoutStatistics"`
+}
+
+// parseAppleHealthXML streams export.xml into the aggregator.
+func parseAppleHealthXML(r io.Reader, agg *healthAggregator) error {
+	dec := xml.NewDecoder(r)
+	sawRoot := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid export.xml: %v", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "HealthData":
+			sawRoot = true
+		case "Record":
+			var rec appleRecord
+			if err := dec.DecodeElement(&rec, &se); err != nil {
+				return fmt.Errorf("invalid export.xml: %v", err)
+			}
+			agg.addAppleRecord(rec)
+		case "Workout":
+			var w appleWorkout
+			if err := dec.DecodeElement(&w, &se); err != nil {
+				return fmt.Errorf("invalid export.xml: %v", err)
+			}
+			agg.addAppleWorkout(w)
+		}
+	}
+	if !sawRoot {
+		return fmt.Errorf("not an Apple Health export.xml")
+	}
+	return nil
+}
+
+// addAppleRecord aggregates the sample types we import; others are ignored.
+func (a *healthAggregator) addAppleRecord(rec appleRecord) {
+	at, err := time.Parse(appleDateLayout, rec.StartDate)
+	if err != nil {
+		return
+	}
+	date := at.Format("2006-01-02")
+	if a.skip(date) {
+		return
+	}
+	v, err := strconv.ParseFloat(rec.Value, 64)
+	if err != nil {
+		return
+	}
+	switch rec.Type {
+	case "HKQuantityTypeIdentifierStepCount":
+		addDaily(a.steps, date, rec.SourceName, v)
+	case "HKQuantityTypeIdentifierActiveEnergyBurned":
+		addDaily(a.energy, date, rec.SourceName, energyToKcal(v, rec.Unit))
+	case "HKQuantityTypeIdentifierBodyMass":
+		setLatest(a.weights, at, weightToLBS(v, rec.Unit))
+	case "HKQuantityTypeIdentifierBodyFatPercentage":
+		// Stored as a fraction (0.22) with unit "%".
+		setLatest(a.bodyFat, at, v*100)
+	}
+}
+
+// addAppleWorkout converts a workout's duration and energy and records it.
+func (a *healthAggregator) addAppleWorkout(w appleWorkout) {
+	start, err := time.Parse(appleDateLayout, w.StartDate)
+	if err != nil {
+		a.issues = append(a.issues, importIssue{Message: fmt.Sprintf("workout with invalid start date %q skipped", w.StartDate)})
+		return
+	}
+	minutes, _ := strconv.ParseFloat(w.Duration, 64)
+	switch w.DurationUnit {
+	case "s", "sec":
+		minutes /= 60
+	case "hr", "h":
+		minutes *= 60
+	}
+	kcal, _ := strconv.ParseFloat(w.TotalEnergyBurned, 64)
+	kcal = energyToKcal(kcal, w.TotalEnergyBurnedUnit)
+	if kcal <= 0 {
+		for _, s := range w.Statistics {
+			if s.Type == "HKQuantityTypeIdentifierActiveEnergyBurned" {
+				v, _ := strconv.ParseFloat(s.Sum, 64)
+				kcal = energyToKcal(v, s.Unit)
+			}
+		}
+	}
+	a.addWorkout("apple_health:"+w.ActivityType+"@"+w.StartDate, w.ActivityType, start, minutes, kcal)
+}
+
+/* ─── Google Fit ─────────────────────────────────────────────────────── */
+
+// googleFitPoint is one entry of a Takeout "All Data" file's "Data Points".
+type googleFitPoint struct {
+	DataTypeName   string `json:"dataTypeName"`
+	StartTimeNanos int64  `json:"startTimeNanos"`
+	FitValue       []struct {
+		Value struct {
+			FpVal  *float64 `json:"fpVal"`
+			IntVal *int64   `json:"intVal"`
+		} `json:"value"`
+	} `json:"fitValue"`
+	OriginDataSourceID string `json:"originDataSourceId"`
+}
+
+// value returns the point's first numeric value.
+func (p googleFitPoint) value() (float64, bool) {
+	if len(p.FitValue) == 0 {
+		return 0, false
+	}
+	v := p.FitValue[0].Value
+	if v.FpVal != nil {
+		return *v.FpVal, true
+	}
+	if v.IntVal != nil {
+		return float64(*v.IntVal), true
+	}
+	return 0, false
+}
+
+// googleFitSession is a Takeout "All Sessions" file.
+type googleFitSession struct {
+	FitnessActivity string `json:"fitnessActivity"`
+	StartTime       string `json:"startTime"`
+	Duration        string `json:"duration"` // e.g. "1800.000s"
+	Aggregate       []struct {
+		MetricName string   `json:"metricName"`
+		FloatValue *float64 `json:"floatValue"`
+		IntValue   *int64   `json:"intValue"`
+	} `json:"aggregate"`
+}
+
+// parseGoogleFitJSON streams one Takeout Fit JSON file into the aggregator.
+// Session files become workouts; "All Data" files contribute steps, weight,
+// and body fat. Google's calories.expended includes resting burn, so active
+// energy comes only from sessions. Timestamps are UTC; loc sets the day.
+func parseGoogleFitJSON(r io.Reader, loc *time.Location, agg *healthAggregator) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("not a Google Fit JSON file")
+	}
+
+	var session googleFitSession
+	dataSource := ""
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid Google Fit JSON: %v", err)
+		}
+		switch keyTok.(string) {
+		case "Data Source":
+			if err := dec.Decode(&dataSource); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		case "Data Points":
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				return fmt.Errorf("invalid Google Fit JSON: Data Points is not an array")
+			}
+			for dec.More() {
+				var p googleFitPoint
+				if err := dec.Decode(&p); err != nil {
+					return fmt.Errorf("invalid Google Fit JSON: %v", err)
+				}
+				agg.addGoogleFitPoint(p, dataSource, loc)
+			}
+			if _, err := dec.Token(); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		case "fitnessActivity":
+			if err := dec.Decode(&session.FitnessActivity); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		case "startTime":
+			if err := dec.Decode(&session.StartTime); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		case "duration":
+			if err := dec.Decode(&session.Duration); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		case "aggregate":
+			if err := dec.Decode(&session.Aggregate); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("invalid Google Fit JSON: %v", err)
+			}
+		}
+	}
+	if session.FitnessActivity != "" {
+		agg.addGoogleFitSession(session, loc)
+	}
+	return nil
+}
+
+// addGoogleFitPoint aggregates the data types we import; others are ignored.
+func (a *healthAggregator) addGoogleFitPoint(p googleFitPoint, dataSource string, loc *time.Location) {
+	v, ok := p.value()
+	if !ok {
+		return
+	}
+	at := time.Unix(0, p.StartTimeNanos).In(loc)
+	date := at.Format("2006-01-02")
+	if a.skip(date) {
+		return
+	}
+	source := p.OriginDataSourceID
+	if source == "" {
+		source = dataSource
+	}
+	switch p.DataTypeName {
+	case "com.google.step_count.delta":
+		addDaily(a.steps, date, source, v)
+	case "com.google.weight":
+		setLatest(a.weights, at, weightToLBS(v, "kg"))
+	case "com.google.body.fat.percentage":
+		setLatest(a.bodyFat, at, v)
+	}
+}
+
+// addGoogleFitSession records a session as a workout. Sleep and still
+// "activities" aren't exercise.
+func (a *healthAggregator) addGoogleFitSession(s googleFitSession, loc *time.Location) {
+	switch s.FitnessActivity {
+	case "sleep", "still", "in_vehicle", "unknown":
+		return
+	}
+	start, err := time.Parse(time.RFC3339, s.StartTime)
+	if err != nil {
+		a.issues = append(a.issues, importIssue{Message: fmt.Sprintf("session with invalid start time %q skipped", s.StartTime)})
+		return
+	}
+	seconds, _ := strconv.ParseFloat(strings.TrimSuffix(s.Duration, "s"), 64)
+	kcal := 0.0
+	for _, m := range s.Aggregate {
+		if m.MetricName == "com.google.calories.expended" && m.FloatValue != nil {
+			kcal = *m.FloatValue
+		}
+	}
+	a.addWorkout("google_fit:"+s.FitnessActivity+"@"+s.StartTime, s.FitnessActivity, start.In(loc), seconds/60, kcal)
+}
+
+/* ─── Upload ─────────────────────────────────────────────────────────── */
+
+// parseHealthExport parses an uploaded Apple Health or Google Takeout file —
+// a zip of either, a bare export.xml, or a single Fit JSON file — and returns
+// the format (apple_health | google_fit).
+func parseHealthExport(f multipart.File, name string, size int64, loc *time.Location, agg *healthAggregator) (string, error) {
+	var magic [4]byte
+	n, _ := io.ReadFull(f, magic[:])
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("could not read file")
+	}
+	switch {
+	case n == 4 && string(magic[:]) == "PK\x03\x04":
+		return parseHealthZip(f, size, loc, agg)
+	case strings.HasSuffix(strings.ToLower(name), ".xml"):
+		return "apple_health", parseAppleHealthXML(f, agg)
+	case strings.HasSuffix(strings.ToLower(name), ".json"):
+		return "google_fit", parseGoogleFitJSON(f, loc, agg)
+	}
+	return "", fmt.Errorf("file must be an Apple Health export (zip or export.xml) or Google Takeout Fit data (zip or JSON)")
+}
+
+// parseHealthZip reads the relevant entries of an export.zip or Takeout zip.
+func parseHealthZip(f io.ReaderAt, size int64, loc *time.Location, agg *healthAggregator) (string, error) {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return "", fmt.Errorf("invalid zip file")
+	}
+	format := ""
+	for _, zf := range zr.File {
+		var kind string
+		switch {
+		case path.Base(zf.Name) == "export.xml":
+			kind = "apple_health"
+		case strings.Contains(zf.Name, "Fit/All Sessions/") && strings.HasSuffix(zf.Name, ".json"),
+			strings.Contains(zf.Name, "Fit/All Data/") && strings.HasSuffix(zf.Name, ".json"):
+			kind = "google_fit"
+		default:
+			continue
+		}
+		if format != "" && format != kind {
+			return "", fmt.Errorf("zip contains both Apple Health and Google Fit data; upload them separately")
+		}
+		format = kind
+
+		rc, err := zf.Open()
+		if err != nil {
+			return "", fmt.Errorf("could not read %s", zf.Name)
+		}
+		if kind == "apple_health" {
+			err = parseAppleHealthXML(rc, agg)
+		} else {
+			err = parseGoogleFitJSON(rc, loc, agg)
+		}
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", path.Base(zf.Name), err)
+		}
+	}
+	if format == "" {
+		return "", fmt.Errorf("zip has no Apple Health export.xml or Google Fit data")
+	}
+	return format, nil
+}
+
+// readHealthUpload parses the multipart "file" field. Optional fields: "since"
+// (YYYY-MM-DD, skip older readings — full exports go back years) and "tz"
+// (IANA zone for Google Fit's UTC timestamps, default UTC).
+func (h *Handler) readHealthUpload(c *gin.Context) (importResult, error) {
+	since := c.PostForm("since")
+	if since != "" {
+		if _, err := time.Parse("2006-01-02", since); err != nil {
+			return importResult{}, fmt.Errorf("invalid since, expected YYYY-MM-DD")
+		}
+	}
+	loc, err := time.LoadLocation(c.DefaultPostForm("tz", "UTC"))
+	if err != nil {
+		return importResult{}, fmt.Errorf("unknown tz")
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return importResult{}, fmt.Errorf("file is required")
+	}
+	if fh.Size > maxHealthImportBytes {
+		return importResult{}, fmt.Errorf("file is too large (max 1 GB)")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return importResult{}, fmt.Errorf("could not read file")
+	}
+	defer f.Close()
+
+	weight, _ := h.currentWeightLBS(c)
+	agg := newHealthAggregator(since, weight)
+	format, err := parseHealthExport(f, fh.Filename, fh.Size, loc, agg)
+	if err != nil {
+		return importResult{}, err
+	}
+	return importResult{
+		Format:  format,
+		Table:   "health",
+		Columns: map[string]string{},
+		Records: agg.records(),
+		Issues:  agg.issues,
+		Source:  format,
+	}, nil
+}
+
+// previewHealthImport parses an Apple Health or Google Fit export and reports
+// what would be imported, without writing anything.
+// POST /api/calorie-log/import/health/preview (multipart: file, since, tz).
+func (h *Handler) previewHealthImport(c *gin.Context) {
+	h.runImport(c, true, h.readHealthUpload)
+}
+
+// commitHealthImport imports new workouts, weigh-ins, and daily readings from
+// an Apple Health or Google Fit export. Workouts are keyed by external_id and
+// readings by date, so re-uploading a newer export only adds what's new.
+// POST /api/calorie-log/import/health/commit (multipart: file, since, tz).
+func (h *Handler) commitHealthImport(c *gin.Context) {
+	h.runImport(c, false, h.readHealthUpload)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const appleHealthXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE HealthData [
+<!ELEMENT HealthData (ExportDate,Me,(Record|Workout)*)>
+]>
+<HealthData locale="en_US">
+ <ExportDate value="2024-03-03 09:00:00 -0800"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" startDate="2024-03-01 08:00:00 -0800" value="4000"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" startDate="2024-03-01 18:00:00 -0800" value="2000"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="Watch" unit="count" startDate="2024-03-01 08:00:00 -0800" value="6500"/>
+ <Record type="HKQuantityTypeIdentifierActiveEnergyBurned" sourceName="Watch" unit="kJ" startDate="2024-03-01 08:00:00 -0800" value="418.4"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" sourceName="Scale" unit="kg" startDate="2024-03-01 07:00:00 -0800" value="81"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" sourceName="Scale" unit="kg" startDate="2024-03-01 21:00:00 -0800" value="80">
+  <MetadataEntry key="HKWasUserEntered" value="1"/>
+ </Record>
+ <Record type="HKQuantityTypeIdentifierBodyFatPercentage" sourceName="Scale" unit="%" startDate="2024-03-01 07:00:00 -0800" value="0.221"/>
+ <Record type="HKQuantityTypeIdentifierHeartRate" sourceName="Watch" unit="count/min" startDate="2024-03-01 07:00:00 -0800" value="60"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" startDate="2023-12-31 08:00:00 -0800" value="999"/>
+ <Workout workoutActivityType="HKWorkoutActivityTypeRunning" duration="30" durationUnit="min" totalEnergyBurned="310.4" totalEnergyBurnedUnit="kcal" startDate="2024-03-01 06:00:00 -0800" endDate="2024-03-01 06:30:00 -0800"/>
+ <Workout workoutActivityType="HKWorkoutActivityTypeTableTennis" duration="45" durationUnit="min" startDate="2024-03-02 12:00:00 -0800" endDate="2024-03-02 12:45:00 -0800">
+  <WorkoutStatistics type="HKQuantityTypeIdentifierActiveEnergyBurned" startDate="2024-03-02 12:00:00 -0800" endDate="2024-03-02 12:45:00 -0800" sum="200" unit="Cal"/>
+ </Workout>
+ <Workout workoutActivityType="HKWorkoutActivityTypeYoga" duration="60" durationUnit="min" startDate="2024-03-02 19:00:00 -0800" endDate="2024-03-02 20:00:00 -0800"/>
+ <Workout workoutActivityType="HKWorkoutActivityTypeCurling" duration="60" durationUnit="min" startDate="2024-03-02 21:00:00 -0800" endDate="2024-03-02 22:00:00 -0800"/>
+</HealthData>`
+
+func findRecord(records []importRecord, kind, metric string) *importRecord {
+	for i := range records {
+		if records[i].Kind == kind && records[i].Metric == metric {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+func TestParseAppleHealthXML(t *testing.T) {
+	agg := newHealthAggregator("2024-01-01", 160)
+	if err := parseAppleHealthXML(strings.NewReader(appleHealthXML), agg); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	records := agg.records()
+
+	var workouts []importRecord
+	for _, r := range records {
+		if r.Kind == "exercise" {
+			workouts = append(workouts, r)
+		}
+	}
+	if len(workouts) != 3 {
+		t.Fatalf("want 3 workouts (curling has no energy and no MET), got %d", len(workouts))
+	}
+	if w := workouts[0]; w.ItemName != "Running" || w.Calories != 310 || w.Date != "2024-03-01" ||
+		w.ExternalID != "apple_health:HKWorkoutActivityTypeRunning@2024-03-01 06:00:00 -0800" {
+		t.Errorf("unexpected running workout: %+v", w)
+	}
+	if w := workouts[1]; w.ItemName != "Table Tennis" || w.Calories != 200 {
+		t.Errorf("WorkoutStatistics energy not used: %+v", w)
+	}
+	if w := workouts[2]; w.ItemName != "Yoga" || w.Calories != metCalories(2.5, 160, 60) {
+		t.Errorf("yoga should be MET-estimated: %+v", w)
+	}
+	if len(agg.issues) != 1 || !strings.Contains(agg.issues[0].Message, "Curling") {
+		t.Errorf("want curling issue, got %v", agg.issues)
+	}
+
+	if s := findRecord(records, "measurement", "steps"); s == nil || s.Value != 6500 || s.Date != "2024-03-01" {
+		t.Errorf("steps should take the largest source total (6500), got %+v", s)
+	}
+	if e := findRecord(records, "measurement", "active_energy_kcal"); e == nil || e.Value != 100 {
+		t.Errorf("kJ not converted: %+v", e)
+	}
+	if w := findRecord(records, "weight", ""); w == nil || w.Value != 176.4 {
+		t.Errorf("want latest weight 80 kg = 176.4 lbs, got %+v", w)
+	}
+	if bf := findRecord(records, "measurement", "body_fat_pct"); bf == nil || bf.Value != 22.1 {
+		t.Errorf("body fat fraction not converted: %+v", bf)
+	}
+	for _, r := range records {
+		if r.Date < "2024-01-01" {
+			t.Errorf("record before since kept: %+v", r)
+		}
+	}
+}
+
+func TestParseGoogleFitJSON(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+	if loc == nil {
+		loc = time.FixedZone("PST", -8*3600)
+	}
+	agg := newHealthAggregator("", 160)
+
+	session := `{"fitnessActivity":"biking","startTime":"2024-03-02T01:00:00.000Z","endTime":"2024-03-02T02:00:00.000Z",
+		"duration":"3600.000s","segment":[{"fitnessActivity":"biking"}],
+		"aggregate":[{"metricName":"com.google.calories.expended","floatValue":450.6},{"metricName":"com.google.step_count.delta","intValue":10}]}`
+	if err := parseGoogleFitJSON(strings.NewReader(session), loc, agg); err != nil {
+		t.Fatalf("session: %v", err)
+	}
+	sleep := `{"fitnessActivity":"sleep","startTime":"2024-03-02T07:00:00Z","duration":"28800s"}`
+	if err := parseGoogleFitJSON(strings.NewReader(sleep), loc, agg); err != nil {
+		t.Fatalf("sleep: %v", err)
+	}
+	points := `{"Data Source":"raw:com.google.weight:user_input","Data Points":[
+		{"fitValue":[{"value":{"fpVal":80.0}}],"dataTypeName":"com.google.weight","startTimeNanos":1709280000000000000,"endTimeNanos":1709280000000000000},
+		{"fitValue":[{"value":{"intVal":1200}}],"dataTypeName":"com.google.step_count.delta","startTimeNanos":1709280000000000000,"originDataSourceId":"phone"},
+		{"fitValue":[{"value":{"intVal":800}}],"dataTypeName":"com.google.step_count.delta","startTimeNanos":1709283600000000000,"originDataSourceId":"phone"}]}`
+	if err := parseGoogleFitJSON(strings.NewReader(points), loc, agg); err != nil {
+		t.Fatalf("points: %v", err)
+	}
+
+	records := agg.records()
+	if len(records) != 3 {
+		t.Fatalf("want workout + steps + weight, got %+v", records)
+	}
+	// 01:00Z is the previous evening in Los Angeles.
+	if w := records[0]; w.Kind != "exercise" || w.ItemName != "Cycling" || w.Calories != 451 || w.Date != "2024-03-01" ||
+		w.Qty == nil || *w.Qty != 60 {
+		t.Errorf("unexpected session workout: %+v", w)
+	}
+	if s := findRecord(records, "measurement", "steps"); s == nil || s.Value != 2000 {
+		t.Errorf("want 2000 steps, got %+v", s)
+	}
+	if w := findRecord(records, "weight", ""); w == nil || w.Value != 176.4 {
+		t.Errorf("want 176.4 lbs, got %+v", w)
+	}
+
+	if err := parseGoogleFitJSON(strings.NewReader(`[1,2]`), loc, agg); err == nil {
+		t.Error("expected non-object JSON to fail")
+	}
+}
+
+func TestParseHealthZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("apple_health_export/export.xml")
+	f.Write([]byte(appleHealthXML))
+	f, _ = zw.Create("apple_health_export/export_cda.xml")
+	f.Write([]byte("<ClinicalDocument/>"))
+	zw.Close()
+
+	agg := newHealthAggregator("", 160)
+	format, err := parseHealthZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()), time.UTC, agg)
+	if err != nil || format != "apple_health" {
+		t.Fatalf("got %q, %v", format, err)
+	}
+	if len(agg.workouts) != 3 {
+		t.Errorf("want 3 workouts, got %d", len(agg.workouts))
+	}
+
+	buf.Reset()
+	zw = zip.NewWriter(&buf)
+	f, _ = zw.Create("Takeout/Drive/notes.txt")
+	f.Write([]byte("hi"))
+	zw.Close()
+	if _, err := parseHealthZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()), time.UTC, newHealthAggregator("", 160)); err == nil {
+		t.Error("expected zip without health data to fail")
+	}
+}
+
+func TestMarkDuplicates_ExternalID(t *testing.T) {
+	records := []importRecord{
+		{Kind: "exercise", Date: "2024-03-01", Type: "exercise", ItemName: "Running", Calories: 310, ExternalID: "apple_health:run@1"},
+		{Kind: "exercise", Date: "2024-03-01", Type: "exercise", ItemName: "Running", Calories: 310, ExternalID: "apple_health:run@2"},
+	}
+	markDuplicates(records, existingImportData{
+		Items:       map[string]int{importItemKey("2024-03-01", "exercise", "Running", 310): 1},
+		ExternalIDs: map[string]bool{"apple_health:run@1": true},
+	})
+	if records[0].Status != "duplicate" || records[1].Status != "new" {
+		t.Errorf("external IDs should decide dedupe, got %s/%s", records[0].Status, records[1].Status)
+	}
+}
@@ -0,0 +1,762 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+/* ─── Normalized records ─────────────────────────────────────────────── */
+
+// importRecord is one row from an external tracker export, normalized to the
+// shape of the table it will be written to. Kind decides which fields apply:
+//   - food / exercise → calorie_log_items (Type, ItemName, Qty, Uom, Calories, macros)
+//   - weight          → weight_log (Value in lbs)
+//   - measurement     → body_measurements (Metric, Value, Unit)
+//
+// Status is filled in by dedupe: new, duplicate (already logged), conflict
+// (a different value already exists for that date, e.g. weight), or update (a
+// measurement whose stored value differs and will be overwritten).
+type importRecord struct {
+	Kind     string   `json:"kind"`
+	Line     int      `json:"line"`
+	Date     string   `json:"date"`
+	ItemName string   `json:"item_name,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Qty      *float64 `json:"qty,omitempty"`
+	Uom      *string  `json:"uom,omitempty"`
+	Calories int      `json:"calories,omitempty"`
+	ProteinG *float64 `json:"protein_g,omitempty"`
+	CarbsG   *float64 `json:"carbs_g,omitempty"`
+	FatG     *float64 `json:"fat_g,omitempty"`
+	Metric   string   `json:"metric,omitempty"`
+	Value    float64  `json:"value,omitempty"`
+	Unit     *string  `json:"unit,omitempty"`
+	// ExternalID is a stable identifier from device exports (workouts); when set
+	// it's used for dedupe instead of the date/type/name/calories key.
+	ExternalID string `json:"external_id,omitempty"`
+	Status     string `json:"status"`
+}
+
+// importIssue is a row that was skipped or adjusted during parsing.
+type importIssue struct {
+	Line    int    `json:"line,omitempty"` // 0 when the issue isn't tied to a CSV line
+	Message string `json:"message"`
+}
+
+/* ─── Adapters ───────────────────────────────────────────────────────── */
+
+// importTable describes one CSV shape within a tracker's export (e.g. the food
+// diary vs. the weight history). Columns maps a logical field to the header
+// names it may appear under; headers are matched case-insensitively with
+// whitespace collapsed. A table matches a file when every Required field is found.
+type importTable struct {
+	Name     string
+	Required []string
+	Columns  map[string][]string
+	Parse    func(r importRow) ([]importRecord, error)
+}
+
+// importAdapter is a tracker export format: one or more table shapes.
+type importAdapter struct {
+	Format string
+	Tables []importTable
+}
+
+// importRow is one CSV data row with column lookup by logical field.
+type importRow struct {
+	Line    int
+	cells   []string
+	header  []string
+	columns map[string]int
+	mealMap map[string]string
+	issues  *[]importIssue
+}
+
+// get returns the trimmed cell for field, or "" when the column is absent.
+func (r importRow) get(field string) string {
+	i, ok := r.columns[field]
+	if !ok || i >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[i])
+}
+
+// float parses field as a number, tolerating thousands separators. Empty → nil.
+func (r importRow) float(field string) (*float64, error) {
+	s := strings.ReplaceAll(r.get(field), ",", "")
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", field, r.get(field))
+	}
+	return &v, nil
+}
+
+// calories parses field as a calorie count, rounded and made positive
+// (several trackers export burned calories as negative numbers).
+func (r importRow) calories(field string) (int, error) {
+	v, err := r.float(field)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, fmt.Errorf("missing %s", field)
+	}
+	return int(math.Round(math.Abs(*v))), nil
+}
+
+// headerUnit returns the unit in the field's header, e.g. "kg" for "Weight (kg)".
+func (r importRow) headerUnit(field string) string {
+	i, ok := r.columns[field]
+	if !ok || i >= len(r.header) {
+		return ""
+	}
+	h := normalizeHeader(r.header[i])
+	open, end := strings.LastIndex(h, "("), strings.LastIndex(h, ")")
+	if open < 0 || end < open {
+		return ""
+	}
+	return h[open+1 : end]
+}
+
+// date parses field with parseImportDate.
+func (r importRow) date(field string) (string, error) {
+	return parseImportDate(r.get(field))
+}
+
+// normalizeHeader lowercases and collapses whitespace for column matching.
+func normalizeHeader(h string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(h, "\uFEFF"))), " ")
+}
+
+// detectColumns maps each of the table's fields to its header index.
+func detectColumns(header []string, t importTable) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[normalizeHeader(h)] = i
+	}
+	cols := map[string]int{}
+	for field, aliases := range t.Columns {
+		for _, a := range aliases {
+			if i, ok := index[a]; ok {
+				cols[field] = i
+				break
+			}
+		}
+	}
+	return cols
+}
+
+// detectImportTable picks the adapter table whose columns best match header:
+// all Required fields present, most columns matched, earliest adapter on ties.
+// format restricts the search to one adapter ("" or "auto" = any).
+func detectImportTable(header []string, format string) (importAdapter, importTable, map[string]int, bool) {
+	var bestA importAdapter
+	var bestT importTable
+	var bestCols map[string]int
+	for _, a := range importAdapters {
+		if format != "" && format != "auto" && a.Format != format {
+			continue
+		}
+		for _, t := range a.Tables {
+			cols := detectColumns(header, t)
+			complete := true
+			for _, f := range t.Required {
+				if _, ok := cols[f]; !ok {
+					complete = false
+					break
+				}
+			}
+			if complete && len(cols) > len(bestCols) {
+				bestA, bestT, bestCols = a, t, cols
+			}
+		}
+	}
+	return bestA, bestT, bestCols, bestCols != nil
+}
+
+/* ─── Field helpers ──────────────────────────────────────────────────── */
+
+// importDateLayouts are the date formats seen in tracker exports.
+var importDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"01/02/2006",
+	"1/2/2006",
+	"1/2/06",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+}
+
+// parseImportDate parses a tracker export date into YYYY-MM-DD.
+func parseImportDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized date %q", s)
+}
+
+// defaultMealMap maps common tracker meal/group names to calorie_log_item_type.
+var defaultMealMap = map[string]string{
+	"breakfast":     "breakfast",
+	"lunch":         "lunch",
+	"dinner":        "dinner",
+	"supper":        "dinner",
+	"snack":         "snack",
+	"snacks":        "snack",
+	"uncategorized": "snack",
+	"other":         "snack",
+	"exercise":      "exercise",
+}
+
+// mapMealType maps a tracker's meal name to an item type. User overrides win,
+// then the defaults, then any name containing a known meal ("Morning Snack").
+// Unknown names fall back to snack with ok=false so the caller can report it.
+func mapMealType(raw string, overrides map[string]string) (string, bool) {
+	key := normalizeHeader(raw)
+	if t, ok := overrides[key]; ok {
+		return t, true
+	}
+	if t, ok := defaultMealMap[key]; ok {
+		return t, true
+	}
+	for _, meal := range mealTypes {
+		if strings.Contains(key, meal) {
+			return meal, true
+		}
+	}
+	return "snack", false
+}
+
+// amountPattern splits "1.50 cup" / "150 g" into quantity and unit.
+var amountPattern = regexp.MustCompile(`^\s*([\d.,]+)\s*(.*?)\s*$`)
+
+// parseAmount splits an amount cell into qty and uom; either may be nil.
+func parseAmount(s string) (*float64, *string) {
+	m := amountPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, nil
+	}
+	var qty *float64
+	if v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64); err == nil {
+		qty = &v
+	}
+	var uom *string
+	if m[2] != "" {
+		u := strings.ToLower(m[2])
+		uom = &u
+	}
+	return qty, uom
+}
+
+// itemUnits maps unit spellings in exports onto the calorie_log_item_uom enum,
+// with the factor that converts a quantity into that unit.
+var itemUnits = map[string]struct {
+	uom    string
+	factor float64
+}{
+	"each": {"each", 1}, "ea": {"each", 1}, "item": {"each", 1}, "items": {"each", 1},
+	"piece": {"each", 1}, "pieces": {"each", 1},
+	"g": {"g", 1}, "gram": {"g", 1}, "grams": {"g", 1},
+	"kg": {"g", 1000}, "oz": {"g", 28.3495}, "ounce": {"g", 28.3495}, "ounces": {"g", 28.3495},
+	"lb": {"g", 453.592}, "lbs": {"g", 453.592},
+	"serving": {"serving", 1}, "servings": {"serving", 1},
+	"min": {"minutes", 1}, "mins": {"minutes", 1}, "minute": {"minutes", 1}, "minutes": {"minutes", 1},
+	"mile": {"miles", 1}, "miles": {"miles", 1}, "mi": {"miles", 1},
+	"km": {"km", 1}, "rep": {"reps", 1}, "reps": {"reps", 1},
+}
+
+// itemUnit maps a record's unit onto the calorie_log_item_uom enum. Any other
+// unit ("cup", "slice") is logged as one serving with the original amount kept
+// in the item name, and reported as an issue.
+func (r importRow) itemUnit(rec *importRecord) {
+	if rec.Uom == nil {
+		return
+	}
+	unit := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(*rec.Uom)), ".")
+	if u, ok := itemUnits[unit]; ok {
+		rec.Uom = &u.uom
+		if rec.Qty != nil && u.factor != 1 {
+			qty := math.Round(*rec.Qty*u.factor*10) / 10
+			rec.Qty = &qty
+		}
+		return
+	}
+	amount := *rec.Uom
+	if rec.Qty != nil {
+		amount = strconv.FormatFloat(*rec.Qty, 'f', -1, 64) + " " + amount
+	}
+	rec.ItemName = fmt.Sprintf("%s (%s)", rec.ItemName, amount)
+	one, serving := 1.0, "serving"
+	rec.Qty, rec.Uom = &one, &serving
+	if r.issues != nil {
+		*r.issues = append(*r.issues, importIssue{Line: r.Line, Message: fmt.Sprintf("unit %q imported as 1 serving", unit)})
+	}
+}
+
+// weightToLBS converts a weight reading to pounds. Unit "" is assumed to be lbs.
+func weightToLBS(v float64, unit string) float64 {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "kg", "kgs", "kilograms":
+		return math.Round(v*2.20462*10) / 10
+	}
+	return v
+}
+
+/* ─── Parsing ────────────────────────────────────────────────────────── */
+
+// importResult is the parsed content of one export file.
+type importResult struct {
+	Format  string            `json:"format"`
+	Table   string            `json:"table"`
+	Columns map[string]string `json:"columns"` // logical field → header as it appeared
+	Records []importRecord    `json:"-"`
+	Issues  []importIssue     `json:"issues"`
+	Source  string            `json:"-"` // calorie_log_items.source for inserted rows
+}
+
+// parseImportCSV detects the export format from the header row and parses every
+// data row. Rows that fail to parse are skipped and reported as issues; the
+// import as a whole only fails when the file can't be read or recognized.
+func parseImportCSV(r io.Reader, format string, mealMap map[string]string) (importResult, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return importResult{}, fmt.Errorf("could not read CSV header")
+	}
+	adapter, table, cols, ok := detectImportTable(header, format)
+	if !ok {
+		return importResult{}, fmt.Errorf("unrecognized export format")
+	}
+
+	res := importResult{Format: adapter.Format, Table: table.Name, Columns: map[string]string{}, Issues: []importIssue{}}
+	for field, i := range cols {
+		res.Columns[field] = header[i]
+	}
+
+	overrides := make(map[string]string, len(mealMap))
+	for k, v := range mealMap {
+		overrides[normalizeHeader(k)] = v
+	}
+
+	for {
+		cells, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line := 0
+			if pe, ok := err.(*csv.ParseError); ok {
+				line = pe.StartLine
+			}
+			res.Issues = append(res.Issues, importIssue{Line: line, Message: err.Error()})
+			continue
+		}
+		// A quoted field can span lines, so take the record's starting line
+		// from the reader rather than counting records.
+		line, _ := cr.FieldPos(0)
+		if isBlankRow(cells) {
+			continue
+		}
+		records, err := table.Parse(importRow{Line: line, cells: cells, header: header, columns: cols, mealMap: overrides, issues: &res.Issues})
+		if err != nil {
+			res.Issues = append(res.Issues, importIssue{Line: line, Message: err.Error()})
+			continue
+		}
+		for i := range records {
+			records[i].Line = line
+		}
+		res.Records = append(res.Records, records...)
+	}
+	return res, nil
+}
+
+// isBlankRow reports whether every cell is empty (trailing lines in exports).
+func isBlankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// mealType maps the row's meal field to an item type. Unknown meal names are
+// filed as snack and reported as an issue so the user can add a meal_map entry.
+func (r importRow) mealType(field string) string {
+	t, ok := mapMealType(r.get(field), r.mealMap)
+	if !ok && r.issues != nil {
+		*r.issues = append(*r.issues, importIssue{Line: r.Line, Message: fmt.Sprintf("unknown meal %q imported as snack", r.get(field))})
+	}
+	return t
+}
+
+/* ─── Dedupe ─────────────────────────────────────────────────────────── */
+
+// importItemKey identifies a food/exercise item for dedupe.
+func importItemKey(date, itemType, name string, calories int) string {
+	return date + "|" + itemType + "|" + strings.ToLower(strings.TrimSpace(name)) + "|" + strconv.Itoa(calories)
+}
+
+// existingImportData is what's already stored for the dates an import touches.
+type existingImportData struct {
+	Items        map[string]int     // importItemKey → count
+	ExternalIDs  map[string]bool    // calorie_log_items.external_id
+	Weights      map[string]float64 // date → lbs
+	Measurements map[string]float64 // date|metric → value
+}
+
+// markDuplicates sets each record's Status against existing data. Items are
+// matched as a multiset: two identical snacks in the export against one stored
+// copy yields one duplicate and one new, so legitimate repeats survive re-imports.
+// Measurements are upserted instead: a stored value that differs is marked
+// update, so re-importing a fuller export corrects a partial daily total.
+func markDuplicates(records []importRecord, existing existingImportData) {
+	items := make(map[string]int, len(existing.Items))
+	for k, v := range existing.Items {
+		items[k] = v
+	}
+	// Records claim their key as they go, so a date or external id repeated
+	// within the file is a duplicate too — the insert would drop it anyway.
+	externalIDs, weights, measurements := map[string]bool{}, map[string]float64{}, map[string]bool{}
+	maps.Copy(externalIDs, existing.ExternalIDs)
+	maps.Copy(weights, existing.Weights)
+	for i := range records {
+		r := &records[i]
+		r.Status = "new"
+		switch r.Kind {
+		case "food", "exercise":
+			if r.ExternalID != "" {
+				if externalIDs[r.ExternalID] {
+					r.Status = "duplicate"
+				}
+				externalIDs[r.ExternalID] = true
+				continue
+			}
+			k := importItemKey(r.Date, r.Type, r.ItemName, r.Calories)
+			if items[k] > 0 {
+				items[k]--
+				r.Status = "duplicate"
+			}
+		case "weight":
+			if w, ok := weights[r.Date]; ok {
+				if math.Abs(w-r.Value) < 0.05 {
+					r.Status = "duplicate"
+				} else {
+					r.Status = "conflict"
+				}
+				continue
+			}
+			weights[r.Date] = r.Value
+		case "measurement":
+			k := r.Date + "|" + r.Metric
+			if measurements[k] {
+				r.Status = "duplicate"
+			} else if v, ok := existing.Measurements[k]; ok {
+				if math.Abs(v-r.Value) < 0.05 {
+					r.Status = "duplicate"
+				} else {
+					r.Status = "update"
+				}
+			}
+			measurements[k] = true
+		}
+	}
+}
+
+// loadExistingImportData fetches stored items, weights, and measurements in the
+// date range covered by records.
+func (h *Handler) loadExistingImportData(c *gin.Context, userID int, records []importRecord) (existingImportData, error) {
+	existing := existingImportData{
+		Items:        map[string]int{},
+		ExternalIDs:  map[string]bool{},
+		Weights:      map[string]float64{},
+		Measurements: map[string]float64{},
+	}
+	if len(records) == 0 {
+		return existing, nil
+	}
+	minDate, maxDate := records[0].Date, records[0].Date
+	for _, r := range records {
+		if r.Date < minDate {
+			minDate = r.Date
+		}
+		if r.Date > maxDate {
+			maxDate = r.Date
+		}
+	}
+	args := pgx.NamedArgs{"userID": userID, "start": minDate, "end": maxDate}
+
+	items, err := queryMany[calorieLogItem](h.db, c,
+		`SELECT * FROM calorie_log_items WHERE user_id = @userID AND date >= @start AND date <= @end`, args)
+	if err != nil {
+		return existing, err
+	}
+	for _, it := range items {
+		existing.Items[importItemKey(it.Date.Format("2006-01-02"), it.Type, it.ItemName, it.Calories)]++
+		if it.ExternalID != nil {
+			existing.ExternalIDs[*it.ExternalID] = true
+		}
+	}
+
+	weights, err := queryMany[weightEntry](h.db, c,
+		`SELECT * FROM weight_log WHERE user_id = @userID AND date >= @start AND date <= @end`, args)
+	if err != nil {
+		return existing, err
+	}
+	for _, w := range weights {
+		existing.Weights[w.Date.Format("2006-01-02")] = w.WeightLBS
+	}
+
+	type storedMeasurement struct {
+		Key   string  `db:"key"`
+		Value float64 `db:"value"`
+	}
+	measurements, err := queryMany[storedMeasurement](h.db, c,
+		`SELECT date::text || '|' || metric AS key, value::float8 AS value FROM body_measurements
+		 WHERE user_id = @userID AND date >= @start AND date <= @end`, args)
+	if err != nil {
+		return existing, err
+	}
+	for _, m := range measurements {
+		existing.Measurements[m.Key] = m.Value
+	}
+	return existing, nil
+}
+
+/* ─── Handlers ───────────────────────────────────────────────────────── */
+
+// maxImportFileBytes caps uploaded export files.
+const maxImportFileBytes = 20 << 20
+
+// importPreviewLimit caps how many parsed records the preview returns.
+const importPreviewLimit = 200
+
+// importKindCounts tallies records of one kind by dedupe status.
+type importKindCounts struct {
+	Total     int `json:"total"`
+	New       int `json:"new"`
+	Duplicate int `json:"duplicate"`
+	Conflict  int `json:"conflict"`
+	Update    int `json:"update"`
+	Inserted  int `json:"inserted"` // rows written, updates included
+}
+
+// importResponse is returned by both the preview and commit endpoints.
+type importResponse struct {
+	importResult
+	DryRun  bool                         `json:"dry_run"`
+	Counts  map[string]*importKindCounts `json:"counts"`
+	Records []importRecord               `json:"records"` // first importPreviewLimit rows
+}
+
+// readImportUpload parses the multipart "file" field using the "format" and
+// optional "meal_map" (JSON object of tracker meal name → item type) fields.
+func readImportUpload(c *gin.Context) (importResult, error) {
+	format := c.DefaultPostForm("format", "auto")
+	if format != "auto" {
+		known := false
+		for _, a := range importAdapters {
+			known = known || a.Format == format
+		}
+		if !known {
+			return importResult{}, fmt.Errorf("unknown format %q", format)
+		}
+	}
+
+	mealMap := map[string]string{}
+	if raw := c.PostForm("meal_map"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mealMap); err != nil {
+			return importResult{}, fmt.Errorf("meal_map must be a JSON object")
+		}
+		for k, v := range mealMap {
+			if !validItemTypes[v] {
+				return importResult{}, fmt.Errorf("meal_map value for %q must be one of: breakfast, lunch, dinner, snack, exercise", k)
+			}
+		}
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return importResult{}, fmt.Errorf("file is required")
+	}
+	if fh.Size > maxImportFileBytes {
+		return importResult{}, fmt.Errorf("file is too large (max 20 MB)")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return importResult{}, fmt.Errorf("could not read file")
+	}
+	defer f.Close()
+	res, err := parseImportCSV(f, format, mealMap)
+	res.Source = "import"
+	return res, err
+}
+
+// previewImport parses an uploaded tracker export and reports what would be
+// imported — per-kind counts, duplicates against existing data, and parse
+// issues — without writing anything.
+// POST /api/calorie-log/import/preview (multipart: file, format, meal_map).
+func (h *Handler) previewImport(c *gin.Context) {
+	h.runImport(c, true, readImportUpload)
+}
+
+// commitImport parses an uploaded tracker export and inserts every record
+// marked new or update in one transaction. Duplicates and conflicts are
+// skipped, so re-uploading the same file is safe.
+// POST /api/calorie-log/import/commit (multipart: file, format, meal_map).
+func (h *Handler) commitImport(c *gin.Context) {
+	h.runImport(c, false, readImportUpload)
+}
+
+// runImport is the shared preview/commit flow: read parses the upload, then
+// records are deduped against stored data and, unless dryRun, the new and
+// updated ones written under res.Source.
+func (h *Handler) runImport(c *gin.Context, dryRun bool, read func(c *gin.Context) (importResult, error)) {
+	userID := c.GetInt("user_id")
+
+	res, err := read(c)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := h.loadExistingImportData(c, userID, res.Records)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check existing entries")
+		return
+	}
+	markDuplicates(res.Records, existing)
+
+	resp := importResponse{importResult: res, DryRun: dryRun, Counts: countImportRecords(res.Records)}
+	resp.Records = res.Records
+	if len(resp.Records) > importPreviewLimit {
+		resp.Records = resp.Records[:importPreviewLimit]
+	}
+	if resp.Records == nil {
+		resp.Records = []importRecord{}
+	}
+
+	if !dryRun {
+		tx, err := h.db.Begin(c)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to start transaction")
+			return
+		}
+		defer tx.Rollback(c)
+
+		for _, r := range res.Records {
+			if r.Status != "new" && r.Status != "update" {
+				continue
+			}
+			if err := insertImportRecord(c, tx, userID, r, res.Source); err != nil {
+				apiError(c, http.StatusInternalServerError, fmt.Sprintf("failed to import record %d", r.Line))
+				return
+			}
+			resp.Counts[r.Kind].Inserted++
+		}
+
+		if err := tx.Commit(c); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to commit")
+			return
+		}
+
+		// g_per_lb macro targets follow the latest weight — re-resolve them (best-effort).
+		if resp.Counts["weight"].Inserted > 0 {
+			if settings, err := queryOne[calorieLogUserSettings](h.db, c,
+				"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+				pgx.NamedArgs{"userID": userID}); err == nil {
+				h.refreshMacroTargets(c, &settings)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// countImportRecords tallies records per kind and status.
+func countImportRecords(records []importRecord) map[string]*importKindCounts {
+	counts := map[string]*importKindCounts{}
+	for _, k := range []string{"food", "exercise", "weight", "measurement"} {
+		counts[k] = &importKindCounts{}
+	}
+	for _, r := range records {
+		kc := counts[r.Kind]
+		kc.Total++
+		switch r.Status {
+		case "new":
+			kc.New++
+		case "duplicate":
+			kc.Duplicate++
+		case "conflict":
+			kc.Conflict++
+		case "update":
+			kc.Update++
+		}
+	}
+	return counts
+}
+
+// insertImportRecord writes one new record to its table, or overwrites the
+// stored value of an updated measurement. source is stored on every row so
+// imported data can be told apart from manual entries.
+func insertImportRecord(ctx context.Context, q execer, userID int, r importRecord, source string) error {
+	var err error
+	switch r.Kind {
+	case "food", "exercise":
+		_, err = q.Exec(ctx,
+			`INSERT INTO calorie_log_items
+			   (user_id, date, item_name, type, qty, uom, calories, protein_g, carbs_g, fat_g, source, confidence, external_id)
+			 VALUES (@userID, @date, @itemName, @type, @qty, @uom, @calories, @proteinG, @carbsG, @fatG, @source, @confidence,
+			         NULLIF(@externalID, ''))
+			 ON CONFLICT (user_id, external_id) WHERE external_id IS NOT NULL DO NOTHING`,
+			pgx.NamedArgs{
+				"userID": userID, "date": r.Date, "itemName": r.ItemName, "type": r.Type,
+				"qty": r.Qty, "uom": r.Uom, "calories": r.Calories,
+				"proteinG": r.ProteinG, "carbsG": r.CarbsG, "fatG": r.FatG,
+				"source": source, "confidence": defaultSourceConfidence[source], "externalID": r.ExternalID,
+			})
+	case "weight":
+		_, err = q.Exec(ctx,
+			`INSERT INTO weight_log (user_id, date, weight_lbs, source)
+			 VALUES (@userID, @date, @weightLBS, @source)
+			 ON CONFLICT (user_id, date) DO NOTHING`,
+			pgx.NamedArgs{"userID": userID, "date": r.Date, "weightLBS": r.Value, "source": source})
+	case "measurement":
+		_, err = q.Exec(ctx,
+			`INSERT INTO body_measurements (user_id, date, metric, value, unit, source)
+			 VALUES (@userID, @date, @metric, @value, @unit, @source)
+			 ON CONFLICT (user_id, date, metric) DO UPDATE
+			   SET value = EXCLUDED.value, unit = EXCLUDED.unit, source = EXCLUDED.source`,
+			pgx.NamedArgs{
+				"userID": userID, "date": r.Date, "metric": r.Metric,
+				"value": r.Value, "unit": r.Unit, "source": source,
+			})
+	}
+	return err
+}
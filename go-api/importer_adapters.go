@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// importAdapters is every supported export format, in auto-detection order.
+// Column aliases are written pre-normalized (lowercase, single spaces).
+var importAdapters = []importAdapter{
+	{
+		// MyFitnessPal "Export data" produces one CSV per area. The nutrition
+		// summary is one row per meal per day, not per food.
+		Format: "myfitnesspal",
+		Tables: []importTable{
+			{
+				Name:     "nutrition",
+				Required: []string{"date", "meal", "calories"},
+				Columns: map[string][]string{
+					"date":     {"date"},
+					"meal":     {"meal"},
+					"calories": {"calories"},
+					"fat":      {"fat (g)", "fat"},
+					"carbs":    {"carbohydrates (g)", "carbohydrates", "carbs (g)"},
+					"protein":  {"protein (g)", "protein"},
+				},
+				Parse: parseMFPNutrition,
+			},
+			{
+				Name:     "exercise",
+				Required: []string{"date", "exercise", "calories"},
+				Columns: map[string][]string{
+					"date":     {"date"},
+					"exercise": {"exercise"},
+					"calories": {"exercise calories", "calories"},
+					"minutes":  {"exercise minutes", "minutes"},
+				},
+				Parse: parseExerciseRow,
+			},
+			{
+				Name:     "measurements",
+				Required: []string{"date", "weight"},
+				Columns: map[string][]string{
+					"date":     {"date"},
+					"weight":   {"weight", "weight (lbs)", "weight (lb)", "weight (kg)"},
+					"body_fat": {"body fat %", "body fat", "body fat (%)"},
+				},
+				Parse: parseWeightRow,
+			},
+		},
+	},
+	{
+		// Cronometer exports servings, exercises, and biometrics as separate CSVs.
+		Format: "cronometer",
+		Tables: []importTable{
+			{
+				Name:     "servings",
+				Required: []string{"date", "food", "calories"},
+				Columns: map[string][]string{
+					"date":     {"day"},
+					"meal":     {"group", "category"},
+					"food":     {"food name"},
+					"amount":   {"amount"},
+					"calories": {"energy (kcal)"},
+					"protein":  {"protein (g)"},
+					"carbs":    {"carbs (g)", "net carbs (g)"},
+					"fat":      {"fat (g)"},
+				},
+				Parse: parseFoodRow,
+			},
+			{
+				Name:     "exercises",
+				Required: []string{"date", "exercise", "calories"},
+				Columns: map[string][]string{
+					"date":     {"day"},
+					"exercise": {"exercise"},
+					"minutes":  {"minutes"},
+					"calories": {"calories burned"},
+				},
+				Parse: parseExerciseRow,
+			},
+			{
+				Name:     "biometrics",
+				Required: []string{"date", "metric", "amount"},
+				Columns: map[string][]string{
+					"date":   {"day"},
+					"metric": {"metric"},
+					"unit":   {"unit"},
+					"amount": {"amount"},
+				},
+				Parse: parseCronometerBiometric,
+			},
+		},
+	},
+	{
+		// Lose It! exports one food log with exercise mixed in (Type = Exercise)
+		// and a separate weights file.
+		Format: "loseit",
+		Tables: []importTable{
+			{
+				Name:     "food_logs",
+				Required: []string{"date", "food", "meal", "calories"},
+				Columns: map[string][]string{
+					"date":     {"date"},
+					"food":     {"name"},
+					"meal":     {"type"},
+					"qty":      {"quantity"},
+					"uom":      {"units"},
+					"calories": {"calories"},
+					"deleted":  {"deleted"},
+					"fat":      {"fat (g)"},
+					"protein":  {"protein (g)"},
+					"carbs":    {"carbohydrates (g)"},
+				},
+				Parse: parseFoodRow,
+			},
+			{
+				Name:     "weights",
+				Required: []string{"date", "weight"},
+				Columns: map[string][]string{
+					"date":     {"date"},
+					"weight":   {"weight"},
+					"body_fat": {"body fat", "body fat %"},
+					"deleted":  {"deleted"},
+				},
+				Parse: parseWeightRow,
+			},
+		},
+	},
+}
+
+// isDeleted reports whether the row is flagged deleted (Lose It! keeps them).
+func (r importRow) isDeleted() bool {
+	v := strings.ToLower(r.get("deleted"))
+	return v == "true" || v == "yes" || v == "1"
+}
+
+// macros reads the optional protein/carbs/fat columns into rec.
+func (r importRow) macros(rec *importRecord) error {
+	var err error
+	if rec.ProteinG, err = r.float("protein"); err != nil {
+		return err
+	}
+	if rec.CarbsG, err = r.float("carbs"); err != nil {
+		return err
+	}
+	rec.FatG, err = r.float("fat")
+	return err
+}
+
+// parseMFPNutrition maps a MyFitnessPal per-meal total to one item named after
+// the meal, since the export doesn't list individual foods.
+func parseMFPNutrition(r importRow) ([]importRecord, error) {
+	date, err := r.date("date")
+	if err != nil {
+		return nil, err
+	}
+	cal, err := r.calories("calories")
+	if err != nil {
+		return nil, err
+	}
+	rec := importRecord{Kind: "food", Date: date, Type: r.mealType("meal"), Calories: cal}
+	rec.ItemName = "MyFitnessPal " + r.get("meal")
+	if err := r.macros(&rec); err != nil {
+		return nil, err
+	}
+	return []importRecord{rec}, nil
+}
+
+// parseFoodRow maps a per-food diary row (Cronometer servings, Lose It! food
+// log). A Lose It! row with meal "Exercise" becomes an exercise item.
+func parseFoodRow(r importRow) ([]importRecord, error) {
+	if r.isDeleted() {
+		return nil, nil
+	}
+	date, err := r.date("date")
+	if err != nil {
+		return nil, err
+	}
+	cal, err := r.calories("calories")
+	if err != nil {
+		return nil, err
+	}
+	name := r.get("food")
+	if name == "" {
+		return nil, fmt.Errorf("missing food name")
+	}
+
+	rec := importRecord{Kind: "food", Date: date, ItemName: name, Type: r.mealType("meal"), Calories: cal}
+	if rec.Type == "exercise" {
+		rec.Kind = "exercise"
+	}
+	if amount := r.get("amount"); amount != "" {
+		rec.Qty, rec.Uom = parseAmount(amount)
+	} else {
+		if rec.Qty, err = r.float("qty"); err != nil {
+			return nil, err
+		}
+		if uom := strings.ToLower(r.get("uom")); uom != "" {
+			rec.Uom = &uom
+		}
+	}
+	r.itemUnit(&rec)
+	if rec.Kind == "food" {
+		if err := r.macros(&rec); err != nil {
+			return nil, err
+		}
+	}
+	return []importRecord{rec}, nil
+}
+
+// parseExerciseRow maps an exercise row with optional minutes.
+func parseExerciseRow(r importRow) ([]importRecord, error) {
+	date, err := r.date("date")
+	if err != nil {
+		return nil, err
+	}
+	cal, err := r.calories("calories")
+	if err != nil {
+		return nil, err
+	}
+	name := r.get("exercise")
+	if name == "" {
+		return nil, fmt.Errorf("missing exercise name")
+	}
+	rec := importRecord{Kind: "exercise", Date: date, ItemName: name, Type: "exercise", Calories: cal}
+	if rec.Qty, err = r.float("minutes"); err != nil {
+		return nil, err
+	}
+	if rec.Qty != nil {
+		uom := "minutes"
+		rec.Uom = &uom
+	}
+	return []importRecord{rec}, nil
+}
+
+// parseWeightRow maps a weigh-in, plus body fat when the export has it. The
+// weight unit comes from the header ("Weight (kg)"), defaulting to lbs.
+func parseWeightRow(r importRow) ([]importRecord, error) {
+	if r.isDeleted() {
+		return nil, nil
+	}
+	date, err := r.date("date")
+	if err != nil {
+		return nil, err
+	}
+	w, err := r.float("weight")
+	if err != nil {
+		return nil, err
+	}
+	var out []importRecord
+	if w != nil && *w > 0 {
+		out = append(out, importRecord{Kind: "weight", Date: date, Value: weightToLBS(*w, r.headerUnit("weight"))})
+	}
+	bf, err := r.float("body_fat")
+	if err != nil {
+		return nil, err
+	}
+	if bf != nil && *bf > 0 {
+		out = append(out, bodyFatRecord(date, *bf))
+	}
+	return out, nil
+}
+
+// parseCronometerBiometric maps one biometric reading. Weight goes to
+// weight_log; everything else to body_measurements under a snake_case metric.
+func parseCronometerBiometric(r importRow) ([]importRecord, error) {
+	date, err := r.date("date")
+	if err != nil {
+		return nil, err
+	}
+	v, err := r.float("amount")
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("missing amount")
+	}
+	unit := r.get("unit")
+	switch metric := normalizeHeader(r.get("metric")); metric {
+	case "weight":
+		return []importRecord{{Kind: "weight", Date: date, Value: weightToLBS(*v, unit)}}, nil
+	case "body fat", "body fat %":
+		return []importRecord{bodyFatRecord(date, *v)}, nil
+	case "":
+		return nil, fmt.Errorf("missing metric")
+	default:
+		rec := importRecord{Kind: "measurement", Date: date, Metric: strings.ReplaceAll(metric, " ", "_"), Value: *v}
+		if unit != "" {
+			rec.Unit = &unit
+		}
+		return []importRecord{rec}, nil
+	}
+}
+
+// bodyFatRecord is a body fat percentage measurement.
+func bodyFatRecord(date string, pct float64) importRecord {
+	unit := "%"
+	return importRecord{Kind: "measurement", Date: date, Metric: "body_fat_pct", Value: pct, Unit: &unit}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func parseCSVString(t *testing.T, csv, format string, mealMap map[string]string) importResult {
+	t.Helper()
+	res, err := parseImportCSV(strings.NewReader(csv), format, mealMap)
+	if err != nil {
+		t.Fatalf("parseImportCSV: %v", err)
+	}
+	return res
+}
+
+func TestParseImportCSV_DetectsFormat(t *testing.T) {
+	cases := []struct {
+		name, csv, format, table string
+	}{
+		{"mfp nutrition", "Date,Meal,Calories,Fat (g),Carbohydrates (g),Protein (g)\n", "myfitnesspal", "nutrition"},
+		{"mfp exercise", "Date,Exercise,Type,Exercise Calories,Exercise Minutes\n", "myfitnesspal", "exercise"},
+		{"cronometer servings", "Day,Time,Group,Food Name,Amount,Energy (kcal),Protein (g),Carbs (g),Fat (g)\n", "cronometer", "servings"},
+		{"cronometer exercises", "Day,Time,Group,Exercise,Minutes,Calories Burned\n", "cronometer", "exercises"},
+		{"cronometer biometrics", "Day,Time,Group,Metric,Unit,Amount\n", "cronometer", "biometrics"},
+		{"loseit food", "Date,Name,Icon,Type,Quantity,Units,Calories,Deleted,Fat (g),Protein (g),Carbohydrates (g)\n", "loseit", "food_logs"},
+		{"loseit weights", "Date,Weight,Deleted\n", "loseit", "weights"},
+	}
+	for _, tc := range cases {
+		res := parseCSVString(t, tc.csv, "auto", nil)
+		if res.Format != tc.format || res.Table != tc.table {
+			t.Errorf("%s: got %s/%s, want %s/%s", tc.name, res.Format, res.Table, tc.format, tc.table)
+		}
+	}
+
+	if _, err := parseImportCSV(strings.NewReader("foo,bar\n1,2\n"), "auto", nil); err == nil {
+		t.Error("expected unrecognized header to fail")
+	}
+	if _, err := parseImportCSV(strings.NewReader("Day,Metric,Unit,Amount\n"), "loseit", nil); err == nil {
+		t.Error("expected forced format to reject another tracker's header")
+	}
+}
+
+func TestParseImportCSV_Cronometer(t *testing.T) {
+	csv := "\uFEFFDay,Time,Group,Food Name,Amount,Energy (kcal),Protein (g),Carbs (g),Fat (g)\n" +
+		"2024-03-01,08:00,Breakfast,Oatmeal,1.00 cup,166.5,5.9,28.1,3.6\n" +
+		"2024-03-01,,Second Breakfast,Banana,1 medium,105,1.3,27,0.4\n" +
+		"not a date,,Lunch,Soup,1 bowl,200,,,\n"
+	res := parseCSVString(t, csv, "auto", map[string]string{"Second Breakfast": "snack"})
+
+	if len(res.Records) != 2 {
+		t.Fatalf("want 2 records, got %d (%v)", len(res.Records), res.Issues)
+	}
+	oat := res.Records[0]
+	// "cup" isn't a calorie_log_item_uom, so the amount moves into the name.
+	if oat.Date != "2024-03-01" || oat.Type != "breakfast" || oat.ItemName != "Oatmeal (1 cup)" || oat.Calories != 167 {
+		t.Errorf("unexpected oatmeal record: %+v", oat)
+	}
+	if oat.Qty == nil || *oat.Qty != 1 || oat.Uom == nil || *oat.Uom != "serving" {
+		t.Errorf("amount not mapped to a serving: qty=%v uom=%v", oat.Qty, oat.Uom)
+	}
+	if oat.ProteinG == nil || *oat.ProteinG != 5.9 {
+		t.Errorf("protein not parsed: %v", oat.ProteinG)
+	}
+	if res.Records[1].Type != "snack" {
+		t.Errorf("meal_map override ignored: %s", res.Records[1].Type)
+	}
+	if len(res.Issues) != 3 || !strings.Contains(res.Issues[0].Message, `"cup"`) || res.Issues[2].Line != 4 {
+		t.Errorf("want unit issues and a bad date on line 4, got %v", res.Issues)
+	}
+}
+
+func TestParseImportCSV_LoseIt(t *testing.T) {
+	csv := "Date,Name,Icon,Type,Quantity,Units,Calories,Deleted,Fat (g),Protein (g),Carbohydrates (g)\n" +
+		"03/01/2024,Eggs,Egg,Breakfast,2,Each,140,false,10,12,1\n" +
+		"03/01/2024,Running,Run,Exercise,30,Minutes,-300,false,,,\n" +
+		"03/01/2024,Chips,Chip,Snacks,1,Bag,150,true,,,\n" +
+		"03/01/2024,Cake,Cake,Elevenses,1,Slice,350,false,,,\n"
+	res := parseCSVString(t, csv, "auto", nil)
+
+	if len(res.Records) != 3 {
+		t.Fatalf("want 3 records (deleted row skipped), got %d", len(res.Records))
+	}
+	run := res.Records[1]
+	if run.Kind != "exercise" || run.Type != "exercise" || run.Calories != 300 || run.FatG != nil {
+		t.Errorf("unexpected exercise record: %+v", run)
+	}
+	if cake := res.Records[2]; cake.Type != "snack" {
+		t.Errorf("unknown meal should fall back to snack, got %s", cake.Type)
+	}
+	if eggs := res.Records[0]; eggs.Uom == nil || *eggs.Uom != "each" || eggs.ItemName != "Eggs" {
+		t.Errorf("unexpected eggs record: %+v", eggs)
+	}
+	if len(res.Issues) != 2 || !strings.Contains(res.Issues[0].Message, "Elevenses") || !strings.Contains(res.Issues[1].Message, `"slice"`) {
+		t.Errorf("want unknown meal and unit issues, got %v", res.Issues)
+	}
+}
+
+func TestItemUnit(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	cases := []struct {
+		qty       *float64
+		unit      string
+		wantQty   float64
+		wantUom   string
+		wantName  string
+		wantIssue bool
+	}{
+		{f64(2), "Each", 2, "each", "Eggs", false},
+		{f64(150), "g", 150, "g", "Eggs", false},
+		{f64(4), "oz", 113.4, "g", "Eggs", false},
+		{f64(30), "Minutes", 30, "minutes", "Eggs", false},
+		{f64(1.5), "cup", 1, "serving", "Eggs (1.5 cup)", true},
+		{nil, "large", 1, "serving", "Eggs (large)", true},
+	}
+	for _, tc := range cases {
+		var issues []importIssue
+		unit := tc.unit
+		rec := importRecord{ItemName: "Eggs", Qty: tc.qty, Uom: &unit}
+		importRow{Line: 2, issues: &issues}.itemUnit(&rec)
+		if *rec.Qty != tc.wantQty || *rec.Uom != tc.wantUom || rec.ItemName != tc.wantName || (len(issues) > 0) != tc.wantIssue {
+			t.Errorf("%s: got %v %s %q, issues %v", tc.unit, *rec.Qty, *rec.Uom, rec.ItemName, issues)
+		}
+	}
+}
+
+func TestParseImportCSV_Weights(t *testing.T) {
+	res := parseCSVString(t, "Date,Weight (kg),Body Fat %\n2024-03-01,80,22.5\n2024-03-02,,\n", "myfitnesspal", nil)
+	if len(res.Records) != 2 {
+		t.Fatalf("want weight + body fat, got %d", len(res.Records))
+	}
+	if w := res.Records[0]; w.Kind != "weight" || w.Value != 176.4 {
+		t.Errorf("kg not converted to lbs: %+v", w)
+	}
+	if bf := res.Records[1]; bf.Kind != "measurement" || bf.Metric != "body_fat_pct" || bf.Value != 22.5 {
+		t.Errorf("unexpected body fat record: %+v", bf)
+	}
+
+	res = parseCSVString(t, "Day,Time,Group,Metric,Unit,Amount\n2024-03-01,,,Weight,lbs,181.2\n2024-03-01,,,Waist Size,in,34\n", "auto", nil)
+	if len(res.Records) != 2 || res.Records[0].Kind != "weight" || res.Records[0].Value != 181.2 {
+		t.Fatalf("unexpected biometrics: %+v", res.Records)
+	}
+	if m := res.Records[1]; m.Metric != "waist_size" || m.Unit == nil || *m.Unit != "in" {
+		t.Errorf("unexpected measurement: %+v", m)
+	}
+}
+
+func TestMarkDuplicates(t *testing.T) {
+	records := []importRecord{
+		{Kind: "food", Date: "2024-03-01", Type: "snack", ItemName: "Apple", Calories: 95},
+		{Kind: "food", Date: "2024-03-01", Type: "snack", ItemName: "apple ", Calories: 95},
+		{Kind: "food", Date: "2024-03-01", Type: "lunch", ItemName: "Apple", Calories: 95},
+		{Kind: "weight", Date: "2024-03-01", Value: 180.0},
+		{Kind: "weight", Date: "2024-03-02", Value: 179.0},
+		{Kind: "weight", Date: "2024-03-03", Value: 178.0},
+		{Kind: "measurement", Date: "2024-03-01", Metric: "body_fat_pct", Value: 22},
+		{Kind: "measurement", Date: "2024-03-01", Metric: "steps", Value: 6500},
+	}
+	existing := existingImportData{
+		Items:        map[string]int{importItemKey("2024-03-01", "snack", "Apple", 95): 1},
+		Weights:      map[string]float64{"2024-03-01": 180.0, "2024-03-02": 181.0},
+		Measurements: map[string]float64{"2024-03-01|body_fat_pct": 22, "2024-03-01|steps": 4000},
+	}
+	markDuplicates(records, existing)
+
+	want := []string{"duplicate", "new", "new", "duplicate", "conflict", "new", "duplicate", "update"}
+	for i, r := range records {
+		if r.Status != want[i] {
+			t.Errorf("record %d: got %s, want %s", i, r.Status, want[i])
+		}
+	}
+	if existing.Items[importItemKey("2024-03-01", "snack", "Apple", 95)] != 1 {
+		t.Error("markDuplicates must not consume the caller's counts")
+	}
+
+	counts := countImportRecords(records)
+	if c := counts["food"]; c.Total != 3 || c.New != 2 || c.Duplicate != 1 {
+		t.Errorf("unexpected food counts: %+v", c)
+	}
+	if c := counts["weight"]; c.Conflict != 1 {
+		t.Errorf("unexpected weight counts: %+v", c)
+	}
+	if c := counts["measurement"]; c.Duplicate != 1 || c.Update != 1 {
+		t.Errorf("unexpected measurement counts: %+v", c)
+	}
+}
+
+func TestMarkDuplicates_WithinFile(t *testing.T) {
+	records := []importRecord{
+		{Kind: "weight", Date: "2024-03-04", Value: 178.0},
+		{Kind: "weight", Date: "2024-03-04", Value: 178.0},
+		{Kind: "weight", Date: "2024-03-04", Value: 176.0},
+		{Kind: "measurement", Date: "2024-03-04", Metric: "waist_in", Value: 34},
+		{Kind: "measurement", Date: "2024-03-04", Metric: "waist_in", Value: 34},
+		{Kind: "food", Date: "2024-03-04", ExternalID: "mfp:1"},
+		{Kind: "food", Date: "2024-03-04", ExternalID: "mfp:1"},
+	}
+	existing := existingImportData{Weights: map[string]float64{}}
+	markDuplicates(records, existing)
+
+	want := []string{"new", "duplicate", "conflict", "new", "duplicate", "new", "duplicate"}
+	for i, r := range records {
+		if r.Status != want[i] {
+			t.Errorf("record %d: got %s, want %s", i, r.Status, want[i])
+		}
+	}
+	if len(existing.Weights) != 0 {
+		t.Error("markDuplicates must not add to the caller's weights")
+	}
+	if c := countImportRecords(records)["weight"]; c.New != 1 {
+		t.Errorf("only one weight per date can be inserted, got %+v", c)
+	}
+}
+
+func TestParseImportCSV_MultilineLineNumbers(t *testing.T) {
+	csv := "Date,Meal,Calories,Fat (g),Carbohydrates (g),Protein (g),Note\n" +
+		"2024-03-01,Breakfast,300,10,40,12,\"two\nlines\"\n" +
+		"2024-03-01,Brunch,200,,,,\n"
+	res := parseCSVString(t, csv, "myfitnesspal", nil)
+
+	if len(res.Records) == 0 || res.Records[0].Line != 2 {
+		t.Fatalf("want first record on line 2, got %+v", res.Records)
+	}
+	if len(res.Issues) != 1 || res.Issues[0].Line != 4 {
+		t.Errorf("want one issue on line 4, got %v", res.Issues)
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	for _, in := range []string{"2024-03-05", "03/05/2024", "3/5/2024", "3/5/24", "Mar 5, 2024", "2024-03-05 07:30:00"} {
+		if got, err := parseImportDate(in); err != nil || got != "2024-03-05" {
+			t.Errorf("parseImportDate(%q) = %q, %v", in, got, err)
+		}
+	}
+}
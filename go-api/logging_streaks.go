@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultCompleteDayMeals are the meals a complete day needs when the user
+// hasn't chosen any.
+var defaultCompleteDayMeals = []string{"breakfast", "lunch", "dinner"}
+
+// partialDayLookback is how many days before today are checked for partial logging.
+const partialDayLookback = 14
+
+// completeDayMeals returns the user's required meals, or the default.
+func completeDayMeals(s *calorieLogUserSettings) []string {
+	if len(s.CompleteDayMeals) == 0 {
+		return defaultCompleteDayMeals
+	}
+	return s.CompleteDayMeals
+}
+
+// completeDayRule returns the user's rule, defaulting to 'meals'.
+func completeDayRule(s *calorieLogUserSettings) string {
+	if s.CompleteDayRule == "" {
+		return "meals"
+	}
+	return s.CompleteDayRule
+}
+
+// dayCompleteness is whether a day counts as fully logged under the user's
+// rule. MissingMeals lists required meals with no items under either rule, so
+// a "you skipped lunch" hint works even when streaks use explicit marks.
+type dayCompleteness struct {
+	Complete     bool     `json:"complete"`
+	Rule         string   `json:"rule"`
+	Marked       bool     `json:"marked"`
+	MissingMeals []string `json:"missing_meals"`
+	// Partial is true when something was logged but the day isn't complete.
+	Partial bool `json:"partial"`
+}
+
+// loggedDayRow is the item types logged on a day and whether it was marked complete.
+type loggedDayRow struct {
+	Date   DateOnly `db:"date"`
+	Types  []string `db:"types"`
+	Marked bool     `db:"marked"`
+}
+
+// newDayCompleteness scores one day. types are the item types logged that day.
+func newDayCompleteness(types []string, marked bool, rule string, required []string) dayCompleteness {
+	dc := dayCompleteness{Rule: rule, Marked: marked, MissingMeals: []string{}}
+	for _, meal := range required {
+		if !slices.Contains(types, meal) {
+			dc.MissingMeals = append(dc.MissingMeals, meal)
+		}
+	}
+	if rule == "mark" {
+		dc.Complete = marked
+	} else {
+		dc.Complete = len(dc.MissingMeals) == 0
+	}
+	dc.Partial = !dc.Complete && len(types) > 0
+	return dc
+}
+
+// itemTypes returns the distinct item types in items.
+func itemTypes(items []calorieLogItem) []string {
+	var types []string
+	for _, item := range items {
+		if !slices.Contains(types, item.Type) {
+			types = append(types, item.Type)
+		}
+	}
+	return types
+}
+
+// partialDay is a past day with some logging that isn't complete.
+type partialDay struct {
+	Date         string   `json:"date"`
+	MissingMeals []string `json:"missing_meals"`
+}
+
+// loggingStreakResponse is the response for GET /api/calorie-log/streak.
+type loggingStreakResponse struct {
+	Rule          string   `json:"rule"`
+	RequiredMeals []string `json:"required_meals"`
+	CurrentStreak int      `json:"current_streak"`
+	LongestStreak int      `json:"longest_streak"`
+	TodayComplete bool     `json:"today_complete"`
+	// PartialDays are incomplete days with some logging in the lookback
+	// window before today, newest first. Today is still in progress and excluded.
+	PartialDays []partialDay `json:"partial_days"`
+}
+
+// computeLoggingStreak scores each row and counts consecutive complete days.
+// As with habit streaks, an incomplete today doesn't break the current streak.
+func computeLoggingStreak(rows []loggedDayRow, rule string, required []string, today time.Time) loggingStreakResponse {
+	resp := loggingStreakResponse{Rule: rule, RequiredMeals: required, PartialDays: []partialDay{}}
+	todayStr := today.Format("2006-01-02")
+	cutoff := today.AddDate(0, 0, -partialDayLookback).Format("2006-01-02")
+
+	complete := map[string]bool{}
+	for i := len(rows) - 1; i >= 0; i-- {
+		date := rows[i].Date.Format("2006-01-02")
+		if date > todayStr {
+			continue
+		}
+		dc := newDayCompleteness(rows[i].Types, rows[i].Marked, rule, required)
+		if dc.Complete {
+			complete[date] = true
+		} else if dc.Partial && date != todayStr && date >= cutoff {
+			resp.PartialDays = append(resp.PartialDays, partialDay{Date: date, MissingMeals: dc.MissingMeals})
+		}
+	}
+	resp.TodayComplete = complete[todayStr]
+	resp.CurrentStreak, resp.LongestStreak = computeDailyStreak(complete, today)
+	return resp
+}
+
+// fetchLoggedDays returns the item types and completion mark for each day in
+// [start, end] that has either.
+func (h *Handler) fetchLoggedDays(c *gin.Context, userID int, start, end string) ([]loggedDayRow, error) {
+	return queryMany[loggedDayRow](h.db, c,
+		`SELECT COALESCE(i.date, d.date) AS date,
+		        COALESCE(i.types, '{}') AS types,
+		        d.date IS NOT NULL AS marked
+		 FROM (
+			SELECT date, array_agg(DISTINCT type::text) AS types
+			FROM calorie_log_items
+			WHERE user_id = @userID AND date >= @start AND date <= @end
+			GROUP BY date
+		 ) i
+		 FULL JOIN (
+			SELECT date FROM calorie_log_days
+			WHERE user_id = @userID AND date >= @start AND date <= @end AND completed
+		 ) d ON d.date = i.date
+		 ORDER BY 1 ASC`,
+		pgx.NamedArgs{"userID": userID, "start": start, "end": end})
+}
+
+// getLoggingStreak returns current and longest streaks of fully logged days,
+// plus recent days that were only partly logged.
+// GET /api/calorie-log/streak?date=YYYY-MM-DD (the client's today; defaults to server today).
+func (h *Handler) getLoggingStreak(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	today, err := time.Parse("2006-01-02", c.DefaultQuery("date", time.Now().Format("2006-01-02")))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	settings, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch settings")
+		return
+	}
+
+	// computeDailyStreak looks back at most ten years.
+	rows, err := h.fetchLoggedDays(c, userID, today.AddDate(-10, 0, 0).Format("2006-01-02"), today.Format("2006-01-02"))
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch logged days")
+		return
+	}
+	c.JSON(http.StatusOK, computeLoggingStreak(rows, completeDayRule(&settings), completeDayMeals(&settings), today))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewDayCompleteness(t *testing.T) {
+	dc := newDayCompleteness([]string{"breakfast", "dinner", "snack"}, false, "meals", defaultCompleteDayMeals)
+	if dc.Complete || !dc.Partial || !slices.Equal(dc.MissingMeals, []string{"lunch"}) {
+		t.Errorf("skipped lunch: got %+v", dc)
+	}
+
+	dc = newDayCompleteness([]string{"breakfast", "lunch", "dinner"}, false, "meals", defaultCompleteDayMeals)
+	if !dc.Complete || dc.Partial || len(dc.MissingMeals) != 0 {
+		t.Errorf("all meals: got %+v", dc)
+	}
+
+	// Under the mark rule the mark decides, but missing meals are still reported.
+	dc = newDayCompleteness([]string{"breakfast"}, true, "mark", defaultCompleteDayMeals)
+	if !dc.Complete || dc.Partial || len(dc.MissingMeals) != 2 {
+		t.Errorf("marked: got %+v", dc)
+	}
+	dc = newDayCompleteness([]string{"breakfast", "lunch", "dinner"}, false, "mark", defaultCompleteDayMeals)
+	if dc.Complete || !dc.Partial {
+		t.Errorf("unmarked: got %+v", dc)
+	}
+
+	if dc := newDayCompleteness(nil, false, "meals", defaultCompleteDayMeals); dc.Partial || dc.Complete {
+		t.Errorf("nothing logged isn't partial: got %+v", dc)
+	}
+}
+
+func TestComputeLoggingStreak(t *testing.T) {
+	all := []string{"breakfast", "lunch", "dinner"}
+	row := func(d string, types []string, marked bool) loggedDayRow {
+		date, _ := time.Parse("2006-01-02", d)
+		return loggedDayRow{Date: DateOnly{date}, Types: types, Marked: marked}
+	}
+	rows := []loggedDayRow{
+		row("2024-03-01", all, false),
+		row("2024-03-02", all, false),
+		row("2024-03-03", all, false),
+		row("2024-03-04", []string{"breakfast", "dinner"}, false), // skipped lunch
+		row("2024-03-05", all, false),
+		row("2024-03-06", all, false),
+		row("2024-03-07", []string{"breakfast"}, false), // today, in progress
+	}
+	today, _ := time.Parse("2006-01-02", "2024-03-07")
+	got := computeLoggingStreak(rows, "meals", defaultCompleteDayMeals, today)
+
+	if got.CurrentStreak != 2 || got.LongestStreak != 3 || got.TodayComplete {
+		t.Errorf("streaks: got current %d longest %d today %v", got.CurrentStreak, got.LongestStreak, got.TodayComplete)
+	}
+	if len(got.PartialDays) != 1 || got.PartialDays[0].Date != "2024-03-04" || !slices.Equal(got.PartialDays[0].MissingMeals, []string{"lunch"}) {
+		t.Errorf("partial days: got %+v", got.PartialDays)
+	}
+
+	// With only breakfast required, every day is complete.
+	got = computeLoggingStreak(rows, "meals", []string{"breakfast"}, today)
+	if got.CurrentStreak != 7 || !got.TodayComplete || len(got.PartialDays) != 0 {
+		t.Errorf("breakfast only: got %+v", got)
+	}
+
+	// Mark rule: a marked day with nothing logged still counts.
+	marked := []loggedDayRow{row("2024-03-05", nil, true), row("2024-03-06", all, true)}
+	got = computeLoggingStreak(marked, "mark", defaultCompleteDayMeals, today)
+	if got.CurrentStreak != 2 || got.LongestStreak != 2 {
+		t.Errorf("mark rule: got %+v", got)
+	}
+}
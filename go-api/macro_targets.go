@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// validMacroTargetModes is the set of allowed *_target_mode values. Mirrors the
+// CHECK constraint on calorie_log_user_settings.
+var validMacroTargetModes = map[string]bool{
+	"grams":    true,
+	"percent":  true,
+	"g_per_lb": true,
+}
+
+// Calories per gram used to turn a percent-of-budget target into grams.
+const (
+	kcalPerGramProtein = 4.0
+	kcalPerGramCarbs   = 4.0
+	kcalPerGramFat     = 9.0
+)
+
+// macroTargetSpec describes how one macro target is derived for a day.
+// Grams is the fixed target (and the fallback when a derived mode can't be
+// resolved, e.g. g_per_lb with no known weight).
+type macroTargetSpec struct {
+	Mode  string   // grams | percent | g_per_lb
+	Value *float64 // percent of calories or grams per lb; unused for grams
+	Grams int
+}
+
+// resolve returns the target in grams for a day with the given calorie budget
+// and body weight. kcalPerGram converts percent-of-budget targets to grams.
+func (m macroTargetSpec) resolve(budget int, weightLBS, kcalPerGram float64) int {
+	if m.Value == nil {
+		return m.Grams
+	}
+	switch m.Mode {
+	case "percent":
+		return int(math.Round(float64(budget) * *m.Value / 100 / kcalPerGram))
+	case "g_per_lb":
+		if weightLBS <= 0 {
+			return m.Grams
+		}
+		return int(math.Round(weightLBS * *m.Value))
+	}
+	return m.Grams
+}
+
+// resolveMacros replaces the day's gram targets with values derived from each
+// macro's mode, using the day's (possibly scheduled) budget and weightLBS.
+func (t *dayTargets) resolveMacros(weightLBS float64) {
+	t.ProteinTargetG = t.Protein.resolve(t.CalorieBudget, weightLBS, kcalPerGramProtein)
+	t.CarbsTargetG = t.Carbs.resolve(t.CalorieBudget, weightLBS, kcalPerGramCarbs)
+	t.FatTargetG = t.Fat.resolve(t.CalorieBudget, weightLBS, kcalPerGramFat)
+}
+
+// settingsMacroSpecs returns the protein, carbs, and fat target specs from settings.
+func settingsMacroSpecs(s *calorieLogUserSettings) (protein, carbs, fat macroTargetSpec) {
+	return macroTargetSpec{Mode: s.ProteinTargetMode, Value: s.ProteinTargetValue, Grams: s.ProteinTargetG},
+		macroTargetSpec{Mode: s.CarbsTargetMode, Value: s.CarbsTargetValue, Grams: s.CarbsTargetG},
+		macroTargetSpec{Mode: s.FatTargetMode, Value: s.FatTargetValue, Grams: s.FatTargetG}
+}
+
+// historyMacroSpec overlays one macro's history columns onto the current spec.
+// Nil history columns inherit the current value (rows predating macro history).
+func historyMacroSpec(cur macroTargetSpec, grams *int, mode *string, value *float64) macroTargetSpec {
+	if grams != nil {
+		cur.Grams = *grams
+	}
+	if mode != nil {
+		cur.Mode = *mode
+		cur.Value = value
+	}
+	return cur
+}
+
+// validateMacroTarget checks a mode/value pair from a settings patch. The value
+// is only required (and range-checked) for the derived modes.
+func validateMacroTarget(name string, mode *string, value *float64) error {
+	if mode != nil && !validMacroTargetModes[*mode] {
+		return fmt.Errorf("%s_target_mode must be one of: grams, percent, g_per_lb", name)
+	}
+	if value == nil {
+		return nil
+	}
+	if *value < 0 {
+		return fmt.Errorf("%s_target_value must not be negative", name)
+	}
+	if mode != nil && *mode == "percent" && *value > 100 {
+		return fmt.Errorf("%s_target_value must be at most 100 for percent mode", name)
+	}
+	if mode != nil && *mode == "g_per_lb" && *value > 5 {
+		return fmt.Errorf("%s_target_value must be at most 5 g/lb", name)
+	}
+	return nil
+}
+
+// patchMacroSpec overlays a settings patch's mode and value for one macro onto
+// its stored spec, giving the target in effect after the patch.
+func patchMacroSpec(cur macroTargetSpec, mode *string, value *float64) macroTargetSpec {
+	if mode != nil {
+		cur.Mode = *mode
+	}
+	if value != nil {
+		cur.Value = value
+	}
+	return cur
+}
+
+// validateEffectiveMacroTargets checks the protein, carbs, and fat targets that
+// will be in effect after a patch: each value against its mode, and percent
+// targets adding up to at most 100% of calories.
+func validateEffectiveMacroTargets(protein, carbs, fat macroTargetSpec) error {
+	total := 0.0
+	for _, m := range []struct {
+		name string
+		spec macroTargetSpec
+	}{
+		{"protein", protein},
+		{"carbs", carbs},
+		{"fat", fat},
+	} {
+		mode := m.spec.Mode
+		if mode == "" {
+			mode = "grams"
+		}
+		if mode == "grams" {
+			continue
+		}
+		if m.spec.Value == nil {
+			return fmt.Errorf("%s_target_value is required for %s mode", m.name, mode)
+		}
+		if err := validateMacroTarget(m.name, &mode, m.spec.Value); err != nil {
+			return err
+		}
+		if mode == "percent" {
+			total += *m.spec.Value
+		}
+	}
+	if total > 100 {
+		return fmt.Errorf("percent macro targets must add up to at most 100%%, got %g%%", total)
+	}
+	return nil
+}
@@ -43,7 +43,7 @@ func main() {
 	if openAIBaseURL == "" {
 		openAIBaseURL = "https://api.openai.com"
 	}
-	handler := Handler{db: pool, openAIBaseURL: openAIBaseURL}
+	handler := Handler{db: pool, openAIBaseURL: openAIBaseURL, cookEvents: newCookSessionHub()}
 
 	router := gin.Default()
 	router.SetTrustedProxies(nil)
@@ -0,0 +1,86 @@
+package main
+
+// mealTypes lists the food item types that have their own budget, in display order.
+var mealTypes = []string{"breakfast", "lunch", "dinner", "snack"}
+
+// mealBudget returns the day's budget for a meal type; 0 means no budget is set.
+func (t dayTargets) mealBudget(meal string) int {
+	switch meal {
+	case "breakfast":
+		return t.BreakfastBudget
+	case "lunch":
+		return t.LunchBudget
+	case "dinner":
+		return t.DinnerBudget
+	case "snack":
+		return t.SnackBudget
+	}
+	return 0
+}
+
+// mealCaloriesFromItems sums food calories per meal type. Exercise items are skipped.
+func mealCaloriesFromItems(items []calorieLogItem) map[string]int {
+	consumed := make(map[string]int, len(mealTypes))
+	for _, item := range items {
+		if item.Type != "exercise" {
+			consumed[item.Type] += item.Calories
+		}
+	}
+	return consumed
+}
+
+// mealBreakdowns builds the per-meal consumed/remaining breakdown for a day.
+// Meals without a budget (0) are included for their consumed total but never
+// flagged as over budget.
+func mealBreakdowns(t dayTargets, consumed map[string]int) []mealBreakdown {
+	out := make([]mealBreakdown, 0, len(mealTypes))
+	for _, meal := range mealTypes {
+		out = append(out, newMealBreakdown(meal, t.mealBudget(meal), consumed[meal]))
+	}
+	return out
+}
+
+// newMealBreakdown computes remaining and the over-budget signal for one meal.
+func newMealBreakdown(meal string, budget, consumed int) mealBreakdown {
+	m := mealBreakdown{Meal: meal, Budget: budget, Consumed: consumed}
+	if m.Budget > 0 {
+		m.Remaining = m.Budget - m.Consumed
+		if m.Remaining < 0 {
+			m.OverBudget = true
+			m.OverBy = -m.Remaining
+		}
+	}
+	return m
+}
+
+// addMealAdherence accumulates one day's breakdowns into the per-meal stats.
+// Only meals with a budget that day count toward adherence.
+func addMealAdherence(stats []mealAdherence, meals []mealBreakdown) []mealAdherence {
+	if stats == nil {
+		stats = make([]mealAdherence, len(mealTypes))
+		for i, meal := range mealTypes {
+			stats[i].Meal = meal
+		}
+	}
+	for i, m := range meals {
+		if m.Budget <= 0 {
+			continue
+		}
+		stats[i].DaysWithBudget++
+		if !m.OverBudget {
+			stats[i].DaysOnBudget++
+		}
+		stats[i].AvgCalories += m.Consumed
+	}
+	return stats
+}
+
+// finishMealAdherence converts accumulated calorie totals to averages.
+func finishMealAdherence(stats []mealAdherence) []mealAdherence {
+	for i := range stats {
+		if stats[i].DaysWithBudget > 0 {
+			stats[i].AvgCalories /= stats[i].DaysWithBudget
+		}
+	}
+	return stats
+}
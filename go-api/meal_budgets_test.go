@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// TestMealBreakdowns verifies consumed/remaining per meal, the over-budget signal,
+// and that meals without a budget are never flagged.
+func TestMealBreakdowns(t *testing.T) {
+	targets := dayTargets{BreakfastBudget: 500, LunchBudget: 700}
+	items := []calorieLogItem{
+		{Type: "breakfast", Calories: 400},
+		{Type: "breakfast", Calories: 220},
+		{Type: "lunch", Calories: 600},
+		{Type: "snack", Calories: 300},
+		{Type: "exercise", Calories: 250},
+	}
+	meals := mealBreakdowns(targets, mealCaloriesFromItems(items))
+	if len(meals) != 4 || meals[0].Meal != "breakfast" || meals[3].Meal != "snack" {
+		t.Fatalf("want breakfast..snack in order, got %+v", meals)
+	}
+	if b := meals[0]; b.Consumed != 620 || !b.OverBudget || b.OverBy != 120 || b.Remaining != -120 {
+		t.Errorf("breakfast: want 620 consumed, 120 over, got %+v", b)
+	}
+	if l := meals[1]; l.Remaining != 100 || l.OverBudget {
+		t.Errorf("lunch: want 100 remaining, not over, got %+v", l)
+	}
+	if s := meals[3]; s.Consumed != 300 || s.OverBudget {
+		t.Errorf("snack: want 300 consumed, no budget so not over, got %+v", s)
+	}
+}
+
+// TestMealAdherence verifies only days where a meal had a budget count, and the
+// average is over those days.
+func TestMealAdherence(t *testing.T) {
+	var stats []mealAdherence
+	stats = addMealAdherence(stats, mealBreakdowns(dayTargets{DinnerBudget: 800}, map[string]int{"dinner": 700}))
+	stats = addMealAdherence(stats, mealBreakdowns(dayTargets{DinnerBudget: 800}, map[string]int{"dinner": 900}))
+	stats = addMealAdherence(stats, mealBreakdowns(dayTargets{}, map[string]int{"dinner": 1500}))
+	stats = finishMealAdherence(stats)
+
+	dinner := stats[2]
+	if dinner.DaysWithBudget != 2 || dinner.DaysOnBudget != 1 || dinner.AvgCalories != 800 {
+		t.Errorf("dinner: want 2 budgeted days, 1 on budget, avg 800, got %+v", dinner)
+	}
+	if stats[0].DaysWithBudget != 0 {
+		t.Errorf("breakfast: want no budgeted days, got %+v", stats[0])
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -35,6 +36,51 @@ func (d *DateOnly) ScanDate(v pgtype.Date) error {
 	return nil
 }
 
+// ClockTime is a time of day from a PostgreSQL time column, serialized as "HH:MM".
+// Minutes counts minutes after midnight.
+type ClockTime struct{ Minutes int }
+
+// parseClockTime parses an "HH:MM" string.
+func parseClockTime(s string) (ClockTime, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return ClockTime{}, err
+	}
+	return ClockTime{Minutes: t.Hour()*60 + t.Minute()}, nil
+}
+
+func (t ClockTime) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Minutes/60, t.Minutes%60)
+}
+
+func (t ClockTime) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.String() + `"`), nil
+}
+
+func (t *ClockTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := parseClockTime(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// ScanTime implements pgtype.TimeScanner so pgx can scan PostgreSQL time
+// columns into ClockTime. Same NULL handling as DateOnly.ScanDate.
+func (t *ClockTime) ScanTime(v pgtype.Time) error {
+	if !v.Valid {
+		t.Minutes = 0
+		return nil
+	}
+	t.Minutes = int(v.Microseconds / int64(time.Minute/time.Microsecond))
+	return nil
+}
+
 /* ─── Domain structs ─────────────────────────────────────────────────── */
 
 // user maps to the users table. AuthToken and Password are hidden from JSON responses.
@@ -66,6 +112,20 @@ type calorieLogItem struct {
 	MealPlanEntryID  *int       `json:"meal_plan_entry_id"  db:"meal_plan_entry_id"`
 	CreatedAt        *time.Time `json:"created_at"          db:"created_at"`
 	UpdatedAt        *time.Time `json:"updated_at"          db:"updated_at"`
+	// Source is where the numbers came from (see validItemSources); Confidence is
+	// 1-5, nil for rows logged before confidence was recorded.
+	Source           string     `json:"source"              db:"source"`
+	Confidence       *int       `json:"confidence"          db:"confidence"`
+	// Set for items ingested from a device export; unique per user so re-imports are no-ops.
+	ExternalID       *string    `json:"external_id"         db:"external_id"`
+	// ConsumedAt is the local time the item was eaten; nil when not recorded.
+	ConsumedAt       *ClockTime `json:"consumed_at"         db:"consumed_at"`
+	// HydrationML is how much of a beverage counts toward the water target; nil = none.
+	HydrationML      *int       `json:"hydration_ml"        db:"hydration_ml"`
+	// Set when this item was logged from a favorite; drives favorite ranking.
+	FavoriteID       *int       `json:"favorite_id"         db:"favorite_id"`
+	// Set with RecipeID: the recipe revision current when the item was logged.
+	RecipeRevisionID *int       `json:"recipe_revision_id"  db:"recipe_revision_id"`
 }
 
 // calorieLogUserSettings maps to calorie_log_user_settings. One row per user
@@ -96,12 +156,46 @@ type calorieLogUserSettings struct {
 	BudgetAuto      bool      `json:"budget_auto"       db:"budget_auto"`
 	SetupComplete   bool      `json:"setup_complete"    db:"setup_complete"`
 
+	// BudgetSchedule is the per-weekday calorie cycling schedule ([]budgetScheduleDay
+	// as JSONB). Null = no schedule; every day uses CalorieBudget and the macro targets.
+	BudgetSchedule *json.RawMessage `json:"budget_schedule" db:"budget_schedule"`
+
+	// Macro target modes: 'grams' uses *TargetG as-is; 'percent' and 'g_per_lb'
+	// derive it from *TargetValue (percent of budget / grams per lb of body weight).
+	// For derived modes *TargetG holds the latest resolved value.
+	ProteinTargetMode  string   `json:"protein_target_mode"  db:"protein_target_mode"`
+	ProteinTargetValue *float64 `json:"protein_target_value" db:"protein_target_value"`
+	CarbsTargetMode    string   `json:"carbs_target_mode"    db:"carbs_target_mode"`
+	CarbsTargetValue   *float64 `json:"carbs_target_value"   db:"carbs_target_value"`
+	FatTargetMode      string   `json:"fat_target_mode"      db:"fat_target_mode"`
+	FatTargetValue     *float64 `json:"fat_target_value"     db:"fat_target_value"`
+
+	// OffsetActivities are the exercise catalog keys used for exercise offset
+	// suggestions. Nil = defaultOffsetActivities.
+	OffsetActivities []string `json:"offset_activities" db:"offset_activities"`
+
+	// FastingTargetHours is the intermittent fasting goal (previous day's last
+	// meal to the day's first meal). Nil = not tracking fasting.
+	FastingTargetHours *float64 `json:"fasting_target_hours" db:"fasting_target_hours"`
+	// WaterTargetML is the daily hydration goal in ml. Nil = no target.
+	WaterTargetML *int `json:"water_target_ml" db:"water_target_ml"`
+
+	// CompleteDayRule decides what a fully logged day is for logging streaks:
+	// 'meals' (every CompleteDayMeals meal has an item) or 'mark' (marked complete).
+	CompleteDayRule string `json:"complete_day_rule" db:"complete_day_rule"`
+	// CompleteDayMeals are the meals a complete day needs. Nil = defaultCompleteDayMeals.
+	CompleteDayMeals []string `json:"complete_day_meals" db:"complete_day_meals"`
+
 	// Computed fields — populated server-side from profile; not stored in DB.
 	// db:"-" tells RowToStructByName to skip these during scanning.
 	ComputedBMR    *int     `json:"computed_bmr,omitempty"      db:"-"`
 	ComputedTDEE   *int     `json:"computed_tdee,omitempty"     db:"-"`
 	ComputedBudget *int     `json:"computed_budget,omitempty"   db:"-"`
 	PaceLbsPerWeek *float64 `json:"pace_lbs_per_week,omitempty" db:"-"`
+	// WaterTarget is WaterTargetML in WaterUnit, the user's display unit
+	// (oz for "us", ml for "metric"); see populateWaterTarget.
+	WaterTarget    *float64 `json:"water_target,omitempty"      db:"-"`
+	WaterUnit      string   `json:"water_unit,omitempty"        db:"-"`
 }
 
 // weekDayDBRow is the shape of each row returned by the week-summary GROUP BY query.
@@ -113,6 +207,42 @@ type weekDayDBRow struct {
 	ProteinG         float64  `db:"protein_g"`
 	CarbsG           float64  `db:"carbs_g"`
 	FatG             float64  `db:"fat_g"`
+	// Food calories per meal type, for per-meal budget tracking.
+	CaloriesBreakfast int `db:"calories_breakfast"`
+	CaloriesLunch     int `db:"calories_lunch"`
+	CaloriesDinner    int `db:"calories_dinner"`
+	CaloriesSnack     int `db:"calories_snack"`
+}
+
+// mealCalories returns the row's food calories keyed by meal type.
+func (r weekDayDBRow) mealCalories() map[string]int {
+	return map[string]int{
+		"breakfast": r.CaloriesBreakfast,
+		"lunch":     r.CaloriesLunch,
+		"dinner":    r.CaloriesDinner,
+		"snack":     r.CaloriesSnack,
+	}
+}
+
+// mealBreakdown is one meal's consumed calories against its budget for a day.
+// Budget 0 means no budget is set for the meal; Remaining and OverBudget are
+// then left zero/false. OverBy is the overage in calories when OverBudget.
+type mealBreakdown struct {
+	Meal       string `json:"meal"`
+	Budget     int    `json:"budget"`
+	Consumed   int    `json:"consumed"`
+	Remaining  int    `json:"remaining"`
+	OverBudget bool   `json:"over_budget"`
+	OverBy     int    `json:"over_by"`
+}
+
+// mealAdherence is one meal's adherence over a progress range. Only days where
+// the meal had a budget count; AvgCalories averages over those days.
+type mealAdherence struct {
+	Meal           string `json:"meal"`
+	DaysWithBudget int    `json:"days_with_budget"`
+	DaysOnBudget   int    `json:"days_on_budget"`
+	AvgCalories    int    `json:"avg_calories"`
 }
 
 // weekDaySummary is one day's entry in the GET /calorie-log/week-summary response.
@@ -128,6 +258,16 @@ type weekDaySummary struct {
 	CarbsG           float64  `json:"carbs_g"`
 	FatG             float64  `json:"fat_g"`
 	HasData          bool     `json:"has_data"`
+	// ScheduleLabel is the label of the budget schedule day that applied (e.g. "training").
+	ScheduleLabel *string `json:"schedule_label,omitempty"`
+	// Macro targets resolved for this date (mode, schedule, and weight applied).
+	ProteinTargetG int `json:"protein_target_g"`
+	CarbsTargetG   int `json:"carbs_target_g"`
+	FatTargetG     int `json:"fat_target_g"`
+	// Meals is the per-meal breakdown against the budgets in effect on this date.
+	Meals []mealBreakdown `json:"meals"`
+	// WaterML is water logged plus beverage items counted toward hydration.
+	WaterML int `json:"water_ml"`
 }
 
 // dailySummary is the response shape for GET /calorie-log/daily.
@@ -142,6 +282,14 @@ type dailySummary struct {
 	ProteinG         float64                `json:"protein_g"`
 	CarbsG           float64                `json:"carbs_g"`
 	FatG             float64                `json:"fat_g"`
+	ScheduleLabel    *string                `json:"schedule_label,omitempty"`
+	Meals            []mealBreakdown        `json:"meals"`
+	MealsOverBudget  bool                   `json:"meals_over_budget"` // any budgeted meal over
+	ExerciseOffsets  []exerciseOffset       `json:"exercise_offsets,omitempty"`
+	Note             *string                `json:"note"`
+	Completeness     dayCompleteness        `json:"completeness"`
+	Water            waterTotals            `json:"water"`
+	Fasting          *fastingDay            `json:"fasting,omitempty"`
 	Items            []calorieLogItem       `json:"items"`
 	Settings         calorieLogUserSettings `json:"settings"`
 }
@@ -159,6 +307,79 @@ type calorieConfigHistory struct {
 	CalorieBudget int        `json:"calorie_budget" db:"calorie_budget"`
 	ActivityLevel *string    `json:"activity_level" db:"activity_level"`
 	CreatedAt     *time.Time `json:"created_at"     db:"created_at"`
+	// BudgetSchedule is the weekday schedule in effect up to ValidUntil; null = none.
+	BudgetSchedule *json.RawMessage `json:"budget_schedule" db:"budget_schedule"`
+
+	// Macro targets in effect up to ValidUntil. Nil = inherit from current settings.
+	ProteinTargetG     *int     `json:"protein_target_g"     db:"protein_target_g"`
+	CarbsTargetG       *int     `json:"carbs_target_g"       db:"carbs_target_g"`
+	FatTargetG         *int     `json:"fat_target_g"         db:"fat_target_g"`
+	ProteinTargetMode  *string  `json:"protein_target_mode"  db:"protein_target_mode"`
+	ProteinTargetValue *float64 `json:"protein_target_value" db:"protein_target_value"`
+	CarbsTargetMode    *string  `json:"carbs_target_mode"    db:"carbs_target_mode"`
+	CarbsTargetValue   *float64 `json:"carbs_target_value"   db:"carbs_target_value"`
+	FatTargetMode      *string  `json:"fat_target_mode"      db:"fat_target_mode"`
+	FatTargetValue     *float64 `json:"fat_target_value"     db:"fat_target_value"`
+
+	// Per-meal budgets and exercise target in effect up to ValidUntil. Nil = inherit.
+	BreakfastBudget        *int `json:"breakfast_budget"         db:"breakfast_budget"`
+	LunchBudget            *int `json:"lunch_budget"             db:"lunch_budget"`
+	DinnerBudget           *int `json:"dinner_budget"            db:"dinner_budget"`
+	SnackBudget            *int `json:"snack_budget"             db:"snack_budget"`
+	ExerciseTargetCalories *int `json:"exercise_target_calories" db:"exercise_target_calories"`
+	// UpdatedAt is set when a row is edited through the config-history endpoints.
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// configHistoryRange is one entry in the GET /api/calorie-log/config-history response:
+// a history row plus the first date it covers (nil for the oldest row, which
+// extends back indefinitely).
+type configHistoryRange struct {
+	calorieConfigHistory
+	ValidFrom *DateOnly `json:"valid_from"`
+}
+
+// configHistoryPatch is the set of target fields that can be overwritten on
+// history rows. Nil fields are left unchanged. Shared by the range and per-row
+// edit endpoints.
+type configHistoryPatch struct {
+	CalorieBudget          *int             `json:"calorie_budget"`
+	ActivityLevel          *string          `json:"activity_level"`
+	BudgetSchedule         *json.RawMessage `json:"budget_schedule"` // empty array clears
+	ProteinTargetG         *int             `json:"protein_target_g"`
+	CarbsTargetG           *int             `json:"carbs_target_g"`
+	FatTargetG             *int             `json:"fat_target_g"`
+	ProteinTargetMode      *string          `json:"protein_target_mode"`
+	ProteinTargetValue     *float64         `json:"protein_target_value"`
+	CarbsTargetMode        *string          `json:"carbs_target_mode"`
+	CarbsTargetValue       *float64         `json:"carbs_target_value"`
+	FatTargetMode          *string          `json:"fat_target_mode"`
+	FatTargetValue         *float64         `json:"fat_target_value"`
+	BreakfastBudget        *int             `json:"breakfast_budget"`
+	LunchBudget            *int             `json:"lunch_budget"`
+	DinnerBudget           *int             `json:"dinner_budget"`
+	SnackBudget            *int             `json:"snack_budget"`
+	ExerciseTargetCalories *int             `json:"exercise_target_calories"`
+}
+
+// configHistoryRangeRequest is the body for PUT /api/calorie-log/config-history/range:
+// overwrite the given fields for every day from Start through End (inclusive).
+type configHistoryRangeRequest struct {
+	Start string `json:"start" binding:"required"` // YYYY-MM-DD
+	End   string `json:"end"   binding:"required"` // YYYY-MM-DD; must be before today
+	configHistoryPatch
+}
+
+// budgetScheduleDay overrides the base calorie budget and macro targets on one
+// weekday. Weekday uses the same 0=Mon … 6=Sun convention as copyWeekInput.Days.
+// Nil targets inherit the base value from settings (or config history).
+type budgetScheduleDay struct {
+	Weekday        int     `json:"weekday"`
+	Label          *string `json:"label"` // e.g. "training", "rest", "weekend"
+	CalorieBudget  *int    `json:"calorie_budget"`
+	ProteinTargetG *int    `json:"protein_target_g"`
+	CarbsTargetG   *int    `json:"carbs_target_g"`
+	FatTargetG     *int    `json:"fat_target_g"`
 }
 
 // weightEntry maps to the weight_log table. One entry per user per date;
@@ -169,16 +390,48 @@ type weightEntry struct {
 	Date      DateOnly   `json:"date"       db:"date"`
 	WeightLBS float64    `json:"weight_lbs" db:"weight_lbs"`
 	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+	Source    string     `json:"source"     db:"source"` // manual, import, apple_health, google_fit
+}
+
+// calorieLogDay maps to calorie_log_days: a free-text note and/or an explicit
+// "day complete" mark for one day of the log.
+type calorieLogDay struct {
+	UserID    int        `json:"user_id"    db:"user_id"`
+	Date      DateOnly   `json:"date"       db:"date"`
+	Note      *string    `json:"note"       db:"note"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+	Completed bool       `json:"completed"  db:"completed"`
+}
+
+// waterEntry maps to the water_log table. Several entries per day are allowed.
+type waterEntry struct {
+	ID         int        `json:"id"          db:"id"`
+	UserID     int        `json:"user_id"     db:"user_id"`
+	Date       DateOnly   `json:"date"        db:"date"`
+	AmountML   int        `json:"amount_ml"   db:"amount_ml"`
+	ConsumedAt *ClockTime `json:"consumed_at" db:"consumed_at"`
+	CreatedAt  *time.Time `json:"created_at"  db:"created_at"`
 }
 
 // progressStats holds aggregate stats computed from a date range for the Progress tab.
 type progressStats struct {
 	DaysTracked              int      `json:"days_tracked"`
+	// DaysComplete counts fully logged days under the user's complete_day_rule.
+	DaysComplete             int      `json:"days_complete"`
 	DaysOnBudget             int      `json:"days_on_budget"`
 	AvgCaloriesFood          int      `json:"avg_calories_food"`
 	AvgCaloriesExercise      int      `json:"avg_calories_exercise"`
 	AvgNetCalories           int      `json:"avg_net_calories"`
 	TotalCaloriesLeft        int      `json:"total_calories_left"`
+	// Macro adherence against the targets in effect on each day. Protein is a
+	// floor (met when protein_g >= target); carbs and fat are ceilings.
+	DaysProteinOnTarget int `json:"days_protein_on_target"`
+	DaysCarbsOnTarget   int `json:"days_carbs_on_target"`
+	DaysFatOnTarget     int `json:"days_fat_on_target"`
+	// MealAdherence is per-meal budget adherence, in breakfast/lunch/dinner/snack order.
+	MealAdherence []mealAdherence `json:"meal_adherence"`
+	// Fasting is target adherence and streaks; omitted when no fasting target is set.
+	Fasting *fastingStats `json:"fasting,omitempty"`
 	// EstimatedWeightChangeLbs is the TDEE-based estimated weight change over the period.
 	// Positive = gaining, negative = losing. Omitted when TDEE profile is incomplete.
 	EstimatedWeightChangeLbs *float64 `json:"estimated_weight_change_lbs,omitempty"`
@@ -190,6 +443,7 @@ type progressStats struct {
 type weekSummaryResponse struct {
 	Days                     []weekDaySummary `json:"days"`
 	EstimatedWeightChangeLbs *float64         `json:"estimated_weight_change_lbs,omitempty"`
+	WaterTargetML            *int             `json:"water_target_ml"`
 }
 
 // progressResponse is the response for GET /api/calorie-log/progress.
@@ -307,14 +561,32 @@ type recipe struct {
 	FatG      *float64   `json:"fat_g"      db:"fat_g"`
 	CreatedAt *time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+	// NutritionComputed is true when the per-serving numbers above are computed
+	// from ingredients linked to foods or other recipes, every one of them
+	// counted (see recomputeRecipeNutrition).
+	NutritionComputed bool `json:"nutrition_computed" db:"nutrition_computed"`
+	// YieldGrams is the cooked weight of all servings, so the recipe can be
+	// used by weight as another recipe's ingredient.
+	YieldGrams *float64 `json:"yield_grams" db:"yield_grams"`
 }
 
 // recipeListItem is the shape returned by GET /api/recipes — recipe plus computed
 // step count and total timer duration (sum of timer_seconds across all timer steps).
 type recipeListItem struct {
 	recipe
-	StepCount         int `json:"step_count"          db:"step_count"`
-	TotalTimerSeconds int `json:"total_timer_seconds" db:"total_timer_seconds"`
+	StepCount         int      `json:"step_count"          db:"step_count"`
+	TotalTimerSeconds int      `json:"total_timer_seconds" db:"total_timer_seconds"`
+	Tags              []string `json:"tags"                db:"tags"` // from recipe_tags; not a real column
+	// Cooking history from recipe_cooks. AvgRating is over rated cooks only.
+	LastCookedOn *DateOnly `json:"last_cooked_on" db:"last_cooked_on"`
+	TimesCooked  int       `json:"times_cooked"   db:"times_cooked"`
+	AvgRating    *float64  `json:"avg_rating"     db:"avg_rating"`
+}
+
+// recipeListResponse is the paginated response from GET /api/recipes.
+type recipeListResponse struct {
+	Recipes []recipeListItem `json:"recipes"`
+	HasMore bool             `json:"has_more"`
 }
 
 // recipeIngredient maps to recipe_ingredients.
@@ -326,6 +598,15 @@ type recipeIngredient struct {
 	Uom       *string  `json:"uom"        db:"uom"`
 	Note      *string  `json:"note"       db:"note"`
 	SortOrder int      `json:"sort_order" db:"sort_order"`
+	// FoodID links a saved food for computed nutrition; Grams is the weight of
+	// Qty/Uom when it can't be converted automatically.
+	FoodID *int     `json:"food_id" db:"food_id"`
+	Grams  *float64 `json:"grams"   db:"grams"`
+	// SubRecipeID references another recipe instead of a food; Qty/Uom are
+	// servings of it, or a weight (see subRecipeServings).
+	SubRecipeID *int `json:"sub_recipe_id" db:"sub_recipe_id"`
+	// QtyText is Qty formatted for display ("1 1/2"); set on scaled recipes.
+	QtyText string `json:"qty_text,omitempty" db:"-"`
 }
 
 // recipeTool maps to recipe_tools.
@@ -346,6 +627,32 @@ type recipeStep struct {
 	TimerSeconds  *int    `json:"timer_seconds"  db:"timer_seconds"`
 	MeanwhileText *string `json:"meanwhile_text" db:"meanwhile_text"`
 	SortOrder     int     `json:"sort_order"     db:"sort_order"`
+	// SubRecipe names the sub-recipe a step comes from when sub-recipe steps
+	// are inlined (see inlineSubRecipeSteps); RecipeID is then the sub-recipe's.
+	SubRecipe string `json:"sub_recipe,omitempty" db:"-"`
+	// Ingredients and Tools are what the step uses, from recipe_step_ingredients
+	// and recipe_step_tools.
+	Ingredients []stepIngredient `json:"ingredients" db:"-"`
+	Tools       []stepTool       `json:"tools"       db:"-"`
+}
+
+// stepIngredient is an ingredient a step uses, with the amount used in that
+// step. Qty is nil for the whole ingredient or an unspecified amount.
+type stepIngredient struct {
+	StepID       int      `json:"-"             db:"step_id"`
+	IngredientID int      `json:"ingredient_id" db:"ingredient_id"`
+	Name         string   `json:"name"          db:"name"`
+	Qty          *float64 `json:"qty"           db:"qty"`
+	Uom          *string  `json:"uom"           db:"uom"`
+	// QtyText is Qty formatted for display; set on scaled recipes.
+	QtyText string `json:"qty_text,omitempty" db:"-"`
+}
+
+// stepTool is a tool a step uses.
+type stepTool struct {
+	StepID int    `json:"-"       db:"step_id"`
+	ToolID int    `json:"tool_id" db:"tool_id"`
+	Name   string `json:"name"    db:"name"`
 }
 
 // recipeDetail is the full recipe response — recipe fields plus all sub-lists.
@@ -355,6 +662,95 @@ type recipeDetail struct {
 	Ingredients []recipeIngredient `json:"ingredients"`
 	Tools       []recipeTool       `json:"tools"`
 	Steps       []recipeStep       `json:"steps"`
+	Tags        []string           `json:"tags"`
+	// Nutrition is the computed breakdown; nil when no ingredient links a food.
+	Nutrition *recipeNutrition `json:"nutrition"`
+}
+
+// cookTimer is a running or paused timer in a cook session, keyed by step.
+// EndsAt is absolute so every device shows the same countdown; it is nil while
+// paused, when RemainingSeconds holds what's left. For running timers
+// RemainingSeconds is recomputed from EndsAt whenever the session is served.
+type cookTimer struct {
+	Step             int        `json:"step"`
+	DurationSeconds  int        `json:"duration_seconds"`
+	EndsAt           *time.Time `json:"ends_at"`
+	RemainingSeconds int        `json:"remaining_seconds"`
+}
+
+// cookSession maps to cook_sessions. Step numbers are 0-based indexes into
+// the steps of the session's recipe revision. Status is 'active', 'finished',
+// or 'abandoned'.
+type cookSession struct {
+	ID               int         `json:"id"                 db:"id"`
+	UserID           int         `json:"user_id"            db:"user_id"`
+	RecipeID         int         `json:"recipe_id"          db:"recipe_id"`
+	RecipeRevisionID *int        `json:"recipe_revision_id" db:"recipe_revision_id"`
+	Status           string      `json:"status"             db:"status"`
+	CurrentStep      int         `json:"current_step"       db:"current_step"`
+	CompletedSteps   []int       `json:"completed_steps"    db:"completed_steps"`
+	Timers           []cookTimer `json:"timers"             db:"timers"`
+	Version          int         `json:"version"            db:"version"`
+	LoggedItemID     *int        `json:"logged_item_id"     db:"logged_item_id"`
+	InlineSteps      bool        `json:"inline_steps"       db:"inline_steps"`
+	StartedAt        time.Time   `json:"started_at"         db:"started_at"`
+	UpdatedAt        time.Time   `json:"updated_at"         db:"updated_at"`
+	FinishedAt       *time.Time  `json:"finished_at"        db:"finished_at"`
+}
+
+// recipeCook maps to recipe_cooks — one time a recipe was made. Rating is 1–5.
+// CookSessionID is set when it was recorded by finishing a cook session.
+type recipeCook struct {
+	ID               int        `json:"id"                 db:"id"`
+	UserID           int        `json:"user_id"            db:"user_id"`
+	RecipeID         int        `json:"recipe_id"          db:"recipe_id"`
+	RecipeRevisionID *int       `json:"recipe_revision_id" db:"recipe_revision_id"`
+	CookSessionID    *int       `json:"cook_session_id"    db:"cook_session_id"`
+	CookedOn         DateOnly   `json:"cooked_on"          db:"cooked_on"`
+	Servings         *float64   `json:"servings"           db:"servings"`
+	Rating           *int       `json:"rating"             db:"rating"`
+	Notes            *string    `json:"notes"              db:"notes"`
+	CreatedAt        *time.Time `json:"created_at"         db:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at"         db:"updated_at"`
+}
+
+// createRecipeCookRequest is the body for POST /api/recipes/:id/cooks.
+// CookedOn defaults to today and Servings to the recipe's yield.
+// RecipeRevisionID defaults to the revision current on CookedOn.
+type createRecipeCookRequest struct {
+	CookedOn         string   `json:"cooked_on"` // YYYY-MM-DD
+	Servings         *float64 `json:"servings"`
+	Rating           *int     `json:"rating"`
+	Notes            *string  `json:"notes"`
+	RecipeRevisionID *int     `json:"recipe_revision_id"`
+}
+
+// updateRecipeCookRequest is the body for PUT /api/recipes/:id/cooks/:cook.
+// Omitted fields are unchanged; a rating of 0 or empty notes clears them.
+type updateRecipeCookRequest struct {
+	CookedOn *string  `json:"cooked_on"`
+	Servings *float64 `json:"servings"`
+	Rating   *int     `json:"rating"`
+	Notes    *string  `json:"notes"`
+}
+
+// recipeRevisionSummary is a recipe_revisions row without its snapshot, as
+// listed by GET /api/recipes/:id/revisions. Author is 'user' or 'ai'.
+type recipeRevisionSummary struct {
+	ID           int       `json:"id"            db:"id"`
+	RecipeID     int       `json:"recipe_id"     db:"recipe_id"`
+	Revision     int       `json:"revision"      db:"revision"`
+	Author       string    `json:"author"        db:"author"`
+	AIPrompt     *string   `json:"ai_prompt"     db:"ai_prompt"`
+	RestoredFrom *int      `json:"restored_from" db:"restored_from"`
+	CreatedAt    time.Time `json:"created_at"    db:"created_at"`
+}
+
+// recipeRevision maps to recipe_revisions — an immutable snapshot of a recipe
+// as it stood after one change.
+type recipeRevision struct {
+	recipeRevisionSummary
+	Snapshot recipeDetail `json:"snapshot" db:"snapshot"`
 }
 
 // ingredientInput is a single ingredient in a create/update request.
@@ -364,6 +760,10 @@ type ingredientInput struct {
 	Uom       *string  `json:"uom"`
 	Note      *string  `json:"note"`
 	SortOrder int      `json:"sort_order"`
+	FoodID    *int     `json:"food_id"`
+	Grams     *float64 `json:"grams"`
+	// SubRecipeID references another of the user's recipes; exclusive with FoodID.
+	SubRecipeID *int `json:"sub_recipe_id"`
 }
 
 // toolInput is a single tool in a create/update request.
@@ -379,6 +779,18 @@ type stepInput struct {
 	TimerSeconds  *int    `json:"timer_seconds"`
 	MeanwhileText *string `json:"meanwhile_text"`
 	SortOrder     int     `json:"sort_order"`
+	// Ingredients and Tools reference the recipe's ingredients and tools by
+	// 0-based position in their lists, since new ones have no ids yet.
+	Ingredients []stepIngredientInput `json:"ingredients"`
+	Tools       []int                 `json:"tools"`
+}
+
+// stepIngredientInput is an ingredient a step uses, by position in the
+// recipe's ingredient list, with the amount used in the step.
+type stepIngredientInput struct {
+	Ingredient int      `json:"ingredient"`
+	Qty        *float64 `json:"qty"`
+	Uom        *string  `json:"uom"`
 }
 
 // createRecipeRequest is the request body for POST /api/recipes.
@@ -388,6 +800,7 @@ type createRecipeRequest struct {
 	Category    string            `json:"category"`
 	Notes       *string           `json:"notes"`
 	Servings    *float64          `json:"servings"`
+	YieldGrams  *float64          `json:"yield_grams"`
 	Calories    *int              `json:"calories"`
 	ProteinG    *float64          `json:"protein_g"`
 	CarbsG      *float64          `json:"carbs_g"`
@@ -395,6 +808,7 @@ type createRecipeRequest struct {
 	Ingredients []ingredientInput `json:"ingredients"`
 	Tools       []toolInput       `json:"tools"`
 	Steps       []stepInput       `json:"steps"`
+	Tags        []string          `json:"tags"`
 }
 
 // updateRecipeRequest is the request body for PUT /api/recipes/:id.
@@ -405,6 +819,7 @@ type updateRecipeRequest struct {
 	Category    *string            `json:"category"`
 	Notes       *string            `json:"notes"`
 	Servings    *float64           `json:"servings"`
+	YieldGrams  *float64           `json:"yield_grams"` // 0 clears
 	Calories    *int               `json:"calories"`
 	ProteinG    *float64           `json:"protein_g"`
 	CarbsG      *float64           `json:"carbs_g"`
@@ -412,6 +827,10 @@ type updateRecipeRequest struct {
 	Ingredients *[]ingredientInput `json:"ingredients"`
 	Tools       *[]toolInput       `json:"tools"`
 	Steps       *[]stepInput       `json:"steps"`
+	Tags        *[]string          `json:"tags"`
+	// AIPrompt is set when saving a draft from ai-modify; the revision is then
+	// recorded as an AI edit with this prompt.
+	AIPrompt *string `json:"ai_prompt"`
 }
 
 // validRecipeCategories is the set of allowed values for the recipe_category enum.
@@ -439,18 +858,87 @@ type calorieLogFavorite struct {
 	CarbsG    *float64   `json:"carbs_g"    db:"carbs_g"`
 	FatG      *float64   `json:"fat_g"      db:"fat_g"`
 	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+	// UseCount and LastUsedAt track logging from this favorite (items with favorite_id).
+	UseCount   int        `json:"use_count"    db:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	// At most one of RecipeID/FoodID is set; the favorite's nutrition is then
+	// kept in sync with that source, scaled by Qty (see syncLinkedFavorites).
+	RecipeID *int `json:"recipe_id" db:"recipe_id"`
+	FoodID   *int `json:"food_id"   db:"food_id"`
+}
+
+// rankedFavorite is a favorite with the usage behind its position in
+// GET /api/calorie-log/favorites?type=&time=.
+type rankedFavorite struct {
+	calorieLogFavorite
+	TypeUses int     `json:"type_uses" db:"type_uses"` // logged as the requested meal type
+	TimeUses int     `json:"time_uses" db:"time_uses"` // logged near the requested time
+	TopType  *string `json:"-"         db:"top_type"`  // meal type it's most often logged as
+	// SuggestedType is the meal type to prefill when logging this favorite.
+	SuggestedType string  `json:"suggested_type" db:"-"`
+	Score         float64 `json:"score"          db:"-"`
 }
 
 // createFavoriteRequest is the request body for POST /api/calorie-log/favorites.
+// Calories is required unless the favorite links a recipe or food.
 type createFavoriteRequest struct {
 	ItemName string   `json:"item_name" binding:"required"`
 	Type     string   `json:"type"      binding:"required"`
 	Qty      *float64 `json:"qty"`
 	Uom      *string  `json:"uom"`
-	Calories int      `json:"calories"  binding:"required"`
+	Calories int      `json:"calories"`
 	ProteinG *float64 `json:"protein_g"`
 	CarbsG   *float64 `json:"carbs_g"`
 	FatG     *float64 `json:"fat_g"`
+	RecipeID *int     `json:"recipe_id"`
+	FoodID   *int     `json:"food_id"`
+}
+
+// updateFavoriteRequest is the request body for PUT /api/calorie-log/favorites/:id.
+// Omitted fields keep their value; recipe_id or food_id of 0 removes the link.
+type updateFavoriteRequest struct {
+	ItemName *string  `json:"item_name"`
+	Type     *string  `json:"type"`
+	Qty      *float64 `json:"qty"`
+	Uom      *string  `json:"uom"`
+	Calories *int     `json:"calories"`
+	ProteinG *float64 `json:"protein_g"`
+	CarbsG   *float64 `json:"carbs_g"`
+	FatG     *float64 `json:"fat_g"`
+	RecipeID *int     `json:"recipe_id"`
+	FoodID   *int     `json:"food_id"`
+}
+
+// food maps to the foods table: nutrition for one serving (ServingQty
+// ServingUom). ServingGrams, when set, allows amounts in grams.
+type food struct {
+	ID           int        `json:"id"            db:"id"`
+	UserID       int        `json:"user_id"       db:"user_id"`
+	Name         string     `json:"name"          db:"name"`
+	Brand        *string    `json:"brand"         db:"brand"`
+	ServingQty   float64    `json:"serving_qty"   db:"serving_qty"`
+	ServingUom   string     `json:"serving_uom"   db:"serving_uom"`
+	ServingGrams *float64   `json:"serving_grams" db:"serving_grams"`
+	Calories     int        `json:"calories"      db:"calories"`
+	ProteinG     *float64   `json:"protein_g"     db:"protein_g"`
+	CarbsG       *float64   `json:"carbs_g"       db:"carbs_g"`
+	FatG         *float64   `json:"fat_g"         db:"fat_g"`
+	CreatedAt    *time.Time `json:"created_at"    db:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"    db:"updated_at"`
+}
+
+// foodRequest is the request body for POST /api/foods and PUT /api/foods/:id.
+// PUT keeps the current value of omitted fields.
+type foodRequest struct {
+	Name         *string  `json:"name"`
+	Brand        *string  `json:"brand"`
+	ServingQty   *float64 `json:"serving_qty"`
+	ServingUom   *string  `json:"serving_uom"`
+	ServingGrams *float64 `json:"serving_grams"`
+	Calories     *int     `json:"calories"`
+	ProteinG     *float64 `json:"protein_g"`
+	CarbsG       *float64 `json:"carbs_g"`
+	FatG         *float64 `json:"fat_g"`
 }
 
 // createCalorieLogItemRequest is the request body for POST /api/calorie-log/items.
@@ -466,6 +954,15 @@ type createCalorieLogItemRequest struct {
 	FatG            *float64 `json:"fat_g"`
 	RecipeID        *int     `json:"recipe_id"`
 	MealPlanEntryID *int     `json:"meal_plan_entry_id"`
+	// FavoriteID records the favorite this was logged from and bumps its use count.
+	FavoriteID *int `json:"favorite_id"`
+	// Source defaults from the links above (meal_plan / recipe / favorite) or to manual.
+	// Confidence defaults per source; AI items should pass the suggestion's value.
+	Source     string  `json:"source"`
+	Confidence *int    `json:"confidence"`
+	ConsumedAt *string `json:"consumed_at"` // HH:MM
+	// HydrationML counts a beverage toward the water target.
+	HydrationML *int `json:"hydration_ml"`
 }
 
 // patchUserSettingsRequest is the request body for PATCH /api/calorie-log/user-settings.
@@ -490,6 +987,30 @@ type patchUserSettingsRequest struct {
 	Units                  *string  `json:"units"`
 	BudgetAuto             *bool    `json:"budget_auto"`
 	SetupComplete          *bool    `json:"setup_complete"`
+	// BudgetSchedule replaces the weekday schedule; an empty array clears it.
+	BudgetSchedule *json.RawMessage `json:"budget_schedule"`
+	// Macro target modes: grams | percent | g_per_lb. Values are the percent of
+	// calories or grams per lb, depending on mode.
+	ProteinTargetMode  *string  `json:"protein_target_mode"`
+	ProteinTargetValue *float64 `json:"protein_target_value"`
+	CarbsTargetMode    *string  `json:"carbs_target_mode"`
+	CarbsTargetValue   *float64 `json:"carbs_target_value"`
+	FatTargetMode      *string  `json:"fat_target_mode"`
+	FatTargetValue     *float64 `json:"fat_target_value"`
+	// OffsetActivities replaces the offset activity list; an empty array resets to the default.
+	OffsetActivities *[]string `json:"offset_activities"`
+	// FastingTargetHours sets the fasting goal; 0 stops tracking fasting.
+	FastingTargetHours *float64 `json:"fasting_target_hours"`
+	// WaterTargetML sets the daily water goal in ml; 0 clears it.
+	WaterTargetML *int `json:"water_target_ml"`
+	// WaterTarget sets the goal in WaterTargetUnit (ml | oz) instead, defaulting
+	// to the user's units (oz for "us"); 0 clears it.
+	WaterTarget     *float64 `json:"water_target"`
+	WaterTargetUnit *string  `json:"water_target_unit"`
+	// CompleteDayRule is 'meals' or 'mark'.
+	CompleteDayRule *string `json:"complete_day_rule"`
+	// CompleteDayMeals replaces the meals a complete day needs; an empty array resets to the default.
+	CompleteDayMeals *[]string `json:"complete_day_meals"`
 }
 
 /* ─── Task structs ───────────────────────────────────────────────────── */
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// A minimal PDF 1.4 writer for the progress report: US Letter pages, the
+// built-in Helvetica fonts (no embedding), text, lines, rectangles, and
+// polylines. Coordinates are in points with the origin at the bottom left.
+
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+)
+
+// pdfColor is an RGB color with components in [0, 1].
+type pdfColor struct{ R, G, B float64 }
+
+var (
+	pdfBlack = pdfColor{0, 0, 0}
+	pdfGray  = pdfColor{0.45, 0.45, 0.45}
+	pdfLight = pdfColor{0.85, 0.85, 0.85}
+)
+
+// pdfDoc accumulates pages; bytes serializes the document.
+type pdfDoc struct {
+	pages []*pdfPage
+}
+
+// pdfPage is one page's content stream.
+type pdfPage struct {
+	buf bytes.Buffer
+}
+
+// addPage appends a blank page and returns it.
+func (d *pdfDoc) addPage() *pdfPage {
+	p := &pdfPage{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// text draws s at (x, y) in Helvetica (bold: Helvetica-Bold) at size points.
+func (p *pdfPage) text(x, y, size float64, bold bool, color pdfColor, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.buf, "BT %.3f %.3f %.3f rg /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		color.R, color.G, color.B, font, size, x, y, pdfEscape(s))
+}
+
+// textRight draws s right-aligned so it ends at x.
+func (p *pdfPage) textRight(x, y, size float64, bold bool, color pdfColor, s string) {
+	p.text(x-pdfTextWidth(s, size), y, size, bold, color, s)
+}
+
+// line strokes a segment from (x1, y1) to (x2, y2).
+func (p *pdfPage) line(x1, y1, x2, y2, width float64, color pdfColor) {
+	fmt.Fprintf(&p.buf, "%.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		color.R, color.G, color.B, width, x1, y1, x2, y2)
+}
+
+// rect fills a rectangle whose bottom-left corner is (x, y).
+func (p *pdfPage) rect(x, y, w, h float64, color pdfColor) {
+	fmt.Fprintf(&p.buf, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n",
+		color.R, color.G, color.B, x, y, w, h)
+}
+
+// polyline strokes a path through pts ([x, y] pairs). Fewer than two points draw nothing.
+func (p *pdfPage) polyline(pts [][2]float64, width float64, color pdfColor) {
+	if len(pts) < 2 {
+		return
+	}
+	fmt.Fprintf(&p.buf, "%.3f %.3f %.3f RG %.2f w 1 j %.2f %.2f m",
+		color.R, color.G, color.B, width, pts[0][0], pts[0][1])
+	for _, pt := range pts[1:] {
+		fmt.Fprintf(&p.buf, " %.2f %.2f l", pt[0], pt[1])
+	}
+	p.buf.WriteString(" S\n")
+}
+
+// dash sets the stroke dash pattern for later lines; on == 0 restores solid lines.
+func (p *pdfPage) dash(on, off float64) {
+	if on == 0 {
+		p.buf.WriteString("[] 0 d\n")
+		return
+	}
+	fmt.Fprintf(&p.buf, "[%.1f %.1f] 0 d\n", on, off)
+}
+
+// pdfTextWidth approximates the width of s in Helvetica at size points. Exact
+// for digits and common punctuation, which is what gets right-aligned.
+func pdfTextWidth(s string, size float64) float64 {
+	var units float64
+	for _, r := range s {
+		switch {
+		case r == ' ' || r == '.' || r == ',' || r == '/' || r == ':':
+			units += 278
+		case r == '-' || r == '(' || r == ')':
+			units += 333
+		case r == '%':
+			units += 889
+		case r == '+':
+			units += 584
+		case r >= 'A' && r <= 'Z':
+			units += 667
+		case r == 'i' || r == 'l' || r == 'j':
+			units += 222
+		case r == 'm' || r == 'w':
+			units += 833
+		default:
+			units += 556
+		}
+	}
+	return units * size / 1000
+}
+
+// pdfReplacements maps common punctuation outside WinAnsi's Latin-1 range to
+// ASCII, since the standard fonts are addressed one byte per glyph.
+var pdfReplacements = map[rune]string{
+	'—': "-", '–': "-", '−': "-", '‘': "'", '’': "'", '“': "\"", '”': "\"",
+	'•': "*", '…': "...", '≈': "~", '≤': "<=", '≥': ">=", '→': "->",
+}
+
+// pdfEscape encodes s as a PDF literal string body in WinAnsiEncoding.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			// WinAnsi matches Latin-1 here; write the raw byte, octal-escaped.
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			if rep, ok := pdfReplacements[r]; ok {
+				b.WriteString(rep)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// bytes serializes the document. Objects: 1 catalog, 2 page tree, 3–4 fonts,
+// then a page object and its compressed content stream per page.
+func (d *pdfDoc) bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.addPage()
+	}
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(p.buf.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// validateRecipeCook checks the fields of a cook. An empty date is left to
+// its default; a rating of 0 means unrated.
+func validateRecipeCook(cookedOn string, servings *float64, rating *int) string {
+	if cookedOn != "" {
+		if _, err := time.Parse("2006-01-02", cookedOn); err != nil {
+			return "invalid cooked_on, expected YYYY-MM-DD"
+		}
+	}
+	if servings != nil && *servings <= 0 {
+		return "servings must be positive"
+	}
+	if rating != nil && (*rating < 0 || *rating > 5) {
+		return "rating must be between 1 and 5"
+	}
+	return ""
+}
+
+// insertRecipeCook records a cook of one of the user's recipes. Without an
+// explicit revision it uses the one current at the end of cooked_on, so a
+// cook logged after the fact points at what was actually made. Returns
+// pgx.ErrNoRows when the recipe or revision doesn't belong to the user.
+func insertRecipeCook(ctx context.Context, q querier, userID, recipeID int, sessionID *int, req createRecipeCookRequest) (recipeCook, error) {
+	if req.CookedOn == "" {
+		req.CookedOn = time.Now().Format("2006-01-02")
+	}
+	rows, err := q.Query(ctx,
+		`INSERT INTO recipe_cooks
+		   (user_id, recipe_id, recipe_revision_id, cook_session_id, cooked_on, servings, rating, notes)
+		 SELECT @userID, r.id,
+		        COALESCE(@revisionID::int,
+		                 (SELECT COALESCE(MAX(id) FILTER (WHERE created_at < @cookedOn::date + 1), MIN(id))
+		                  FROM recipe_revisions WHERE recipe_id = r.id)),
+		        @sessionID, @cookedOn, COALESCE(@servings, r.servings),
+		        NULLIF(@rating::int, 0), NULLIF(@notes::text, '')
+		 FROM recipes r
+		 WHERE r.id = @recipeID AND r.user_id = @userID
+		   AND (@revisionID::int IS NULL OR EXISTS (
+		         SELECT 1 FROM recipe_revisions WHERE id = @revisionID AND recipe_id = r.id))
+		 RETURNING *`,
+		pgx.NamedArgs{
+			"userID": userID, "recipeID": recipeID, "revisionID": req.RecipeRevisionID,
+			"sessionID": sessionID, "cookedOn": req.CookedOn, "servings": req.Servings,
+			"rating": req.Rating, "notes": req.Notes,
+		})
+	if err != nil {
+		return recipeCook{}, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[recipeCook])
+}
+
+// listRecipeCooks returns a recipe's cooking history, most recent first.
+// GET /api/recipes/:id/cooks
+func (h *Handler) listRecipeCooks(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow(c,
+		`SELECT EXISTS (SELECT 1 FROM recipes WHERE id = @id AND user_id = @userID)`,
+		pgx.NamedArgs{"id": id, "userID": userID}).Scan(&exists)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+	if !exists {
+		apiError(c, http.StatusNotFound, "recipe not found")
+		return
+	}
+
+	cooks, err := queryMany[recipeCook](h.db, c,
+		`SELECT * FROM recipe_cooks
+		 WHERE recipe_id = @id
+		 ORDER BY cooked_on DESC, id DESC`,
+		pgx.NamedArgs{"id": id})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch cooks")
+		return
+	}
+	if cooks == nil {
+		cooks = []recipeCook{}
+	}
+	c.JSON(http.StatusOK, cooks)
+}
+
+// createRecipeCook records a cook by hand, for meals made without a cook
+// session. Finishing a cook session records one automatically.
+// POST /api/recipes/:id/cooks
+func (h *Handler) createRecipeCook(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+	var body createRecipeCookRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := validateRecipeCook(body.CookedOn, body.Servings, body.Rating); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	cook, err := insertRecipeCook(c, h.db, userID, id, nil, body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apiError(c, http.StatusNotFound, "recipe or revision not found")
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to record cook")
+		return
+	}
+	c.JSON(http.StatusCreated, cook)
+}
+
+// updateRecipeCook changes a cook's date, servings, rating, or notes — rating
+// and notes are usually added after eating.
+// PUT /api/recipes/:id/cooks/:cook
+func (h *Handler) updateRecipeCook(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+	cookID, err := strconv.Atoi(c.Param("cook"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid cook id")
+		return
+	}
+	var body updateRecipeCookRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	cookedOn := ""
+	if body.CookedOn != nil {
+		if cookedOn = *body.CookedOn; cookedOn == "" {
+			apiError(c, http.StatusBadRequest, "cooked_on must not be empty")
+			return
+		}
+	}
+	if msg := validateRecipeCook(cookedOn, body.Servings, body.Rating); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	cook, err := queryOne[recipeCook](h.db, c,
+		`UPDATE recipe_cooks SET
+			cooked_on  = COALESCE(@cookedOn::date, cooked_on),
+			servings   = COALESCE(@servings, servings),
+			rating     = CASE WHEN @rating::int IS NULL THEN rating ELSE NULLIF(@rating::int, 0) END,
+			notes      = CASE WHEN @notes::text IS NULL THEN notes ELSE NULLIF(@notes::text, '') END,
+			updated_at = now()
+		 WHERE id = @cookID AND recipe_id = @recipeID AND user_id = @userID
+		 RETURNING *`,
+		pgx.NamedArgs{
+			"cookID": cookID, "recipeID": id, "userID": userID,
+			"cookedOn": body.CookedOn, "servings": body.Servings, "rating": body.Rating, "notes": body.Notes,
+		})
+	if errors.Is(err, pgx.ErrNoRows) {
+		apiError(c, http.StatusNotFound, "cook not found")
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update cook")
+		return
+	}
+	c.JSON(http.StatusOK, cook)
+}
+
+// deleteRecipeCook removes a cook from a recipe's history.
+// DELETE /api/recipes/:id/cooks/:cook
+func (h *Handler) deleteRecipeCook(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+	cookID, err := strconv.Atoi(c.Param("cook"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid cook id")
+		return
+	}
+	result, err := h.db.Exec(c, `
+		DELETE FROM recipe_cooks
+		WHERE id = @cookID AND recipe_id = @recipeID AND user_id = @userID
+	`, pgx.NamedArgs{"cookID": cookID, "recipeID": id, "userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete cook")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		apiError(c, http.StatusNotFound, "cook not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestValidateRecipeCook(t *testing.T) {
+	servings := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name     string
+		cookedOn string
+		servings *float64
+		rating   *int
+		ok       bool
+	}{
+		{"defaults", "", nil, nil, true},
+		{"full", "2026-10-18", servings(4), intPtr(5), true},
+		{"clear rating", "", nil, intPtr(0), true},
+		{"bad date", "18/10/2026", nil, nil, false},
+		{"zero servings", "", servings(0), nil, false},
+		{"rating too high", "", nil, intPtr(6), false},
+		{"negative rating", "", nil, intPtr(-1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := validateRecipeCook(tt.cookedOn, tt.servings, tt.rating)
+			if (msg == "") != tt.ok {
+				t.Errorf("got %q, want ok=%v", msg, tt.ok)
+			}
+		})
+	}
+}
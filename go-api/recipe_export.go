@@ -0,0 +1,355 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// Interchange format identifiers; see docs/recipe-format.md.
+const (
+	recipeFormatID      = "daily-habit.recipe"
+	recipeCollectionID  = "daily-habit.recipes"
+	recipeFormatVersion = 1
+)
+
+/* ─── JSON interchange format ────────────────────────────────────────── */
+
+// exportNutrition is per-serving nutrition in the interchange format.
+type exportNutrition struct {
+	Calories *int     `json:"calories"`
+	ProteinG *float64 `json:"protein_g"`
+	CarbsG   *float64 `json:"carbs_g"`
+	FatG     *float64 `json:"fat_g"`
+}
+
+// exportIngredient is one ingredient in the interchange format. Food links
+// are per-user and aren't exported; grams keeps the conversion.
+type exportIngredient struct {
+	Name  string   `json:"name"`
+	Qty   *float64 `json:"qty"`
+	Uom   *string  `json:"uom"`
+	Note  *string  `json:"note"`
+	Grams *float64 `json:"grams,omitempty"`
+}
+
+// exportStep is one step in the interchange format. Ingredients and Tools
+// reference the recipe's lists by position, as in requests.
+type exportStep struct {
+	Type          string                `json:"type"`
+	Text          string                `json:"text"`
+	TimerSeconds  *int                  `json:"timer_seconds,omitempty"`
+	MeanwhileText *string               `json:"meanwhile_text,omitempty"`
+	Ingredients   []stepIngredientInput `json:"ingredients,omitempty"`
+	Tools         []int                 `json:"tools,omitempty"`
+}
+
+// exportRecipe is one recipe in the interchange format.
+type exportRecipe struct {
+	Format      string             `json:"format,omitempty"` // set on single-recipe files
+	Version     int                `json:"version,omitempty"`
+	Name        string             `json:"name"`
+	Emoji       *string            `json:"emoji"`
+	Category    string             `json:"category"`
+	Notes       *string            `json:"notes"`
+	Servings    float64            `json:"servings"`
+	YieldGrams  *float64           `json:"yield_grams,omitempty"`
+	Nutrition   exportNutrition    `json:"nutrition"`
+	Ingredients []exportIngredient `json:"ingredients"`
+	Tools       []string           `json:"tools"`
+	Steps       []exportStep       `json:"steps"`
+	Tags        []string           `json:"tags,omitempty"`
+}
+
+// exportCollection is a bulk export file.
+type exportCollection struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Recipes    []exportRecipe `json:"recipes"`
+}
+
+// newExportRecipe converts a stored recipe to the interchange format.
+func newExportRecipe(d recipeDetail) exportRecipe {
+	e := exportRecipe{
+		Name: d.Name, Emoji: d.Emoji, Category: d.Category, Notes: d.Notes, Servings: d.Servings,
+		YieldGrams:  d.YieldGrams,
+		Nutrition:   exportNutrition{Calories: d.Calories, ProteinG: d.ProteinG, CarbsG: d.CarbsG, FatG: d.FatG},
+		Ingredients: make([]exportIngredient, 0, len(d.Ingredients)),
+		Tools:       make([]string, 0, len(d.Tools)),
+		Steps:       make([]exportStep, 0, len(d.Steps)),
+		Tags:        d.Tags,
+	}
+	ingredientPos, toolPos := map[int]int{}, map[int]int{}
+	for i, ing := range d.Ingredients {
+		e.Ingredients = append(e.Ingredients, exportIngredient{Name: ing.Name, Qty: ing.Qty, Uom: ing.Uom, Note: ing.Note, Grams: ing.Grams})
+		ingredientPos[ing.ID] = i
+	}
+	for i, t := range d.Tools {
+		e.Tools = append(e.Tools, t.Name)
+		toolPos[t.ID] = i
+	}
+	for _, s := range d.Steps {
+		ingredients, tools := stepRefInputs(s, ingredientPos, toolPos)
+		e.Steps = append(e.Steps, exportStep{
+			Type: s.Type, Text: s.Text, TimerSeconds: s.TimerSeconds, MeanwhileText: s.MeanwhileText,
+			Ingredients: ingredients, Tools: tools,
+		})
+	}
+	return e
+}
+
+// recipeRequestFromExport converts an interchange recipe to a create request.
+func recipeRequestFromExport(e exportRecipe) createRecipeRequest {
+	r := createRecipeRequest{
+		Name: e.Name, Emoji: e.Emoji, Category: e.Category, Notes: e.Notes,
+		Calories: e.Nutrition.Calories, ProteinG: e.Nutrition.ProteinG, CarbsG: e.Nutrition.CarbsG, FatG: e.Nutrition.FatG,
+		Tags: e.Tags,
+	}
+	if e.Servings > 0 {
+		servings := e.Servings
+		r.Servings = &servings
+	}
+	if e.YieldGrams != nil && *e.YieldGrams > 0 {
+		r.YieldGrams = e.YieldGrams
+	}
+	for i, ing := range e.Ingredients {
+		r.Ingredients = append(r.Ingredients, ingredientInput{Name: ing.Name, Qty: ing.Qty, Uom: ing.Uom, Note: ing.Note, Grams: ing.Grams, SortOrder: i})
+	}
+	for i, t := range e.Tools {
+		r.Tools = append(r.Tools, toolInput{Name: t, SortOrder: i})
+	}
+	for i, s := range e.Steps {
+		if s.Type != "timer" || s.TimerSeconds == nil {
+			s.Type, s.TimerSeconds = "instruction", nil
+		}
+		r.Steps = append(r.Steps, stepInput{
+			Type: s.Type, Text: s.Text, TimerSeconds: s.TimerSeconds, MeanwhileText: s.MeanwhileText, SortOrder: i,
+			Ingredients: s.Ingredients, Tools: s.Tools,
+		})
+	}
+	return r
+}
+
+/* ─── Markdown ───────────────────────────────────────────────────────── */
+
+// formatIngredientQty renders a stored quantity — decimals for metric units,
+// kitchen fractions otherwise.
+func formatIngredientQty(qty float64, uom *string) string {
+	if alias, ok := scaleUnitAliases[normalizeUom(uom)]; ok && strings.HasPrefix(alias.System, "metric") {
+		_, s := formatDecimal(qty)
+		return s
+	}
+	if f, s := formatFraction(qty); math.Abs(f-qty) < 0.01 {
+		return s
+	}
+	return strconv.FormatFloat(qty, 'f', -1, 64)
+}
+
+// formatTimer renders seconds as "1 h 15 min", "45 s".
+func formatTimer(seconds int) string {
+	h, m, s := seconds/3600, seconds%3600/60, seconds%60
+	var parts []string
+	if h > 0 {
+		parts = append(parts, fmt.Sprintf("%d h", h))
+	}
+	if m > 0 {
+		parts = append(parts, fmt.Sprintf("%d min", m))
+	}
+	if s > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d s", s))
+	}
+	return strings.Join(parts, " ")
+}
+
+// recipeMarkdown renders a recipe as Markdown.
+func recipeMarkdown(d recipeDetail) string {
+	var b strings.Builder
+	title := d.Name
+	if d.Emoji != nil && *d.Emoji != "" {
+		title = *d.Emoji + " " + title
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+
+	meta := []string{titleCase(d.Category), fmt.Sprintf("%s servings", strconv.FormatFloat(d.Servings, 'f', -1, 64))}
+	if d.Calories != nil {
+		kcal := fmt.Sprintf("%d kcal per serving", *d.Calories)
+		var macros []string
+		for _, m := range []struct {
+			label string
+			v     *float64
+		}{{"P", d.ProteinG}, {"C", d.CarbsG}, {"F", d.FatG}} {
+			if m.v != nil {
+				macros = append(macros, fmt.Sprintf("%s %s g", m.label, strconv.FormatFloat(*m.v, 'f', -1, 64)))
+			}
+		}
+		if len(macros) > 0 {
+			kcal += " (" + strings.Join(macros, " · ") + ")"
+		}
+		meta = append(meta, kcal)
+	}
+	fmt.Fprintf(&b, "*%s*\n", strings.Join(meta, " · "))
+	if d.Notes != nil && strings.TrimSpace(*d.Notes) != "" {
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(*d.Notes))
+	}
+
+	if len(d.Ingredients) > 0 {
+		b.WriteString("\n## Ingredients\n\n")
+		for _, ing := range d.Ingredients {
+			var parts []string
+			if ing.Qty != nil {
+				parts = append(parts, formatIngredientQty(*ing.Qty, ing.Uom))
+			}
+			if ing.Uom != nil && *ing.Uom != "" {
+				parts = append(parts, *ing.Uom)
+			}
+			line := strings.Join(append(parts, ing.Name), " ")
+			if ing.Note != nil && *ing.Note != "" {
+				line += ", " + *ing.Note
+			}
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+	}
+
+	if len(d.Tools) > 0 {
+		b.WriteString("\n## Tools\n\n")
+		for _, t := range d.Tools {
+			fmt.Fprintf(&b, "- %s\n", t.Name)
+		}
+	}
+
+	if len(d.Steps) > 0 {
+		b.WriteString("\n## Steps\n\n")
+		for i, s := range d.Steps {
+			text := s.Text
+			if s.Type == "timer" && s.TimerSeconds != nil {
+				text += fmt.Sprintf(" **(timer: %s)**", formatTimer(*s.TimerSeconds))
+			}
+			fmt.Fprintf(&b, "%d. %s\n", i+1, text)
+			if s.MeanwhileText != nil && *s.MeanwhileText != "" {
+				fmt.Fprintf(&b, "   - *Meanwhile:* %s\n", *s.MeanwhileText)
+			}
+		}
+	}
+	return b.String()
+}
+
+// titleCase upper-cases the first letter of s.
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+var unsafeFilenameRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// exportFilename makes a download filename from a recipe name.
+func exportFilename(name, ext string) string {
+	slug := strings.Trim(strings.ToLower(unsafeFilenameRe.ReplaceAllString(name, "-")), "-")
+	if slug == "" {
+		slug = "recipe"
+	}
+	return slug + "." + ext
+}
+
+/* ─── Handlers ───────────────────────────────────────────────────────── */
+
+// exportFormat reads ?format=markdown|json (default markdown), writing a 400
+// on anything else.
+func exportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", "markdown")
+	if format != "markdown" && format != "json" {
+		apiError(c, http.StatusBadRequest, "format must be markdown or json")
+		return "", false
+	}
+	return format, true
+}
+
+// exportRecipe downloads one recipe as Markdown or interchange JSON.
+// GET /api/recipes/:id/export?format=markdown|json
+func (h *Handler) exportRecipe(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	d, err := fetchRecipeDetail(h, c, id)
+	if err != nil || d.UserID != userID {
+		apiError(c, http.StatusNotFound, "recipe not found")
+		return
+	}
+
+	if format == "json" {
+		e := newExportRecipe(d)
+		e.Format, e.Version = recipeFormatID, recipeFormatVersion
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename(d.Name, "json")))
+		c.JSON(http.StatusOK, e)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename(d.Name, "md")))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(recipeMarkdown(d)))
+}
+
+// exportRecipes downloads every recipe as one Markdown file (recipes
+// separated by rules) or an interchange collection.
+// GET /api/recipes/export?format=markdown|json
+func (h *Handler) exportRecipes(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	ids, err := queryMany[struct {
+		ID int `db:"id"`
+	}](h.db, c,
+		`SELECT id FROM recipes WHERE user_id = @userID ORDER BY lower(name), id`,
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipes")
+		return
+	}
+
+	details := make([]recipeDetail, 0, len(ids))
+	for _, row := range ids {
+		d, err := fetchRecipeDetail(h, c, row.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue // deleted mid-export
+		}
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to fetch recipes")
+			return
+		}
+		details = append(details, d)
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if format == "json" {
+		out := exportCollection{Format: recipeCollectionID, Version: recipeFormatVersion, ExportedAt: time.Now().UTC(), Recipes: make([]exportRecipe, 0, len(details))}
+		for _, d := range details {
+			out.Recipes = append(out.Recipes, newExportRecipe(d))
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="recipes-%s.json"`, date))
+		c.JSON(http.StatusOK, out)
+		return
+	}
+	parts := make([]string, 0, len(details))
+	for _, d := range details {
+		parts = append(parts, recipeMarkdown(d))
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="recipes-%s.md"`, date))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(strings.Join(parts, "\n---\n\n")))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// sampleRecipeDetail is a small recipe with every sub-list populated.
+func sampleRecipeDetail() recipeDetail {
+	f64 := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+	cal, timer := 410, 1500
+	return recipeDetail{
+		recipe: recipe{
+			Name: "Sheet-Pan Gnocchi", Emoji: str("🍝"), Category: "dinner", Servings: 4,
+			Calories: &cal, ProteinG: f64(12), Notes: str("Weeknight favorite."),
+		},
+		Ingredients: []recipeIngredient{
+			{Name: "gnocchi", Qty: f64(500), Uom: str("g")},
+			{Name: "olive oil", Qty: f64(1.5), Uom: str("tbsp"), Grams: f64(20)},
+			{Name: "cherry tomatoes", Qty: f64(2), Uom: str("cups"), Note: str("halved")},
+			{Name: "salt"},
+		},
+		Tools: []recipeTool{{Name: "Sheet pan"}},
+		Steps: []recipeStep{
+			{Type: "instruction", Text: "Heat the oven to 220°C."},
+			{Type: "timer", Text: "Roast until golden.", TimerSeconds: &timer, MeanwhileText: str("Tear the basil.")},
+		},
+	}
+}
+
+func TestRecipeMarkdown(t *testing.T) {
+	md := recipeMarkdown(sampleRecipeDetail())
+	for _, want := range []string{
+		"# 🍝 Sheet-Pan Gnocchi\n",
+		"*Dinner · 4 servings · 410 kcal per serving (P 12 g)*",
+		"- 500 g gnocchi\n",
+		"- 1 1/2 tbsp olive oil\n",
+		"- 2 cups cherry tomatoes, halved\n",
+		"- salt\n",
+		"## Tools\n\n- Sheet pan\n",
+		"2. Roast until golden. **(timer: 25 min)**\n   - *Meanwhile:* Tear the basil.\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("missing %q in:\n%s", want, md)
+		}
+	}
+}
+
+func TestExportRecipeRoundTrip(t *testing.T) {
+	e := newExportRecipe(sampleRecipeDetail())
+	e.Format, e.Version = recipeFormatID, recipeFormatVersion
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes, issues := parseRecipeFile("gnocchi.json", b)
+	if len(issues) != 0 || len(recipes) != 1 {
+		t.Fatalf("got %d recipes, issues %v", len(recipes), issues)
+	}
+	r := recipes[0].Recipe
+	if recipes[0].Source != "daily-habit" || r.Name != "Sheet-Pan Gnocchi" || *r.Servings != 4 || *r.Calories != 410 {
+		t.Errorf("recipe: got %+v", r)
+	}
+	if len(r.Ingredients) != 4 || *r.Ingredients[1].Grams != 20 || r.Ingredients[3].Qty != nil {
+		t.Errorf("ingredients: got %+v", r.Ingredients)
+	}
+	if len(r.Steps) != 2 || r.Steps[1].Type != "timer" || *r.Steps[1].MeanwhileText != "Tear the basil." {
+		t.Errorf("steps: got %+v", r.Steps)
+	}
+}
+
+func TestFormatTimer(t *testing.T) {
+	cases := map[int]string{45: "45 s", 600: "10 min", 4500: "1 h 15 min", 3605: "1 h 5 s", 0: "0 s"}
+	for seconds, want := range cases {
+		if got := formatTimer(seconds); got != want {
+			t.Errorf("%d: got %q, want %q", seconds, got, want)
+		}
+	}
+}
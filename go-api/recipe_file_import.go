@@ -0,0 +1,633 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxRecipeImportBytes caps an uploaded recipe file and each archive entry;
+// Paprika exports embed photos, so they run larger than tracker CSVs.
+const maxRecipeImportBytes = 50 << 20
+
+// maxRecipeImportCount caps how many recipes one file may hold.
+const maxRecipeImportCount = 2000
+
+// maxRecipeZipDepth caps how deeply zips may nest: an export holding one zip
+// per recipe (Tandoor) is as deep as real exports go.
+const maxRecipeZipDepth = 2
+
+// parsedRecipe is one recipe read from an import file.
+type parsedRecipe struct {
+	Recipe createRecipeRequest
+	Source string // paprika, mealie, tandoor, or daily-habit
+	File   string // archive entry or upload name
+}
+
+/* ─── Paprika ────────────────────────────────────────────────────────── */
+
+// paprikaRecipe is a Paprika .paprikarecipe entry (gzipped JSON). Ingredients
+// and directions are newline-separated text.
+type paprikaRecipe struct {
+	Name            string   `json:"name"`
+	Ingredients     string   `json:"ingredients"`
+	Directions      string   `json:"directions"`
+	Notes           string   `json:"notes"`
+	Description     string   `json:"description"`
+	Servings        string   `json:"servings"`
+	NutritionalInfo string   `json:"nutritional_info"`
+	Categories      []string `json:"categories"`
+	SourceURL       string   `json:"source_url"`
+}
+
+var nutritionTextRes = map[string]*regexp.Regexp{
+	"calories": regexp.MustCompile(`(?i)calories\D{0,5}(\d+(?:\.\d+)?)`),
+	"protein":  regexp.MustCompile(`(?i)protein\D{0,5}(\d+(?:\.\d+)?)`),
+	"carbs":    regexp.MustCompile(`(?i)carb(?:ohydrate)?s?\D{0,5}(\d+(?:\.\d+)?)`),
+	"fat":      regexp.MustCompile(`(?i)(?:total )?fat\D{0,5}(\d+(?:\.\d+)?)`),
+}
+
+// nutritionFromText reads "Calories: 240, Protein: 5g"-style free text.
+func nutritionFromText(s string, r *createRecipeRequest) {
+	find := func(key string) *float64 {
+		if m := nutritionTextRes[key].FindStringSubmatch(s); m != nil {
+			return schemaNumber(m[1])
+		}
+		return nil
+	}
+	if cal := find("calories"); cal != nil {
+		v := int(*cal + 0.5)
+		r.Calories = &v
+	}
+	r.ProteinG, r.CarbsG, r.FatG = find("protein"), find("carbs"), find("fat")
+}
+
+// textLines splits text into trimmed non-empty lines.
+func textLines(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// joinNotes joins non-empty note parts with blank lines, or returns nil.
+func joinNotes(parts ...string) *string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	s := strings.Join(kept, "\n\n")
+	return &s
+}
+
+// sourceNote formats a source URL for notes.
+func sourceNote(url string) string {
+	if url = strings.TrimSpace(url); url == "" {
+		return ""
+	}
+	return "Source: " + url
+}
+
+func (p paprikaRecipe) toRequest() createRecipeRequest {
+	r := createRecipeRequest{
+		Name:     strings.TrimSpace(p.Name),
+		Category: schemaCategory(strings.Join(p.Categories, " ")),
+		Servings: schemaNumber(p.Servings),
+		Notes:    joinNotes(p.Description, p.Notes, sourceNote(p.SourceURL)),
+	}
+	nutritionFromText(p.NutritionalInfo, &r)
+	for _, line := range textLines(p.Ingredients) {
+		// Section headers ("For the sauce:") aren't ingredients.
+		if strings.HasSuffix(line, ":") && !strings.ContainsAny(line, "0123456789") {
+			continue
+		}
+		ing := parseIngredientLine(line)
+		ing.SortOrder = len(r.Ingredients)
+		r.Ingredients = append(r.Ingredients, ing)
+	}
+	for i, line := range textLines(p.Directions) {
+		step := stepFromText(line)
+		step.SortOrder = i
+		r.Steps = append(r.Steps, step)
+	}
+	return r
+}
+
+/* ─── Mealie ─────────────────────────────────────────────────────────── */
+
+// mealieRecipe is a Mealie recipe export. Yield and nutrition values are
+// strings or numbers depending on version.
+type mealieRecipe struct {
+	Name               string             `json:"name"`
+	Description        string             `json:"description"`
+	RecipeYield        any                `json:"recipeYield"`
+	RecipeServings     any                `json:"recipeServings"`
+	RecipeIngredient   []mealieIngredient `json:"recipeIngredient"`
+	RecipeInstructions []struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"recipeInstructions"`
+	Tools          []struct{ Name string } `json:"tools"`
+	RecipeCategory []struct{ Name string } `json:"recipeCategory"`
+	Nutrition      *struct {
+		Calories            any `json:"calories"`
+		ProteinContent      any `json:"proteinContent"`
+		CarbohydrateContent any `json:"carbohydrateContent"`
+		FatContent          any `json:"fatContent"`
+	} `json:"nutrition"`
+	Notes []struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"notes"`
+	OrgURL string `json:"orgURL"`
+}
+
+// mealieIngredient is parsed (food/unit/quantity) or, for recipes Mealie
+// never parsed, only a note or original text line.
+type mealieIngredient struct {
+	Quantity     *float64               `json:"quantity"`
+	Unit         *struct{ Name string } `json:"unit"`
+	Food         *struct{ Name string } `json:"food"`
+	Note         string                 `json:"note"`
+	OriginalText string                 `json:"originalText"`
+	Display      string                 `json:"display"`
+}
+
+func (m mealieRecipe) toRequest() createRecipeRequest {
+	var notes []string
+	notes = append(notes, m.Description)
+	for _, n := range m.Notes {
+		notes = append(notes, strings.TrimSpace(n.Title+"\n"+n.Text))
+	}
+	notes = append(notes, sourceNote(m.OrgURL))
+	var categories []string
+	for _, c := range m.RecipeCategory {
+		categories = append(categories, c.Name)
+	}
+	r := createRecipeRequest{
+		Name:     strings.TrimSpace(m.Name),
+		Category: schemaCategory(strings.Join(categories, " ")),
+		Notes:    joinNotes(notes...),
+	}
+	if s := schemaNumber(m.RecipeServings); s != nil && *s > 0 {
+		r.Servings = s
+	} else {
+		r.Servings = schemaNumber(m.RecipeYield)
+	}
+	if n := m.Nutrition; n != nil {
+		if cal := schemaNumber(n.Calories); cal != nil {
+			v := int(*cal + 0.5)
+			r.Calories = &v
+		}
+		r.ProteinG, r.CarbsG, r.FatG = schemaNumber(n.ProteinContent), schemaNumber(n.CarbohydrateContent), schemaNumber(n.FatContent)
+	}
+
+	for _, mi := range m.RecipeIngredient {
+		var ing ingredientInput
+		if mi.Food != nil && mi.Food.Name != "" {
+			ing = ingredientInput{Name: mi.Food.Name}
+			if mi.Quantity != nil && *mi.Quantity > 0 {
+				ing.Qty = mi.Quantity
+			}
+			if mi.Unit != nil && mi.Unit.Name != "" {
+				unit := mi.Unit.Name
+				ing.Uom = &unit
+			}
+			if note := strings.TrimSpace(mi.Note); note != "" {
+				ing.Note = &note
+			}
+		} else {
+			line := firstNonEmpty(mi.Note, mi.OriginalText, mi.Display)
+			if line == "" {
+				continue
+			}
+			// Unparsed lines carry a placeholder quantity of 1; trust the text.
+			ing = parseIngredientLine(line)
+		}
+		ing.SortOrder = len(r.Ingredients)
+		r.Ingredients = append(r.Ingredients, ing)
+	}
+	for i, t := range m.Tools {
+		r.Tools = append(r.Tools, toolInput{Name: t.Name, SortOrder: i})
+	}
+	for _, in := range m.RecipeInstructions {
+		if text := strings.TrimSpace(in.Text); text != "" {
+			step := stepFromText(text)
+			step.SortOrder = len(r.Steps)
+			r.Steps = append(r.Steps, step)
+		}
+	}
+	return r
+}
+
+// firstNonEmpty returns the first non-blank value, trimmed.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+/* ─── Tandoor ────────────────────────────────────────────────────────── */
+
+// tandoorRecipe is a Tandoor recipe.json. Ingredients belong to steps, and a
+// step's time is in minutes.
+type tandoorRecipe struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Servings    float64                 `json:"servings"`
+	Keywords    []struct{ Name string } `json:"keywords"`
+	SourceURL   string                  `json:"source_url"`
+	Steps       []struct {
+		Name        string              `json:"name"`
+		Instruction string              `json:"instruction"`
+		Time        int                 `json:"time"`
+		Ingredients []tandoorIngredient `json:"ingredients"`
+	} `json:"steps"`
+	Nutrition *struct {
+		Calories      any `json:"calories"`
+		Proteins      any `json:"proteins"`
+		Carbohydrates any `json:"carbohydrates"`
+		Fats          any `json:"fats"`
+	} `json:"nutrition"`
+}
+
+type tandoorIngredient struct {
+	Food     *struct{ Name string } `json:"food"`
+	Unit     *struct{ Name string } `json:"unit"`
+	Amount   float64                `json:"amount"`
+	Note     string                 `json:"note"`
+	IsHeader bool                   `json:"is_header"`
+	NoAmount bool                   `json:"no_amount"`
+}
+
+func (t tandoorRecipe) toRequest() createRecipeRequest {
+	var keywords []string
+	for _, k := range t.Keywords {
+		keywords = append(keywords, k.Name)
+	}
+	r := createRecipeRequest{
+		Name:     strings.TrimSpace(t.Name),
+		Category: schemaCategory(strings.Join(keywords, " ")),
+		Notes:    joinNotes(t.Description, sourceNote(t.SourceURL)),
+	}
+	if t.Servings > 0 {
+		servings := t.Servings
+		r.Servings = &servings
+	}
+	if n := t.Nutrition; n != nil {
+		if cal := schemaNumber(n.Calories); cal != nil {
+			v := int(*cal + 0.5)
+			r.Calories = &v
+		}
+		r.ProteinG, r.CarbsG, r.FatG = schemaNumber(n.Proteins), schemaNumber(n.Carbohydrates), schemaNumber(n.Fats)
+	}
+
+	for _, s := range t.Steps {
+		for _, ti := range s.Ingredients {
+			if ti.IsHeader || ti.Food == nil || ti.Food.Name == "" {
+				continue
+			}
+			ing := ingredientInput{Name: ti.Food.Name, SortOrder: len(r.Ingredients)}
+			if !ti.NoAmount && ti.Amount > 0 {
+				amount := ti.Amount
+				ing.Qty = &amount
+			}
+			if ti.Unit != nil && ti.Unit.Name != "" {
+				unit := ti.Unit.Name
+				ing.Uom = &unit
+			}
+			if note := strings.TrimSpace(ti.Note); note != "" {
+				ing.Note = &note
+			}
+			r.Ingredients = append(r.Ingredients, ing)
+		}
+		text := firstNonEmpty(s.Instruction, s.Name)
+		if text == "" {
+			continue
+		}
+		step := stepFromText(text)
+		if s.Time > 0 {
+			seconds := s.Time * 60
+			step.Type, step.TimerSeconds = "timer", &seconds
+		}
+		step.SortOrder = len(r.Steps)
+		r.Steps = append(r.Steps, step)
+	}
+	return r
+}
+
+/* ─── Detection ──────────────────────────────────────────────────────── */
+
+// parseRecipeObject detects which format one JSON object is in and converts it.
+func parseRecipeObject(raw json.RawMessage) ([]parsedRecipe, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("not a recipe object")
+	}
+	has := func(k string) bool { _, ok := keys[k]; return ok }
+	var format string
+	json.Unmarshal(keys["format"], &format)
+
+	switch {
+	case format == recipeCollectionID:
+		var col exportCollection
+		if err := json.Unmarshal(raw, &col); err != nil {
+			return nil, fmt.Errorf("invalid recipe collection: %v", err)
+		}
+		out := make([]parsedRecipe, 0, len(col.Recipes))
+		for _, e := range col.Recipes {
+			out = append(out, parsedRecipe{Recipe: recipeRequestFromExport(e), Source: "daily-habit"})
+		}
+		return out, nil
+	case format == recipeFormatID:
+		var e exportRecipe
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return nil, fmt.Errorf("invalid recipe: %v", err)
+		}
+		return []parsedRecipe{{Recipe: recipeRequestFromExport(e), Source: "daily-habit"}}, nil
+	case has("directions"):
+		var p paprikaRecipe
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("invalid Paprika recipe: %v", err)
+		}
+		return []parsedRecipe{{Recipe: p.toRequest(), Source: "paprika"}}, nil
+	case has("recipeIngredient") || has("recipeInstructions"):
+		var m mealieRecipe
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("invalid Mealie recipe: %v", err)
+		}
+		return []parsedRecipe{{Recipe: m.toRequest(), Source: "mealie"}}, nil
+	case has("steps") && (has("working_time") || has("keywords") || has("servings_text")):
+		var t tandoorRecipe
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, fmt.Errorf("invalid Tandoor recipe: %v", err)
+		}
+		return []parsedRecipe{{Recipe: t.toRequest(), Source: "tandoor"}}, nil
+	}
+	return nil, fmt.Errorf("unrecognized recipe format")
+}
+
+// parseRecipeJSON parses a JSON document holding one recipe, a list of
+// recipes, or an interchange collection.
+func parseRecipeJSON(data []byte) ([]parsedRecipe, error) {
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err == nil {
+		var out []parsedRecipe
+		for i, raw := range list {
+			recipes, err := parseRecipeObject(raw)
+			if err != nil {
+				return out, fmt.Errorf("item %d: %v", i+1, err)
+			}
+			out = append(out, recipes...)
+		}
+		return out, nil
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("not valid JSON")
+	}
+	return parseRecipeObject(data)
+}
+
+// gunzip decompresses a gzip stream, capped at maxRecipeImportBytes.
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, maxRecipeImportBytes))
+}
+
+// recipeFileParser collects recipes and issues across nested archives.
+type recipeFileParser struct {
+	recipes []parsedRecipe
+	issues  []importIssue
+}
+
+// parse reads one file: a zip (Paprika .paprikarecipes, Mealie or Tandoor
+// export — Tandoor nests one zip per recipe), a gzipped .paprikarecipe, or
+// JSON. zipDepth is how many zips enclose the file. Unreadable entries become
+// issues rather than failing the upload.
+func (p *recipeFileParser) parse(name string, data []byte, zipDepth int) {
+	if len(p.recipes) >= maxRecipeImportCount {
+		return
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		if zipDepth >= maxRecipeZipDepth {
+			p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: zip archives nested more than %d deep are not supported", name, maxRecipeZipDepth)})
+			return
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: unreadable zip archive", name)})
+			return
+		}
+		for _, f := range zr.File {
+			ext := strings.ToLower(path.Ext(f.Name))
+			if f.FileInfo().IsDir() || (ext != ".json" && ext != ".zip" && ext != ".paprikarecipe") {
+				continue // photos and other assets
+			}
+			rc, err := f.Open()
+			if err != nil {
+				p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: %v", f.Name, err)})
+				continue
+			}
+			entry, err := io.ReadAll(io.LimitReader(rc, maxRecipeImportBytes))
+			rc.Close()
+			if err != nil {
+				p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: %v", f.Name, err)})
+				continue
+			}
+			p.parse(f.Name, entry, zipDepth+1)
+		}
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		plain, err := gunzip(data)
+		if err != nil {
+			p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: unreadable gzip data", name)})
+			return
+		}
+		if bytes.HasPrefix(plain, []byte{0x1f, 0x8b}) {
+			p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: nested gzip data is not supported", name)})
+			return
+		}
+		p.parse(name, plain, zipDepth)
+	default:
+		recipes, err := parseRecipeJSON(data)
+		for _, r := range recipes {
+			if r.Recipe.Name == "" {
+				p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: recipe has no name", name)})
+				continue
+			}
+			r.File = name
+			p.recipes = append(p.recipes, r)
+		}
+		if err != nil {
+			p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("%s: %v", name, err)})
+		}
+	}
+	if len(p.recipes) > maxRecipeImportCount {
+		p.recipes = p.recipes[:maxRecipeImportCount]
+		p.issues = append(p.issues, importIssue{Message: fmt.Sprintf("only the first %d recipes are imported", maxRecipeImportCount)})
+	}
+}
+
+// parseRecipeFile parses an uploaded recipe file in any supported format.
+func parseRecipeFile(name string, data []byte) ([]parsedRecipe, []importIssue) {
+	p := &recipeFileParser{}
+	p.parse(name, data, 0)
+	return p.recipes, p.issues
+}
+
+/* ─── Handlers ───────────────────────────────────────────────────────── */
+
+// recipeFileImportItem is one recipe in an import preview or result.
+type recipeFileImportItem struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"`
+	File        string `json:"file"`
+	Ingredients int    `json:"ingredients"`
+	Steps       int    `json:"steps"`
+	Status      string `json:"status"` // new or duplicate (same name as an existing recipe)
+	ID          *int   `json:"id,omitempty"`
+}
+
+// recipeFileImportResponse reports what an import found and, unless DryRun,
+// what it created.
+type recipeFileImportResponse struct {
+	DryRun   bool                   `json:"dry_run"`
+	Recipes  []recipeFileImportItem `json:"recipes"`
+	Issues   []importIssue          `json:"issues"`
+	Imported int                    `json:"imported"`
+}
+
+// previewRecipeFileImport parses an uploaded recipe file and lists what
+// would be imported, without writing anything.
+// POST /api/recipes/import/file/preview (multipart: file)
+func (h *Handler) previewRecipeFileImport(c *gin.Context) {
+	h.runRecipeFileImport(c, true)
+}
+
+// commitRecipeFileImport imports every new recipe in an uploaded file in one
+// transaction. Recipes named like an existing one are skipped, so
+// re-uploading the same export is safe.
+// POST /api/recipes/import/file/commit (multipart: file)
+func (h *Handler) commitRecipeFileImport(c *gin.Context) {
+	h.runRecipeFileImport(c, false)
+}
+
+// runRecipeFileImport is the shared preview/commit flow for recipe files.
+func (h *Handler) runRecipeFileImport(c *gin.Context, dryRun bool) {
+	userID := c.GetInt("user_id")
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	if fh.Size > maxRecipeImportBytes {
+		apiError(c, http.StatusBadRequest, "file is too large (max 50 MB)")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "could not read file")
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "could not read file")
+		return
+	}
+
+	recipes, issues := parseRecipeFile(fh.Filename, data)
+	if len(recipes) == 0 {
+		msg := "no recipes found in file"
+		if len(issues) > 0 {
+			msg += ": " + issues[0].Message
+		}
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	existing, err := queryMany[struct {
+		Name string `db:"name"`
+	}](h.db, c,
+		`SELECT lower(name) AS name FROM recipes WHERE user_id = @userID`,
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check existing recipes")
+		return
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		seen[e.Name] = true
+	}
+
+	resp := recipeFileImportResponse{DryRun: dryRun, Recipes: make([]recipeFileImportItem, 0, len(recipes)), Issues: issues}
+	if resp.Issues == nil {
+		resp.Issues = []importIssue{}
+	}
+	for _, r := range recipes {
+		item := recipeFileImportItem{
+			Name: r.Recipe.Name, Source: r.Source, File: r.File,
+			Ingredients: len(r.Recipe.Ingredients), Steps: len(r.Recipe.Steps), Status: "new",
+		}
+		key := strings.ToLower(r.Recipe.Name)
+		if seen[key] {
+			item.Status = "duplicate"
+		}
+		seen[key] = true
+		resp.Recipes = append(resp.Recipes, item)
+	}
+
+	if !dryRun {
+		tx, err := h.db.Begin(c)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to start transaction")
+			return
+		}
+		defer tx.Rollback(c)
+
+		for i, r := range recipes {
+			if resp.Recipes[i].Status != "new" {
+				continue
+			}
+			id, err := insertRecipe(tx, c, userID, r.Recipe)
+			if err != nil {
+				apiError(c, http.StatusInternalServerError, fmt.Sprintf("failed to import %q", r.Recipe.Name))
+				return
+			}
+			resp.Recipes[i].ID = &id
+			resp.Imported++
+		}
+		if err := tx.Commit(c); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to commit")
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// gzipBytes gzips data, as Paprika does for each recipe.
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipBytes builds a zip archive from name → contents.
+func zipBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/recipes/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseRecipeFile_Paprika(t *testing.T) {
+	pancakes, _ := json.Marshal(paprikaRecipe{
+		Name:            "Pancakes",
+		Ingredients:     "For the batter:\n1 1/2 cups flour\n2 eggs\n\n1 cup milk",
+		Directions:      "Whisk everything together.\nCook 2 minutes per side.",
+		Servings:        "4 servings",
+		NutritionalInfo: "Calories: 220\nProtein: 7g\nTotal Fat: 5 g\nCarbohydrates: 35g",
+		Categories:      []string{"Breakfast"},
+		SourceURL:       "https://example.com/pancakes",
+	})
+	archive := zipBytes(t, map[string][]byte{
+		"Pancakes.paprikarecipe": gzipBytes(t, pancakes),
+		"Broken.paprikarecipe":   []byte("not gzip"),
+	})
+
+	recipes, issues := parseRecipeFile("My Recipes.paprikarecipes", archive)
+	if len(recipes) != 1 || len(issues) != 1 {
+		t.Fatalf("got %d recipes, issues %v", len(recipes), issues)
+	}
+	r := recipes[0].Recipe
+	if recipes[0].Source != "paprika" || r.Category != "breakfast" || *r.Servings != 4 {
+		t.Errorf("recipe: got %+v", r)
+	}
+	if *r.Calories != 220 || *r.ProteinG != 7 || *r.FatG != 5 || *r.CarbsG != 35 {
+		t.Errorf("nutrition: got %d %v %v %v", *r.Calories, *r.ProteinG, *r.FatG, *r.CarbsG)
+	}
+	if len(r.Ingredients) != 3 || r.Ingredients[0].Name != "flour" || r.Ingredients[2].SortOrder != 2 {
+		t.Errorf("ingredients: got %+v", r.Ingredients)
+	}
+	if len(r.Steps) != 2 || r.Steps[1].Type != "timer" || *r.Steps[1].TimerSeconds != 120 {
+		t.Errorf("steps: got %+v", r.Steps)
+	}
+	if r.Notes == nil || *r.Notes != "Source: https://example.com/pancakes" {
+		t.Errorf("notes: got %v", r.Notes)
+	}
+
+	// A single exported recipe is just the gzipped JSON.
+	if recipes, _ := parseRecipeFile("Pancakes.paprikarecipe", gzipBytes(t, pancakes)); len(recipes) != 1 {
+		t.Errorf("single paprikarecipe: got %d", len(recipes))
+	}
+}
+
+func TestParseRecipeFile_Mealie(t *testing.T) {
+	recipes, issues := parseRecipeFile("mealie.json", readFixture(t, "mealie.json"))
+	if len(recipes) != 1 || len(issues) != 0 {
+		t.Fatalf("got %d recipes, issues %v", len(recipes), issues)
+	}
+	r := recipes[0].Recipe
+	if recipes[0].Source != "mealie" || r.Name != "Lemon Garlic Salmon" || r.Category != "dinner" || *r.Servings != 4 {
+		t.Errorf("recipe: got %+v", r)
+	}
+	if *r.Calories != 380 || *r.FatG != 25.5 {
+		t.Errorf("nutrition: got %d %v", *r.Calories, *r.FatG)
+	}
+	if ing := r.Ingredients[0]; ing.Name != "salmon fillet" || *ing.Qty != 1.5 || *ing.Uom != "pound" || *ing.Note != "skin on" {
+		t.Errorf("parsed ingredient: got %+v", ing)
+	}
+	if ing := r.Ingredients[2]; ing.Name != "garlic" || *ing.Qty != 3 || *ing.Uom != "cloves" {
+		t.Errorf("unparsed ingredient: got %+v", ing)
+	}
+	if ing := r.Ingredients[3]; ing.Qty != nil || ing.Name != "Salt to taste" {
+		t.Errorf("no-quantity ingredient: got %+v", ing)
+	}
+	if len(r.Tools) != 1 || len(r.Steps) != 2 || *r.Steps[1].TimerSeconds != 720 {
+		t.Errorf("tools/steps: got %+v %+v", r.Tools, r.Steps)
+	}
+	if r.Notes == nil || *r.Notes != "Sheet-pan salmon.\n\nTip\nUse wild salmon.\n\nSource: https://example.com/salmon" {
+		t.Errorf("notes: got %q", *r.Notes)
+	}
+}
+
+func TestParseRecipeFile_Tandoor(t *testing.T) {
+	// Tandoor exports a zip holding one zip per recipe.
+	inner := zipBytes(t, map[string][]byte{"recipe.json": readFixture(t, "tandoor.json"), "image.jpg": {0xff, 0xd8}})
+	archive := zipBytes(t, map[string][]byte{"1.zip": inner})
+
+	recipes, issues := parseRecipeFile("export.zip", archive)
+	if len(recipes) != 1 || len(issues) != 0 {
+		t.Fatalf("got %d recipes, issues %v", len(recipes), issues)
+	}
+	r := recipes[0].Recipe
+	if recipes[0].Source != "tandoor" || r.Category != "breakfast" || *r.Servings != 2 || *r.Calories != 310 {
+		t.Errorf("recipe: got %+v", r)
+	}
+	if len(r.Ingredients) != 3 || *r.Ingredients[1].Note != "any kind" || r.Ingredients[2].Qty != nil {
+		t.Errorf("ingredients: got %+v", r.Ingredients)
+	}
+	if len(r.Steps) != 2 || r.Steps[0].Type != "instruction" || *r.Steps[1].TimerSeconds != 480*60 {
+		t.Errorf("steps: got %+v", r.Steps)
+	}
+}
+
+func TestParseRecipeFile_Unrecognized(t *testing.T) {
+	recipes, issues := parseRecipeFile("notes.json", []byte(`{"title": "not a recipe"}`))
+	if len(recipes) != 0 || len(issues) != 1 {
+		t.Errorf("got %d recipes, issues %v", len(recipes), issues)
+	}
+	if _, issues := parseRecipeFile("bad.json", []byte(`{`)); len(issues) != 1 {
+		t.Errorf("invalid json: issues %v", issues)
+	}
+}
+
+func TestParseRecipeFile_NestingLimits(t *testing.T) {
+	inner := zipBytes(t, map[string][]byte{"recipe.json": readFixture(t, "tandoor.json")})
+	middle := zipBytes(t, map[string][]byte{"1.zip": inner})
+	archive := zipBytes(t, map[string][]byte{"export.zip": middle})
+
+	recipes, issues := parseRecipeFile("deep.zip", archive)
+	if len(recipes) != 0 || len(issues) != 1 || !strings.Contains(issues[0].Message, "nested more than 2 deep") {
+		t.Errorf("zip depth: got %d recipes, issues %v", len(recipes), issues)
+	}
+
+	twice := gzipBytes(t, gzipBytes(t, readFixture(t, "tandoor.json")))
+	recipes, issues = parseRecipeFile("twice.paprikarecipe", twice)
+	if len(recipes) != 0 || len(issues) != 1 || !strings.Contains(issues[0].Message, "nested gzip") {
+		t.Errorf("nested gzip: got %d recipes, issues %v", len(recipes), issues)
+	}
+}
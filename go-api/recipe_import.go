@@ -0,0 +1,732 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxImportPageBytes caps how much of a fetched page is read.
+const maxImportPageBytes = 5 << 20
+
+// maxImportRequestBytes caps an import request body: a pasted page up to
+// maxImportPageBytes, with room for its JSON escaping.
+const maxImportRequestBytes = 2 * maxImportPageBytes
+
+// maxImportAIText caps the page text sent to the AI fallback, in bytes.
+const maxImportAIText = 15000
+
+/* ─── Fetching ───────────────────────────────────────────────────────── */
+
+// recipeFetchClient fetches recipe pages. It refuses to connect to private
+// and loopback addresses so the import can't be used to probe our network.
+var recipeFetchClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: publicAddressOnly}).DialContext,
+	},
+}
+
+// publicAddressOnly is a net.Dialer Control hook rejecting non-public IPs.
+func publicAddressOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("address %s is not allowed", host)
+	}
+	return nil
+}
+
+// validImportURL reports whether rawURL is an absolute http(s) address.
+func validImportURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// fetchRecipePage downloads a page for import.
+func fetchRecipePage(c *gin.Context, u string) (string, error) {
+	req, err := http.NewRequestWithContext(c.Request.Context(), "GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; daily-habit recipe import)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := recipeFetchClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("page returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImportPageBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+/* ─── Structured data ────────────────────────────────────────────────── */
+
+// isSchemaType reports whether a JSON-LD node's @type includes typ.
+func isSchemaType(m map[string]any, typ string) bool {
+	matches := func(v any) bool {
+		s, _ := v.(string)
+		return s == typ || strings.HasSuffix(s, "/"+typ) || strings.HasSuffix(s, ":"+typ)
+	}
+	switch t := m["@type"].(type) {
+	case string:
+		return matches(t)
+	case []any:
+		for _, v := range t {
+			if matches(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findSchemaRecipe searches decoded JSON-LD for a Recipe node, looking
+// through arrays, @graph, and mainEntity.
+func findSchemaRecipe(v any) map[string]any {
+	switch t := v.(type) {
+	case []any:
+		for _, item := range t {
+			if r := findSchemaRecipe(item); r != nil {
+				return r
+			}
+		}
+	case map[string]any:
+		if isSchemaType(t, "Recipe") {
+			return t
+		}
+		for _, key := range []string{"@graph", "mainEntity", "mainEntityOfPage"} {
+			if r := findSchemaRecipe(t[key]); r != nil {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// jsonLDRecipe returns the first Recipe in the page's JSON-LD scripts.
+func jsonLDRecipe(root *html.Node) map[string]any {
+	var found map[string]any
+	walkHTML(root, func(n *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n.DataAtom == atom.Script && strings.Contains(htmlAttr(n, "type"), "ld+json") {
+			var v any
+			if err := json.Unmarshal([]byte(nodeText(n, false)), &v); err == nil {
+				found = findSchemaRecipe(v)
+			}
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// microdataRecipe returns the first itemscope with a schema.org Recipe
+// itemtype, converted to the same shape as JSON-LD.
+func microdataRecipe(root *html.Node) map[string]any {
+	var found map[string]any
+	walkHTML(root, func(n *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if hasAttr(n, "itemscope") && strings.HasSuffix(strings.TrimRight(htmlAttr(n, "itemtype"), "/"), "/Recipe") {
+			found = microdataItem(n)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// microdataItem collects the itemprops of an itemscope. Values are strings,
+// or maps for nested items; repeated props become lists.
+func microdataItem(scope *html.Node) map[string]any {
+	item := map[string]any{}
+	if t := htmlAttr(scope, "itemtype"); t != "" {
+		item["@type"] = t[strings.LastIndex(t, "/")+1:]
+	}
+	for c := scope.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, func(n *html.Node) bool {
+			if props := strings.Fields(htmlAttr(n, "itemprop")); len(props) > 0 {
+				var value any
+				if hasAttr(n, "itemscope") {
+					value = microdataItem(n)
+				} else {
+					value = microdataValue(n)
+				}
+				for _, p := range props {
+					list, _ := item[p].([]any)
+					item[p] = append(list, value)
+				}
+			}
+			return !hasAttr(n, "itemscope")
+		})
+	}
+	return item
+}
+
+// microdataValue is the value of a non-scope itemprop element.
+func microdataValue(n *html.Node) string {
+	if v := htmlAttr(n, "content"); v != "" {
+		return v
+	}
+	switch n.DataAtom {
+	case atom.A, atom.Link:
+		return htmlAttr(n, "href")
+	case atom.Img:
+		return htmlAttr(n, "src")
+	case atom.Time:
+		if v := htmlAttr(n, "datetime"); v != "" {
+			return v
+		}
+	case atom.Data, atom.Meter:
+		return htmlAttr(n, "value")
+	}
+	return nodeText(n, true)
+}
+
+/* ─── HTML helpers ───────────────────────────────────────────────────── */
+
+// walkHTML visits element nodes depth-first; visit returns false to skip
+// the node's children.
+func walkHTML(n *html.Node, visit func(*html.Node) bool) {
+	if n.Type == html.ElementNode && !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, visit)
+	}
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// blockElements start a new line in extracted text.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Li: true, atom.Br: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.Section: true, atom.Article: true,
+}
+
+// skippedElements never contribute visible text.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Form: true, atom.Svg: true,
+}
+
+// nodeText returns the text under n. When visible, script-like elements are
+// skipped and block elements are separated by newlines.
+func nodeText(n *html.Node, visible bool) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if visible && skippedElements[n.DataAtom] {
+				return
+			}
+			if visible && blockElements[n.DataAtom] {
+				b.WriteString("\n")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	if !visible {
+		return b.String()
+	}
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// cleanSchemaText strips markup and entities that sites leave in JSON-LD
+// strings and collapses whitespace.
+func cleanSchemaText(s string) string {
+	s = html.UnescapeString(htmlTagRe.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+/* ─── Schema → recipe ────────────────────────────────────────────────── */
+
+// schemaText returns the first text value of a property.
+func schemaText(v any) string {
+	switch t := v.(type) {
+	case string:
+		return cleanSchemaText(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case []any:
+		for _, item := range t {
+			if s := schemaText(item); s != "" {
+				return s
+			}
+		}
+	case map[string]any:
+		for _, key := range []string{"name", "text", "@value"} {
+			if s := schemaText(t[key]); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// schemaList returns every text value of a property.
+func schemaList(v any) []string {
+	var out []string
+	switch t := v.(type) {
+	case []any:
+		for _, item := range t {
+			out = append(out, schemaList(item)...)
+		}
+	default:
+		if s := schemaText(t); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+var firstNumberRe = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// schemaNumber returns the first number in a property ("4 servings",
+// "240 kcal"), or nil.
+func schemaNumber(v any) *float64 {
+	m := firstNumberRe.FindString(schemaText(v))
+	if m == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(m, ",", "."), 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// schemaInstructions flattens recipeInstructions — a string, a list of
+// strings, HowToSteps, or HowToSections of steps — into step texts.
+func schemaInstructions(v any) []string {
+	var out []string
+	switch t := v.(type) {
+	case string:
+		for _, line := range strings.Split(htmlTagRe.ReplaceAllString(strings.ReplaceAll(t, "<br", "\n<br"), "\n"), "\n") {
+			if line = cleanSchemaText(line); line != "" {
+				out = append(out, line)
+			}
+		}
+	case []any:
+		for _, item := range t {
+			out = append(out, schemaInstructions(item)...)
+		}
+	case map[string]any:
+		if list, ok := t["itemListElement"]; ok {
+			return schemaInstructions(list)
+		}
+		if text := schemaText(t["text"]); text != "" {
+			return []string{text}
+		}
+		if name := schemaText(t["name"]); name != "" {
+			return []string{name}
+		}
+	}
+	return out
+}
+
+// schemaCategory maps recipeCategory text onto our categories.
+func schemaCategory(v any) string {
+	text := strings.ToLower(strings.Join(schemaList(v), " "))
+	keywords := []struct{ word, category string }{
+		{"breakfast", "breakfast"}, {"brunch", "breakfast"},
+		{"dessert", "dessert"}, {"baking", "dessert"},
+		{"lunch", "lunch"}, {"sandwich", "lunch"}, {"salad", "lunch"},
+		{"dinner", "dinner"}, {"main", "dinner"}, {"entree", "dinner"},
+		{"snack", "snack"}, {"appetizer", "snack"},
+	}
+	for _, k := range keywords {
+		if strings.Contains(text, k.word) {
+			return k.category
+		}
+	}
+	return "other"
+}
+
+// recipeFromSchema converts a schema.org Recipe (JSON-LD, or microdata in
+// the same shape) into a draft recipe.
+func recipeFromSchema(m map[string]any) createRecipeRequest {
+	r := createRecipeRequest{
+		Name:     schemaText(m["name"]),
+		Category: schemaCategory(m["recipeCategory"]),
+		Servings: schemaNumber(m["recipeYield"]),
+	}
+	if r.Name == "" {
+		r.Name = "Imported recipe"
+	}
+	if desc := schemaText(m["description"]); desc != "" {
+		r.Notes = &desc
+	}
+
+	// Nutrition on schema.org recipes is per serving, as ours is.
+	if n, ok := firstSchemaItem(m["nutrition"]); ok {
+		if cal := schemaNumber(n["calories"]); cal != nil {
+			v := int(*cal + 0.5)
+			r.Calories = &v
+		}
+		r.ProteinG = schemaNumber(n["proteinContent"])
+		r.CarbsG = schemaNumber(n["carbohydrateContent"])
+		r.FatG = schemaNumber(n["fatContent"])
+	}
+
+	lines := schemaList(m["recipeIngredient"])
+	if len(lines) == 0 {
+		lines = schemaList(m["ingredients"])
+	}
+	for i, line := range lines {
+		ing := parseIngredientLine(line)
+		ing.SortOrder = i
+		r.Ingredients = append(r.Ingredients, ing)
+	}
+	for i, name := range schemaList(m["tool"]) {
+		r.Tools = append(r.Tools, toolInput{Name: name, SortOrder: i})
+	}
+	for i, text := range schemaInstructions(m["recipeInstructions"]) {
+		step := stepFromText(text)
+		step.SortOrder = i
+		r.Steps = append(r.Steps, step)
+	}
+	return r
+}
+
+// firstSchemaItem returns v as an object, or the first object in a list.
+func firstSchemaItem(v any) (map[string]any, bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		return t, true
+	case []any:
+		for _, item := range t {
+			if m, ok := item.(map[string]any); ok {
+				return m, true
+			}
+		}
+	}
+	return nil, false
+}
+
+/* ─── Ingredient lines and timers ────────────────────────────────────── */
+
+// unicodeFractions are vulgar fraction characters common on recipe sites.
+var unicodeFractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4",
+	"⅛", " 1/8", "⅜", " 3/8", "⅝", " 5/8", "⅞", " 7/8", "⅕", " 1/5", "⁄", "/",
+)
+
+const qtyPattern = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?`
+
+var (
+	ingredientQtyRe = regexp.MustCompile(`^(` + qtyPattern + `)(?:\s*(?:-|–|to)\s*(?:` + qtyPattern + `))?\s*`)
+	gluedUnitRe     = regexp.MustCompile(`^(\d+(?:\.\d+)?)([a-zA-Z])`)
+	parentheticalRe = regexp.MustCompile(`\s*\(([^)]*)\)`)
+)
+
+// ingredientUnits are the units recognized after a quantity, beyond the
+// convertible ones in scaleUnitAliases.
+var ingredientUnits = map[string]bool{
+	"clove": true, "cloves": true, "can": true, "cans": true, "pinch": true, "pinches": true,
+	"dash": true, "dashes": true, "slice": true, "slices": true, "stick": true, "sticks": true,
+	"bunch": true, "bunches": true, "sprig": true, "sprigs": true, "package": true, "packages": true,
+	"pkg": true, "jar": true, "jars": true, "head": true, "heads": true, "handful": true, "handfuls": true,
+	"piece": true, "pieces": true, "tsps": true, "tbsps": true, "tbl": true, "c": true,
+}
+
+// parseQty parses "2", "1.5", "3/4", or "1 1/2".
+func parseQty(s string) float64 {
+	total := 0.0
+	for _, part := range strings.Fields(s) {
+		if num, den, ok := strings.Cut(part, "/"); ok {
+			n, _ := strconv.ParseFloat(num, 64)
+			d, _ := strconv.ParseFloat(den, 64)
+			if d != 0 {
+				total += n / d
+			}
+			continue
+		}
+		f, _ := strconv.ParseFloat(part, 64)
+		total += f
+	}
+	return total
+}
+
+// parseIngredientLine splits "1 1/2 cups flour, sifted" into qty, uom, name,
+// and note. Ranges keep their lower bound; parentheticals and text after the
+// first comma become the note.
+func parseIngredientLine(line string) ingredientInput {
+	s := strings.Join(strings.Fields(unicodeFractions.Replace(cleanSchemaText(line))), " ")
+	s = gluedUnitRe.ReplaceAllString(s, "$1 $2")
+	ing := ingredientInput{}
+	var notes []string
+
+	if m := ingredientQtyRe.FindStringSubmatch(s); m != nil {
+		qty := parseQty(m[1])
+		ing.Qty = &qty
+		s = s[len(m[0]):]
+		if p := parentheticalRe.FindStringSubmatchIndex(s); p != nil && p[0] == 0 {
+			notes = append(notes, s[p[2]:p[3]])
+			s = strings.TrimSpace(s[p[1]:])
+		}
+		word, rest, _ := strings.Cut(s, " ")
+		unit := strings.TrimSuffix(word, ".")
+		lower := strings.ToLower(unit)
+		if lower == "fl" && strings.HasPrefix(strings.ToLower(rest), "oz") {
+			unit, lower = "fl oz", "fl oz"
+			_, rest, _ = strings.Cut(rest, " ")
+		}
+		if _, ok := scaleUnitAliases[lower]; ok || ingredientUnits[lower] {
+			ing.Uom = &unit
+			s = strings.TrimPrefix(rest, "of ")
+		}
+	}
+
+	for _, p := range parentheticalRe.FindAllStringSubmatch(s, -1) {
+		notes = append(notes, p[1])
+	}
+	s = parentheticalRe.ReplaceAllString(s, "")
+	if name, note, ok := strings.Cut(s, ","); ok {
+		s = name
+		notes = append(notes, strings.TrimSpace(note))
+	}
+	ing.Name = strings.TrimSpace(s)
+	if ing.Name == "" {
+		ing.Name = strings.TrimSpace(line)
+	}
+	if len(notes) > 0 {
+		note := strings.Join(notes, "; ")
+		ing.Note = &note
+	}
+	return ing
+}
+
+var durationRe = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?)(?:\s*(?:-|–|to)\s*\d+(?:\.\d+)?)?\s*(hours?|hrs?|minutes?|mins?|seconds?|secs?)\b`)
+
+// durationSeconds converts a durationRe match to seconds.
+func durationSeconds(amount, unit string) int {
+	n, _ := strconv.ParseFloat(amount, 64)
+	switch u := strings.ToLower(unit); {
+	case strings.HasPrefix(u, "h"):
+		return int(n * 3600)
+	case strings.HasPrefix(u, "m"):
+		return int(n * 60)
+	default:
+		return int(n)
+	}
+}
+
+// stepFromText makes a step from instruction text. Text with a duration
+// ("bake 20 minutes", "simmer 1 hour 15 minutes") becomes a timer step; a
+// range uses its lower bound so the cook checks early.
+func stepFromText(text string) stepInput {
+	step := stepInput{Type: "instruction", Text: text}
+	matches := durationRe.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return step
+	}
+	m := matches[0]
+	seconds := durationSeconds(text[m[2]:m[3]], text[m[4]:m[5]])
+	// "1 hour 30 minutes" / "1 hour and 30 minutes" is one duration.
+	if len(matches) > 1 && seconds >= 3600 {
+		next := matches[1]
+		gap := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text[m[1]:next[0]]), "and"))
+		if gap == "" {
+			seconds += durationSeconds(text[next[2]:next[3]], text[next[4]:next[5]])
+		}
+	}
+	if seconds > 0 {
+		step.Type = "timer"
+		step.TimerSeconds = &seconds
+	}
+	return step
+}
+
+/* ─── Extraction ─────────────────────────────────────────────────────── */
+
+// extractRecipe finds structured recipe data in a page. source is "json-ld"
+// or "microdata"; ok is false when the page has neither.
+func extractRecipe(root *html.Node) (r createRecipeRequest, source string, ok bool) {
+	if m := jsonLDRecipe(root); m != nil {
+		return recipeFromSchema(m), "json-ld", true
+	}
+	if m := microdataRecipe(root); m != nil {
+		return recipeFromSchema(m), "microdata", true
+	}
+	return createRecipeRequest{}, "", false
+}
+
+// importRecipeRequest is the body for POST /api/recipes/import. Exactly one
+// of URL or HTML is required.
+type importRecipeRequest struct {
+	URL  string `json:"url"`
+	HTML string `json:"html"`
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// importRecipeResponse is an unsaved recipe draft read from a page.
+type importRecipeResponse struct {
+	Recipe createRecipeRequest `json:"recipe"`
+	Source string              `json:"source"` // json-ld, microdata, or ai
+	URL    string              `json:"url,omitempty"`
+}
+
+// importRecipe reads a recipe from a web page's schema.org data, falling
+// back to the AI only when the page has none. Nothing is saved — like
+// ai-copy, the client reviews the draft and saves it.
+// POST /api/recipes/import  {"url": "https://..."} or {"html": "<html>..."}
+func (h *Handler) importRecipe(c *gin.Context) {
+	var req importRecipeRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportRequestBytes)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apiError(c, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if (req.URL == "") == (req.HTML == "") {
+		apiError(c, http.StatusBadRequest, "exactly one of url or html is required")
+		return
+	}
+
+	if req.URL != "" && !validImportURL(req.URL) {
+		apiError(c, http.StatusBadRequest, "url must be an http or https address")
+		return
+	}
+
+	page := req.HTML
+	if req.URL != "" {
+		var err error
+		if page, err = fetchRecipePage(c, req.URL); err != nil {
+			log.Printf("[recipe/import] fetch %s: %v", req.URL, err)
+			apiError(c, http.StatusBadGateway, "failed to fetch url")
+			return
+		}
+	}
+	root, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid html")
+		return
+	}
+
+	resp := importRecipeResponse{URL: req.URL}
+	if r, source, ok := extractRecipe(root); ok {
+		resp.Recipe, resp.Source = r, source
+	} else {
+		text := truncateUTF8(nodeText(root, true), maxImportAIText)
+		if strings.TrimSpace(text) == "" {
+			apiError(c, http.StatusUnprocessableEntity, "no recipe found on page")
+			return
+		}
+		draft, err := h.aiExtractRecipe(c, text)
+		if err != nil {
+			log.Printf("[recipe/import] AI fallback: %v", err)
+			apiError(c, http.StatusInternalServerError, "ai request failed")
+			return
+		}
+		if draft.Name == "" || len(draft.Ingredients) == 0 {
+			apiError(c, http.StatusUnprocessableEntity, "no recipe found on page")
+			return
+		}
+		resp.Recipe, resp.Source = draft, "ai"
+	}
+
+	if req.URL != "" {
+		note := "Source: " + req.URL
+		if resp.Recipe.Notes != nil {
+			note = *resp.Recipe.Notes + "\n\n" + note
+		}
+		resp.Recipe.Notes = &note
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// aiExtractRecipe asks the AI to pull a recipe out of page text.
+func (h *Handler) aiExtractRecipe(c *gin.Context, text string) (createRecipeRequest, error) {
+	messages := []openAIMessage{
+		{Role: "system", Content: "You extract recipes from web page text. Return the recipe as written on the page — do not invent ingredients or steps. Use timer steps for steps with a cooking time. If the page has no recipe, return an empty name and empty lists."},
+		{Role: "user", Content: text},
+	}
+	content, err := callOpenAIModel(c, "gpt-4o", messages, recipeResponseFormat, h.openAIBaseURL)
+	if err != nil {
+		return createRecipeRequest{}, err
+	}
+	var draft createRecipeRequest
+	if err := json.Unmarshal([]byte(content), &draft); err != nil {
+		return createRecipeRequest{}, fmt.Errorf("parse response: %w", err)
+	}
+	if !validRecipeCategories[draft.Category] {
+		draft.Category = "other"
+	}
+	return draft, nil
+}
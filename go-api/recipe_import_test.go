@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/html"
+)
+
+// loadRecipeFixture parses an HTML fixture from testdata/recipes.
+func loadRecipeFixture(t *testing.T, name string) *html.Node {
+	t.Helper()
+	f, err := os.Open("testdata/recipes/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	root, err := html.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestExtractRecipe_JSONLD(t *testing.T) {
+	r, source, ok := extractRecipe(loadRecipeFixture(t, "jsonld_graph.html"))
+	if !ok || source != "json-ld" {
+		t.Fatalf("got source %q ok %v", source, ok)
+	}
+	if r.Name != "Weeknight Chicken Chili" || r.Category != "dinner" || *r.Servings != 6 {
+		t.Errorf("recipe: got name %q category %q servings %v", r.Name, r.Category, *r.Servings)
+	}
+	if r.Notes == nil || *r.Notes != "A quick chili with pantry staples & leftover chicken." {
+		t.Errorf("notes: got %v", r.Notes)
+	}
+	if *r.Calories != 412 || *r.ProteinG != 34 || *r.CarbsG != 38.5 || *r.FatG != 12 {
+		t.Errorf("nutrition: got %d %v %v %v", *r.Calories, *r.ProteinG, *r.CarbsG, *r.FatG)
+	}
+	if len(r.Tools) != 1 || r.Tools[0].Name != "Dutch oven" {
+		t.Errorf("tools: got %+v", r.Tools)
+	}
+	if len(r.Ingredients) != 9 {
+		t.Fatalf("ingredients: got %d", len(r.Ingredients))
+	}
+	if ing := r.Ingredients[3]; *ing.Qty != 1.5 || *ing.Uom != "pounds" || ing.Name != "cooked chicken" {
+		t.Errorf("entity fraction: got %v %v %q", *ing.Qty, *ing.Uom, ing.Name)
+	}
+
+	if len(r.Steps) != 4 {
+		t.Fatalf("steps: got %d", len(r.Steps))
+	}
+	if r.Steps[0].Type != "instruction" {
+		t.Errorf("step 0: got %+v", r.Steps[0])
+	}
+	if s := r.Steps[1]; s.Type != "timer" || *s.TimerSeconds != 300 {
+		t.Errorf("range timer: got %+v", s)
+	}
+	if s := r.Steps[2]; s.Type != "timer" || *s.TimerSeconds != 4500 {
+		t.Errorf("compound timer: got %+v", s)
+	}
+	if r.Steps[3].SortOrder != 3 {
+		t.Errorf("sort order: got %d", r.Steps[3].SortOrder)
+	}
+}
+
+func TestExtractRecipe_Microdata(t *testing.T) {
+	r, source, ok := extractRecipe(loadRecipeFixture(t, "microdata.html"))
+	if !ok || source != "microdata" {
+		t.Fatalf("got source %q ok %v", source, ok)
+	}
+	if r.Name != "Banana Bread" || r.Category != "dessert" || *r.Servings != 1 {
+		t.Errorf("recipe: got name %q category %q servings %v", r.Name, r.Category, *r.Servings)
+	}
+	if *r.Calories != 240 || *r.FatG != 9 || r.ProteinG != nil {
+		t.Errorf("nutrition: got %d %v %v", *r.Calories, *r.FatG, r.ProteinG)
+	}
+	if len(r.Ingredients) != 5 || *r.Ingredients[2].Qty != 0.75 || *r.Ingredients[3].Uom != "tsp" {
+		t.Errorf("ingredients: got %+v", r.Ingredients)
+	}
+	if len(r.Steps) != 3 || r.Steps[2].Type != "timer" || *r.Steps[2].TimerSeconds != 3600 {
+		t.Errorf("steps: got %+v", r.Steps)
+	}
+}
+
+func TestExtractRecipe_NoStructuredData(t *testing.T) {
+	root := loadRecipeFixture(t, "no_structured_data.html")
+	if _, _, ok := extractRecipe(root); ok {
+		t.Fatal("expected no structured recipe")
+	}
+	text := nodeText(root, true)
+	if strings.Contains(text, "tracking") || strings.Contains(text, "Home") || !strings.Contains(text, "1 cup flour") {
+		t.Errorf("visible text: got %q", text)
+	}
+}
+
+func TestParseIngredientLine(t *testing.T) {
+	cases := []struct {
+		line, qty, uom, name, note string
+	}{
+		{"2 tbsp olive oil", "2", "tbsp", "olive oil", ""},
+		{"1 large onion, diced", "1", "", "large onion", "diced"},
+		{"3 cloves garlic, minced", "3", "cloves", "garlic", "minced"},
+		{"2 (15 oz) cans black beans, drained", "2", "cans", "black beans", "15 oz; drained"},
+		{"1½ cups chicken broth", "1.5", "cups", "chicken broth", ""},
+		{"2-3 tsp chili powder", "2", "tsp", "chili powder", ""},
+		{"200g crushed tomatoes", "200", "g", "crushed tomatoes", ""},
+		{"2 cups of flour", "2", "cups", "flour", ""},
+		{"8 fl oz cream", "8", "fl oz", "cream", ""},
+		{"Salt to taste", "", "", "Salt to taste", ""},
+	}
+	for _, tc := range cases {
+		got := parseIngredientLine(tc.line)
+		qty, uom, note := "", "", ""
+		if got.Qty != nil {
+			qty = strconv.FormatFloat(*got.Qty, 'f', -1, 64)
+		}
+		if got.Uom != nil {
+			uom = *got.Uom
+		}
+		if got.Note != nil {
+			note = *got.Note
+		}
+		if qty != tc.qty || uom != tc.uom || got.Name != tc.name || note != tc.note {
+			t.Errorf("%q: got qty %q uom %q name %q note %q", tc.line, qty, uom, got.Name, note)
+		}
+	}
+}
+
+func TestStepFromText(t *testing.T) {
+	cases := map[string]int{
+		"Bake 20 minutes.":                       1200,
+		"Let rest for 30 secs":                   30,
+		"Simmer 1 hr 30 min, stirring":           5400,
+		"Bake 25 minutes, then cool 10 minutes.": 1500,
+		"Chill 2 to 3 hours":                     7200,
+		"Stir in the cheese.":                    0,
+	}
+	for text, want := range cases {
+		step := stepFromText(text)
+		got := 0
+		if step.TimerSeconds != nil {
+			got = *step.TimerSeconds
+		}
+		if got != want || (want > 0) != (step.Type == "timer") {
+			t.Errorf("%q: got %s %d, want %d", text, step.Type, got, want)
+		}
+	}
+}
+
+func TestImportRecipe(t *testing.T) {
+	var aiCalls int
+	mockOpenAI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aiCalls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openAIChatResponse(`{"name":"Grandma's Pancakes","emoji":"🥞","category":"brunch","notes":null,"servings":2,"calories":null,"protein_g":null,"carbs_g":null,"fat_g":null,"ingredients":[{"name":"flour","qty":1,"uom":"cup","note":null,"sort_order":0}],"tools":[],"steps":[]}`))
+	}))
+	defer mockOpenAI.Close()
+	t.Setenv("OPENAI_API_KEY", "test")
+
+	gin.SetMode(gin.TestMode)
+	h := Handler{openAIBaseURL: mockOpenAI.URL}
+	router := gin.New()
+	router.POST("/api/recipes/import", h.importRecipe)
+	post := func(body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/recipes/import", strings.NewReader(string(b)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	fixture := func(name string) string {
+		b, err := os.ReadFile("testdata/recipes/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	// Structured data never calls the AI.
+	w := post(map[string]string{"html": fixture("jsonld_graph.html")})
+	var resp importRecipeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Source != "json-ld" || aiCalls != 0 {
+		t.Errorf("json-ld: got %d %q, %d AI calls", w.Code, resp.Source, aiCalls)
+	}
+
+	w = post(map[string]string{"html": fixture("no_structured_data.html")})
+	resp = importRecipeResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Source != "ai" || aiCalls != 1 || resp.Recipe.Category != "other" {
+		t.Errorf("ai fallback: got %d %+v, %d AI calls", w.Code, resp, aiCalls)
+	}
+
+	if w := post(map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("empty body: got %d", w.Code)
+	}
+	if w := post(map[string]string{"url": "file:///etc/passwd"}); w.Code != http.StatusBadRequest {
+		t.Errorf("file url: got %d", w.Code)
+	}
+	// Private addresses are refused at dial time.
+	if w := post(map[string]string{"url": mockOpenAI.URL}); w.Code != http.StatusBadGateway || aiCalls != 1 {
+		t.Errorf("loopback url: got %d", w.Code)
+	}
+	if w := post(map[string]string{"html": strings.Repeat("x", maxImportRequestBytes)}); w.Code != http.StatusRequestEntityTooLarge || aiCalls != 1 {
+		t.Errorf("oversized html: got %d", w.Code)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc"},
+		{"crème", 3, "cr"}, // è is two bytes; don't keep half of it
+		{"crème", 4, "crè"},
+		{"🥞🥞", 5, "🥞"},
+	}
+	for _, tc := range cases {
+		if got := truncateUTF8(tc.in, tc.n); got != tc.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// massUnitGrams converts ingredient mass units to grams. Volume units need an
+// explicit gram weight since density varies by ingredient.
+var massUnitGrams = map[string]float64{
+	"g": 1, "gram": 1, "grams": 1,
+	"kg": 1000, "mg": 0.001,
+	"oz": 28.3495, "ounce": 28.3495, "ounces": 28.3495,
+	"lb": 453.592, "lbs": 453.592, "pound": 453.592, "pounds": 453.592,
+}
+
+// nutritionTotals is calories and macros for some amount of food.
+type nutritionTotals struct {
+	Calories int     `json:"calories"`
+	ProteinG float64 `json:"protein_g"`
+	CarbsG   float64 `json:"carbs_g"`
+	FatG     float64 `json:"fat_g"`
+}
+
+// ingredientNutrition is one ingredient's contribution to the recipe. When
+// Computed is false, Reason says why and the ingredient adds nothing.
+type ingredientNutrition struct {
+	IngredientID int      `json:"ingredient_id"`
+	Name         string   `json:"name"`
+	FoodID       *int     `json:"food_id"`
+	SubRecipeID  *int     `json:"sub_recipe_id"`
+	Grams        *float64 `json:"grams"` // resolved weight; nil when unknown
+	nutritionTotals
+	CaloriePct int    `json:"calorie_pct"` // share of the recipe's calories
+	Computed   bool   `json:"computed"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// recipeNutrition is the computed nutrition of a recipe from its linked
+// ingredients (foods and sub-recipes). Complete is false when some ingredient couldn't be counted.
+type recipeNutrition struct {
+	Servings    float64               `json:"servings"`
+	Total       nutritionTotals       `json:"total"`
+	PerServing  nutritionTotals       `json:"per_serving"`
+	Ingredients []ingredientNutrition `json:"ingredients"`
+	Complete    bool                  `json:"complete"`
+}
+
+// normalizeUom lowercases a unit and drops a trailing period ("Tbsp." → "tbsp").
+func normalizeUom(uom *string) string {
+	if uom == nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(*uom)), ".")
+}
+
+// matchesServingUnit reports whether an ingredient unit is the food's serving
+// unit, so qty counts servings directly.
+func matchesServingUnit(uom string, f food) bool {
+	switch f.ServingUom {
+	case "serving":
+		return uom == "" || uom == "serving" || uom == "servings"
+	case "each":
+		return uom == "" || uom == "each" || uom == "ea" || uom == "whole"
+	default:
+		return uom == f.ServingUom
+	}
+}
+
+// ingredientGrams resolves an ingredient's weight: the explicit grams, a mass
+// unit, or servings of a food with a known serving weight.
+func ingredientGrams(ing recipeIngredient, f *food) *float64 {
+	if ing.Grams != nil {
+		return ing.Grams
+	}
+	qty := 1.0
+	if ing.Qty != nil {
+		qty = *ing.Qty
+	}
+	uom := normalizeUom(ing.Uom)
+	if perUnit, ok := massUnitGrams[uom]; ok {
+		g := qty * perUnit
+		return &g
+	}
+	if f != nil && f.ServingGrams != nil && matchesServingUnit(uom, *f) {
+		g := qty / f.ServingQty * *f.ServingGrams
+		return &g
+	}
+	return nil
+}
+
+// foodServings is how many of the food's servings an ingredient amounts to.
+func foodServings(ing recipeIngredient, f food, grams *float64) (float64, bool) {
+	switch {
+	case grams != nil && f.ServingGrams != nil:
+		return *grams / *f.ServingGrams, true
+	case grams != nil && f.ServingUom == "g":
+		return *grams / f.ServingQty, true
+	case matchesServingUnit(normalizeUom(ing.Uom), f):
+		qty := 1.0
+		if ing.Qty != nil {
+			qty = *ing.Qty
+		}
+		return qty / f.ServingQty, true
+	}
+	return 0, false
+}
+
+// isServingUnit reports whether a sub-recipe ingredient's unit counts
+// servings of the sub-recipe.
+func isServingUnit(uom string) bool {
+	switch uom {
+	case "", "serving", "servings", "portion", "portions":
+		return true
+	}
+	return false
+}
+
+// subRecipeServings is how many servings of sub an ingredient amounts to:
+// qty in servings, or a weight against the sub-recipe's yield weight.
+func subRecipeServings(ing recipeIngredient, sub recipe, grams *float64) (float64, bool) {
+	if ing.Grams == nil && isServingUnit(normalizeUom(ing.Uom)) {
+		qty := 1.0
+		if ing.Qty != nil {
+			qty = *ing.Qty
+		}
+		return qty, true
+	}
+	if grams == nil || sub.YieldGrams == nil || *sub.YieldGrams <= 0 {
+		return 0, false
+	}
+	servings := sub.Servings
+	if servings <= 0 {
+		servings = 1
+	}
+	return *grams / *sub.YieldGrams * servings, true
+}
+
+// round1 rounds to one decimal place.
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// computeRecipeNutrition totals the linked ingredients of a recipe. foods and
+// subs (sub-recipes, whose stored per-serving numbers already roll up their
+// own sub-recipes) are keyed by id; ingredients whose food or sub-recipe is
+// missing count as unlinked.
+func computeRecipeNutrition(servings float64, ingredients []recipeIngredient, foods map[int]food, subs map[int]recipe) recipeNutrition {
+	if servings <= 0 {
+		servings = 1
+	}
+	n := recipeNutrition{Servings: servings, Ingredients: make([]ingredientNutrition, 0, len(ingredients)), Complete: true}
+	var calories, protein, carbs, fat float64
+	for _, ing := range ingredients {
+		in := ingredientNutrition{IngredientID: ing.ID, Name: ing.Name, FoodID: ing.FoodID, SubRecipeID: ing.SubRecipeID}
+		f, linked := food{}, false
+		if ing.FoodID != nil {
+			f, linked = foods[*ing.FoodID]
+		}
+		sub, isSub := recipe{}, false
+		if ing.SubRecipeID != nil {
+			sub, isSub = subs[*ing.SubRecipeID]
+		}
+		switch {
+		case linked:
+			in.Grams = ingredientGrams(ing, &f)
+		default:
+			in.Grams = ingredientGrams(ing, nil)
+		}
+		if in.Grams == nil && isSub && sub.YieldGrams != nil && sub.Servings > 0 {
+			if count, ok := subRecipeServings(ing, sub, nil); ok {
+				g := count * *sub.YieldGrams / sub.Servings
+				in.Grams = &g
+			}
+		}
+		if in.Grams != nil {
+			g := round1(*in.Grams)
+			in.Grams = &g
+		}
+
+		// base is the nutrition of one serving of the food or sub-recipe.
+		var factor float64
+		var base nutritionTotals
+		switch {
+		case isSub:
+			var ok bool
+			switch factor, ok = subRecipeServings(ing, sub, in.Grams); {
+			case !ok && in.Grams == nil:
+				in.Reason = fmt.Sprintf("no gram weight for %q; set grams or use servings", normalizeUom(ing.Uom))
+			case !ok:
+				in.Reason = fmt.Sprintf("no yield weight for %q; set its yield_grams or use servings", sub.Name)
+			case sub.Calories == nil:
+				in.Reason = fmt.Sprintf("%q has no nutrition", sub.Name)
+			default:
+				in.Computed = true
+				base = nutritionTotals{Calories: *sub.Calories, ProteinG: deref(sub.ProteinG), CarbsG: deref(sub.CarbsG), FatG: deref(sub.FatG)}
+			}
+		case !linked:
+			in.Reason = "no food linked"
+		default:
+			var ok bool
+			if factor, ok = foodServings(ing, f, in.Grams); !ok {
+				in.Reason = fmt.Sprintf("no gram weight for %q; set grams or the food's serving weight", normalizeUom(ing.Uom))
+			} else {
+				in.Computed = true
+				base = nutritionTotals{Calories: f.Calories, ProteinG: deref(f.ProteinG), CarbsG: deref(f.CarbsG), FatG: deref(f.FatG)}
+			}
+		}
+		if !in.Computed {
+			n.Complete = false
+			n.Ingredients = append(n.Ingredients, in)
+			continue
+		}
+
+		c := float64(base.Calories) * factor
+		p, cb, ft := base.ProteinG*factor, base.CarbsG*factor, base.FatG*factor
+		calories, protein, carbs, fat = calories+c, protein+p, carbs+cb, fat+ft
+		in.nutritionTotals = nutritionTotals{Calories: int(math.Round(c)), ProteinG: round1(p), CarbsG: round1(cb), FatG: round1(ft)}
+		n.Ingredients = append(n.Ingredients, in)
+	}
+
+	n.Total = nutritionTotals{Calories: int(math.Round(calories)), ProteinG: round1(protein), CarbsG: round1(carbs), FatG: round1(fat)}
+	n.PerServing = nutritionTotals{
+		Calories: int(math.Round(calories / servings)),
+		ProteinG: round1(protein / servings),
+		CarbsG:   round1(carbs / servings),
+		FatG:     round1(fat / servings),
+	}
+	for i := range n.Ingredients {
+		if n.Total.Calories > 0 {
+			n.Ingredients[i].CaloriePct = int(math.Round(float64(n.Ingredients[i].Calories) * 100 / float64(n.Total.Calories)))
+		}
+	}
+	return n
+}
+
+// deref returns *v, or 0 when v is nil.
+func deref(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// loadRecipeNutrition computes a recipe's nutrition from its stored
+// ingredients. Returns nil when no ingredient links a food or sub-recipe.
+func loadRecipeNutrition(ctx context.Context, q querier, recipeID int, servings float64) (*recipeNutrition, error) {
+	rows, err := q.Query(ctx,
+		`SELECT * FROM recipe_ingredients WHERE recipe_id = @id ORDER BY sort_order`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, err
+	}
+	ingredients, err := pgx.CollectRows(rows, pgx.RowToStructByName[recipeIngredient])
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx,
+		`SELECT DISTINCT f.* FROM foods f
+		 JOIN recipe_ingredients i ON i.food_id = f.id
+		 WHERE i.recipe_id = @id`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, err
+	}
+	linked, err := pgx.CollectRows(rows, pgx.RowToStructByName[food])
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx,
+		`SELECT DISTINCT r.* FROM recipes r
+		 JOIN recipe_ingredients i ON i.sub_recipe_id = r.id
+		 WHERE i.recipe_id = @id`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, err
+	}
+	linkedRecipes, err := pgx.CollectRows(rows, pgx.RowToStructByName[recipe])
+	if err != nil {
+		return nil, err
+	}
+	if len(linked) == 0 && len(linkedRecipes) == 0 {
+		return nil, nil
+	}
+	foods := make(map[int]food, len(linked))
+	for _, f := range linked {
+		foods[f.ID] = f
+	}
+	subs := make(map[int]recipe, len(linkedRecipes))
+	for _, r := range linkedRecipes {
+		subs[r.ID] = r
+	}
+	n := computeRecipeNutrition(servings, ingredients, foods, subs)
+	return &n, nil
+}
+
+// recomputeRecipeNutrition stores computed per-serving nutrition on a recipe
+// with linked ingredients, then on every recipe that uses it as a sub-recipe,
+// up the chain. Recipes without links, or with an ingredient that can't be
+// counted, keep their manual numbers. Call inside the transaction that
+// changed the ingredients, servings, or a food.
+func recomputeRecipeNutrition(c *gin.Context, tx pgx.Tx, recipeID int) error {
+	return recomputeWithParents(c, tx, recipeID, map[int]bool{})
+}
+
+// recomputeWithParents recomputes recipeID and then the recipes using it.
+// seen guards against a cycle that slipped past checkSubRecipes.
+func recomputeWithParents(c *gin.Context, tx pgx.Tx, recipeID int, seen map[int]bool) error {
+	seen[recipeID] = true
+	if err := storeRecipeNutrition(c, tx, recipeID); err != nil {
+		return err
+	}
+	parents, err := recipesUsingRecipe(c, tx, recipeID)
+	if err != nil {
+		return err
+	}
+	for _, id := range parents {
+		if seen[id] {
+			continue
+		}
+		if err := recomputeWithParents(c, tx, id, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeRecipeNutrition recomputes and stores one recipe's nutrition. The
+// computed numbers replace the recipe's only when every ingredient could be
+// counted; otherwise the manual numbers stay and nutrition_computed is false,
+// so a partial sum never passes for the recipe's nutrition.
+func storeRecipeNutrition(c *gin.Context, tx pgx.Tx, recipeID int) error {
+	var servings float64
+	if err := tx.QueryRow(c, `SELECT servings FROM recipes WHERE id = @id`,
+		pgx.NamedArgs{"id": recipeID}).Scan(&servings); err != nil {
+		return err
+	}
+	n, err := loadRecipeNutrition(c, tx, recipeID, servings)
+	if err != nil {
+		return err
+	}
+	if n == nil || !n.Complete {
+		_, err = tx.Exec(c, `UPDATE recipes SET nutrition_computed = false WHERE id = @id`,
+			pgx.NamedArgs{"id": recipeID})
+		return err
+	}
+	_, err = tx.Exec(c,
+		`UPDATE recipes SET
+			calories = @calories, protein_g = @proteinG, carbs_g = @carbsG, fat_g = @fatG,
+			nutrition_computed = true
+		 WHERE id = @id`,
+		pgx.NamedArgs{
+			"id": recipeID, "calories": n.PerServing.Calories,
+			"proteinG": n.PerServing.ProteinG, "carbsG": n.PerServing.CarbsG, "fatG": n.PerServing.FatG,
+		})
+	return err
+}
+
+// recipesUsingFood returns the recipes with an ingredient linked to foodID.
+func recipesUsingFood(c *gin.Context, tx pgx.Tx, foodID int) ([]int, error) {
+	rows, err := tx.Query(c,
+		`SELECT DISTINCT recipe_id FROM recipe_ingredients WHERE food_id = @id`,
+		pgx.NamedArgs{"id": foodID})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// recomputeRecipes recomputes nutrition for each recipe in ids.
+func recomputeRecipes(c *gin.Context, tx pgx.Tx, ids []int) error {
+	for _, id := range ids {
+		if err := recomputeRecipeNutrition(c, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIngredientFoods validates ingredient food links and gram weights.
+// Returns a user-facing message, or "" when they're valid.
+func checkIngredientFoods(c *gin.Context, tx pgx.Tx, userID int, ingredients []ingredientInput) string {
+	var ids []int
+	for _, ing := range ingredients {
+		if ing.Grams != nil && *ing.Grams <= 0 {
+			return "ingredient grams must be positive"
+		}
+		if ing.FoodID != nil {
+			ids = append(ids, *ing.FoodID)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	var missing int
+	err := tx.QueryRow(c,
+		`SELECT COUNT(*) FROM unnest(@ids::int[]) AS u(food_id)
+		 WHERE NOT EXISTS (SELECT 1 FROM foods f WHERE f.id = u.food_id AND f.user_id = @userID)`,
+		pgx.NamedArgs{"ids": ids, "userID": userID}).Scan(&missing)
+	if err != nil || missing > 0 {
+		return "ingredient food not found"
+	}
+	return ""
+}
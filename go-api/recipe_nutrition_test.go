@@ -0,0 +1,108 @@
+package main
+
+import "testing"
+
+func TestComputeRecipeNutrition(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+	id := func(i int) *int { return &i }
+
+	foods := map[int]food{
+		// 100 g of oats per serving.
+		1: {ID: 1, ServingQty: 1, ServingUom: "serving", ServingGrams: f64(100), Calories: 380, ProteinG: f64(13), CarbsG: f64(67), FatG: f64(7)},
+		// One egg, no gram weight.
+		2: {ID: 2, ServingQty: 1, ServingUom: "each", Calories: 70, ProteinG: f64(6), FatG: f64(5)},
+		// Label per 15 g.
+		3: {ID: 3, ServingQty: 15, ServingUom: "g", Calories: 100, FatG: f64(11)},
+	}
+	ings := []recipeIngredient{
+		{ID: 10, Name: "Oats", Qty: f64(200), Uom: str("g"), FoodID: id(1)},
+		{ID: 11, Name: "Eggs", Qty: f64(2), FoodID: id(2)},
+		{ID: 12, Name: "Butter", Qty: f64(1), Uom: str("Tbsp."), Grams: f64(15), FoodID: id(3)},
+		{ID: 13, Name: "Milk", Qty: f64(1), Uom: str("cup"), FoodID: id(2)},
+		{ID: 14, Name: "Salt", Qty: f64(1), Uom: str("pinch")},
+	}
+	n := computeRecipeNutrition(4, ings, foods, nil)
+
+	// 760 oats + 140 eggs + 100 butter.
+	if n.Total.Calories != 1000 || n.PerServing.Calories != 250 {
+		t.Errorf("calories: got total %d per serving %d", n.Total.Calories, n.PerServing.Calories)
+	}
+	if n.Total.ProteinG != 38 || n.PerServing.FatG != 8.8 {
+		t.Errorf("macros: got %+v per serving %+v", n.Total, n.PerServing)
+	}
+	if n.Complete {
+		t.Error("milk has no gram weight and salt no food; should be incomplete")
+	}
+	byID := map[int]ingredientNutrition{}
+	for _, in := range n.Ingredients {
+		byID[in.IngredientID] = in
+	}
+	if oats := byID[10]; !oats.Computed || oats.Calories != 760 || oats.CaloriePct != 76 || *oats.Grams != 200 {
+		t.Errorf("oats: got %+v", oats)
+	}
+	if eggs := byID[11]; !eggs.Computed || eggs.Calories != 140 || eggs.Grams != nil {
+		t.Errorf("eggs: got %+v", eggs)
+	}
+	if milk := byID[13]; milk.Computed || milk.Reason == "" {
+		t.Errorf("milk: got %+v", milk)
+	}
+	if salt := byID[14]; salt.Computed || salt.Reason != "no food linked" {
+		t.Errorf("salt: got %+v", salt)
+	}
+}
+
+func TestIngredientGrams(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+
+	if g := ingredientGrams(recipeIngredient{Qty: f64(2), Uom: str("lb")}, nil); g == nil || *g != 907.184 {
+		t.Errorf("lb: got %v", g)
+	}
+	// Two servings of a food weighing 30 g per 0.5 cup serving.
+	cereal := food{ServingQty: 0.5, ServingUom: "serving", ServingGrams: f64(30)}
+	if g := ingredientGrams(recipeIngredient{Qty: f64(1), Uom: str("servings")}, &cereal); g == nil || *g != 60 {
+		t.Errorf("servings: got %v", g)
+	}
+	if g := ingredientGrams(recipeIngredient{Qty: f64(1), Uom: str("cup")}, &cereal); g != nil {
+		t.Errorf("cup: got %v, want nil", *g)
+	}
+}
+
+func TestComputeRecipeNutrition_SubRecipes(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+	id := func(i int) *int { return &i }
+
+	subs := map[int]recipe{
+		// Bolognese: 6 servings, 1.8 kg cooked.
+		7: {ID: 7, Name: "Bolognese", Servings: 6, YieldGrams: f64(1800), Calories: id(300), ProteinG: f64(20), FatG: f64(15)},
+		// Pesto with no yield weight.
+		8: {ID: 8, Name: "Pesto", Servings: 4, Calories: id(200)},
+		9: {ID: 9, Name: "Stock", Servings: 8},
+	}
+	ings := []recipeIngredient{
+		{ID: 1, Name: "bolognese", Qty: f64(2), Uom: str("servings"), SubRecipeID: id(7)},
+		{ID: 2, Name: "bolognese", Qty: f64(600), Uom: str("g"), SubRecipeID: id(7)},
+		{ID: 3, Name: "pesto", Qty: f64(100), Uom: str("g"), SubRecipeID: id(8)},
+		{ID: 4, Name: "stock", Qty: f64(1), SubRecipeID: id(9)},
+	}
+	n := computeRecipeNutrition(2, ings, nil, subs)
+
+	// 2 servings + 600 g (2 servings) of bolognese.
+	if n.Total.Calories != 1200 || n.PerServing.Calories != 600 || n.Total.ProteinG != 80 {
+		t.Errorf("got total %+v per serving %+v", n.Total, n.PerServing)
+	}
+	if n.Complete {
+		t.Error("pesto and stock can't be counted; should be incomplete")
+	}
+	if in := n.Ingredients[0]; !in.Computed || *in.SubRecipeID != 7 || *in.Grams != 600 {
+		t.Errorf("bolognese servings: got %+v", in)
+	}
+	if in := n.Ingredients[2]; in.Computed || in.Reason == "" {
+		t.Errorf("pesto by weight: got %+v", in)
+	}
+	if in := n.Ingredients[3]; in.Computed || in.Reason != `"Stock" has no nutrition` {
+		t.Errorf("stock: got %+v", in)
+	}
+}
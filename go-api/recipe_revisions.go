@@ -0,0 +1,526 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+/* ─── Recording ──────────────────────────────────────────────────────── */
+
+// sameRecipeContent reports whether two snapshots hold the same recipe,
+// ignoring row IDs and timestamps that change on every save.
+func sameRecipeContent(a, b recipeDetail) bool {
+	ja, errA := json.Marshal(recipeRequestFromDetail(a))
+	jb, errB := json.Marshal(recipeRequestFromDetail(b))
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// recordRecipeRevision snapshots a recipe as it stands in tx. author is
+// 'user' or 'ai'; prompt is the AI prompt behind the change. Nothing is
+// recorded when the recipe matches its latest revision, so a save without
+// changes doesn't add an empty entry. Call after every write to a recipe.
+func recordRecipeRevision(ctx context.Context, tx pgx.Tx, recipeID int, author string, prompt *string, restoredFrom *int) error {
+	current, err := loadRecipeDetail(ctx, tx, recipeID)
+	if err != nil {
+		return err
+	}
+
+	var latest recipeDetail
+	err = tx.QueryRow(ctx,
+		`SELECT snapshot FROM recipe_revisions WHERE recipe_id = @id ORDER BY revision DESC LIMIT 1`,
+		pgx.NamedArgs{"id": recipeID}).Scan(&latest)
+	switch {
+	case err == nil:
+		if sameRecipeContent(latest, current) {
+			return nil
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	args, err := recipeRevisionArgs(recipeID, current, author, prompt, restoredFrom)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO recipe_revisions (recipe_id, revision, snapshot, author, ai_prompt, restored_from)
+		 VALUES (@id,
+		         COALESCE((SELECT MAX(revision) FROM recipe_revisions WHERE recipe_id = @id), 0) + 1,
+		         @snapshot::jsonb, @author, @prompt, @restoredFrom)`,
+		args)
+	return err
+}
+
+// recipeRevisionArgs builds the insert args for a revision. The snapshot is
+// passed as JSON text (cast with ::jsonb) since the simple query protocol
+// can't encode a struct.
+func recipeRevisionArgs(recipeID int, snapshot recipeDetail, author string, prompt *string, restoredFrom *int) (pgx.NamedArgs, error) {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.NamedArgs{
+		"id": recipeID, "snapshot": string(b), "author": author,
+		"prompt": prompt, "restoredFrom": restoredFrom,
+	}, nil
+}
+
+// revisionAuthor returns the author and prompt for a user save; a save that
+// carries an AI prompt is recorded as the AI's edit.
+func revisionAuthor(prompt *string) (string, *string) {
+	if prompt != nil && strings.TrimSpace(*prompt) != "" {
+		return "ai", prompt
+	}
+	return "user", nil
+}
+
+// clearMissingFoodLinks unlinks ingredients whose food has since been deleted,
+// so an old snapshot can be written back.
+func clearMissingFoodLinks(c *gin.Context, tx pgx.Tx, userID int, ingredients []ingredientInput) error {
+	var ids []int
+	for _, ing := range ingredients {
+		if ing.FoodID != nil {
+			ids = append(ids, *ing.FoodID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := tx.Query(c,
+		`SELECT id FROM foods WHERE user_id = @userID AND id = ANY(@ids::int[])`,
+		pgx.NamedArgs{"userID": userID, "ids": ids})
+	if err != nil {
+		return err
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return err
+	}
+	found := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+	for i := range ingredients {
+		if ingredients[i].FoodID != nil && !found[*ingredients[i].FoodID] {
+			ingredients[i].FoodID = nil
+		}
+	}
+	return nil
+}
+
+/* ─── Diff ───────────────────────────────────────────────────────────── */
+
+// fieldChange is one top-level recipe field that differs between revisions.
+type fieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// ingredientChange is an ingredient added, removed, or changed (qty, unit,
+// note, grams, or food or recipe link). Ingredients are matched by name.
+type ingredientChange struct {
+	Change string            `json:"change"`
+	Name   string            `json:"name"`
+	From   *recipeIngredient `json:"from"`
+	To     *recipeIngredient `json:"to"`
+}
+
+// stepChange is a step added, removed, or rewritten. FromStep/ToStep are
+// 1-based step numbers in each revision.
+type stepChange struct {
+	Change   string      `json:"change"`
+	FromStep *int        `json:"from_step"`
+	ToStep   *int        `json:"to_step"`
+	From     *recipeStep `json:"from"`
+	To       *recipeStep `json:"to"`
+}
+
+// recipeDiff is the response for GET /api/recipes/:id/revisions/diff.
+type recipeDiff struct {
+	From         int                `json:"from"`
+	To           int                `json:"to"`
+	Fields       []fieldChange      `json:"fields"`
+	Ingredients  []ingredientChange `json:"ingredients"`
+	ToolsAdded   []string           `json:"tools_added"`
+	ToolsRemoved []string           `json:"tools_removed"`
+	Steps        []stepChange       `json:"steps"`
+}
+
+// ingredientKey matches ingredients across revisions.
+func ingredientKey(ing recipeIngredient) string {
+	return strings.ToLower(strings.TrimSpace(ing.Name))
+}
+
+// sameIngredient compares the parts of an ingredient a user would notice.
+func sameIngredient(a, b recipeIngredient) bool {
+	return a.Name == b.Name &&
+		reflect.DeepEqual(a.Qty, b.Qty) && reflect.DeepEqual(a.Uom, b.Uom) &&
+		reflect.DeepEqual(a.Note, b.Note) && reflect.DeepEqual(a.Grams, b.Grams) &&
+		reflect.DeepEqual(a.FoodID, b.FoodID) && reflect.DeepEqual(a.SubRecipeID, b.SubRecipeID)
+}
+
+func stepKey(s recipeStep) string {
+	key := s.Type + "\x00" + s.Text + "\x00"
+	if s.TimerSeconds != nil {
+		key += strconv.Itoa(*s.TimerSeconds)
+	}
+	if s.MeanwhileText != nil {
+		key += "\x00" + *s.MeanwhileText
+	}
+	for _, ref := range s.Ingredients {
+		key += "\x00" + strings.ToLower(ref.Name)
+		if ref.Qty != nil {
+			key += " " + strconv.FormatFloat(*ref.Qty, 'f', -1, 64)
+		}
+		if ref.Uom != nil {
+			key += " " + *ref.Uom
+		}
+	}
+	for _, ref := range s.Tools {
+		key += "\x00" + strings.ToLower(ref.Name)
+	}
+	return key
+}
+
+// diffIngredients pairs ingredients by name, in order for repeated names.
+// Changes follow the newer ingredient order; removals come last.
+func diffIngredients(from, to []recipeIngredient) []ingredientChange {
+	unmatched := map[string][]int{}
+	for i, ing := range from {
+		k := ingredientKey(ing)
+		unmatched[k] = append(unmatched[k], i)
+	}
+	matched := make([]bool, len(from))
+	changes := []ingredientChange{}
+	for i := range to {
+		k := ingredientKey(to[i])
+		if idx := unmatched[k]; len(idx) > 0 {
+			unmatched[k] = idx[1:]
+			matched[idx[0]] = true
+			if !sameIngredient(from[idx[0]], to[i]) {
+				changes = append(changes, ingredientChange{Change: "changed", Name: to[i].Name, From: &from[idx[0]], To: &to[i]})
+			}
+			continue
+		}
+		changes = append(changes, ingredientChange{Change: "added", Name: to[i].Name, To: &to[i]})
+	}
+	for i := range from {
+		if !matched[i] {
+			changes = append(changes, ingredientChange{Change: "removed", Name: from[i].Name, From: &from[i]})
+		}
+	}
+	return changes
+}
+
+// diffSteps aligns steps with a longest-common-subsequence match on their
+// content. A run of removed steps followed by added ones is reported as
+// rewritten steps, pairwise, since that's how an edit to a step reads.
+func diffSteps(from, to []recipeStep) []stepChange {
+	n, m := len(from), len(to)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if stepKey(from[i]) == stepKey(to[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := []stepChange{}
+	var removed, added []int
+	number := func(i int) *int {
+		n := i + 1
+		return &n
+	}
+	flush := func() {
+		paired := min(len(removed), len(added))
+		for k := 0; k < paired; k++ {
+			fi, ti := removed[k], added[k]
+			changes = append(changes, stepChange{Change: "changed",
+				FromStep: number(fi), ToStep: number(ti), From: &from[fi], To: &to[ti]})
+		}
+		for _, fi := range removed[paired:] {
+			changes = append(changes, stepChange{Change: "removed", FromStep: number(fi), From: &from[fi]})
+		}
+		for _, ti := range added[paired:] {
+			changes = append(changes, stepChange{Change: "added", ToStep: number(ti), To: &to[ti]})
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && stepKey(from[i]) == stepKey(to[j]):
+			flush()
+			i, j = i+1, j+1
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, j)
+			j++
+		default:
+			removed = append(removed, i)
+			i++
+		}
+	}
+	flush()
+	return changes
+}
+
+// diffTools returns tool names only in to (added) and only in from (removed).
+func diffTools(from, to []recipeTool) (added, removed []string) {
+	count := map[string]int{}
+	for _, t := range from {
+		count[strings.ToLower(t.Name)]++
+	}
+	added, removed = []string{}, []string{}
+	for _, t := range to {
+		k := strings.ToLower(t.Name)
+		if count[k] > 0 {
+			count[k]--
+			continue
+		}
+		added = append(added, t.Name)
+	}
+	for _, t := range from {
+		k := strings.ToLower(t.Name)
+		if count[k] > 0 {
+			count[k]--
+			removed = append(removed, t.Name)
+		}
+	}
+	return added, removed
+}
+
+// diffRecipes compares two recipe snapshots field by field, ingredient by
+// ingredient, and step by step.
+func diffRecipes(from, to recipeDetail) recipeDiff {
+	d := recipeDiff{Fields: []fieldChange{}}
+	field := func(name string, a, b any) {
+		if !reflect.DeepEqual(a, b) {
+			d.Fields = append(d.Fields, fieldChange{Field: name, From: a, To: b})
+		}
+	}
+	field("name", from.Name, to.Name)
+	field("emoji", from.Emoji, to.Emoji)
+	field("category", from.Category, to.Category)
+	field("notes", from.Notes, to.Notes)
+	field("servings", from.Servings, to.Servings)
+	field("calories", from.Calories, to.Calories)
+	field("protein_g", from.ProteinG, to.ProteinG)
+	field("carbs_g", from.CarbsG, to.CarbsG)
+	field("fat_g", from.FatG, to.FatG)
+
+	if strings.Join(from.Tags, ",") != strings.Join(to.Tags, ",") {
+		d.Fields = append(d.Fields, fieldChange{Field: "tags", From: from.Tags, To: to.Tags})
+	}
+
+	d.Ingredients = diffIngredients(from.Ingredients, to.Ingredients)
+	d.ToolsAdded, d.ToolsRemoved = diffTools(from.Tools, to.Tools)
+	d.Steps = diffSteps(from.Steps, to.Steps)
+	return d
+}
+
+/* ─── Handlers ───────────────────────────────────────────────────────── */
+
+// loadRecipeRevision returns one revision of a user's recipe by number.
+func (h *Handler) loadRecipeRevision(c *gin.Context, userID, recipeID, revision int) (recipeRevision, error) {
+	return queryOne[recipeRevision](h.db, c,
+		`SELECT rv.id, rv.recipe_id, rv.revision, rv.author, rv.ai_prompt, rv.restored_from, rv.created_at, rv.snapshot
+		 FROM recipe_revisions rv
+		 JOIN recipes r ON r.id = rv.recipe_id
+		 WHERE rv.recipe_id = @recipeID AND rv.revision = @revision AND r.user_id = @userID`,
+		pgx.NamedArgs{"recipeID": recipeID, "revision": revision, "userID": userID})
+}
+
+// listRecipeRevisions returns a recipe's revisions, newest first, without
+// their snapshots.
+// GET /api/recipes/:id/revisions
+func (h *Handler) listRecipeRevisions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow(c,
+		`SELECT EXISTS (SELECT 1 FROM recipes WHERE id = @id AND user_id = @userID)`,
+		pgx.NamedArgs{"id": id, "userID": userID}).Scan(&exists)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+	if !exists {
+		apiError(c, http.StatusNotFound, "recipe not found")
+		return
+	}
+
+	revisions, err := queryMany[recipeRevisionSummary](h.db, c,
+		`SELECT id, recipe_id, revision, author, ai_prompt, restored_from, created_at
+		 FROM recipe_revisions
+		 WHERE recipe_id = @id
+		 ORDER BY revision DESC`,
+		pgx.NamedArgs{"id": id})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch revisions")
+		return
+	}
+	if revisions == nil {
+		revisions = []recipeRevisionSummary{}
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// getRecipeRevision returns one revision with its full recipe snapshot.
+// GET /api/recipes/:id/revisions/:rev
+func (h *Handler) getRecipeRevision(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	revision, err := h.loadRecipeRevision(c, userID, id, rev)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "revision not found")
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// diffRecipeRevisions compares two revisions of a recipe. to defaults to the
+// latest revision and from to the one before it.
+// GET /api/recipes/:id/revisions/diff?from=N&to=M
+func (h *Handler) diffRecipeRevisions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+
+	var to int
+	if s := c.Query("to"); s != "" {
+		if to, err = strconv.Atoi(s); err != nil {
+			apiError(c, http.StatusBadRequest, "invalid to revision")
+			return
+		}
+	} else {
+		err = h.db.QueryRow(c,
+			`SELECT COALESCE(MAX(rv.revision), 0)
+			 FROM recipe_revisions rv JOIN recipes r ON r.id = rv.recipe_id
+			 WHERE rv.recipe_id = @id AND r.user_id = @userID`,
+			pgx.NamedArgs{"id": id, "userID": userID}).Scan(&to)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to fetch revisions")
+			return
+		}
+	}
+	from := to - 1
+	if s := c.Query("from"); s != "" {
+		if from, err = strconv.Atoi(s); err != nil {
+			apiError(c, http.StatusBadRequest, "invalid from revision")
+			return
+		}
+	}
+
+	fromRev, err := h.loadRecipeRevision(c, userID, id, from)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "revision not found")
+		return
+	}
+	toRev, err := h.loadRecipeRevision(c, userID, id, to)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "revision not found")
+		return
+	}
+
+	d := diffRecipes(fromRev.Snapshot, toRev.Snapshot)
+	d.From, d.To = from, to
+	c.JSON(http.StatusOK, d)
+}
+
+// restoreRecipeRevision writes an earlier revision back as the recipe's
+// current state. The restore is itself a new revision, so it can be undone.
+// Links to foods deleted since are dropped.
+// POST /api/recipes/:id/revisions/:rev/restore
+func (h *Handler) restoreRecipeRevision(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	revision, err := h.loadRecipeRevision(c, userID, id, rev)
+	if err != nil {
+		apiError(c, http.StatusNotFound, "revision not found")
+		return
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	req := recipeRequestFromDetail(revision.Snapshot)
+	if err := clearMissingFoodLinks(c, tx, userID, req.Ingredients); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check ingredient foods")
+		return
+	}
+	if err := clearInvalidSubRecipeLinks(c, tx, userID, id, req.Ingredients); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check ingredient recipes")
+		return
+	}
+	if err := replaceRecipe(tx, c, id, req); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to restore recipe")
+		return
+	}
+	if err := syncLinkedFavorites(c, tx, userID); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to sync favorites")
+		return
+	}
+	if err := recordRecipeRevision(c, tx, id, "user", nil, &rev); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to record revision")
+		return
+	}
+
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	detail, err := fetchRecipeDetail(h, c, id)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch restored recipe")
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func revisionFixture() recipeDetail {
+	f64 := func(v float64) *float64 { return &v }
+	d := sampleRecipeDetail()
+	d.Ingredients = []recipeIngredient{
+		{ID: 1, Name: "butter", Qty: f64(2), Uom: strPtr("tbsp")},
+		{ID: 2, Name: "onion", Qty: f64(1)},
+		{ID: 3, Name: "salt"},
+	}
+	d.Tools = []recipeTool{{ID: 1, Name: "Skillet"}}
+	d.Steps = []recipeStep{
+		{ID: 1, Type: "instruction", Text: "Melt the butter."},
+		{ID: 2, Type: "instruction", Text: "Add the onion."},
+		{ID: 3, Type: "timer", Text: "Cook until soft.", TimerSeconds: intPtr(600)},
+		{ID: 4, Type: "instruction", Text: "Season with salt."},
+	}
+	return d
+}
+
+func TestSameRecipeContent(t *testing.T) {
+	a := revisionFixture()
+	b := revisionFixture()
+	// New row IDs from a save without changes don't count.
+	for i := range b.Ingredients {
+		b.Ingredients[i].ID += 10
+	}
+	if !sameRecipeContent(a, b) {
+		t.Error("expected same content")
+	}
+	b.Steps[1].Text = "Add the shallot."
+	if sameRecipeContent(a, b) {
+		t.Error("expected different content")
+	}
+}
+
+func TestDiffRecipes(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	from := revisionFixture()
+	to := revisionFixture()
+	to.Name = "Spicy " + from.Name
+	to.Servings = 6
+	to.Ingredients = []recipeIngredient{
+		{ID: 11, Name: "olive oil", Qty: f64(2), Uom: strPtr("tbsp")},
+		{ID: 12, Name: "Onion", Qty: f64(2)},
+		{ID: 13, Name: "salt"},
+		{ID: 14, Name: "chili flakes", Qty: f64(1), Uom: strPtr("tsp")},
+	}
+	to.Tools = []recipeTool{{Name: "skillet"}, {Name: "Grater"}}
+	to.Steps = []recipeStep{
+		{Type: "instruction", Text: "Heat the oil."},
+		{Type: "instruction", Text: "Add the onion."},
+		{Type: "timer", Text: "Cook until soft.", TimerSeconds: intPtr(900)},
+		{Type: "instruction", Text: "Season with salt."},
+		{Type: "instruction", Text: "Top with chili flakes."},
+	}
+
+	d := diffRecipes(from, to)
+
+	if len(d.Fields) != 2 || d.Fields[0].Field != "name" || d.Fields[1].Field != "servings" {
+		t.Errorf("fields: got %+v", d.Fields)
+	}
+
+	var got []string
+	for _, ch := range d.Ingredients {
+		got = append(got, ch.Change+" "+ch.Name)
+	}
+	want := []string{"added olive oil", "changed Onion", "added chili flakes", "removed butter"}
+	if len(got) != len(want) {
+		t.Fatalf("ingredients: got %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ingredient %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+	if ch := d.Ingredients[1]; *ch.From.Qty != 1 || *ch.To.Qty != 2 {
+		t.Errorf("onion qty: got %v -> %v", *ch.From.Qty, *ch.To.Qty)
+	}
+
+	if len(d.ToolsAdded) != 1 || d.ToolsAdded[0] != "Grater" || len(d.ToolsRemoved) != 0 {
+		t.Errorf("tools: got +%v -%v", d.ToolsAdded, d.ToolsRemoved)
+	}
+
+	if len(d.Steps) != 3 {
+		t.Fatalf("steps: got %+v", d.Steps)
+	}
+	if s := d.Steps[0]; s.Change != "changed" || *s.FromStep != 1 || *s.ToStep != 1 || s.To.Text != "Heat the oil." {
+		t.Errorf("step 1: got %+v", s)
+	}
+	if s := d.Steps[1]; s.Change != "changed" || *s.FromStep != 3 || *s.To.TimerSeconds != 900 {
+		t.Errorf("timer step: got %+v", s)
+	}
+	if s := d.Steps[2]; s.Change != "added" || s.FromStep != nil || *s.ToStep != 5 {
+		t.Errorf("added step: got %+v", s)
+	}
+}
+
+func TestDiffSteps_Reorder(t *testing.T) {
+	a := []recipeStep{{Text: "one"}, {Text: "two"}, {Text: "three"}}
+	b := []recipeStep{{Text: "two"}, {Text: "three"}, {Text: "one"}}
+	d := diffSteps(a, b)
+	if len(d) != 2 || d[0].Change != "removed" || *d[0].FromStep != 1 || d[1].Change != "added" || *d[1].ToStep != 3 {
+		t.Errorf("got %+v", d)
+	}
+	if d := diffSteps(a, a); len(d) != 0 {
+		t.Errorf("unchanged: got %+v", d)
+	}
+}
+
+func TestRevisionAuthor(t *testing.T) {
+	if author, prompt := revisionAuthor(nil); author != "user" || prompt != nil {
+		t.Errorf("nil prompt: got %q %v", author, prompt)
+	}
+	if author, _ := revisionAuthor(strPtr("  ")); author != "user" {
+		t.Errorf("blank prompt: got %q", author)
+	}
+	if author, prompt := revisionAuthor(strPtr("make it vegan")); author != "ai" || *prompt != "make it vegan" {
+		t.Errorf("ai prompt: got %q %v", author, prompt)
+	}
+}
+
+// TestRevisionAuthor_SaveBody decodes the body the web client sends when
+// saving an ai-modify draft (RecipeDetail → updateRecipe).
+func TestRevisionAuthor_SaveBody(t *testing.T) {
+	var req updateRecipeRequest
+	body := `{"name":"Vegan Chili","servings":4,"ai_prompt":"make it vegan\nless salt"}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	if author, prompt := revisionAuthor(req.AIPrompt); author != "ai" || prompt == nil || *prompt != "make it vegan\nless salt" {
+		t.Errorf("ai save: got %q %v", author, prompt)
+	}
+
+	req = updateRecipeRequest{}
+	if err := json.Unmarshal([]byte(`{"name":"Vegan Chili"}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if author, _ := revisionAuthor(req.AIPrompt); author != "user" {
+		t.Errorf("manual save: got %q", author)
+	}
+}
+
+// assertSimpleProtocolArgs fails when an arg can't be encoded as text with no
+// type OID — how the pool (QueryExecModeSimpleProtocol) sends parameters.
+func assertSimpleProtocolArgs(t *testing.T, args pgx.NamedArgs) {
+	t.Helper()
+	m := pgtype.NewMap()
+	for name, v := range args {
+		if _, err := m.Encode(0, pgtype.TextFormatCode, v, nil); err != nil {
+			t.Errorf("@%s (%T) doesn't encode under the simple protocol: %v", name, v, err)
+		}
+	}
+}
+
+func TestRecipeRevisionArgs(t *testing.T) {
+	snapshot := revisionFixture()
+	args, err := recipeRevisionArgs(1, snapshot, "ai", strPtr("make it vegan"), intPtr(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSimpleProtocolArgs(t, args)
+
+	var back recipeDetail
+	if err := json.Unmarshal([]byte(args["snapshot"].(string)), &back); err != nil || !sameRecipeContent(back, snapshot) {
+		t.Errorf("snapshot doesn't round-trip: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxScaledServings caps how far a recipe can be scaled up.
+const maxScaledServings = 1000
+
+// scaleUnit is one unit of a measurement system. Base is its size in the
+// system's smallest unit; the largest unit whose Min the amount reaches is
+// used for display.
+type scaleUnit struct {
+	Name string
+	Base float64
+	Min  float64 // in base units
+}
+
+// scaleSystems lists each system's units smallest first. Imperial volumes
+// stop at cups since that's how recipes are written; mg is accepted but
+// scaled amounts are shown in grams.
+var scaleSystems = map[string][]scaleUnit{
+	"us_volume":     {{"tsp", 1, 0}, {"tbsp", 3, 3}, {"cup", 48, 12}},
+	"metric_volume": {{"ml", 1, 0}, {"l", 1000, 1000}},
+	"us_mass":       {{"oz", 1, 0}, {"lb", 16, 16}},
+	"metric_mass":   {{"g", 1, 0}, {"kg", 1000, 1000}},
+}
+
+// scaleUnitAliases maps normalized unit spellings to a system and base size.
+var scaleUnitAliases = map[string]struct {
+	System string
+	Base   float64
+}{
+	"tsp": {"us_volume", 1}, "teaspoon": {"us_volume", 1}, "teaspoons": {"us_volume", 1},
+	"tbsp": {"us_volume", 3}, "tbs": {"us_volume", 3}, "tablespoon": {"us_volume", 3}, "tablespoons": {"us_volume", 3},
+	"fl oz": {"us_volume", 6}, "cup": {"us_volume", 48}, "cups": {"us_volume", 48},
+	"pint": {"us_volume", 96}, "pints": {"us_volume", 96}, "quart": {"us_volume", 192}, "quarts": {"us_volume", 192},
+	"ml": {"metric_volume", 1}, "milliliter": {"metric_volume", 1}, "milliliters": {"metric_volume", 1},
+	"l": {"metric_volume", 1000}, "liter": {"metric_volume", 1000}, "liters": {"metric_volume", 1000}, "litre": {"metric_volume", 1000},
+	"oz": {"us_mass", 1}, "ounce": {"us_mass", 1}, "ounces": {"us_mass", 1},
+	"lb": {"us_mass", 16}, "lbs": {"us_mass", 16}, "pound": {"us_mass", 16}, "pounds": {"us_mass", 16},
+	"mg": {"metric_mass", 0.001}, "g": {"metric_mass", 1}, "gram": {"metric_mass", 1}, "grams": {"metric_mass", 1},
+	"kg": {"metric_mass", 1000}, "kilogram": {"metric_mass", 1000}, "kilograms": {"metric_mass", 1000},
+}
+
+// kitchenFractions are the fractions imperial amounts are rounded to.
+var kitchenFractions = []struct {
+	Value float64
+	Text  string
+}{
+	{0, ""}, {1.0 / 8, "1/8"}, {1.0 / 4, "1/4"}, {1.0 / 3, "1/3"}, {3.0 / 8, "3/8"}, {1.0 / 2, "1/2"},
+	{5.0 / 8, "5/8"}, {2.0 / 3, "2/3"}, {3.0 / 4, "3/4"}, {7.0 / 8, "7/8"}, {1, ""},
+}
+
+// formatFraction rounds v to the nearest kitchen fraction and renders it
+// ("1 1/2"). Amounts that would round to zero show as 1/8.
+func formatFraction(v float64) (float64, string) {
+	whole := math.Floor(v)
+	best := kitchenFractions[0]
+	for _, f := range kitchenFractions[1:] {
+		if math.Abs(v-whole-f.Value) < math.Abs(v-whole-best.Value) {
+			best = f
+		}
+	}
+	if best.Value == 1 {
+		whole, best = whole+1, kitchenFractions[0]
+	}
+	if whole == 0 && best.Value == 0 {
+		best = kitchenFractions[1]
+	}
+	rounded := math.Round((whole+best.Value)*1000) / 1000
+	switch {
+	case best.Text == "":
+		return rounded, strconv.Itoa(int(whole))
+	case whole == 0:
+		return rounded, best.Text
+	default:
+		return rounded, fmt.Sprintf("%d %s", int(whole), best.Text)
+	}
+}
+
+// formatDecimal rounds a metric amount to a sensible precision.
+func formatDecimal(v float64) (float64, string) {
+	switch {
+	case v >= 100:
+		v = math.Round(v)
+	case v >= 10:
+		v = math.Round(v*10) / 10
+	default:
+		v = math.Round(v*100) / 100
+	}
+	return v, strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// scaleIngredient multiplies an ingredient by factor, moving to a larger or
+// smaller unit of the same system when that reads better (3 tsp → 1 tbsp,
+// 1000 g → 1 kg). Unknown units are scaled in place.
+func scaleIngredient(ing recipeIngredient, factor float64) recipeIngredient {
+	if ing.Grams != nil {
+		g := round1(*ing.Grams * factor)
+		ing.Grams = &g
+	}
+	if ing.Qty == nil {
+		return ing
+	}
+	qty := *ing.Qty * factor
+	system, metric := "", false
+	if alias, ok := scaleUnitAliases[normalizeUom(ing.Uom)]; ok {
+		system, metric = alias.System, alias.System == "metric_volume" || alias.System == "metric_mass"
+		amount := qty * alias.Base
+		units := scaleSystems[system]
+		unit := units[0]
+		for _, u := range units {
+			if amount >= u.Min {
+				unit = u
+			}
+		}
+		qty = amount / unit.Base
+		if unit.Base != alias.Base {
+			name := unit.Name
+			if name == "cup" && qty > 1 {
+				name = "cups"
+			}
+			ing.Uom = &name
+		}
+	}
+
+	if metric {
+		qty, ing.QtyText = formatDecimal(qty)
+	} else {
+		qty, ing.QtyText = formatFraction(qty)
+	}
+	ing.Qty = &qty
+	return ing
+}
+
+// scaleNutrition scales a computed nutrition breakdown to a new yield.
+// Per-serving numbers don't change.
+func scaleNutrition(n recipeNutrition, factor, servings float64) recipeNutrition {
+	scale := func(t nutritionTotals) nutritionTotals {
+		return nutritionTotals{
+			Calories: int(math.Round(float64(t.Calories) * factor)),
+			ProteinG: round1(t.ProteinG * factor),
+			CarbsG:   round1(t.CarbsG * factor),
+			FatG:     round1(t.FatG * factor),
+		}
+	}
+	n.Servings = servings
+	n.Total = scale(n.Total)
+	ingredients := make([]ingredientNutrition, len(n.Ingredients))
+	for i, in := range n.Ingredients {
+		if in.Grams != nil {
+			g := round1(*in.Grams * factor)
+			in.Grams = &g
+		}
+		in.nutritionTotals = scale(in.nutritionTotals)
+		ingredients[i] = in
+	}
+	n.Ingredients = ingredients
+	return n
+}
+
+// scaleRequest picks a yield by servings or by total calories for the batch.
+type scaleRequest struct {
+	Servings *float64 `json:"servings" form:"servings"`
+	Calories *int     `json:"calories" form:"calories"`
+	Name     *string  `json:"name"`
+}
+
+// scaleFactor resolves a scale request against a recipe, returning the factor
+// and new servings, or a user-facing message.
+func scaleFactor(r recipe, req scaleRequest) (float64, float64, string) {
+	if (req.Servings == nil) == (req.Calories == nil) {
+		return 0, 0, "exactly one of servings or calories is required"
+	}
+	if r.Servings <= 0 {
+		return 0, 0, "recipe has no servings to scale from"
+	}
+	servings := 0.0
+	if req.Servings != nil {
+		servings = *req.Servings
+	} else {
+		if r.Calories == nil || *r.Calories <= 0 {
+			return 0, 0, "recipe has no calories to scale by"
+		}
+		if *req.Calories <= 0 {
+			return 0, 0, "calories must be positive"
+		}
+		servings = math.Round(float64(*req.Calories)/float64(*r.Calories)*100) / 100
+	}
+	if servings <= 0 || servings > maxScaledServings {
+		return 0, 0, fmt.Sprintf("servings must be between 0 and %d", maxScaledServings)
+	}
+	return servings / r.Servings, servings, ""
+}
+
+// scaleRecipeDetail returns a copy of d scaled by factor to servings,
+// including the amounts its steps use.
+func scaleRecipeDetail(d recipeDetail, factor, servings float64) recipeDetail {
+	d.Servings = servings
+	ingredients := make([]recipeIngredient, len(d.Ingredients))
+	for i, ing := range d.Ingredients {
+		ingredients[i] = scaleIngredient(ing, factor)
+	}
+	d.Ingredients = ingredients
+	steps := make([]recipeStep, len(d.Steps))
+	for i, step := range d.Steps {
+		refs := make([]stepIngredient, len(step.Ingredients))
+		for j, ref := range step.Ingredients {
+			scaled := scaleIngredient(recipeIngredient{Qty: ref.Qty, Uom: ref.Uom}, factor)
+			ref.Qty, ref.Uom, ref.QtyText = scaled.Qty, scaled.Uom, scaled.QtyText
+			refs[j] = ref
+		}
+		step.Ingredients = refs
+		steps[i] = step
+	}
+	d.Steps = steps
+	if d.Nutrition != nil {
+		n := scaleNutrition(*d.Nutrition, factor, servings)
+		d.Nutrition = &n
+	}
+	return d
+}
+
+// scaledRecipe is a recipe scaled to a new yield.
+type scaledRecipe struct {
+	recipeDetail
+	ScaleFactor      float64 `json:"scale_factor"`
+	OriginalServings float64 `json:"original_servings"`
+}
+
+// loadScaledRecipe loads the caller's recipe and scales it per req, writing
+// an error response on failure.
+func (h *Handler) loadScaledRecipe(c *gin.Context, req scaleRequest) (scaledRecipe, bool) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return scaledRecipe{}, false
+	}
+	src, err := fetchRecipeDetail(h, c, id)
+	if err != nil || src.UserID != userID {
+		apiError(c, http.StatusNotFound, "recipe not found")
+		return scaledRecipe{}, false
+	}
+	factor, servings, msg := scaleFactor(src.recipe, req)
+	if msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return scaledRecipe{}, false
+	}
+	return scaledRecipe{
+		recipeDetail:     scaleRecipeDetail(src, factor, servings),
+		ScaleFactor:      math.Round(factor*1000) / 1000,
+		OriginalServings: src.Servings,
+	}, true
+}
+
+// getScaledRecipe returns a recipe scaled to a number of servings or a total
+// calorie count. Nothing is saved.
+// GET /api/recipes/:id/scaled?servings=6 or ?calories=2000
+func (h *Handler) getScaledRecipe(c *gin.Context) {
+	var req scaleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apiError(c, http.StatusBadRequest, "servings and calories must be numbers")
+		return
+	}
+	scaled, ok := h.loadScaledRecipe(c, req)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, scaled)
+}
+
+// saveScaledRecipe saves a scaled copy of a recipe as a new recipe. The name
+// defaults to the original's with the new yield.
+// POST /api/recipes/:id/scaled  {"servings": 6} or {"calories": 2000}
+func (h *Handler) saveScaledRecipe(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	var req scaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	scaled, ok := h.loadScaledRecipe(c, req)
+	if !ok {
+		return
+	}
+	name := fmt.Sprintf("%s (%s servings)", scaled.Name, strconv.FormatFloat(scaled.Servings, 'f', -1, 64))
+	if req.Name != nil && *req.Name != "" {
+		name = *req.Name
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	newID, err := insertRecipeCopy(tx, c, userID, scaled.recipeDetail, name)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to save scaled recipe")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	detail, err := fetchRecipeDetail(h, c, newID)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch scaled recipe")
+		return
+	}
+	c.JSON(http.StatusCreated, detail)
+}
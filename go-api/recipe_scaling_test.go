@@ -0,0 +1,113 @@
+package main
+
+import "testing"
+
+func TestScaleIngredient(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		name    string
+		qty     float64
+		uom     string
+		factor  float64
+		wantQty string
+		wantUom string
+	}{
+		{"tsp to tbsp", 1, "tsp", 3, "1", "tbsp"},
+		{"tbsp to cup", 6, "Tbsp", 1.5, "1/2", "cup"},
+		{"cups plural", 1, "cup", 2.5, "2 1/2", "cup"},
+		{"tbsp to tsp", 1, "tbsp", 0.5, "1 1/2", "tsp"},
+		{"g to kg", 500, "g", 2, "1", "kg"},
+		{"kg to g", 1, "kg", 0.25, "250", "g"},
+		{"oz to lb", 12, "oz", 2, "1 1/2", "lb"},
+		{"ml to l", 750, "ml", 2, "1.5", "l"},
+		{"unknown unit", 2, "cloves", 1.5, "3", "cloves"},
+		{"count", 3, "", 0.5, "1 1/2", ""},
+	}
+	for _, tc := range cases {
+		ing := recipeIngredient{Qty: f64(tc.qty)}
+		if tc.uom != "" {
+			ing.Uom = str(tc.uom)
+		}
+		got := scaleIngredient(ing, tc.factor)
+		uom := ""
+		if got.Uom != nil {
+			uom = *got.Uom
+		}
+		if got.QtyText != tc.wantQty || uom != tc.wantUom {
+			t.Errorf("%s: got %s %s, want %s %s", tc.name, got.QtyText, uom, tc.wantQty, tc.wantUom)
+		}
+	}
+
+	// Ingredients without a quantity ("salt to taste") are left alone.
+	if got := scaleIngredient(recipeIngredient{Name: "Salt"}, 2); got.Qty != nil || got.QtyText != "" {
+		t.Errorf("no qty: got %+v", got)
+	}
+}
+
+func TestScaleFactor(t *testing.T) {
+	cal := 500
+	r := recipe{Servings: 4, Calories: &cal}
+	six, total := 6.0, 3000
+
+	factor, servings, msg := scaleFactor(r, scaleRequest{Servings: &six})
+	if msg != "" || factor != 1.5 || servings != 6 {
+		t.Errorf("servings: got %v %v %q", factor, servings, msg)
+	}
+	factor, servings, msg = scaleFactor(r, scaleRequest{Calories: &total})
+	if msg != "" || factor != 1.5 || servings != 6 {
+		t.Errorf("calories: got %v %v %q", factor, servings, msg)
+	}
+	if _, _, msg := scaleFactor(r, scaleRequest{Servings: &six, Calories: &total}); msg == "" {
+		t.Error("both servings and calories should fail")
+	}
+	if _, _, msg := scaleFactor(recipe{Servings: 4}, scaleRequest{Calories: &total}); msg == "" {
+		t.Error("calorie scaling without calories should fail")
+	}
+}
+
+func TestScaleRecipeDetail(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	d := recipeDetail{
+		recipe:      recipe{Servings: 2},
+		Ingredients: []recipeIngredient{{Name: "Oats", Qty: f64(100), Grams: f64(100)}},
+		Nutrition: &recipeNutrition{
+			Servings:    2,
+			Total:       nutritionTotals{Calories: 380, ProteinG: 13},
+			PerServing:  nutritionTotals{Calories: 190, ProteinG: 6.5},
+			Ingredients: []ingredientNutrition{{Name: "Oats", Grams: f64(100), nutritionTotals: nutritionTotals{Calories: 380, ProteinG: 13}}},
+		},
+	}
+	got := scaleRecipeDetail(d, 3, 6)
+	if got.Servings != 6 || *got.Ingredients[0].Grams != 300 {
+		t.Errorf("detail: got servings %v grams %v", got.Servings, *got.Ingredients[0].Grams)
+	}
+	n := got.Nutrition
+	if n.Total.Calories != 1140 || n.PerServing.Calories != 190 || n.Ingredients[0].ProteinG != 39 {
+		t.Errorf("nutrition: got %+v", n)
+	}
+	// The source is untouched.
+	if *d.Ingredients[0].Qty != 100 || d.Nutrition.Total.Calories != 380 {
+		t.Error("scaling modified the source recipe")
+	}
+}
+
+func TestScaleRecipeDetail_StepIngredients(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	d := recipeDetail{
+		recipe:      recipe{Servings: 2},
+		Ingredients: []recipeIngredient{{ID: 1, Name: "flour", Qty: f64(400), Uom: strPtr("g")}},
+		Steps: []recipeStep{{Type: "instruction", Text: "Add half the flour.", Ingredients: []stepIngredient{
+			{IngredientID: 1, Name: "flour", Qty: f64(200), Uom: strPtr("g")},
+			{IngredientID: 2, Name: "salt"},
+		}}},
+	}
+	got := scaleRecipeDetail(d, 2.5, 5)
+	refs := got.Steps[0].Ingredients
+	if *refs[0].Qty != 500 || *refs[0].Uom != "g" || refs[0].QtyText != "500" || refs[1].Qty != nil {
+		t.Errorf("step ingredients: got %+v", refs)
+	}
+	if *d.Steps[0].Ingredients[0].Qty != 200 {
+		t.Error("scaling modified the source steps")
+	}
+}
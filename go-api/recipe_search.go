@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+/* ─── Tags ───────────────────────────────────────────────────────────── */
+
+// normalizeTags trims, lowercases, and de-duplicates recipe tags, dropping
+// empty ones, so "Weeknight" and "weeknight " filter as the same tag.
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// recipeTagCount is one entry in GET /api/recipes/tags.
+type recipeTagCount struct {
+	Tag   string `json:"tag"   db:"tag"`
+	Count int    `json:"count" db:"count"`
+}
+
+// listRecipeTags returns the user's recipe tags with how many recipes use
+// each, most used first — for tag pickers and filter chips.
+// GET /api/recipes/tags
+func (h *Handler) listRecipeTags(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	tags, err := queryMany[recipeTagCount](h.db, c,
+		`SELECT t.tag, COUNT(*) AS count
+		 FROM recipe_tags t
+		 JOIN recipes r ON r.id = t.recipe_id
+		 WHERE r.user_id = @userID
+		 GROUP BY t.tag
+		 ORDER BY count DESC, t.tag`,
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch tags")
+		return
+	}
+	if tags == nil {
+		tags = []recipeTagCount{}
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+/* ─── Search and filters ─────────────────────────────────────────────── */
+
+// recipeListQuery is the query string for GET /api/recipes. Repeated params
+// (tag, ingredient, exclude_ingredient, tool) must all match. NotCookedDays
+// is the "haven't made in a while" filter: recipes cooked before, but not in
+// the last N days; it defaults the sort to least recently cooked.
+type recipeListQuery struct {
+	Q               string   `form:"q"`
+	Category        string   `form:"category"`
+	Tags            []string `form:"tag"`
+	MaxCalories     *int     `form:"max_calories"` // per serving
+	MinProtein      *float64 `form:"min_protein"`  // grams per serving
+	MaxTimerMinutes *int     `form:"max_timer_minutes"`
+	Ingredients     []string `form:"ingredient"`
+	Excludes        []string `form:"exclude_ingredient"`
+	Tools           []string `form:"tool"`
+	NotCookedDays   *int     `form:"not_cooked_days"`
+	MinRating       *float64 `form:"min_rating"` // average of rated cooks
+	Sort            string   `form:"sort"`
+	Order           string   `form:"order"`
+	Limit           int      `form:"limit"`
+	Offset          int      `form:"offset"`
+}
+
+// recipeSorts maps the sort param to its ORDER BY expression and default
+// direction. relevance needs a search query.
+var recipeSorts = map[string]struct{ expr, order string }{
+	"updated":   {"r.updated_at", "desc"},
+	"created":   {"r.created_at", "desc"},
+	"name":      {"lower(r.name)", "asc"},
+	"calories":  {"r.calories", "asc"},
+	"protein":   {"r.protein_g", "desc"},
+	"time":      {"st.total_timer_seconds", "asc"},
+	"cooked":    {"ck.last_cooked_on", "asc"},
+	"popular":   {"ck.times_cooked", "desc"},
+	"relevance": {"ts_rank(s.document, query)", "desc"},
+}
+
+// Page size for GET /api/recipes.
+const (
+	defaultRecipePageSize = 50
+	maxRecipePageSize     = 200
+)
+
+// buildRecipeListSQL turns the list query into SQL and args. Returns a
+// message for invalid params. The query fetches limit+1 rows so the caller
+// can report has_more without a COUNT.
+func buildRecipeListSQL(userID int, q recipeListQuery) (string, pgx.NamedArgs, string) {
+	if q.Limit <= 0 {
+		q.Limit = defaultRecipePageSize
+	}
+	if q.Limit > maxRecipePageSize {
+		q.Limit = maxRecipePageSize
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	args := pgx.NamedArgs{"userID": userID, "limit": q.Limit + 1, "offset": q.Offset}
+
+	var where, search string
+	q.Q = strings.TrimSpace(q.Q)
+	if q.Q != "" {
+		// recipe_search_documents holds each recipe's full-text document
+		// (name and tags weigh most, then ingredients, notes, and step text),
+		// kept current by triggers and GIN-indexed.
+		search = `
+		JOIN recipe_search_documents s ON s.recipe_id = r.id
+		CROSS JOIN websearch_to_tsquery('english', @q) AS query`
+		where += " AND s.document @@ query"
+		args["q"] = q.Q
+	}
+	if q.Category != "" {
+		if !validRecipeCategories[q.Category] {
+			return "", nil, "invalid category"
+		}
+		where += " AND r.category = @category::recipe_category"
+		args["category"] = q.Category
+	}
+	if tags := normalizeTags(q.Tags); len(tags) > 0 {
+		where += ` AND NOT EXISTS (
+			SELECT 1 FROM unnest(@tags::text[]) AS want(tag)
+			WHERE NOT EXISTS (SELECT 1 FROM recipe_tags t WHERE t.recipe_id = r.id AND t.tag = want.tag))`
+		args["tags"] = tags
+	}
+	if q.MaxCalories != nil {
+		where += " AND r.calories <= @maxCalories"
+		args["maxCalories"] = *q.MaxCalories
+	}
+	if q.MinProtein != nil {
+		where += " AND r.protein_g >= @minProtein"
+		args["minProtein"] = *q.MinProtein
+	}
+	if q.MaxTimerMinutes != nil {
+		where += " AND st.total_timer_seconds <= @maxTimerSeconds"
+		args["maxTimerSeconds"] = *q.MaxTimerMinutes * 60
+	}
+	if terms := searchTerms(q.Ingredients); len(terms) > 0 {
+		where += ` AND NOT EXISTS (
+			SELECT 1 FROM unnest(@ingredients::text[]) AS want(term)
+			WHERE NOT EXISTS (SELECT 1 FROM recipe_ingredients i
+			                  WHERE i.recipe_id = r.id AND i.name ILIKE '%' || want.term || '%' ESCAPE '\'))`
+		args["ingredients"] = terms
+	}
+	if terms := searchTerms(q.Excludes); len(terms) > 0 {
+		where += ` AND NOT EXISTS (
+			SELECT 1 FROM recipe_ingredients i, unnest(@excludes::text[]) AS avoid(term)
+			WHERE i.recipe_id = r.id AND i.name ILIKE '%' || avoid.term || '%' ESCAPE '\')`
+		args["excludes"] = terms
+	}
+	if terms := searchTerms(q.Tools); len(terms) > 0 {
+		where += ` AND NOT EXISTS (
+			SELECT 1 FROM unnest(@tools::text[]) AS want(term)
+			WHERE NOT EXISTS (SELECT 1 FROM recipe_tools t
+			                  WHERE t.recipe_id = r.id AND t.name ILIKE '%' || want.term || '%' ESCAPE '\'))`
+		args["tools"] = terms
+	}
+	if q.NotCookedDays != nil {
+		if *q.NotCookedDays < 0 {
+			return "", nil, "not_cooked_days must not be negative"
+		}
+		where += " AND ck.last_cooked_on < CURRENT_DATE - @notCookedDays::int"
+		args["notCookedDays"] = *q.NotCookedDays
+	}
+	if q.MinRating != nil {
+		where += " AND ck.avg_rating >= @minRating"
+		args["minRating"] = *q.MinRating
+	}
+
+	if q.Sort == "" {
+		switch {
+		case q.Q != "":
+			q.Sort = "relevance"
+		case q.NotCookedDays != nil:
+			q.Sort = "cooked"
+		default:
+			q.Sort = "updated"
+		}
+	}
+	sort, ok := recipeSorts[q.Sort]
+	if !ok || (q.Sort == "relevance" && q.Q == "") {
+		return "", nil, "invalid sort; expected updated|created|name|calories|protein|time|cooked|popular|relevance (relevance needs q)"
+	}
+	order := sort.order
+	switch q.Order {
+	case "":
+	case "asc", "desc":
+		order = q.Order
+	default:
+		return "", nil, "invalid order; expected asc|desc"
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT r.*, st.step_count, st.total_timer_seconds,
+		  COALESCE(ARRAY(SELECT tag FROM recipe_tags WHERE recipe_id = r.id ORDER BY tag), '{}') AS tags,
+		  ck.last_cooked_on, ck.times_cooked, ck.avg_rating
+		FROM recipes r
+		CROSS JOIN LATERAL (
+		  SELECT COUNT(*) AS step_count,
+		         COALESCE(SUM(timer_seconds) FILTER (WHERE type = 'timer'), 0) AS total_timer_seconds
+		  FROM recipe_steps WHERE recipe_id = r.id
+		) st
+		CROSS JOIN LATERAL (
+		  SELECT MAX(cooked_on) AS last_cooked_on, COUNT(*) AS times_cooked,
+		         ROUND(AVG(rating), 1)::float8 AS avg_rating
+		  FROM recipe_cooks WHERE recipe_id = r.id
+		) ck%s
+		WHERE r.user_id = @userID%s
+		ORDER BY %s %s NULLS LAST, r.id %s
+		LIMIT @limit OFFSET @offset`, search, where, sort.expr, order, order)
+	return sql, args, ""
+}
+
+// likeEscaper escapes LIKE wildcards, for patterns matched with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchTerms trims filter terms and drops empty ones. Terms are escaped for
+// substring matching with ILIKE, so "%" or "_" in a term matches literally.
+func searchTerms(terms []string) []string {
+	var out []string
+	for _, t := range terms {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, likeEscaper.Replace(t))
+		}
+	}
+	return out
+}
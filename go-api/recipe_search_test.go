@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" Weeknight ", "weeknight", "", "Freezer  Friendly", "   "})
+	if strings.Join(got, "|") != "weeknight|freezer friendly" {
+		t.Errorf("got %q", got)
+	}
+	if normalizeTags(nil) != nil {
+		t.Error("expected nil for no tags")
+	}
+}
+
+func TestBuildRecipeListSQL_Defaults(t *testing.T) {
+	sql, args, msg := buildRecipeListSQL(7, recipeListQuery{})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if args["limit"] != defaultRecipePageSize+1 || args["offset"] != 0 || args["userID"] != 7 {
+		t.Errorf("args: got %v", args)
+	}
+	if !strings.Contains(sql, "ORDER BY r.updated_at desc NULLS LAST, r.id desc") {
+		t.Errorf("default order: got %s", sql)
+	}
+	if strings.Contains(sql, "websearch_to_tsquery") {
+		t.Error("search join without q")
+	}
+}
+
+func TestBuildRecipeListSQL_Filters(t *testing.T) {
+	maxCal, maxTimer, minProtein := 500, 30, 25.0
+	sql, args, msg := buildRecipeListSQL(1, recipeListQuery{
+		Q:               " chili ",
+		Category:        "dinner",
+		Tags:            []string{"Weeknight"},
+		MaxCalories:     &maxCal,
+		MinProtein:      &minProtein,
+		MaxTimerMinutes: &maxTimer,
+		Ingredients:     []string{"chicken", " "},
+		Excludes:        []string{"peanut"},
+		Tools:           []string{"dutch oven"},
+		Limit:           500,
+		Offset:          -3,
+	})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	for _, want := range []string{
+		"recipe_search_documents s", "s.document @@ query", "r.category = @category", "t.tag = want.tag",
+		"r.calories <= @maxCalories", "r.protein_g >= @minProtein", "st.total_timer_seconds <= @maxTimerSeconds",
+		"unnest(@ingredients::text[])", "unnest(@excludes::text[])", "unnest(@tools::text[])",
+		"ORDER BY ts_rank(s.document, query) desc", `ESCAPE '\'`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if args["q"] != "chili" || args["maxTimerSeconds"] != 1800 {
+		t.Errorf("args: got q %v timer %v", args["q"], args["maxTimerSeconds"])
+	}
+	if tags := args["tags"].([]string); len(tags) != 1 || tags[0] != "weeknight" {
+		t.Errorf("tags: got %v", tags)
+	}
+	if terms := args["ingredients"].([]string); len(terms) != 1 {
+		t.Errorf("ingredients: got %v", terms)
+	}
+	if strings.Contains(sql, "to_tsvector") {
+		t.Error("search must use the stored document")
+	}
+	if args["limit"] != maxRecipePageSize+1 || args["offset"] != 0 {
+		t.Errorf("paging: got %v %v", args["limit"], args["offset"])
+	}
+}
+
+func TestBuildRecipeListSQL_Sort(t *testing.T) {
+	sql, _, msg := buildRecipeListSQL(1, recipeListQuery{Sort: "calories", Order: "desc"})
+	if msg != "" || !strings.Contains(sql, "ORDER BY r.calories desc NULLS LAST") {
+		t.Errorf("calories desc: got %q %s", msg, sql)
+	}
+	for _, q := range []recipeListQuery{
+		{Sort: "relevance"},
+		{Sort: "rating"},
+		{Order: "up"},
+		{Category: "brunch"},
+	} {
+		if _, _, msg := buildRecipeListSQL(1, q); msg == "" {
+			t.Errorf("%+v: expected error", q)
+		}
+	}
+}
+
+func TestBuildRecipeListSQL_CookHistory(t *testing.T) {
+	days, rating := 30, 4.0
+	sql, args, msg := buildRecipeListSQL(1, recipeListQuery{NotCookedDays: &days, MinRating: &rating})
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	for _, want := range []string{
+		"ck.last_cooked_on < CURRENT_DATE - @notCookedDays::int", "ck.avg_rating >= @minRating",
+		"ORDER BY ck.last_cooked_on asc NULLS LAST",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if args["notCookedDays"] != 30 || args["minRating"] != 4.0 {
+		t.Errorf("args: got %v", args)
+	}
+
+	sql, _, msg = buildRecipeListSQL(1, recipeListQuery{Sort: "popular"})
+	if msg != "" || !strings.Contains(sql, "ORDER BY ck.times_cooked desc") {
+		t.Errorf("popular: got %q %s", msg, sql)
+	}
+	days = -1
+	if _, _, msg := buildRecipeListSQL(1, recipeListQuery{NotCookedDays: &days}); msg == "" {
+		t.Error("expected error for negative not_cooked_days")
+	}
+}
+
+func TestSearchTerms_EscapesWildcards(t *testing.T) {
+	got := searchTerms([]string{" 100% rye ", "half_and_half", `back\slash`, ""})
+	want := []string{`100\% rye`, `half\_and\_half`, `back\\slash`}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
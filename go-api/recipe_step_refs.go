@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// Steps reference the recipe's ingredients and tools by position in their
+// lists, since a request's new ingredients have no ids yet: the lists as sent
+// alongside the steps, or the stored lists (by sort_order) when a request
+// only replaces the steps. Stored, they point at recipe_ingredients and
+// recipe_tools ids.
+
+// validateStepRefs checks step references against a recipe with the given
+// number of ingredients and tools. Returns a user-facing message, or "" when
+// they're valid.
+func validateStepRefs(steps []stepInput, ingredients, tools int) string {
+	for i, step := range steps {
+		seen := map[int]bool{}
+		for _, ref := range step.Ingredients {
+			if ref.Ingredient < 0 || ref.Ingredient >= ingredients {
+				return fmt.Sprintf("step %d references ingredient %d, but the recipe has %d", i+1, ref.Ingredient, ingredients)
+			}
+			if seen[ref.Ingredient] {
+				return fmt.Sprintf("step %d references ingredient %d twice", i+1, ref.Ingredient)
+			}
+			seen[ref.Ingredient] = true
+			if ref.Qty != nil && *ref.Qty <= 0 {
+				return fmt.Sprintf("step %d: ingredient qty must be positive", i+1)
+			}
+		}
+		seen = map[int]bool{}
+		for _, ref := range step.Tools {
+			if ref < 0 || ref >= tools {
+				return fmt.Sprintf("step %d references tool %d, but the recipe has %d", i+1, ref, tools)
+			}
+			if seen[ref] {
+				return fmt.Sprintf("step %d references tool %d twice", i+1, ref)
+			}
+			seen[ref] = true
+		}
+	}
+	return ""
+}
+
+// hasStepRefs reports whether any step references an ingredient or tool.
+func hasStepRefs(steps []stepInput) bool {
+	for _, step := range steps {
+		if len(step.Ingredients) > 0 || len(step.Tools) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recipeItemIDs returns the ids of a recipe's stored ingredients and tools
+// in list order.
+func recipeItemIDs(ctx context.Context, q querier, recipeID int) (ingredients, tools []int, err error) {
+	rows, err := q.Query(ctx,
+		`SELECT id FROM recipe_ingredients WHERE recipe_id = @id ORDER BY sort_order, id`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if ingredients, err = pgx.CollectRows(rows, pgx.RowTo[int]); err != nil {
+		return nil, nil, err
+	}
+	rows, err = q.Query(ctx,
+		`SELECT id FROM recipe_tools WHERE recipe_id = @id ORDER BY sort_order, id`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, nil, err
+	}
+	tools, err = pgx.CollectRows(rows, pgx.RowTo[int])
+	return ingredients, tools, err
+}
+
+// insertStepRefs stores a step's references, resolving positions against
+// the recipe's ingredient and tool ids. References that don't resolve are
+// skipped rather than failing the save, since AI drafts and old snapshots
+// aren't validated.
+func insertStepRefs(tx pgx.Tx, ctx *gin.Context, stepID int, step stepInput, ingredientIDs, toolIDs []int) error {
+	for i, ref := range step.Ingredients {
+		if ref.Ingredient < 0 || ref.Ingredient >= len(ingredientIDs) || (ref.Qty != nil && *ref.Qty <= 0) {
+			continue
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO recipe_step_ingredients (step_id, ingredient_id, qty, uom, sort_order)
+			 VALUES (@stepID, @ingredientID, @qty, @uom, @sortOrder)
+			 ON CONFLICT DO NOTHING`,
+			pgx.NamedArgs{
+				"stepID": stepID, "ingredientID": ingredientIDs[ref.Ingredient],
+				"qty": ref.Qty, "uom": ref.Uom, "sortOrder": i,
+			})
+		if err != nil {
+			return err
+		}
+	}
+	for i, ref := range step.Tools {
+		if ref < 0 || ref >= len(toolIDs) {
+			continue
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO recipe_step_tools (step_id, tool_id, sort_order)
+			 VALUES (@stepID, @toolID, @sortOrder)
+			 ON CONFLICT DO NOTHING`,
+			pgx.NamedArgs{"stepID": stepID, "toolID": toolIDs[ref], "sortOrder": i})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadStepRefs loads the ingredients and tools each of a recipe's steps
+// uses, with their names, keyed by step id.
+func loadStepRefs(ctx context.Context, q querier, recipeID int) (map[int][]stepIngredient, map[int][]stepTool, error) {
+	rows, err := q.Query(ctx,
+		`SELECT si.step_id, si.ingredient_id, i.name, si.qty, si.uom
+		 FROM recipe_step_ingredients si
+		 JOIN recipe_steps s ON s.id = si.step_id
+		 JOIN recipe_ingredients i ON i.id = si.ingredient_id
+		 WHERE s.recipe_id = @id
+		 ORDER BY si.step_id, si.sort_order`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, nil, err
+	}
+	ingredients, err := pgx.CollectRows(rows, pgx.RowToStructByName[stepIngredient])
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err = q.Query(ctx,
+		`SELECT st.step_id, st.tool_id, t.name
+		 FROM recipe_step_tools st
+		 JOIN recipe_steps s ON s.id = st.step_id
+		 JOIN recipe_tools t ON t.id = st.tool_id
+		 WHERE s.recipe_id = @id
+		 ORDER BY st.step_id, st.sort_order`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, nil, err
+	}
+	tools, err := pgx.CollectRows(rows, pgx.RowToStructByName[stepTool])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byStep := map[int][]stepIngredient{}
+	for _, ref := range ingredients {
+		byStep[ref.StepID] = append(byStep[ref.StepID], ref)
+	}
+	toolsByStep := map[int][]stepTool{}
+	for _, ref := range tools {
+		toolsByStep[ref.StepID] = append(toolsByStep[ref.StepID], ref)
+	}
+	return byStep, toolsByStep, nil
+}
+
+// relinkStepRefs points step references at the recipe's current ingredients
+// and tools by name, after updateRecipe replaced those lists but kept the
+// steps. ingredients and tools are the references loaded before the
+// replace; one whose name no longer exists is dropped.
+func relinkStepRefs(tx pgx.Tx, ctx *gin.Context, recipeID int, ingredients map[int][]stepIngredient, tools map[int][]stepTool) error {
+	for stepID, refs := range ingredients {
+		for i, ref := range refs {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO recipe_step_ingredients (step_id, ingredient_id, qty, uom, sort_order)
+				 SELECT @stepID, id, @qty, @uom, @sortOrder
+				 FROM recipe_ingredients
+				 WHERE recipe_id = @recipeID AND lower(trim(name)) = lower(trim(@name))
+				 ORDER BY sort_order, id
+				 LIMIT 1
+				 ON CONFLICT DO NOTHING`,
+				pgx.NamedArgs{
+					"stepID": stepID, "recipeID": recipeID, "name": ref.Name,
+					"qty": ref.Qty, "uom": ref.Uom, "sortOrder": i,
+				})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for stepID, refs := range tools {
+		for i, ref := range refs {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO recipe_step_tools (step_id, tool_id, sort_order)
+				 SELECT @stepID, id, @sortOrder
+				 FROM recipe_tools
+				 WHERE recipe_id = @recipeID AND lower(trim(name)) = lower(trim(@name))
+				 ORDER BY sort_order, id
+				 LIMIT 1
+				 ON CONFLICT DO NOTHING`,
+				pgx.NamedArgs{"stepID": stepID, "recipeID": recipeID, "name": ref.Name, "sortOrder": i})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stepRefInputs converts a stored step's references back to positions in
+// the recipe's ingredient and tool lists. Both are nil when the step has
+// none, so requests built from old snapshots compare equal.
+func stepRefInputs(step recipeStep, ingredientPos, toolPos map[int]int) ([]stepIngredientInput, []int) {
+	var ingredients []stepIngredientInput
+	for _, ref := range step.Ingredients {
+		if pos, ok := ingredientPos[ref.IngredientID]; ok {
+			ingredients = append(ingredients, stepIngredientInput{Ingredient: pos, Qty: ref.Qty, Uom: ref.Uom})
+		}
+	}
+	var tools []int
+	for _, ref := range step.Tools {
+		if pos, ok := toolPos[ref.ToolID]; ok {
+			tools = append(tools, pos)
+		}
+	}
+	return ingredients, tools
+}
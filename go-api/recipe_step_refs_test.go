@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestValidateStepRefs(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	step := func(ings []stepIngredientInput, tools ...int) []stepInput {
+		return []stepInput{{Type: "instruction", Text: "Mix."}, {Type: "instruction", Text: "Bake.", Ingredients: ings, Tools: tools}}
+	}
+	cases := []struct {
+		name  string
+		steps []stepInput
+		want  string
+	}{
+		{"valid", step([]stepIngredientInput{{Ingredient: 0, Qty: f64(200), Uom: strPtr("g")}, {Ingredient: 2}}, 1), ""},
+		{"no refs", step(nil), ""},
+		{"ingredient out of range", step([]stepIngredientInput{{Ingredient: 3}}), "step 2 references ingredient 3, but the recipe has 3"},
+		{"negative", step([]stepIngredientInput{{Ingredient: -1}}), "step 2 references ingredient -1, but the recipe has 3"},
+		{"duplicate ingredient", step([]stepIngredientInput{{Ingredient: 1}, {Ingredient: 1}}), "step 2 references ingredient 1 twice"},
+		{"zero qty", step([]stepIngredientInput{{Ingredient: 1, Qty: f64(0)}}), "step 2: ingredient qty must be positive"},
+		{"tool out of range", step(nil, 2), "step 2 references tool 2, but the recipe has 2"},
+		{"duplicate tool", step(nil, 0, 0), "step 2 references tool 0 twice"},
+	}
+	for _, tc := range cases {
+		if got := validateStepRefs(tc.steps, 3, 2); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRecipeRequestFromDetail_StepRefs(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	d := revisionFixture()
+	d.Steps[0].Ingredients = []stepIngredient{{IngredientID: 1, Name: "butter", Qty: f64(1), Uom: strPtr("tbsp")}}
+	d.Steps[0].Tools = []stepTool{{ToolID: 1, Name: "Skillet"}}
+	d.Steps[3].Ingredients = []stepIngredient{{IngredientID: 3, Name: "salt"}, {IngredientID: 99, Name: "gone"}}
+
+	req := recipeRequestFromDetail(d)
+	first := req.Steps[0]
+	if len(first.Ingredients) != 1 || first.Ingredients[0].Ingredient != 0 || *first.Ingredients[0].Qty != 1 || len(first.Tools) != 1 || first.Tools[0] != 0 {
+		t.Errorf("step 1: got %+v", first)
+	}
+	if last := req.Steps[3]; len(last.Ingredients) != 1 || last.Ingredients[0].Ingredient != 2 {
+		t.Errorf("step 4: got %+v, want only salt at position 2", last.Ingredients)
+	}
+	if req.Steps[1].Ingredients != nil || req.Steps[1].Tools != nil {
+		t.Errorf("step 2: got %+v, want no refs", req.Steps[1])
+	}
+	if msg := validateStepRefs(req.Steps, len(req.Ingredients), len(req.Tools)); msg != "" {
+		t.Errorf("round-tripped refs don't validate: %s", msg)
+	}
+
+	// A save with new row ids but the same references is unchanged; a
+	// different amount isn't.
+	b := revisionFixture()
+	for i := range b.Ingredients {
+		b.Ingredients[i].ID += 10
+	}
+	b.Tools[0].ID += 10
+	b.Steps[0].Ingredients = []stepIngredient{{IngredientID: 11, Name: "butter", Qty: f64(1), Uom: strPtr("tbsp")}}
+	b.Steps[0].Tools = []stepTool{{ToolID: 11, Name: "Skillet"}}
+	b.Steps[3].Ingredients = []stepIngredient{{IngredientID: 13, Name: "salt"}}
+	if !sameRecipeContent(d, b) {
+		t.Error("expected same content")
+	}
+	b.Steps[0].Ingredients[0].Qty = f64(2)
+	if sameRecipeContent(d, b) {
+		t.Error("expected a changed step amount to count")
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+/* ─── Links and cycles ───────────────────────────────────────────────── */
+
+// subRecipeGraph is the user's recipes by id and the sub-recipes each one's
+// ingredients reference.
+type subRecipeGraph struct {
+	names map[int]string
+	edges map[int][]int
+}
+
+// loadSubRecipeGraph loads the sub-recipe links between the user's recipes.
+func loadSubRecipeGraph(ctx context.Context, q querier, userID int) (subRecipeGraph, error) {
+	rows, err := q.Query(ctx,
+		`SELECT r.id, r.name,
+		        COALESCE(array_agg(i.sub_recipe_id) FILTER (WHERE i.sub_recipe_id IS NOT NULL), '{}') AS subs
+		 FROM recipes r
+		 LEFT JOIN recipe_ingredients i ON i.recipe_id = r.id
+		 WHERE r.user_id = @userID
+		 GROUP BY r.id`,
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		return subRecipeGraph{}, err
+	}
+	g := subRecipeGraph{names: map[int]string{}, edges: map[int][]int{}}
+	var id int
+	var name string
+	var subs []int
+	_, err = pgx.ForEachRow(rows, []any{&id, &name, &subs}, func() error {
+		g.names[id] = name
+		if len(subs) > 0 {
+			g.edges[id] = subs
+		}
+		return nil
+	})
+	return g, err
+}
+
+// findSubRecipeCycle returns the cycle that giving recipeID the sub-recipes
+// subs would create, as a path of recipe ids from recipeID back to itself,
+// or nil when there's none. edges are the current links; recipeID's own are
+// replaced by subs.
+func findSubRecipeCycle(edges map[int][]int, recipeID int, subs []int) []int {
+	visited := map[int]bool{}
+	path := []int{recipeID}
+	var walk func(next []int) bool
+	walk = func(next []int) bool {
+		for _, id := range next {
+			if id == recipeID {
+				path = append(path, id)
+				return true
+			}
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			path = append(path, id)
+			if walk(edges[id]) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if walk(subs) {
+		return path
+	}
+	return nil
+}
+
+// checkSubRecipes validates ingredient links to other recipes: each must be
+// one of the user's recipes, not alongside a food link, and not lead back to
+// recipeID. Returns a user-facing message, or "" when they're valid; err is
+// set only when the recipes couldn't be loaded.
+func checkSubRecipes(c *gin.Context, tx pgx.Tx, userID, recipeID int, ingredients []ingredientInput) (string, error) {
+	var subs []int
+	for _, ing := range ingredients {
+		if ing.SubRecipeID == nil {
+			continue
+		}
+		if ing.FoodID != nil {
+			return "an ingredient can link a food or a recipe, not both", nil
+		}
+		subs = append(subs, *ing.SubRecipeID)
+	}
+	if len(subs) == 0 {
+		return "", nil
+	}
+
+	g, err := loadSubRecipeGraph(c, tx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range subs {
+		if _, ok := g.names[id]; !ok {
+			return "ingredient recipe not found", nil
+		}
+	}
+	if cycle := findSubRecipeCycle(g.edges, recipeID, subs); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = g.names[id]
+		}
+		return "recipes can't include themselves: " + strings.Join(names, " → "), nil
+	}
+	return "", nil
+}
+
+// clearInvalidSubRecipeLinks unlinks ingredients whose sub-recipe has since
+// been deleted or now uses recipeID itself, or that also link a food, so an
+// old snapshot or an AI draft can be written back.
+func clearInvalidSubRecipeLinks(c *gin.Context, tx pgx.Tx, userID, recipeID int, ingredients []ingredientInput) error {
+	g, err := loadSubRecipeGraph(c, tx, userID)
+	if err != nil {
+		return err
+	}
+	var kept []int
+	for i := range ingredients {
+		id := ingredients[i].SubRecipeID
+		if id == nil {
+			continue
+		}
+		if _, ok := g.names[*id]; !ok || ingredients[i].FoodID != nil || findSubRecipeCycle(g.edges, recipeID, append(kept, *id)) != nil {
+			ingredients[i].SubRecipeID = nil
+			continue
+		}
+		kept = append(kept, *id)
+	}
+	return nil
+}
+
+// recipesUsingRecipe returns the recipes with an ingredient linked to
+// recipeID, whose nutrition depends on it.
+func recipesUsingRecipe(c *gin.Context, tx pgx.Tx, recipeID int) ([]int, error) {
+	rows, err := tx.Query(c,
+		`SELECT DISTINCT recipe_id FROM recipe_ingredients WHERE sub_recipe_id = @id`,
+		pgx.NamedArgs{"id": recipeID})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+/* ─── Expansion ──────────────────────────────────────────────────────── */
+
+// loadSubRecipes loads every recipe root uses, directly or through other
+// sub-recipes, keyed by id.
+func loadSubRecipes(ctx context.Context, q querier, root recipeDetail) (map[int]recipeDetail, error) {
+	subs := map[int]recipeDetail{}
+	queue := subRecipeRefs(root)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, ok := subs[id]; ok || id == root.ID {
+			continue
+		}
+		d, err := loadRecipeDetail(ctx, q, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d.UserID != root.UserID {
+			continue
+		}
+		subs[id] = d
+		queue = append(queue, subRecipeRefs(d)...)
+	}
+	return subs, nil
+}
+
+// subRecipeRefs returns the sub-recipe ids a recipe's ingredients reference.
+func subRecipeRefs(d recipeDetail) []int {
+	var ids []int
+	for _, ing := range d.Ingredients {
+		if ing.SubRecipeID != nil {
+			ids = append(ids, *ing.SubRecipeID)
+		}
+	}
+	return ids
+}
+
+// inlineSubRecipeSteps returns a recipe's steps preceded by the steps of its
+// sub-recipes, in ingredient order — a sub-recipe's own sub-recipes first.
+// Inlined steps keep their recipe_id and name the sub-recipe. A sub-recipe
+// used twice is inlined once.
+func inlineSubRecipeSteps(root recipeDetail, subs map[int]recipeDetail) []recipeStep {
+	steps := []recipeStep{}
+	seen := map[int]bool{root.ID: true}
+	var walk func(d recipeDetail)
+	walk = func(d recipeDetail) {
+		for _, id := range subRecipeRefs(d) {
+			sub, ok := subs[id]
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			walk(sub)
+			for _, step := range sub.Steps {
+				step.SubRecipe = sub.Name
+				steps = append(steps, step)
+			}
+		}
+	}
+	walk(root)
+	return append(steps, root.Steps...)
+}
+
+// shoppingListItem is one line of a shopping list. Ingredients with the same
+// name and unit are combined; Recipes are the recipes that need it.
+type shoppingListItem struct {
+	Name    string   `json:"name"`
+	Qty     *float64 `json:"qty"`
+	Uom     *string  `json:"uom"`
+	FoodID  *int     `json:"food_id"`
+	Recipes []string `json:"recipes"`
+}
+
+// shoppingListResponse is the response for GET /api/recipes/:id/shopping-list.
+type shoppingListResponse struct {
+	RecipeID int                `json:"recipe_id"`
+	Servings float64            `json:"servings"`
+	Items    []shoppingListItem `json:"items"`
+}
+
+// buildShoppingList lists what to buy to make servings of root. Ingredients
+// that reference a sub-recipe expand into the sub-recipe's ingredients,
+// scaled to the amount used; one whose amount can't be worked out (a weight
+// without the sub-recipe's yield weight) stays a single line.
+func buildShoppingList(root recipeDetail, servings float64, subs map[int]recipeDetail) []shoppingListItem {
+	items := []shoppingListItem{}
+	index := map[string]int{}
+	add := func(ing recipeIngredient, scale float64, from string) {
+		var qty *float64
+		if ing.Qty != nil {
+			q := *ing.Qty * scale
+			qty = &q
+		}
+		key := strings.ToLower(strings.TrimSpace(ing.Name)) + "\x00" + normalizeUom(ing.Uom)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(items)
+			items = append(items, shoppingListItem{Name: ing.Name, Qty: qty, Uom: ing.Uom, FoodID: ing.FoodID, Recipes: []string{from}})
+			return
+		}
+		item := &items[i]
+		switch {
+		case item.Qty == nil:
+			item.Qty = qty
+		case qty != nil:
+			sum := *item.Qty + *qty
+			item.Qty = &sum
+		}
+		if item.FoodID == nil {
+			item.FoodID = ing.FoodID
+		}
+		if !slices.Contains(item.Recipes, from) {
+			item.Recipes = append(item.Recipes, from)
+		}
+	}
+
+	yield := func(d recipeDetail) float64 {
+		if d.Servings <= 0 {
+			return 1
+		}
+		return d.Servings
+	}
+	path := map[int]bool{root.ID: true}
+	var walk func(d recipeDetail, scale float64)
+	walk = func(d recipeDetail, scale float64) {
+		for _, ing := range d.Ingredients {
+			if ing.SubRecipeID != nil {
+				sub, ok := subs[*ing.SubRecipeID]
+				if ok && !path[sub.ID] {
+					if count, ok := subRecipeServings(ing, sub.recipe, ingredientGrams(ing, nil)); ok {
+						path[sub.ID] = true
+						walk(sub, scale*count/yield(sub))
+						delete(path, sub.ID)
+						continue
+					}
+				}
+			}
+			add(ing, scale, d.Name)
+		}
+	}
+	walk(root, servings/yield(root))
+
+	for i := range items {
+		if q := items[i].Qty; q != nil {
+			rounded := math.Round(*q*100) / 100
+			items[i].Qty = &rounded
+		}
+	}
+	return items
+}
+
+// getShoppingList returns the shopping list for a recipe, with sub-recipes
+// expanded into their ingredients. ?servings= scales it (default: the
+// recipe's yield).
+// GET /api/recipes/:id/shopping-list
+func (h *Handler) getShoppingList(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid recipe id")
+		return
+	}
+
+	d, err := fetchRecipeDetail(h, c, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && d.UserID != userID) {
+		apiError(c, http.StatusNotFound, "recipe not found")
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch recipe")
+		return
+	}
+	servings := d.Servings
+	if v := c.Query("servings"); v != "" {
+		if servings, err = strconv.ParseFloat(v, 64); err != nil || servings <= 0 {
+			apiError(c, http.StatusBadRequest, "servings must be a positive number")
+			return
+		}
+	}
+
+	subs, err := loadSubRecipes(c, h.db, d)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch sub-recipes")
+		return
+	}
+	c.JSON(http.StatusOK, shoppingListResponse{RecipeID: id, Servings: servings, Items: buildShoppingList(d, servings, subs)})
+}
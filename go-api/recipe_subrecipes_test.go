@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindSubRecipeCycle(t *testing.T) {
+	// lasagna(1) uses bolognese(2), which uses stock(3).
+	edges := map[int][]int{1: {2}, 2: {3}}
+
+	if got := findSubRecipeCycle(edges, 3, []int{1}); !reflect.DeepEqual(got, []int{3, 1, 2, 3}) {
+		t.Errorf("stock using lasagna: got %v", got)
+	}
+	if got := findSubRecipeCycle(edges, 2, []int{2}); !reflect.DeepEqual(got, []int{2, 2}) {
+		t.Errorf("self: got %v", got)
+	}
+	if got := findSubRecipeCycle(edges, 4, []int{1, 3}); got != nil {
+		t.Errorf("new recipe: got %v", got)
+	}
+	// Replacing lasagna's own links doesn't count its old ones.
+	if got := findSubRecipeCycle(map[int][]int{1: {2}, 2: {1}}, 1, []int{3}); got != nil {
+		t.Errorf("relink: got %v", got)
+	}
+}
+
+func TestInlineSubRecipeSteps(t *testing.T) {
+	id := func(i int) *int { return &i }
+	stock := recipeDetail{recipe: recipe{ID: 3, Name: "Stock"}, Steps: []recipeStep{{RecipeID: 3, Text: "Simmer bones."}}}
+	bolognese := recipeDetail{
+		recipe:      recipe{ID: 2, Name: "Bolognese"},
+		Ingredients: []recipeIngredient{{Name: "stock", SubRecipeID: id(3)}},
+		Steps:       []recipeStep{{RecipeID: 2, Text: "Brown the meat."}},
+	}
+	lasagna := recipeDetail{
+		recipe: recipe{ID: 1, Name: "Lasagna"},
+		Ingredients: []recipeIngredient{
+			{Name: "bolognese", SubRecipeID: id(2)},
+			{Name: "stock", SubRecipeID: id(3)},
+			{Name: "missing", SubRecipeID: id(9)},
+		},
+		Steps: []recipeStep{{RecipeID: 1, Text: "Layer and bake."}},
+	}
+	steps := inlineSubRecipeSteps(lasagna, map[int]recipeDetail{2: bolognese, 3: stock})
+
+	var got []string
+	for _, s := range steps {
+		got = append(got, s.SubRecipe+":"+s.Text)
+	}
+	want := []string{"Stock:Simmer bones.", "Bolognese:Brown the meat.", ":Layer and bake."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBuildShoppingList(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+	id := func(i int) *int { return &i }
+
+	bolognese := recipeDetail{
+		recipe: recipe{ID: 2, Name: "Bolognese", Servings: 4},
+		Ingredients: []recipeIngredient{
+			{Name: "Beef mince", Qty: f64(500), Uom: str("g")},
+			{Name: "Onion", Qty: f64(1)},
+		},
+	}
+	lasagna := recipeDetail{
+		recipe: recipe{ID: 1, Name: "Lasagna", Servings: 6},
+		Ingredients: []recipeIngredient{
+			{Name: "Bolognese", Qty: f64(2), Uom: str("servings"), SubRecipeID: id(2)},
+			{Name: "onion", Qty: f64(0.5)},
+			{Name: "Lasagna sheets", Qty: f64(12)},
+			{Name: "Bolognese", Qty: f64(300), Uom: str("g"), SubRecipeID: id(2)},
+		},
+	}
+	items := buildShoppingList(lasagna, 12, map[int]recipeDetail{2: bolognese})
+
+	type line struct {
+		name    string
+		qty     float64
+		recipes int
+	}
+	var got []line
+	for _, it := range items {
+		got = append(got, line{it.Name, *it.Qty, len(it.Recipes)})
+	}
+	// Doubled: 4 servings of bolognese (one full batch), plus 600 g of
+	// bolognese kept as a line since it has no yield weight.
+	want := []line{
+		{"Beef mince", 500, 1},
+		{"Onion", 2, 2},
+		{"Lasagna sheets", 24, 1},
+		{"Bolognese", 600, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+}
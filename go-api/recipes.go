@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
@@ -10,47 +11,72 @@ import (
 
 /* ─── Helpers ────────────────────────────────────────────────────────── */
 
-// insertSubLists inserts ingredients, tools, and steps for a recipe within
+// insertSubLists inserts ingredients, tools, steps, and tags for a recipe within
 // an existing transaction. Called by both createRecipe and updateRecipe.
 func insertSubLists(tx pgx.Tx, ctx *gin.Context, recipeID int, req createRecipeRequest) error {
+	var ingredientIDs, toolIDs []int
 	for i, ing := range req.Ingredients {
 		if ing.SortOrder == 0 {
 			ing.SortOrder = i
 		}
-		_, err := tx.Exec(ctx,
-			`INSERT INTO recipe_ingredients (recipe_id, name, qty, uom, note, sort_order)
-			 VALUES (@recipeID, @name, @qty, @uom, @note, @sortOrder)`,
+		var ingredientID int
+		err := tx.QueryRow(ctx,
+			`INSERT INTO recipe_ingredients (recipe_id, name, qty, uom, note, sort_order, food_id, grams, sub_recipe_id)
+			 VALUES (@recipeID, @name, @qty, @uom, @note, @sortOrder, @foodID, @grams, @subRecipeID)
+			 RETURNING id`,
 			pgx.NamedArgs{
-				"recipeID":  recipeID,
-				"name":      ing.Name,
-				"qty":       ing.Qty,
-				"uom":       ing.Uom,
-				"note":      ing.Note,
-				"sortOrder": ing.SortOrder,
-			})
+				"recipeID":    recipeID,
+				"name":        ing.Name,
+				"qty":         ing.Qty,
+				"uom":         ing.Uom,
+				"note":        ing.Note,
+				"sortOrder":   ing.SortOrder,
+				"foodID":      ing.FoodID,
+				"grams":       ing.Grams,
+				"subRecipeID": ing.SubRecipeID,
+			}).Scan(&ingredientID)
 		if err != nil {
 			return err
 		}
+		ingredientIDs = append(ingredientIDs, ingredientID)
 	}
 	for i, tool := range req.Tools {
 		if tool.SortOrder == 0 {
 			tool.SortOrder = i
 		}
-		_, err := tx.Exec(ctx,
+		var toolID int
+		err := tx.QueryRow(ctx,
 			`INSERT INTO recipe_tools (recipe_id, name, sort_order)
-			 VALUES (@recipeID, @name, @sortOrder)`,
-			pgx.NamedArgs{"recipeID": recipeID, "name": tool.Name, "sortOrder": tool.SortOrder})
+			 VALUES (@recipeID, @name, @sortOrder)
+			 RETURNING id`,
+			pgx.NamedArgs{"recipeID": recipeID, "name": tool.Name, "sortOrder": tool.SortOrder}).Scan(&toolID)
 		if err != nil {
 			return err
 		}
+		toolIDs = append(toolIDs, toolID)
+	}
+	// Steps sent without their ingredients or tools reference the stored ones.
+	if hasStepRefs(req.Steps) && (len(req.Ingredients) == 0 || len(req.Tools) == 0) {
+		storedIngredients, storedTools, err := recipeItemIDs(ctx, tx, recipeID)
+		if err != nil {
+			return err
+		}
+		if len(req.Ingredients) == 0 {
+			ingredientIDs = storedIngredients
+		}
+		if len(req.Tools) == 0 {
+			toolIDs = storedTools
+		}
 	}
 	for i, step := range req.Steps {
 		if step.SortOrder == 0 {
 			step.SortOrder = i
 		}
-		_, err := tx.Exec(ctx,
+		var stepID int
+		err := tx.QueryRow(ctx,
 			`INSERT INTO recipe_steps (recipe_id, type, text, timer_seconds, meanwhile_text, sort_order)
-			 VALUES (@recipeID, @type, @text, @timerSeconds, @meanwhileText, @sortOrder)`,
+			 VALUES (@recipeID, @type, @text, @timerSeconds, @meanwhileText, @sortOrder)
+			 RETURNING id`,
 			pgx.NamedArgs{
 				"recipeID":      recipeID,
 				"type":          step.Type,
@@ -58,7 +84,18 @@ func insertSubLists(tx pgx.Tx, ctx *gin.Context, recipeID int, req createRecipeR
 				"timerSeconds":  step.TimerSeconds,
 				"meanwhileText": step.MeanwhileText,
 				"sortOrder":     step.SortOrder,
-			})
+			}).Scan(&stepID)
+		if err != nil {
+			return err
+		}
+		if err := insertStepRefs(tx, ctx, stepID, step, ingredientIDs, toolIDs); err != nil {
+			return err
+		}
+	}
+	for _, tag := range normalizeTags(req.Tags) {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO recipe_tags (recipe_id, tag) VALUES (@recipeID, @tag) ON CONFLICT DO NOTHING`,
+			pgx.NamedArgs{"recipeID": recipeID, "tag": tag})
 		if err != nil {
 			return err
 		}
@@ -66,77 +103,274 @@ func insertSubLists(tx pgx.Tx, ctx *gin.Context, recipeID int, req createRecipeR
 	return nil
 }
 
+// insertRecipe inserts a recipe with its sub-lists and computed nutrition
+// within an existing transaction. Used by copies, scaled saves, and imports.
+func insertRecipe(tx pgx.Tx, ctx *gin.Context, userID int, req createRecipeRequest) (int, error) {
+	if req.Category == "" || !validRecipeCategories[req.Category] {
+		req.Category = "other"
+	}
+	servings := 1.0
+	if req.Servings != nil && *req.Servings > 0 {
+		servings = *req.Servings
+	}
+
+	var newID int
+	err := tx.QueryRow(ctx,
+		`INSERT INTO recipes (user_id, name, emoji, category, notes, servings, yield_grams, calories, protein_g, carbs_g, fat_g)
+		 VALUES (@userID, @name, @emoji, @category, @notes, @servings, @yieldGrams, @calories, @proteinG, @carbsG, @fatG)
+		 RETURNING id`,
+		pgx.NamedArgs{
+			"userID":     userID,
+			"name":       req.Name,
+			"emoji":      req.Emoji,
+			"category":   req.Category,
+			"notes":      req.Notes,
+			"servings":   servings,
+			"yieldGrams": req.YieldGrams,
+			"calories":   req.Calories,
+			"proteinG":   req.ProteinG,
+			"carbsG":     req.CarbsG,
+			"fatG":       req.FatG,
+		}).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertSubLists(tx, ctx, newID, req); err != nil {
+		return 0, err
+	}
+	if err := recomputeRecipeNutrition(ctx, tx, newID); err != nil {
+		return 0, err
+	}
+	if err := recordRecipeRevision(ctx, tx, newID, "user", nil, nil); err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+// replaceRecipe overwrites a recipe's fields and sub-lists with req and
+// recomputes its nutrition, within an existing transaction. Used when a whole
+// recipe is written back: restoring a revision or saving an AI edit.
+func replaceRecipe(tx pgx.Tx, ctx *gin.Context, id int, req createRecipeRequest) error {
+	if req.Category == "" || !validRecipeCategories[req.Category] {
+		req.Category = "other"
+	}
+	servings := 1.0
+	if req.Servings != nil && *req.Servings > 0 {
+		servings = *req.Servings
+	}
+
+	_, err := tx.Exec(ctx,
+		`UPDATE recipes SET
+		   name = @name, emoji = @emoji, category = @category, notes = @notes, servings = @servings,
+		   yield_grams = @yieldGrams, calories = @calories, protein_g = @proteinG, carbs_g = @carbsG, fat_g = @fatG,
+		   updated_at = now()
+		 WHERE id = @id`,
+		pgx.NamedArgs{
+			"id": id, "name": req.Name, "emoji": req.Emoji, "category": req.Category,
+			"notes": req.Notes, "servings": servings, "yieldGrams": req.YieldGrams,
+			"calories": req.Calories, "proteinG": req.ProteinG,
+			"carbsG": req.CarbsG, "fatG": req.FatG,
+		})
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"recipe_ingredients", "recipe_tools", "recipe_steps", "recipe_tags"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE recipe_id = @id`, pgx.NamedArgs{"id": id}); err != nil {
+			return err
+		}
+	}
+	if err := insertSubLists(tx, ctx, id, req); err != nil {
+		return err
+	}
+	return recomputeRecipeNutrition(ctx, tx, id)
+}
+
+// recipeRequestFromDetail converts a stored recipe back to request form, for
+// copying it or writing it elsewhere.
+func recipeRequestFromDetail(src recipeDetail) createRecipeRequest {
+	servings := src.Servings
+	req := createRecipeRequest{
+		Name: src.Name, Emoji: src.Emoji, Category: src.Category, Notes: src.Notes,
+		Servings: &servings, YieldGrams: src.YieldGrams, Calories: src.Calories,
+		ProteinG: src.ProteinG, CarbsG: src.CarbsG, FatG: src.FatG,
+		Tags: append([]string(nil), src.Tags...),
+	}
+	for _, ing := range src.Ingredients {
+		req.Ingredients = append(req.Ingredients, ingredientInput{
+			Name: ing.Name, Qty: ing.Qty, Uom: ing.Uom, Note: ing.Note, SortOrder: ing.SortOrder,
+			FoodID: ing.FoodID, Grams: ing.Grams, SubRecipeID: ing.SubRecipeID,
+		})
+	}
+	for _, tool := range src.Tools {
+		req.Tools = append(req.Tools, toolInput{Name: tool.Name, SortOrder: tool.SortOrder})
+	}
+	ingredientPos, toolPos := map[int]int{}, map[int]int{}
+	for i, ing := range src.Ingredients {
+		ingredientPos[ing.ID] = i
+	}
+	for i, tool := range src.Tools {
+		toolPos[tool.ID] = i
+	}
+	for _, step := range src.Steps {
+		ingredients, tools := stepRefInputs(step, ingredientPos, toolPos)
+		req.Steps = append(req.Steps, stepInput{
+			Type: step.Type, Text: step.Text,
+			TimerSeconds: step.TimerSeconds, MeanwhileText: step.MeanwhileText,
+			SortOrder: step.SortOrder, Ingredients: ingredients, Tools: tools,
+		})
+	}
+	return req
+}
+
+// insertRecipeCopy inserts src as a new recipe named name, with all its
+// sub-lists. Used by duplicateRecipe and saveScaledRecipe.
+func insertRecipeCopy(tx pgx.Tx, ctx *gin.Context, userID int, src recipeDetail, name string) (int, error) {
+	req := recipeRequestFromDetail(src)
+	req.Name = name
+	return insertRecipe(tx, ctx, userID, req)
+}
+
 // fetchRecipeDetail loads the full recipe + sub-lists for the given recipe ID.
 func fetchRecipeDetail(h *Handler, c *gin.Context, id int) (recipeDetail, error) {
-	r, err := queryOne[recipe](h.db, c,
-		`SELECT * FROM recipes WHERE id = @id`,
-		pgx.NamedArgs{"id": id})
+	return loadRecipeDetail(c, h.db, id)
+}
+
+// loadRecipeDetail is fetchRecipeDetail against any querier, so a transaction
+// can read back what it just wrote (see recordRecipeRevision).
+func loadRecipeDetail(ctx context.Context, q querier, id int) (recipeDetail, error) {
+	rows, err := q.Query(ctx, `SELECT * FROM recipes WHERE id = @id`, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return recipeDetail{}, err
+	}
+	r, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[recipe])
 	if err != nil {
 		return recipeDetail{}, err
 	}
 
-	ingredients, err := queryMany[recipeIngredient](h.db, c,
-		`SELECT * FROM recipe_ingredients WHERE recipe_id = @id ORDER BY sort_order`,
+	rows, err = q.Query(ctx,
+		`SELECT * FROM recipe_ingredients WHERE recipe_id = @id ORDER BY sort_order, id`,
 		pgx.NamedArgs{"id": id})
 	if err != nil {
 		return recipeDetail{}, err
 	}
+	ingredients, err := pgx.CollectRows(rows, pgx.RowToStructByName[recipeIngredient])
+	if err != nil {
+		return recipeDetail{}, err
+	}
 	if ingredients == nil {
 		ingredients = []recipeIngredient{}
 	}
 
-	tools, err := queryMany[recipeTool](h.db, c,
-		`SELECT * FROM recipe_tools WHERE recipe_id = @id ORDER BY sort_order`,
+	rows, err = q.Query(ctx,
+		`SELECT * FROM recipe_tools WHERE recipe_id = @id ORDER BY sort_order, id`,
 		pgx.NamedArgs{"id": id})
 	if err != nil {
 		return recipeDetail{}, err
 	}
+	tools, err := pgx.CollectRows(rows, pgx.RowToStructByName[recipeTool])
+	if err != nil {
+		return recipeDetail{}, err
+	}
 	if tools == nil {
 		tools = []recipeTool{}
 	}
 
-	steps, err := queryMany[recipeStep](h.db, c,
+	rows, err = q.Query(ctx,
 		`SELECT * FROM recipe_steps WHERE recipe_id = @id ORDER BY sort_order`,
 		pgx.NamedArgs{"id": id})
 	if err != nil {
 		return recipeDetail{}, err
 	}
+	steps, err := pgx.CollectRows(rows, pgx.RowToStructByName[recipeStep])
+	if err != nil {
+		return recipeDetail{}, err
+	}
 	if steps == nil {
 		steps = []recipeStep{}
 	}
+	stepIngredients, stepTools, err := loadStepRefs(ctx, q, id)
+	if err != nil {
+		return recipeDetail{}, err
+	}
+	for i := range steps {
+		steps[i].Ingredients = stepIngredients[steps[i].ID]
+		if steps[i].Ingredients == nil {
+			steps[i].Ingredients = []stepIngredient{}
+		}
+		steps[i].Tools = stepTools[steps[i].ID]
+		if steps[i].Tools == nil {
+			steps[i].Tools = []stepTool{}
+		}
+	}
+
+	rows, err = q.Query(ctx,
+		`SELECT tag FROM recipe_tags WHERE recipe_id = @id ORDER BY tag`,
+		pgx.NamedArgs{"id": id})
+	if err != nil {
+		return recipeDetail{}, err
+	}
+	tags, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return recipeDetail{}, err
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	nutrition, err := loadRecipeNutrition(ctx, q, id, r.Servings)
+	if err != nil {
+		return recipeDetail{}, err
+	}
 
 	return recipeDetail{
 		recipe:      r,
 		Ingredients: ingredients,
 		Tools:       tools,
 		Steps:       steps,
+		Tags:        tags,
+		Nutrition:   nutrition,
 	}, nil
 }
 
 /* ─── Handlers ───────────────────────────────────────────────────────── */
 
-// listRecipes returns all recipes for the authenticated user, with computed
-// step count and total timer seconds from recipe_steps.
+// listRecipes returns a page of the user's recipes, with computed step count,
+// total timer seconds, tags, and cooking history (last cooked, times cooked,
+// average rating). Supports full-text search (q), filters, and sorting — see
+// recipeListQuery. Defaults to most recently updated first, or best match
+// when searching.
+// Response: { recipes: [...], has_more: bool }
 // GET /api/recipes
 func (h *Handler) listRecipes(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	items, err := queryMany[recipeListItem](h.db, c,
-		`SELECT r.*,
-		   (SELECT COUNT(*)           FROM recipe_steps WHERE recipe_id = r.id) AS step_count,
-		   (SELECT COALESCE(SUM(timer_seconds), 0) FROM recipe_steps WHERE recipe_id = r.id AND type = 'timer') AS total_timer_seconds
-		 FROM recipes r
-		 WHERE r.user_id = @userID
-		 ORDER BY r.updated_at DESC`,
-		pgx.NamedArgs{"userID": userID})
+	var q recipeListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid query parameters")
+		return
+	}
+	sql, args, msg := buildRecipeListSQL(userID, q)
+	if msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	items, err := queryMany[recipeListItem](h.db, c, sql, args)
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to fetch recipes")
 		return
 	}
+
+	// Trim the extra row and signal whether more pages exist.
+	limit := args["limit"].(int) - 1
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
 	if items == nil {
 		items = []recipeListItem{}
 	}
-	c.JSON(http.StatusOK, items)
+	c.JSON(http.StatusOK, recipeListResponse{Recipes: items, HasMore: hasMore})
 }
 
 // getRecipe returns a full recipe detail by ID.
@@ -159,6 +393,16 @@ func (h *Handler) getRecipe(c *gin.Context) {
 		apiError(c, http.StatusNotFound, "recipe not found")
 		return
 	}
+	// ?inline_steps=true puts sub-recipe steps before the recipe's own, for
+	// cooking everything from one list.
+	if c.Query("inline_steps") == "true" {
+		subs, err := loadSubRecipes(c, h.db, detail)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to fetch sub-recipes")
+			return
+		}
+		detail.Steps = inlineSubRecipeSteps(detail, subs)
+	}
 	c.JSON(http.StatusOK, detail)
 }
 
@@ -181,6 +425,14 @@ func (h *Handler) createRecipe(c *gin.Context) {
 		apiError(c, http.StatusBadRequest, "invalid category")
 		return
 	}
+	if req.YieldGrams != nil && *req.YieldGrams <= 0 {
+		apiError(c, http.StatusBadRequest, "yield_grams must be positive")
+		return
+	}
+	if msg := validateStepRefs(req.Steps, len(req.Ingredients), len(req.Tools)); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
 
 	servings := 1.0
 	if req.Servings != nil {
@@ -197,30 +449,52 @@ func (h *Handler) createRecipe(c *gin.Context) {
 	// Insert the recipe record and get the new ID
 	var newID int
 	err = tx.QueryRow(c,
-		`INSERT INTO recipes (user_id, name, emoji, category, notes, servings, calories, protein_g, carbs_g, fat_g)
-		 VALUES (@userID, @name, @emoji, @category, @notes, @servings, @calories, @proteinG, @carbsG, @fatG)
+		`INSERT INTO recipes (user_id, name, emoji, category, notes, servings, yield_grams, calories, protein_g, carbs_g, fat_g)
+		 VALUES (@userID, @name, @emoji, @category, @notes, @servings, @yieldGrams, @calories, @proteinG, @carbsG, @fatG)
 		 RETURNING id`,
 		pgx.NamedArgs{
-			"userID":   userID,
-			"name":     req.Name,
-			"emoji":    req.Emoji,
-			"category": req.Category,
-			"notes":    req.Notes,
-			"servings": servings,
-			"calories": req.Calories,
-			"proteinG": req.ProteinG,
-			"carbsG":   req.CarbsG,
-			"fatG":     req.FatG,
+			"userID":     userID,
+			"name":       req.Name,
+			"emoji":      req.Emoji,
+			"category":   req.Category,
+			"notes":      req.Notes,
+			"servings":   servings,
+			"yieldGrams": req.YieldGrams,
+			"calories":   req.Calories,
+			"proteinG":   req.ProteinG,
+			"carbsG":     req.CarbsG,
+			"fatG":       req.FatG,
 		}).Scan(&newID)
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to create recipe")
 		return
 	}
 
+	if msg := checkIngredientFoods(c, tx, userID, req.Ingredients); msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
+	msg, err := checkSubRecipes(c, tx, userID, newID, req.Ingredients)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check ingredient recipes")
+		return
+	}
+	if msg != "" {
+		apiError(c, http.StatusBadRequest, msg)
+		return
+	}
 	if err := insertSubLists(tx, c, newID, req); err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to insert recipe sub-lists")
 		return
 	}
+	if err := recomputeRecipeNutrition(c, tx, newID); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to compute nutrition")
+		return
+	}
+	if err := recordRecipeRevision(c, tx, newID, "user", nil, nil); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to record revision")
+		return
+	}
 
 	if err := tx.Commit(c); err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to commit")
@@ -257,6 +531,10 @@ func (h *Handler) updateRecipe(c *gin.Context) {
 		apiError(c, http.StatusBadRequest, "invalid category")
 		return
 	}
+	if req.YieldGrams != nil && *req.YieldGrams < 0 {
+		apiError(c, http.StatusBadRequest, "yield_grams must be positive")
+		return
+	}
 
 	tx, err := h.db.Begin(c)
 	if err != nil {
@@ -273,6 +551,7 @@ func (h *Handler) updateRecipe(c *gin.Context) {
 		   category   = COALESCE(@category::recipe_category, category),
 		   notes      = COALESCE(@notes, notes),
 		   servings   = COALESCE(@servings, servings),
+		   yield_grams = CASE WHEN @yieldGrams::numeric IS NULL THEN yield_grams ELSE NULLIF(@yieldGrams::numeric, 0) END,
 		   calories   = COALESCE(@calories, calories),
 		   protein_g  = COALESCE(@proteinG, protein_g),
 		   carbs_g    = COALESCE(@carbsG, carbs_g),
@@ -282,7 +561,7 @@ func (h *Handler) updateRecipe(c *gin.Context) {
 		pgx.NamedArgs{
 			"id": id, "userID": userID,
 			"name": req.Name, "emoji": req.Emoji, "category": req.Category,
-			"notes": req.Notes, "servings": req.Servings,
+			"notes": req.Notes, "servings": req.Servings, "yieldGrams": req.YieldGrams,
 			"calories": req.Calories, "proteinG": req.ProteinG,
 			"carbsG": req.CarbsG, "fatG": req.FatG,
 		})
@@ -295,39 +574,119 @@ func (h *Handler) updateRecipe(c *gin.Context) {
 		return
 	}
 
-	// Replace sub-lists if provided
+	// Steps index into the ingredients and tools sent with them, or the
+	// stored ones.
+	if req.Steps != nil {
+		var ingredientCount, toolCount int
+		err := tx.QueryRow(c,
+			`SELECT (SELECT count(*) FROM recipe_ingredients WHERE recipe_id = @id),
+			        (SELECT count(*) FROM recipe_tools WHERE recipe_id = @id)`,
+			pgx.NamedArgs{"id": id}).Scan(&ingredientCount, &toolCount)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to update steps")
+			return
+		}
+		if req.Ingredients != nil {
+			ingredientCount = len(*req.Ingredients)
+		}
+		if req.Tools != nil {
+			toolCount = len(*req.Tools)
+		}
+		if msg := validateStepRefs(*req.Steps, ingredientCount, toolCount); msg != "" {
+			apiError(c, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	// Replacing ingredients or tools drops the steps' references to them;
+	// when the steps are kept, relink those by name afterwards.
+	var keptIngredientRefs map[int][]stepIngredient
+	var keptToolRefs map[int][]stepTool
+	if req.Steps == nil && (req.Ingredients != nil || req.Tools != nil) {
+		ingredientRefs, toolRefs, err := loadStepRefs(c, tx, id)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to update recipe")
+			return
+		}
+		if req.Ingredients != nil {
+			keptIngredientRefs = ingredientRefs
+		}
+		if req.Tools != nil {
+			keptToolRefs = toolRefs
+		}
+	}
+
+	// Replace sub-lists if provided. They're inserted together so steps can
+	// reference the ingredients and tools sent with them.
+	var replace createRecipeRequest
 	if req.Ingredients != nil {
 		if _, err := tx.Exec(c, `DELETE FROM recipe_ingredients WHERE recipe_id = @id`, pgx.NamedArgs{"id": id}); err != nil {
 			apiError(c, http.StatusInternalServerError, "failed to update ingredients")
 			return
 		}
-		createReq := createRecipeRequest{Ingredients: *req.Ingredients}
-		if err := insertSubLists(tx, c, id, createReq); err != nil {
-			apiError(c, http.StatusInternalServerError, "failed to insert ingredients")
+		if msg := checkIngredientFoods(c, tx, userID, *req.Ingredients); msg != "" {
+			apiError(c, http.StatusBadRequest, msg)
+			return
+		}
+		msg, err := checkSubRecipes(c, tx, userID, id, *req.Ingredients)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to check ingredient recipes")
 			return
 		}
+		if msg != "" {
+			apiError(c, http.StatusBadRequest, msg)
+			return
+		}
+		replace.Ingredients = *req.Ingredients
 	}
 	if req.Tools != nil {
 		if _, err := tx.Exec(c, `DELETE FROM recipe_tools WHERE recipe_id = @id`, pgx.NamedArgs{"id": id}); err != nil {
 			apiError(c, http.StatusInternalServerError, "failed to update tools")
 			return
 		}
-		createReq := createRecipeRequest{Tools: *req.Tools}
-		if err := insertSubLists(tx, c, id, createReq); err != nil {
-			apiError(c, http.StatusInternalServerError, "failed to insert tools")
-			return
-		}
+		replace.Tools = *req.Tools
 	}
 	if req.Steps != nil {
 		if _, err := tx.Exec(c, `DELETE FROM recipe_steps WHERE recipe_id = @id`, pgx.NamedArgs{"id": id}); err != nil {
 			apiError(c, http.StatusInternalServerError, "failed to update steps")
 			return
 		}
-		createReq := createRecipeRequest{Steps: *req.Steps}
-		if err := insertSubLists(tx, c, id, createReq); err != nil {
-			apiError(c, http.StatusInternalServerError, "failed to insert steps")
+		replace.Steps = *req.Steps
+	}
+
+	if req.Tags != nil {
+		if _, err := tx.Exec(c, `DELETE FROM recipe_tags WHERE recipe_id = @id`, pgx.NamedArgs{"id": id}); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to update tags")
 			return
 		}
+		replace.Tags = *req.Tags
+	}
+	if err := insertSubLists(tx, c, id, replace); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to insert recipe sub-lists")
+		return
+	}
+	if err := relinkStepRefs(tx, c, id, keptIngredientRefs, keptToolRefs); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to update steps")
+		return
+	}
+
+	// Linked ingredients override typed-in nutrition; recompute after any
+	// ingredient or servings change so per-serving numbers stay in step.
+	if err := recomputeRecipeNutrition(c, tx, id); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to compute nutrition")
+		return
+	}
+
+	// Favorites linked to this recipe follow its per-serving nutrition.
+	if err := syncLinkedFavorites(c, tx, userID); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to sync favorites")
+		return
+	}
+
+	author, prompt := revisionAuthor(req.AIPrompt)
+	if err := recordRecipeRevision(c, tx, id, author, prompt, nil); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to record revision")
+		return
 	}
 
 	if err := tx.Commit(c); err != nil {
@@ -353,7 +712,20 @@ func (h *Handler) deleteRecipe(c *gin.Context) {
 		return
 	}
 
-	tag, err := h.db.Exec(c,
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	// Recipes using this one as a sub-recipe lose the link; recompute them.
+	parents, err := recipesUsingRecipe(c, tx, id)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete recipe")
+		return
+	}
+	tag, err := tx.Exec(c,
 		`DELETE FROM recipes WHERE id = @id AND user_id = @userID`,
 		pgx.NamedArgs{"id": id, "userID": userID})
 	if err != nil {
@@ -364,6 +736,20 @@ func (h *Handler) deleteRecipe(c *gin.Context) {
 		apiError(c, http.StatusNotFound, "recipe not found")
 		return
 	}
+	if len(parents) > 0 {
+		if err := recomputeRecipes(c, tx, parents); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to compute nutrition")
+			return
+		}
+		if err := syncLinkedFavorites(c, tx, userID); err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to sync favorites")
+			return
+		}
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -391,53 +777,12 @@ func (h *Handler) duplicateRecipe(c *gin.Context) {
 	}
 	defer tx.Rollback(c)
 
-	// Insert the new recipe record
-	var newID int
-	copyName := src.Name + " (copy)"
-	err = tx.QueryRow(c,
-		`INSERT INTO recipes (user_id, name, emoji, category, notes, servings, calories, protein_g, carbs_g, fat_g)
-		 VALUES (@userID, @name, @emoji, @category, @notes, @servings, @calories, @proteinG, @carbsG, @fatG)
-		 RETURNING id`,
-		pgx.NamedArgs{
-			"userID":   userID,
-			"name":     copyName,
-			"emoji":    src.Emoji,
-			"category": src.Category,
-			"notes":    src.Notes,
-			"servings": src.Servings,
-			"calories": src.Calories,
-			"proteinG": src.ProteinG,
-			"carbsG":   src.CarbsG,
-			"fatG":     src.FatG,
-		}).Scan(&newID)
+	newID, err := insertRecipeCopy(tx, c, userID, src, src.Name+" (copy)")
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to duplicate recipe")
 		return
 	}
 
-	// Convert sub-lists to input types for insertSubLists
-	req := createRecipeRequest{}
-	for _, ing := range src.Ingredients {
-		req.Ingredients = append(req.Ingredients, ingredientInput{
-			Name: ing.Name, Qty: ing.Qty, Uom: ing.Uom, Note: ing.Note, SortOrder: ing.SortOrder,
-		})
-	}
-	for _, tool := range src.Tools {
-		req.Tools = append(req.Tools, toolInput{Name: tool.Name, SortOrder: tool.SortOrder})
-	}
-	for _, step := range src.Steps {
-		req.Steps = append(req.Steps, stepInput{
-			Type: step.Type, Text: step.Text,
-			TimerSeconds: step.TimerSeconds, MeanwhileText: step.MeanwhileText,
-			SortOrder: step.SortOrder,
-		})
-	}
-
-	if err := insertSubLists(tx, c, newID, req); err != nil {
-		apiError(c, http.StatusInternalServerError, "failed to copy recipe sub-lists")
-		return
-	}
-
 	if err := tx.Commit(c); err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to commit")
 		return
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -71,8 +72,24 @@ var recipeSchema = map[string]interface{}{
 					"timer_seconds":  map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "null"}}},
 					"meanwhile_text": map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "null"}}},
 					"sort_order":     map[string]interface{}{"type": "integer"},
+					// Ingredients and tools the step uses, by 0-based position
+					// in the recipe's lists (see stepInput).
+					"ingredients": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"ingredient": map[string]interface{}{"type": "integer"},
+								"qty":        map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "null"}}},
+								"uom":        map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "null"}}},
+							},
+							"required":             []string{"ingredient", "qty", "uom"},
+							"additionalProperties": false,
+						},
+					},
+					"tools": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
 				},
-				"required":             []string{"type", "text", "timer_seconds", "meanwhile_text", "sort_order"},
+				"required":             []string{"type", "text", "timer_seconds", "meanwhile_text", "sort_order", "ingredients", "tools"},
 				"additionalProperties": false,
 			},
 		},
@@ -81,6 +98,10 @@ var recipeSchema = map[string]interface{}{
 	"additionalProperties": false,
 }
 
+// stepRefsPrompt tells the model how steps reference ingredients and tools
+// in recipeSchema; appended to the recipe system prompts.
+const stepRefsPrompt = " For each step, list the ingredients it uses by 0-based position in the ingredients list, with the amount used in that step (qty null if it uses all of it), and the tools it uses by 0-based position in the tools list."
+
 // nutritionSchema is the OpenAI json_schema for nutrition-only estimates (ai-nutrition endpoint).
 var nutritionSchema = map[string]interface{}{
 	"type": "object",
@@ -198,7 +219,7 @@ func (h *Handler) generateRecipe(c *gin.Context) {
 	}
 
 	messages := []openAIMessage{
-		{Role: "system", Content: "You are a recipe creator. Given a description or request, create a complete, practical recipe with detailed ingredients, any required tools, and clear step-by-step instructions. Include realistic nutritional estimates per serving. Pick an appropriate emoji for the recipe."},
+		{Role: "system", Content: "You are a recipe creator. Given a description or request, create a complete, practical recipe with detailed ingredients, any required tools, and clear step-by-step instructions. Include realistic nutritional estimates per serving. Pick an appropriate emoji for the recipe." + stepRefsPrompt},
 		{Role: "user", Content: req.Prompt},
 	}
 
@@ -261,6 +282,10 @@ func (h *Handler) generateRecipe(c *gin.Context) {
 		apiError(c, http.StatusInternalServerError, "failed to insert recipe sub-lists")
 		return
 	}
+	if err := recordRecipeRevision(c, tx, newID, "ai", &req.Prompt, nil); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to record revision")
+		return
+	}
 
 	if err := tx.Commit(c); err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to commit")
@@ -278,7 +303,9 @@ func (h *Handler) generateRecipe(c *gin.Context) {
 // aiModifyRecipe handles POST /api/recipes/:id/ai-modify.
 // Sends the current recipe to OpenAI with a modification prompt and returns the
 // AI-suggested changes. Nothing is written to the DB — the client applies the
-// result to its local draft and must click Save to persist.
+// result to its local draft and must click Save to persist (passing the prompt
+// as ai_prompt so the revision is attributed to the AI). With ?save=true the
+// draft is applied directly as a new AI revision and the saved recipe returned.
 func (h *Handler) aiModifyRecipe(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	id, err := strconv.Atoi(c.Param("id"))
@@ -300,15 +327,16 @@ func (h *Handler) aiModifyRecipe(c *gin.Context) {
 		return
 	}
 
-	// Serialize the current recipe as context for the AI
-	currentJSON, err := json.Marshal(src)
+	// Serialize the current recipe as context for the AI, in request form so
+	// steps reference ingredients by position as in the response.
+	currentJSON, err := json.Marshal(recipeRequestFromDetail(src))
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to serialize recipe")
 		return
 	}
 
 	messages := []openAIMessage{
-		{Role: "system", Content: "You are a recipe editor. Given an existing recipe (as JSON) and a modification request, return the updated recipe with the requested changes applied. Preserve all fields that are not being modified. Keep the same structure and completeness."},
+		{Role: "system", Content: "You are a recipe editor. Given an existing recipe (as JSON) and a modification request, return the updated recipe with the requested changes applied. Preserve all fields that are not being modified. Keep the same structure and completeness." + stepRefsPrompt},
 		{Role: "user", Content: fmt.Sprintf("Current recipe:\n%s\n\nModification request: %s", string(currentJSON), req.Prompt)},
 	}
 
@@ -326,7 +354,66 @@ func (h *Handler) aiModifyRecipe(c *gin.Context) {
 		apiError(c, http.StatusInternalServerError, "ai response parse error")
 		return
 	}
-	c.JSON(http.StatusOK, draft)
+	if c.Query("save") != "true" {
+		c.JSON(http.StatusOK, draft)
+		return
+	}
+
+	// The AI doesn't see food or recipe links; keep them on ingredients it
+	// left alone.
+	linked := map[string]recipeIngredient{}
+	for _, ing := range src.Ingredients {
+		linked[ingredientKey(ing)] = ing
+	}
+	for i, ing := range draft.Ingredients {
+		if old, ok := linked[strings.ToLower(strings.TrimSpace(ing.Name))]; ok &&
+			ing.FoodID == nil && ing.SubRecipeID == nil && reflect.DeepEqual(ing.Qty, old.Qty) && reflect.DeepEqual(ing.Uom, old.Uom) {
+			draft.Ingredients[i].FoodID, draft.Ingredients[i].Grams = old.FoodID, old.Grams
+			draft.Ingredients[i].SubRecipeID = old.SubRecipeID
+		}
+	}
+	if draft.Servings == nil {
+		draft.Servings = &src.Servings
+	}
+
+	tx, err := h.db.Begin(c)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to start transaction")
+		return
+	}
+	defer tx.Rollback(c)
+
+	if err := clearMissingFoodLinks(c, tx, userID, draft.Ingredients); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check ingredient foods")
+		return
+	}
+	if err := clearInvalidSubRecipeLinks(c, tx, userID, id, draft.Ingredients); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to check ingredient recipes")
+		return
+	}
+	if err := replaceRecipe(tx, c, id, draft); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to save recipe")
+		return
+	}
+	if err := syncLinkedFavorites(c, tx, userID); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to sync favorites")
+		return
+	}
+	if err := recordRecipeRevision(c, tx, id, "ai", &req.Prompt, nil); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to record revision")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to commit")
+		return
+	}
+
+	detail, err := fetchRecipeDetail(h, c, id)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch updated recipe")
+		return
+	}
+	c.JSON(http.StatusOK, detail)
 }
 
 // aiCopyRecipe handles POST /api/recipes/:id/ai-copy.
@@ -352,14 +439,14 @@ func (h *Handler) aiCopyRecipe(c *gin.Context) {
 		return
 	}
 
-	currentJSON, err := json.Marshal(src)
+	currentJSON, err := json.Marshal(recipeRequestFromDetail(src))
 	if err != nil {
 		apiError(c, http.StatusInternalServerError, "failed to serialize recipe")
 		return
 	}
 
 	messages := []openAIMessage{
-		{Role: "system", Content: "You are a creative recipe developer. Given an existing recipe (as JSON) and a variation request, create a new recipe inspired by the original but incorporating the requested changes. Give it a new appropriate name and emoji."},
+		{Role: "system", Content: "You are a creative recipe developer. Given an existing recipe (as JSON) and a variation request, create a new recipe inspired by the original but incorporating the requested changes. Give it a new appropriate name and emoji." + stepRefsPrompt},
 		{Role: "user", Content: fmt.Sprintf("Original recipe:\n%s\n\nVariation request: %s", string(currentJSON), req.Prompt)},
 	}
 
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// reportData is a progress report for a date range, built from the same
+// per-day totals and stats as GET /progress. Rendered to HTML and PDF.
+type reportData struct {
+	Start       string
+	End         string
+	GeneratedOn string
+	DaysInRange int
+	Days        []weekDaySummary
+	Stats       progressStats
+	AvgBudget   int
+	OnBudgetPct int
+	Macros      []reportMacro
+	WeightUnit  string             // lb | kg
+	Weight      *reportWeightTrend // nil when no weigh-ins fall in the range
+	// EstimatedWeightChange is Stats.EstimatedWeightChangeLbs in WeightUnit.
+	EstimatedWeightChange *float64
+	NetChart              reportChart
+}
+
+// reportMacro is one macro's average intake against its average target.
+// Protein is a floor; carbs and fat are ceilings (same rule as progressStats).
+type reportMacro struct {
+	Name         string
+	Rule         string // "at least" | "at most"
+	AvgG         int
+	AvgTargetG   int
+	DaysOnTarget int
+	Pct          int
+}
+
+// reportWeightTrend is the weigh-ins within the range and the change across them.
+type reportWeightTrend struct {
+	Points []reportWeightPoint
+	Start  float64
+	End    float64
+	Change float64
+	Chart  reportChart
+}
+
+type reportWeightPoint struct {
+	Date   string
+	Weight float64
+}
+
+// reportChart is a chart laid out in a Width×Height box with y growing
+// downward, as in SVG. The PDF renderer flips it.
+type reportChart struct {
+	Width  float64
+	Height float64
+	Bars   []reportBar
+	Line   []reportPoint
+	Min    float64 // value at the bottom edge
+	Max    float64 // value at the top edge
+}
+
+type reportBar struct {
+	X, Y, W, H float64
+	Over       bool
+}
+
+type reportPoint struct{ X, Y float64 }
+
+// Points formats Line for an SVG polyline's points attribute.
+func (ch reportChart) Points() string {
+	parts := make([]string, len(ch.Line))
+	for i, p := range ch.Line {
+		parts[i] = fmt.Sprintf("%.1f,%.1f", p.X, p.Y)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ViewBox is the SVG viewBox for the chart: the plot area plus a margin on
+// the left for the axis labels and above and below so the edge labels and
+// points aren't clipped.
+func (ch reportChart) ViewBox() string {
+	return fmt.Sprintf("%g %g %g %g", -reportChartMarginLeft, -reportChartMarginTop,
+		ch.Width+reportChartMarginLeft, ch.Height+2*reportChartMarginTop)
+}
+
+const (
+	reportChartWidth  = 520.0
+	reportChartHeight = 140.0
+
+	reportChartMarginLeft = 40.0
+	reportChartMarginTop  = 10.0
+)
+
+// pct returns n as a whole percentage of of (0 when of is 0).
+func pct(n, of int) int {
+	if of == 0 {
+		return 0
+	}
+	return int(math.Round(float64(n) * 100 / float64(of)))
+}
+
+// title upper-cases the first letter of s, for labels like meal types.
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// newReportData assembles the report from a progress response and the weight
+// log. units is the settings value ("us" | "metric") and picks lb or kg.
+func newReportData(start, end string, progress progressResponse, weights []weightEntry, units string, now time.Time) reportData {
+	startDate, _ := time.Parse("2006-01-02", start)
+	endDate, _ := time.Parse("2006-01-02", end)
+	stats := progress.Stats
+	r := reportData{
+		Start:       start,
+		End:         end,
+		GeneratedOn: now.Format("2006-01-02"),
+		DaysInRange: int(endDate.Sub(startDate).Hours()/24) + 1,
+		Days:        progress.Days,
+		Stats:       stats,
+		OnBudgetPct: pct(stats.DaysOnBudget, stats.DaysTracked),
+		WeightUnit:  "lb",
+	}
+
+	var budget, protein, carbs, fat, proteinT, carbsT, fatT float64
+	for _, d := range progress.Days {
+		budget += float64(d.CalorieBudget)
+		protein += d.ProteinG
+		carbs += d.CarbsG
+		fat += d.FatG
+		proteinT += float64(d.ProteinTargetG)
+		carbsT += float64(d.CarbsTargetG)
+		fatT += float64(d.FatTargetG)
+	}
+	avg := func(total float64) int {
+		if stats.DaysTracked == 0 {
+			return 0
+		}
+		return int(math.Round(total / float64(stats.DaysTracked)))
+	}
+	r.AvgBudget = avg(budget)
+	r.Macros = []reportMacro{
+		{Name: "Protein", Rule: "at least", AvgG: avg(protein), AvgTargetG: avg(proteinT), DaysOnTarget: stats.DaysProteinOnTarget},
+		{Name: "Carbs", Rule: "at most", AvgG: avg(carbs), AvgTargetG: avg(carbsT), DaysOnTarget: stats.DaysCarbsOnTarget},
+		{Name: "Fat", Rule: "at most", AvgG: avg(fat), AvgTargetG: avg(fatT), DaysOnTarget: stats.DaysFatOnTarget},
+	}
+	for i := range r.Macros {
+		r.Macros[i].Pct = pct(r.Macros[i].DaysOnTarget, stats.DaysTracked)
+	}
+
+	convert := func(lbs float64) float64 { return math.Round(lbs*10) / 10 }
+	if units == "metric" {
+		r.WeightUnit = "kg"
+		convert = func(lbs float64) float64 { return math.Round(lbs/2.20462*10) / 10 }
+	}
+	if stats.EstimatedWeightChangeLbs != nil {
+		wc := convert(*stats.EstimatedWeightChangeLbs)
+		r.EstimatedWeightChange = &wc
+	}
+
+	var points []reportWeightPoint
+	for _, w := range weights {
+		date := w.Date.Format("2006-01-02")
+		if date >= start && date <= end {
+			points = append(points, reportWeightPoint{Date: date, Weight: convert(w.WeightLBS)})
+		}
+	}
+	if len(points) > 0 {
+		first, last := points[0].Weight, points[len(points)-1].Weight
+		r.Weight = &reportWeightTrend{
+			Points: points,
+			Start:  first,
+			End:    last,
+			Change: math.Round((last-first)*10) / 10,
+			Chart:  newWeightChart(points, startDate, r.DaysInRange),
+		}
+	}
+	r.NetChart = newNetChart(progress.Days, startDate, r.DaysInRange)
+	return r
+}
+
+// dayIndex is the zero-based offset of date from start.
+func dayIndex(start time.Time, date string) int {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0
+	}
+	return int(t.Sub(start).Hours() / 24)
+}
+
+// newNetChart plots one bar per logged day for net calories, with the budget
+// as a line across them. Days without data leave a gap.
+func newNetChart(days []weekDaySummary, start time.Time, n int) reportChart {
+	ch := reportChart{Width: reportChartWidth, Height: reportChartHeight}
+	for _, d := range days {
+		ch.Max = math.Max(ch.Max, math.Max(float64(d.NetCalories), float64(d.CalorieBudget)))
+	}
+	if ch.Max == 0 || n <= 0 {
+		return ch
+	}
+	ch.Max = math.Ceil(ch.Max*1.1/100) * 100
+	slot := ch.Width / float64(n)
+	for _, d := range days {
+		i := dayIndex(start, d.Date.Format("2006-01-02"))
+		h := math.Max(float64(d.NetCalories), 0) / ch.Max * ch.Height
+		ch.Bars = append(ch.Bars, reportBar{
+			X:    float64(i)*slot + slot*0.15,
+			Y:    ch.Height - h,
+			W:    slot * 0.7,
+			H:    h,
+			Over: d.NetCalories > d.CalorieBudget,
+		})
+		ch.Line = append(ch.Line, reportPoint{
+			X: (float64(i) + 0.5) * slot,
+			Y: ch.Height - float64(d.CalorieBudget)/ch.Max*ch.Height,
+		})
+	}
+	return ch
+}
+
+// newWeightChart plots weigh-ins by date, scaled to their range plus padding.
+func newWeightChart(points []reportWeightPoint, start time.Time, n int) reportChart {
+	ch := reportChart{Width: reportChartWidth, Height: reportChartHeight}
+	lo, hi := points[0].Weight, points[0].Weight
+	for _, p := range points {
+		lo, hi = math.Min(lo, p.Weight), math.Max(hi, p.Weight)
+	}
+	ch.Min, ch.Max = math.Floor(lo-1), math.Ceil(hi+1)
+	slot := ch.Width / float64(max(n, 1))
+	for _, p := range points {
+		ch.Line = append(ch.Line, reportPoint{
+			X: (float64(dayIndex(start, p.Date)) + 0.5) * slot,
+			Y: ch.Height - (p.Weight-ch.Min)/(ch.Max-ch.Min)*ch.Height,
+		})
+	}
+	return ch
+}
+
+//go:embed report.html.tmpl
+var reportHTMLSource string
+
+var reportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"title":  title,
+	"signed": func(v float64) string { return fmt.Sprintf("%+.1f", v) },
+	"g":      func(v float64) string { return fmt.Sprintf("%.0f", v) },
+	"pct":    pct,
+}).Parse(reportHTMLSource))
+
+// renderReportHTML renders the report as a self-contained HTML page: inline
+// CSS and SVG charts, no external assets, print-friendly.
+func renderReportHTML(r reportData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := reportHTML.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getReport renders a progress report to share with a dietitian or coach.
+// GET /api/calorie-log/report?start=YYYY-MM-DD&end=YYYY-MM-DD&format=html|pdf.
+// format defaults to html; pdf is sent as a download. Rendering is local.
+func (h *Handler) getReport(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	start, end, ok := progressRange(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "pdf" {
+		apiError(c, http.StatusBadRequest, "format must be html or pdf")
+		return
+	}
+
+	progress, weights, err := h.buildProgress(c, userID, start, end)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	settings, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch settings")
+		return
+	}
+	report := newReportData(start, end, progress, weights, settings.Units, time.Now())
+
+	if format == "pdf" {
+		pdf, err := renderReportPDF(report)
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to render report")
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="progress-report-%s-to-%s.pdf"`, start, end))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+	page, err := renderReportHTML(report)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to render report")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
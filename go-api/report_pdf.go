@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfMargin    = 54.0
+	pdfRowHeight = 15.0
+)
+
+var (
+	pdfBlue  = pdfColor{0.29, 0.56, 0.85}
+	pdfRed   = pdfColor{0.90, 0.47, 0.43}
+	pdfGreen = pdfColor{0.18, 0.49, 0.20}
+)
+
+// reportPDF lays out the report top to bottom, starting a new page when the
+// next block doesn't fit. y is the baseline of the next line.
+type reportPDF struct {
+	doc  pdfDoc
+	page *pdfPage
+	y    float64
+}
+
+func (r *reportPDF) newPage() {
+	r.page = r.doc.addPage()
+	r.y = pdfPageHeight - pdfMargin
+}
+
+// need starts a new page unless h points remain above the bottom margin.
+func (r *reportPDF) need(h float64) {
+	if r.y-h < pdfMargin {
+		r.newPage()
+	}
+}
+
+func (r *reportPDF) heading(s string) {
+	r.need(24 + 3*pdfRowHeight)
+	r.y -= 10
+	r.page.text(pdfMargin, r.y, 13, true, pdfBlack, s)
+	r.y -= 5
+	r.page.line(pdfMargin, r.y, pdfPageWidth-pdfMargin, r.y, 0.5, pdfLight)
+	r.y -= pdfRowHeight
+}
+
+func (r *reportPDF) paragraph(s string, color pdfColor) {
+	r.need(pdfRowHeight)
+	r.page.text(pdfMargin, r.y, 10, false, color, s)
+	r.y -= pdfRowHeight
+}
+
+// row draws cells at the given column x positions: the first left-aligned at
+// cols[0], the rest right-aligned ending at their x.
+func (r *reportPDF) row(cols []float64, cells []string, bold bool, color pdfColor) {
+	r.need(pdfRowHeight)
+	for i, cell := range cells {
+		if i == 0 {
+			r.page.text(cols[0], r.y, 9.5, bold, color, cell)
+		} else {
+			r.page.textRight(cols[i], r.y, 9.5, bold, color, cell)
+		}
+	}
+	r.y -= pdfRowHeight
+}
+
+// chart draws ch below the cursor, flipping its SVG-style y axis.
+func (r *reportPDF) chart(ch reportChart, lineColor pdfColor, dashed bool, minLabel, maxLabel string) {
+	left := pdfMargin + 36
+	scale := (pdfPageWidth - pdfMargin - left) / ch.Width
+	h := ch.Height * scale
+	r.need(h + 2*pdfRowHeight)
+	top := r.y
+	bottom := top - h
+	at := func(x, y float64) [2]float64 { return [2]float64{left + x*scale, top - y*scale} }
+
+	r.page.line(left, bottom, left+ch.Width*scale, bottom, 0.5, pdfLight)
+	r.page.textRight(left-4, top-8, 8, false, pdfGray, maxLabel)
+	r.page.textRight(left-4, bottom, 8, false, pdfGray, minLabel)
+	for _, b := range ch.Bars {
+		color := pdfBlue
+		if b.Over {
+			color = pdfRed
+		}
+		p := at(b.X, b.Y+b.H)
+		r.page.rect(p[0], p[1], b.W*scale, b.H*scale, color)
+	}
+	pts := make([][2]float64, len(ch.Line))
+	for i, p := range ch.Line {
+		pts[i] = at(p.X, p.Y)
+	}
+	if dashed {
+		r.page.dash(3, 2)
+	}
+	r.page.polyline(pts, 1.2, lineColor)
+	if dashed {
+		r.page.dash(0, 0)
+	}
+	r.y = bottom - 1.5*pdfRowHeight
+}
+
+// renderReportPDF renders the report as a PDF with the same sections as the
+// HTML version.
+func renderReportPDF(d reportData) ([]byte, error) {
+	r := &reportPDF{}
+	r.newPage()
+	right := pdfPageWidth - pdfMargin
+
+	r.page.text(pdfMargin, r.y, 20, true, pdfBlack, "Progress report")
+	r.y -= 18
+	r.paragraph(fmt.Sprintf("%s to %s - %d days - generated %s", d.Start, d.End, d.DaysInRange, d.GeneratedOn), pdfGray)
+
+	r.heading("Summary")
+	summary := [][2]string{
+		{"Days logged", fmt.Sprintf("%d of %d", d.Stats.DaysTracked, d.DaysInRange)},
+		{"Days fully logged", fmt.Sprint(d.Stats.DaysComplete)},
+		{"Days on budget", fmt.Sprintf("%d (%d%%)", d.Stats.DaysOnBudget, d.OnBudgetPct)},
+		{"Average net calories", fmt.Sprintf("%d kcal (budget %d)", d.Stats.AvgNetCalories, d.AvgBudget)},
+		{"Average food / exercise", fmt.Sprintf("%d / %d kcal", d.Stats.AvgCaloriesFood, d.Stats.AvgCaloriesExercise)},
+	}
+	if d.EstimatedWeightChange != nil {
+		summary = append(summary, [2]string{"Estimated weight change", fmt.Sprintf("%+.1f %s", *d.EstimatedWeightChange, d.WeightUnit)})
+	}
+	if d.Weight != nil {
+		summary = append(summary, [2]string{"Measured weight change", fmt.Sprintf("%+.1f %s", d.Weight.Change, d.WeightUnit)})
+	}
+	for _, s := range summary {
+		r.row([]float64{pdfMargin, pdfMargin + 300}, s[:], false, pdfBlack)
+	}
+
+	r.heading("Macro adherence")
+	cols := []float64{pdfMargin, pdfMargin + 250, pdfMargin + 350, right}
+	r.row(cols, []string{"Macro", "Avg intake", "Avg target", "Days on target"}, true, pdfGray)
+	for _, m := range d.Macros {
+		r.row(cols, []string{
+			fmt.Sprintf("%s (%s)", m.Name, m.Rule),
+			fmt.Sprintf("%d g", m.AvgG),
+			fmt.Sprintf("%d g", m.AvgTargetG),
+			fmt.Sprintf("%d (%d%%)", m.DaysOnTarget, m.Pct),
+		}, false, pdfBlack)
+	}
+
+	r.heading("Meals")
+	cols = []float64{pdfMargin, pdfMargin + 250, right}
+	r.row(cols, []string{"Meal", "Avg kcal", "Days on budget"}, true, pdfGray)
+	for _, m := range d.Stats.MealAdherence {
+		avg, onBudget := "-", "no budget"
+		if m.DaysWithBudget > 0 {
+			avg = fmt.Sprint(m.AvgCalories)
+			onBudget = fmt.Sprintf("%d of %d (%d%%)", m.DaysOnBudget, m.DaysWithBudget, pct(m.DaysOnBudget, m.DaysWithBudget))
+		}
+		r.row(cols, []string{strings.ToUpper(m.Meal[:1]) + m.Meal[1:], avg, onBudget}, false, pdfBlack)
+	}
+	if f := d.Stats.Fasting; f != nil {
+		r.paragraph(fmt.Sprintf("Fasting target %g h: met on %d of %d measured days, average fast %g h, longest streak %d days.",
+			f.TargetHours, f.DaysMet, f.DaysMeasured, f.AvgFastHours, f.LongestStreak), pdfBlack)
+	}
+
+	r.heading("Weight trend")
+	if w := d.Weight; w != nil {
+		r.paragraph(fmt.Sprintf("%.1f %s -> %.1f %s (%+.1f %s) over %d weigh-ins.",
+			w.Start, d.WeightUnit, w.End, d.WeightUnit, w.Change, d.WeightUnit, len(w.Points)), pdfBlack)
+		r.y -= 4
+		r.chart(w.Chart, pdfGreen, false, fmt.Sprintf("%.0f", w.Chart.Min), fmt.Sprintf("%.0f", w.Chart.Max))
+	} else {
+		r.paragraph("No weigh-ins in this range.", pdfGray)
+	}
+
+	r.heading("Daily net calories vs budget")
+	if len(d.NetChart.Bars) > 0 {
+		r.y += 4
+		r.chart(d.NetChart, pdfBlack, true, "0", fmt.Sprintf("%.0f", d.NetChart.Max))
+	}
+
+	r.heading("Daily log")
+	cols = []float64{pdfMargin, pdfMargin + 150, pdfMargin + 205, pdfMargin + 265, pdfMargin + 315, pdfMargin + 370, right}
+	header := []string{"Date", "Budget", "Food", "Exercise", "Net", "Left", "P / C / F (g)"}
+	r.row(cols, header, true, pdfGray)
+	for _, day := range d.Days {
+		if r.y-pdfRowHeight < pdfMargin {
+			r.newPage()
+			r.row(cols, header, true, pdfGray)
+		}
+		color := pdfBlack
+		if day.CaloriesLeft < 0 {
+			color = pdfColor{0.71, 0.14, 0.09}
+		}
+		r.row(cols, []string{
+			day.Date.Format("Mon Jan 2, 2006"),
+			fmt.Sprint(day.CalorieBudget),
+			fmt.Sprint(day.CaloriesFood),
+			fmt.Sprint(day.CaloriesExercise),
+			fmt.Sprint(day.NetCalories),
+			fmt.Sprint(day.CaloriesLeft),
+			fmt.Sprintf("%.0f / %.0f / %.0f", day.ProteinG, day.CarbsG, day.FatG),
+		}, false, color)
+	}
+	if len(d.Days) == 0 {
+		r.paragraph("Nothing logged in this range.", pdfGray)
+	}
+
+	r.y -= 8
+	r.paragraph("Budgets and macro targets are those in effect on each day. Protein counts as on target at or above its", pdfGray)
+	r.paragraph("target; carbs and fat at or below. Estimated weight change uses 3,500 kcal per pound.", pdfGray)
+	return r.doc.bytes()
+}
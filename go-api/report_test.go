@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportFixture() (progressResponse, []weightEntry) {
+	day := func(s string) DateOnly {
+		t, _ := time.Parse("2006-01-02", s)
+		return DateOnly{t}
+	}
+	wc := -1.2
+	progress := progressResponse{
+		Days: []weekDaySummary{
+			{Date: day("2024-03-01"), CalorieBudget: 2000, CaloriesFood: 1900, NetCalories: 1900, CaloriesLeft: 100,
+				ProteinG: 150, CarbsG: 180, FatG: 60, ProteinTargetG: 140, CarbsTargetG: 200, FatTargetG: 70},
+			{Date: day("2024-03-03"), CalorieBudget: 2200, CaloriesFood: 2500, CaloriesExercise: 100, NetCalories: 2400, CaloriesLeft: -200,
+				ProteinG: 120, CarbsG: 260, FatG: 90, ProteinTargetG: 140, CarbsTargetG: 220, FatTargetG: 80},
+		},
+		Stats: progressStats{
+			DaysTracked: 2, DaysOnBudget: 1, AvgNetCalories: 2150,
+			DaysProteinOnTarget: 1, DaysCarbsOnTarget: 1, DaysFatOnTarget: 1,
+			MealAdherence:            []mealAdherence{{Meal: "breakfast", DaysWithBudget: 2, DaysOnBudget: 1, AvgCalories: 450}, {Meal: "snack"}},
+			EstimatedWeightChangeLbs: &wc,
+		},
+	}
+	weights := []weightEntry{
+		{Date: day("2024-02-20"), WeightLBS: 182},
+		{Date: day("2024-03-01"), WeightLBS: 180},
+		{Date: day("2024-03-04"), WeightLBS: 178.6},
+	}
+	return progress, weights
+}
+
+func TestNewReportData(t *testing.T) {
+	progress, weights := reportFixture()
+	now := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	r := newReportData("2024-03-01", "2024-03-04", progress, weights, "us", now)
+
+	if r.DaysInRange != 4 || r.AvgBudget != 2100 || r.OnBudgetPct != 50 || r.GeneratedOn != "2024-03-05" {
+		t.Errorf("summary: got days %d budget %d on-budget %d%% generated %s", r.DaysInRange, r.AvgBudget, r.OnBudgetPct, r.GeneratedOn)
+	}
+	if m := r.Macros[0]; m.Name != "Protein" || m.AvgG != 135 || m.AvgTargetG != 140 || m.Pct != 50 {
+		t.Errorf("protein: got %+v", m)
+	}
+	// The Feb 20 weigh-in is before the range and isn't part of the trend.
+	if r.Weight == nil || len(r.Weight.Points) != 2 || r.Weight.Start != 180 || r.Weight.End != 178.6 || r.Weight.Change != -1.4 {
+		t.Fatalf("weight trend: got %+v", r.Weight)
+	}
+	if r.EstimatedWeightChange == nil || *r.EstimatedWeightChange != -1.2 || r.WeightUnit != "lb" {
+		t.Errorf("estimated change: got %v %s", r.EstimatedWeightChange, r.WeightUnit)
+	}
+	// Two logged days → two bars; Mar 3 is over budget. Four-day range → 130pt slots.
+	if len(r.NetChart.Bars) != 2 || r.NetChart.Bars[0].Over || !r.NetChart.Bars[1].Over || r.NetChart.Bars[1].X != 2*130+130*0.15 {
+		t.Errorf("net chart bars: got %+v", r.NetChart.Bars)
+	}
+
+	metric := newReportData("2024-03-01", "2024-03-04", progress, weights, "metric", now)
+	if metric.WeightUnit != "kg" || metric.Weight.Start != 81.6 || *metric.EstimatedWeightChange != -0.5 {
+		t.Errorf("metric: got %s start %v est %v", metric.WeightUnit, metric.Weight.Start, *metric.EstimatedWeightChange)
+	}
+
+	empty := newReportData("2024-03-01", "2024-03-04", progressResponse{}, nil, "us", now)
+	if empty.Weight != nil || len(empty.NetChart.Bars) != 0 || empty.AvgBudget != 0 {
+		t.Errorf("empty range: got %+v", empty)
+	}
+}
+
+func TestRenderReportHTML(t *testing.T) {
+	progress, weights := reportFixture()
+	r := newReportData("2024-03-01", "2024-03-04", progress, weights, "us", time.Now())
+	page, err := renderReportHTML(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(page)
+	for _, want := range []string{"2024-03-01 to 2024-03-04", "1 (50%)", "Protein", "Breakfast", "-1.4 lb", "<polyline", `class="bar over"`, "Sun Mar 3", `viewBox="-40 -10 560 160"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected HTML to contain %q", want)
+		}
+	}
+	if strings.Contains(html, "http://") || strings.Contains(html, "https://") {
+		t.Error("report must be self-contained")
+	}
+}
+
+func TestRenderReportPDF(t *testing.T) {
+	progress, weights := reportFixture()
+	// Enough days to spill onto a second page.
+	for i := range 60 {
+		d := progress.Days[0]
+		d.Date = DateOnly{d.Date.AddDate(0, 0, i+5)}
+		progress.Days = append(progress.Days, d)
+	}
+	r := newReportData("2024-03-01", "2024-05-05", progress, weights, "us", time.Now())
+	pdf, err := renderReportPDF(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if n := bytes.Count(pdf, []byte("/Type /Page ")); n < 2 {
+		t.Errorf("expected the daily log to continue on a second page, got %d pages", n)
+	}
+
+	// Every xref entry must point at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for i := 1; i < count; i++ {
+		off, _ := strconv.Atoi(lines[2+i][:10])
+		if want := fmt.Sprintf("%d 0 obj", i); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i, pdf[off:off+10])
+		}
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	if got := pdfEscape(`a (b) \ c — café`); got != `a \(b\) \\ c - caf\351` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	for in, want := range map[string]string{"": "", "lunch": "Lunch", "Snack": "Snack"} {
+		if got := title(in); got != want {
+			t.Errorf("title(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
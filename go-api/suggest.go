@@ -24,9 +24,10 @@ type suggestRequest struct {
 	Type        string `json:"type"`
 }
 
-// suggestionResponse is the structured nutrition data returned by the AI.
-// For exercise entries, only ItemName and Calories are populated.
-// Confidence is 1-5 indicating how accurate the estimate is.
+// suggestionResponse is the structured nutrition data returned by the AI or the
+// built-in exercise catalog. For exercise entries, only ItemName and Calories are
+// populated. Confidence is 1-5 indicating how accurate the estimate is.
+// Source is "catalog" when the MET catalog computed the result, otherwise "ai".
 type suggestionResponse struct {
 	ItemName   string  `json:"item_name"`
 	Qty        float64 `json:"qty"`
@@ -36,6 +37,7 @@ type suggestionResponse struct {
 	CarbsG     float64 `json:"carbs_g"`
 	FatG       float64 `json:"fat_g"`
 	Confidence int     `json:"confidence"`
+	Source     string  `json:"source"`
 }
 
 /* ─── OpenAI prompt constants ────────────────────────────────────────── */
@@ -173,7 +175,9 @@ func callOpenAI(ctx context.Context, messages []openAIMessage, baseURL string) (
 
 // suggestCalorieLogItem handles POST /api/calorie-log/suggest.
 // Accepts a food or exercise description, calls OpenAI to parse it into
-// structured nutrition data, and returns the suggestion.
+// structured nutrition data, and returns the suggestion. Exercise descriptions
+// that match the built-in catalog (activity plus duration or distance) are
+// computed deterministically from MET values without calling OpenAI.
 func (h *Handler) suggestCalorieLogItem(c *gin.Context) {
 	var req suggestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -186,6 +190,14 @@ func (h *Handler) suggestCalorieLogItem(c *gin.Context) {
 		return
 	}
 
+	if req.Type == "exercise" {
+		if parsed, ok := parseExerciseDescription(req.Description); ok {
+			weight, _ := h.currentWeightLBS(c)
+			c.JSON(http.StatusOK, catalogSuggestion(parsed, weight))
+			return
+		}
+	}
+
 	// Build the system prompt based on entry type
 	var systemPrompt string
 	if req.Type == "exercise" {
@@ -234,6 +246,7 @@ func (h *Handler) suggestCalorieLogItem(c *gin.Context) {
 		return
 	}
 
+	suggestion.Source = "ai"
 	c.JSON(http.StatusOK, suggestion)
 }
 
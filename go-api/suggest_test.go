@@ -92,12 +92,13 @@ func TestSuggest_ExerciseSuccess(t *testing.T) {
 	router, mockServer, setMock := setupSuggestTest()
 	defer mockServer.Close()
 
-	// Exercise entries without DB still work — they use the fallback prompt
-	suggestion := `{"item_name":"Jogging","qty":30,"uom":"minutes","calories":250,"protein_g":0,"carbs_g":0,"fat_g":0,"confidence":3}`
+	// Activities outside the catalog fall back to the AI estimate. Exercise
+	// entries without DB still work — they use the fallback prompt
+	suggestion := `{"item_name":"Fencing","qty":30,"uom":"minutes","calories":250,"protein_g":0,"carbs_g":0,"fat_g":0,"confidence":3}`
 	setMock(http.StatusOK, openAIChatResponse(suggestion))
 	t.Setenv("OPENAI_API_KEY", "test-key")
 
-	w := doSuggestRequest(router, `{"description":"30 minute jog","type":"exercise"}`)
+	w := doSuggestRequest(router, `{"description":"30 minutes of fencing","type":"exercise"}`)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
@@ -107,12 +108,68 @@ func TestSuggest_ExerciseSuccess(t *testing.T) {
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("failed to parse response: %v", err)
 	}
-	if resp.ItemName != "Jogging" {
-		t.Errorf("expected item_name 'Jogging', got '%s'", resp.ItemName)
+	if resp.ItemName != "Fencing" {
+		t.Errorf("expected item_name 'Fencing', got '%s'", resp.ItemName)
 	}
 	if resp.Calories != 250 {
 		t.Errorf("expected calories 250, got %d", resp.Calories)
 	}
+	if resp.Source != "ai" {
+		t.Errorf("expected source 'ai', got '%s'", resp.Source)
+	}
+}
+
+func TestSuggest_ExerciseCatalog(t *testing.T) {
+	router, mockServer, setMock := setupSuggestTest()
+	defer mockServer.Close()
+
+	// A catalog match must not call OpenAI — make the mock fail if it does.
+	setMock(http.StatusInternalServerError, map[string]string{"error": "should not be called"})
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	w := doSuggestRequest(router, `{"description":"30 minute jog","type":"exercise"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp suggestionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	// 7.0 MET × (160 lb default / 2.20462) kg × 0.5 h ≈ 254
+	if resp.ItemName != "Jogging" || resp.Calories != 254 || resp.Source != "catalog" {
+		t.Errorf("expected Jogging / 254 kcal / catalog, got %s / %d / %s", resp.ItemName, resp.Calories, resp.Source)
+	}
+}
+
+func TestParseExerciseDescription(t *testing.T) {
+	cases := []struct {
+		desc    string
+		wantKey string
+		wantUom string
+		wantMin float64
+		wantOK  bool
+	}{
+		{"30 minute jog", "jogging", "minutes", 30, true},
+		{"1.5 hours of cycling", "cycling", "minutes", 90, true},
+		{"ran 6 miles", "running", "miles", 60, true},
+		{"brisk walk 45 min", "brisk_walking", "minutes", 45, true},
+		{"swam 2 miles", "", "", 0, false}, // swimming is duration-only
+		{"yoga", "", "", 0, false},         // no duration
+		{"30 min fencing", "", "", 0, false},
+	}
+	for _, tc := range cases {
+		p, ok := parseExerciseDescription(tc.desc)
+		if ok != tc.wantOK {
+			t.Errorf("%q: want ok=%v, got %v", tc.desc, tc.wantOK, ok)
+			continue
+		}
+		if ok && (p.Activity.Key != tc.wantKey || p.Uom != tc.wantUom || p.Minutes != tc.wantMin) {
+			t.Errorf("%q: want %s/%s/%.0f min, got %s/%s/%.0f min",
+				tc.desc, tc.wantKey, tc.wantUom, tc.wantMin, p.Activity.Key, p.Uom, p.Minutes)
+		}
+	}
 }
 
 func TestSuggest_Unrecognized(t *testing.T) {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 	"time"
@@ -368,10 +369,10 @@ func TestConfigForDate_UsesHistoryWhenDateInRange(t *testing.T) {
 	history := makeConfigHistory("2024-01-31", 2200, "2024-02-28", 2000)
 	settings := settingsWithBudget(1800)
 
-	if b, _ := configForDate(history, settings, "2024-01-15"); b != 2200 {
+	if b := configForDate(history, settings, "2024-01-15").CalorieBudget; b != 2200 {
 		t.Errorf("expected 2200 for Jan 15, got %d", b)
 	}
-	if b, _ := configForDate(history, settings, "2024-02-10"); b != 2000 {
+	if b := configForDate(history, settings, "2024-02-10").CalorieBudget; b != 2000 {
 		t.Errorf("expected 2000 for Feb 10, got %d", b)
 	}
 }
@@ -381,7 +382,7 @@ func TestConfigForDate_UsesHistoryWhenDateInRange(t *testing.T) {
 func TestConfigForDate_FallsBackToCurrentSettings(t *testing.T) {
 	history := makeConfigHistory("2024-01-31", 2200)
 	settings := settingsWithBudget(1800)
-	if b, _ := configForDate(history, settings, "2024-03-01"); b != 1800 {
+	if b := configForDate(history, settings, "2024-03-01").CalorieBudget; b != 1800 {
 		t.Errorf("expected current budget 1800 for Mar 1, got %d", b)
 	}
 }
@@ -390,7 +391,153 @@ func TestConfigForDate_FallsBackToCurrentSettings(t *testing.T) {
 // case — current settings apply for any date.
 func TestConfigForDate_EmptyHistoryReturnsCurrentSettings(t *testing.T) {
 	settings := settingsWithBudget(2300)
-	if b, _ := configForDate([]calorieConfigHistory{}, settings, "2023-06-01"); b != 2300 {
+	if b := configForDate([]calorieConfigHistory{}, settings, "2023-06-01").CalorieBudget; b != 2300 {
 		t.Errorf("expected 2300, got %d", b)
 	}
 }
+
+// scheduleJSON marshals schedule days into the JSONB shape stored in settings.
+func scheduleJSON(days ...budgetScheduleDay) *json.RawMessage {
+	b, _ := json.Marshal(days)
+	raw := json.RawMessage(b)
+	return &raw
+}
+
+// TestConfigForDate_AppliesWeekdaySchedule verifies that a scheduled weekday
+// overrides the base budget and macros while other days keep the base values.
+func TestConfigForDate_AppliesWeekdaySchedule(t *testing.T) {
+	settings := settingsWithBudget(2000)
+	settings.ProteinTargetG = 150
+	label := "training"
+	settings.BudgetSchedule = scheduleJSON(budgetScheduleDay{
+		Weekday: 0, Label: &label, CalorieBudget: intPtr(2400), ProteinTargetG: intPtr(180),
+	})
+
+	// 2024-03-04 is a Monday (weekday 0).
+	mon := configForDate(nil, settings, "2024-03-04")
+	if mon.CalorieBudget != 2400 || mon.ProteinTargetG != 180 {
+		t.Errorf("Monday: want budget 2400 / protein 180, got %d / %d", mon.CalorieBudget, mon.ProteinTargetG)
+	}
+	if mon.ScheduleLabel == nil || *mon.ScheduleLabel != "training" {
+		t.Errorf("Monday: expected schedule label 'training', got %v", mon.ScheduleLabel)
+	}
+
+	tue := configForDate(nil, settings, "2024-03-05")
+	if tue.CalorieBudget != 2000 || tue.ProteinTargetG != 150 || tue.ScheduleLabel != nil {
+		t.Errorf("Tuesday: want base budget 2000 / protein 150 / no label, got %d / %d / %v",
+			tue.CalorieBudget, tue.ProteinTargetG, tue.ScheduleLabel)
+	}
+}
+
+// TestConfigForDate_UsesHistoricalSchedule verifies that dates covered by a history
+// row use that row's schedule, not the current one.
+func TestConfigForDate_UsesHistoricalSchedule(t *testing.T) {
+	history := makeConfigHistory("2024-02-29", 2200)
+	history[0].BudgetSchedule = scheduleJSON(budgetScheduleDay{Weekday: 6, CalorieBudget: intPtr(2600)})
+	settings := settingsWithBudget(1800)
+	settings.BudgetSchedule = scheduleJSON(budgetScheduleDay{Weekday: 6, CalorieBudget: intPtr(2100)})
+
+	// 2024-02-25 and 2024-03-03 are Sundays (weekday 6).
+	if b := configForDate(history, settings, "2024-02-25").CalorieBudget; b != 2600 {
+		t.Errorf("expected historical Sunday budget 2600, got %d", b)
+	}
+	if b := configForDate(history, settings, "2024-03-03").CalorieBudget; b != 2100 {
+		t.Errorf("expected current Sunday budget 2100, got %d", b)
+	}
+	// A history row with no schedule means no overrides applied in that period.
+	history[0].BudgetSchedule = nil
+	if b := configForDate(history, settings, "2024-02-25").CalorieBudget; b != 2200 {
+		t.Errorf("expected unscheduled historical budget 2200, got %d", b)
+	}
+}
+
+/* ─── Macro target resolution tests ──────────────────────────────────────── */
+
+// TestResolveMacros_Modes verifies grams, percent-of-budget, and g/lb resolution,
+// including the grams fallback when no body weight is known.
+func TestResolveMacros_Modes(t *testing.T) {
+	protein := 1.0 // g per lb
+	carbs := 40.0  // percent
+	fat := 30.0    // percent
+	settings := settingsWithBudget(2000)
+	settings.ProteinTargetG, settings.ProteinTargetMode, settings.ProteinTargetValue = 150, "g_per_lb", &protein
+	settings.CarbsTargetG, settings.CarbsTargetMode, settings.CarbsTargetValue = 250, "percent", &carbs
+	settings.FatTargetG, settings.FatTargetMode, settings.FatTargetValue = 80, "percent", &fat
+
+	got := configForDate(nil, settings, "2024-03-05")
+	got.resolveMacros(180)
+	// 180 lb × 1 g/lb = 180 g; 2000 × 40% / 4 = 200 g; 2000 × 30% / 9 ≈ 67 g.
+	if got.ProteinTargetG != 180 || got.CarbsTargetG != 200 || got.FatTargetG != 67 {
+		t.Errorf("want 180/200/67, got %d/%d/%d", got.ProteinTargetG, got.CarbsTargetG, got.FatTargetG)
+	}
+
+	// Unknown weight: g/lb falls back to the stored grams target.
+	noWeight := configForDate(nil, settings, "2024-03-05")
+	noWeight.resolveMacros(0)
+	if noWeight.ProteinTargetG != 150 {
+		t.Errorf("want grams fallback 150 with no weight, got %d", noWeight.ProteinTargetG)
+	}
+}
+
+// TestResolveMacros_PercentFollowsScheduledBudget verifies that percent targets
+// are computed from the day's scheduled budget, not the base budget.
+func TestResolveMacros_PercentFollowsScheduledBudget(t *testing.T) {
+	carbs := 50.0
+	settings := settingsWithBudget(2000)
+	settings.CarbsTargetMode, settings.CarbsTargetValue = "percent", &carbs
+	settings.BudgetSchedule = scheduleJSON(budgetScheduleDay{Weekday: 0, CalorieBudget: intPtr(2800)})
+
+	mon := configForDate(nil, settings, "2024-03-04") // Monday
+	mon.resolveMacros(180)
+	if mon.CarbsTargetG != 350 { // 2800 × 50% / 4
+		t.Errorf("want 350 g carbs on scheduled day, got %d", mon.CarbsTargetG)
+	}
+}
+
+// TestConfigForDate_HistoricalMacroTargets verifies that history rows carry the
+// macro mode in effect at the time, and nil columns inherit current settings.
+func TestConfigForDate_HistoricalMacroTargets(t *testing.T) {
+	history := makeConfigHistory("2024-01-31", 2000)
+	history[0].ProteinTargetG = intPtr(120)
+	history[0].ProteinTargetMode = strPtr("grams")
+	perLb := 1.0
+	settings := settingsWithBudget(2000)
+	settings.ProteinTargetG, settings.ProteinTargetMode, settings.ProteinTargetValue = 190, "g_per_lb", &perLb
+	settings.FatTargetG, settings.FatTargetMode = 70, "grams"
+
+	jan := configForDate(history, settings, "2024-01-15")
+	jan.resolveMacros(190)
+	if jan.ProteinTargetG != 120 {
+		t.Errorf("want historical protein 120, got %d", jan.ProteinTargetG)
+	}
+	if jan.FatTargetG != 70 {
+		t.Errorf("want inherited fat 70, got %d", jan.FatTargetG)
+	}
+	feb := configForDate(history, settings, "2024-02-15")
+	feb.resolveMacros(190)
+	if feb.ProteinTargetG != 190 {
+		t.Errorf("want current g/lb protein 190, got %d", feb.ProteinTargetG)
+	}
+}
+
+// TestConfigForDate_HistoricalMealBudgets verifies that per-meal budgets and the
+// exercise target come from history, and nil columns inherit current settings.
+func TestConfigForDate_HistoricalMealBudgets(t *testing.T) {
+	history := makeConfigHistory("2024-01-31", 2000)
+	history[0].BreakfastBudget = intPtr(400)
+	history[0].ExerciseTargetCalories = intPtr(250)
+	settings := settingsWithBudget(2000)
+	settings.BreakfastBudget, settings.DinnerBudget, settings.ExerciseTargetCalories = 500, 700, 300
+
+	jan := configForDate(history, settings, "2024-01-15")
+	if jan.BreakfastBudget != 400 || jan.ExerciseTargetCalories != 250 {
+		t.Errorf("want historical breakfast 400 / exercise 250, got %d / %d", jan.BreakfastBudget, jan.ExerciseTargetCalories)
+	}
+	if jan.DinnerBudget != 700 {
+		t.Errorf("want inherited dinner 700, got %d", jan.DinnerBudget)
+	}
+	feb := configForDate(history, settings, "2024-02-15")
+	if feb.BreakfastBudget != 500 || feb.ExerciseTargetCalories != 300 {
+		t.Errorf("want current breakfast 500 / exercise 300, got %d / %d", feb.BreakfastBudget, feb.ExerciseTargetCalories)
+	}
+}
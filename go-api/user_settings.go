@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // getUserSettings returns the calorie log settings for the authenticated user.
@@ -26,12 +31,15 @@ func (h *Handler) getUserSettings(c *gin.Context) {
 	}
 
 	populateComputedTDEE(&s)
+	populateWaterTarget(&s)
 
 	c.JSON(http.StatusOK, s)
 }
 
 // shouldRecordConfigHistory returns true if the patch request contains a change
-// to calorie_budget or activity_level that differs from the current settings.
+// to any target snapshotted in calorie_config_history (calorie budget, activity
+// level, budget schedule, macro targets, per-meal budgets, or exercise target)
+// that differs from the current settings.
 // Extracted as a pure function so it can be unit-tested without a DB.
 func shouldRecordConfigHistory(body patchUserSettingsRequest, cur *calorieLogUserSettings) bool {
 	if body.CalorieBudget != nil && *body.CalorieBudget != cur.CalorieBudget {
@@ -42,9 +50,196 @@ func shouldRecordConfigHistory(body patchUserSettingsRequest, cur *calorieLogUse
 			return true
 		}
 	}
+	if body.BudgetSchedule != nil && !sameBudgetSchedule(body.BudgetSchedule, cur.BudgetSchedule) {
+		return true
+	}
+	if macroTargetChanged(body.ProteinTargetG, body.ProteinTargetMode, body.ProteinTargetValue,
+		cur.ProteinTargetG, cur.ProteinTargetMode, cur.ProteinTargetValue) ||
+		macroTargetChanged(body.CarbsTargetG, body.CarbsTargetMode, body.CarbsTargetValue,
+			cur.CarbsTargetG, cur.CarbsTargetMode, cur.CarbsTargetValue) ||
+		macroTargetChanged(body.FatTargetG, body.FatTargetMode, body.FatTargetValue,
+			cur.FatTargetG, cur.FatTargetMode, cur.FatTargetValue) {
+		return true
+	}
+	for _, f := range []struct {
+		patch *int
+		cur   int
+	}{
+		{body.BreakfastBudget, cur.BreakfastBudget},
+		{body.LunchBudget, cur.LunchBudget},
+		{body.DinnerBudget, cur.DinnerBudget},
+		{body.SnackBudget, cur.SnackBudget},
+		{body.ExerciseTargetCalories, cur.ExerciseTargetCalories},
+	} {
+		if f.patch != nil && *f.patch != f.cur {
+			return true
+		}
+	}
+	return false
+}
+
+// macroTargetChanged reports whether a patch changes one macro's grams, mode, or value.
+func macroTargetChanged(grams *int, mode *string, value *float64, curGrams int, curMode string, curValue *float64) bool {
+	if grams != nil && *grams != curGrams {
+		return true
+	}
+	if mode != nil && *mode != curMode {
+		return true
+	}
+	if value != nil && (curValue == nil || *value != *curValue) {
+		return true
+	}
 	return false
 }
 
+// touchesConfigHistory reports whether the patch includes any field snapshotted
+// in calorie_config_history, i.e. whether the current settings need to be fetched
+// for a history comparison.
+func (body patchUserSettingsRequest) touchesConfigHistory() bool {
+	return body.CalorieBudget != nil || body.ActivityLevel != nil || body.BudgetSchedule != nil ||
+		body.ProteinTargetG != nil || body.CarbsTargetG != nil || body.FatTargetG != nil ||
+		body.ProteinTargetMode != nil || body.CarbsTargetMode != nil || body.FatTargetMode != nil ||
+		body.ProteinTargetValue != nil || body.CarbsTargetValue != nil || body.FatTargetValue != nil ||
+		body.BreakfastBudget != nil || body.LunchBudget != nil || body.DinnerBudget != nil ||
+		body.SnackBudget != nil || body.ExerciseTargetCalories != nil
+}
+
+// historySnapshot converts settings into a calorie_config_history row that records
+// every tracked target as in effect up to and including validUntil.
+func historySnapshot(userID int, validUntil string, s *calorieLogUserSettings) (calorieConfigHistory, error) {
+	d, err := time.Parse("2006-01-02", validUntil)
+	if err != nil {
+		return calorieConfigHistory{}, err
+	}
+	return calorieConfigHistory{
+		UserID:                 userID,
+		ValidUntil:             DateOnly{d},
+		CalorieBudget:          s.CalorieBudget,
+		ActivityLevel:          s.ActivityLevel,
+		BudgetSchedule:         s.BudgetSchedule,
+		ProteinTargetG:         &s.ProteinTargetG,
+		CarbsTargetG:           &s.CarbsTargetG,
+		FatTargetG:             &s.FatTargetG,
+		ProteinTargetMode:      &s.ProteinTargetMode,
+		ProteinTargetValue:     s.ProteinTargetValue,
+		CarbsTargetMode:        &s.CarbsTargetMode,
+		CarbsTargetValue:       s.CarbsTargetValue,
+		FatTargetMode:          &s.FatTargetMode,
+		FatTargetValue:         s.FatTargetValue,
+		BreakfastBudget:        &s.BreakfastBudget,
+		LunchBudget:            &s.LunchBudget,
+		DinnerBudget:           &s.DinnerBudget,
+		SnackBudget:            &s.SnackBudget,
+		ExerciseTargetCalories: &s.ExerciseTargetCalories,
+	}, nil
+}
+
+// writeConfigHistory upserts a calorie_config_history row recording the targets
+// in snap as in effect up to and including validUntil. Upserting handles the user
+// changing settings twice in the same day.
+func (h *Handler) writeConfigHistory(c *gin.Context, userID int, validUntil string, snap *calorieLogUserSettings) error {
+	row, err := historySnapshot(userID, validUntil, snap)
+	if err != nil {
+		return err
+	}
+	return upsertConfigHistory(c, h.db, row)
+}
+
+// execer is the subset of *pgxpool.Pool and pgx.Tx needed to write history rows,
+// so the same upsert can run standalone or inside a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// upsertConfigHistory inserts row, or overwrites every tracked column of the
+// existing row for the same (user_id, valid_until).
+func upsertConfigHistory(ctx context.Context, q execer, row calorieConfigHistory) error {
+	_, err := q.Exec(ctx,
+		`INSERT INTO calorie_config_history (
+		   user_id, valid_until, calorie_budget, activity_level, budget_schedule,
+		   protein_target_g, carbs_target_g, fat_target_g,
+		   protein_target_mode, protein_target_value,
+		   carbs_target_mode, carbs_target_value,
+		   fat_target_mode, fat_target_value,
+		   breakfast_budget, lunch_budget, dinner_budget, snack_budget,
+		   exercise_target_calories)
+		 VALUES (
+		   @userID, @validUntil, @calorieBudget, @activityLevel, @budgetSchedule::jsonb,
+		   @proteinTargetG, @carbsTargetG, @fatTargetG,
+		   @proteinTargetMode, @proteinTargetValue,
+		   @carbsTargetMode, @carbsTargetValue,
+		   @fatTargetMode, @fatTargetValue,
+		   @breakfastBudget, @lunchBudget, @dinnerBudget, @snackBudget,
+		   @exerciseTargetCalories)
+		 ON CONFLICT (user_id, valid_until) DO UPDATE
+		   SET calorie_budget           = EXCLUDED.calorie_budget,
+		       activity_level           = EXCLUDED.activity_level,
+		       budget_schedule          = EXCLUDED.budget_schedule,
+		       protein_target_g         = EXCLUDED.protein_target_g,
+		       carbs_target_g           = EXCLUDED.carbs_target_g,
+		       fat_target_g             = EXCLUDED.fat_target_g,
+		       protein_target_mode      = EXCLUDED.protein_target_mode,
+		       protein_target_value     = EXCLUDED.protein_target_value,
+		       carbs_target_mode        = EXCLUDED.carbs_target_mode,
+		       carbs_target_value       = EXCLUDED.carbs_target_value,
+		       fat_target_mode          = EXCLUDED.fat_target_mode,
+		       fat_target_value         = EXCLUDED.fat_target_value,
+		       breakfast_budget         = EXCLUDED.breakfast_budget,
+		       lunch_budget             = EXCLUDED.lunch_budget,
+		       dinner_budget            = EXCLUDED.dinner_budget,
+		       snack_budget             = EXCLUDED.snack_budget,
+		       exercise_target_calories = EXCLUDED.exercise_target_calories`,
+		pgx.NamedArgs{
+			"userID":                 row.UserID,
+			"validUntil":             row.ValidUntil.Format("2006-01-02"),
+			"calorieBudget":          row.CalorieBudget,
+			"activityLevel":          row.ActivityLevel,
+			"budgetSchedule":         rawJSONArg(row.BudgetSchedule),
+			"proteinTargetG":         row.ProteinTargetG,
+			"carbsTargetG":           row.CarbsTargetG,
+			"fatTargetG":             row.FatTargetG,
+			"proteinTargetMode":      row.ProteinTargetMode,
+			"proteinTargetValue":     row.ProteinTargetValue,
+			"carbsTargetMode":        row.CarbsTargetMode,
+			"carbsTargetValue":       row.CarbsTargetValue,
+			"fatTargetMode":          row.FatTargetMode,
+			"fatTargetValue":         row.FatTargetValue,
+			"breakfastBudget":        row.BreakfastBudget,
+			"lunchBudget":            row.LunchBudget,
+			"dinnerBudget":           row.DinnerBudget,
+			"snackBudget":            row.SnackBudget,
+			"exerciseTargetCalories": row.ExerciseTargetCalories,
+		})
+	return err
+}
+
+// refreshMacroTargets re-resolves derived (percent / g_per_lb) macro targets from
+// the current budget and latest weight and persists them to *_target_g, so the
+// stored grams don't go stale when the budget or weight changes. No-op when all
+// macros use fixed grams.
+func (h *Handler) refreshMacroTargets(c *gin.Context, s *calorieLogUserSettings) {
+	if s.ProteinTargetMode == "grams" && s.CarbsTargetMode == "grams" && s.FatTargetMode == "grams" {
+		return
+	}
+	weight, _ := h.latestWeightLBS(c, s.UserID, s.WeightLBS)
+	t := dayTargets{CalorieBudget: s.CalorieBudget}
+	t.Protein, t.Carbs, t.Fat = settingsMacroSpecs(s)
+	t.resolveMacros(weight)
+	if t.ProteinTargetG == s.ProteinTargetG && t.CarbsTargetG == s.CarbsTargetG && t.FatTargetG == s.FatTargetG {
+		return
+	}
+	updated, err := queryOne[calorieLogUserSettings](h.db, c,
+		`UPDATE calorie_log_user_settings
+		 SET protein_target_g = @protein, carbs_target_g = @carbs, fat_target_g = @fat
+		 WHERE user_id = @userID RETURNING *`,
+		pgx.NamedArgs{"protein": t.ProteinTargetG, "carbs": t.CarbsTargetG, "fat": t.FatTargetG, "userID": s.UserID})
+	if err != nil {
+		log.Printf("[refreshMacroTargets] failed to persist resolved macro targets for user %d: %v", s.UserID, err)
+		return
+	}
+	*s = updated
+}
+
 // patchUserSettings updates only the provided calorie log settings fields.
 // PATCH /api/calorie-log/user-settings. Uses pointer fields in the request body
 // to distinguish "not provided" from zero — only non-nil fields get updated.
@@ -68,10 +263,130 @@ func (h *Handler) patchUserSettings(c *gin.Context) {
 		}
 	}
 
-	// If calorie_budget or activity_level is changing, snapshot the current values
-	// into calorie_config_history before overwriting them. This lets the progress
-	// endpoint resolve the correct budget/activity for any historical date.
-	if body.CalorieBudget != nil || body.ActivityLevel != nil {
+	// Validate the weekday schedule up front; an empty array clears it (stored as NULL).
+	var scheduleArg interface{}
+	if body.BudgetSchedule != nil {
+		days, err := parseBudgetSchedule(body.BudgetSchedule)
+		if err != nil {
+			apiError(c, http.StatusBadRequest, "budget_schedule must be an array of weekday entries")
+			return
+		}
+		if err := validateBudgetSchedule(days); err != nil {
+			apiError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(days) > 0 {
+			scheduleArg = string(*body.BudgetSchedule)
+		}
+	}
+
+	// Validate macro target modes. Derived modes need a value to derive from.
+	for _, m := range []struct {
+		name  string
+		mode  *string
+		value *float64
+	}{
+		{"protein", body.ProteinTargetMode, body.ProteinTargetValue},
+		{"carbs", body.CarbsTargetMode, body.CarbsTargetValue},
+		{"fat", body.FatTargetMode, body.FatTargetValue},
+	} {
+		if err := validateMacroTarget(m.name, m.mode, m.value); err != nil {
+			apiError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if m.mode != nil && *m.mode != "grams" && m.value == nil {
+			apiError(c, http.StatusBadRequest, m.name+"_target_value is required for "+*m.mode+" mode")
+			return
+		}
+	}
+	// A value sent without its mode is checked against the stored mode, and
+	// percent targets must fit in the budget together.
+	if body.ProteinTargetMode != nil || body.CarbsTargetMode != nil || body.FatTargetMode != nil ||
+		body.ProteinTargetValue != nil || body.CarbsTargetValue != nil || body.FatTargetValue != nil {
+		cur, err := queryOne[calorieLogUserSettings](h.db, c,
+			"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+			pgx.NamedArgs{"userID": userID})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			apiError(c, http.StatusInternalServerError, "failed to fetch settings")
+			return
+		}
+		protein, carbs, fat := settingsMacroSpecs(&cur)
+		if err := validateEffectiveMacroTargets(
+			patchMacroSpec(protein, body.ProteinTargetMode, body.ProteinTargetValue),
+			patchMacroSpec(carbs, body.CarbsTargetMode, body.CarbsTargetValue),
+			patchMacroSpec(fat, body.FatTargetMode, body.FatTargetValue),
+		); err != nil {
+			apiError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if body.FastingTargetHours != nil && (*body.FastingTargetHours < 0 || *body.FastingTargetHours > 72) {
+		apiError(c, http.StatusBadRequest, "fasting_target_hours must be between 0 and 72")
+		return
+	}
+
+	// water_target is in the user's display unit (or water_target_unit); it is
+	// stored as water_target_ml, so convert before the range check.
+	if body.WaterTarget != nil {
+		if body.WaterTargetML != nil {
+			apiError(c, http.StatusBadRequest, "send water_target or water_target_ml, not both")
+			return
+		}
+		units := ""
+		if body.Units != nil {
+			units = *body.Units
+		} else if body.WaterTargetUnit == nil {
+			cur, err := queryOne[calorieLogUserSettings](h.db, c,
+				"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+				pgx.NamedArgs{"userID": userID})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				apiError(c, http.StatusInternalServerError, "failed to fetch settings")
+				return
+			}
+			units = cur.Units
+		}
+		ml, err := waterTargetToML(*body.WaterTarget, body.WaterTargetUnit, units)
+		if err != nil {
+			apiError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		body.WaterTargetML = &ml
+	}
+
+	if body.WaterTargetML != nil && (*body.WaterTargetML < 0 || *body.WaterTargetML > 10000) {
+		apiError(c, http.StatusBadRequest, "water_target_ml must be between 0 and 10000")
+		return
+	}
+
+	if body.CompleteDayRule != nil && *body.CompleteDayRule != "meals" && *body.CompleteDayRule != "mark" {
+		apiError(c, http.StatusBadRequest, "complete_day_rule must be meals or mark")
+		return
+	}
+	if body.CompleteDayMeals != nil {
+		for _, meal := range *body.CompleteDayMeals {
+			if !slices.Contains(mealTypes, meal) {
+				apiError(c, http.StatusBadRequest, "complete_day_meals contains unknown meal: "+meal)
+				return
+			}
+		}
+	}
+
+	if body.OffsetActivities != nil {
+		for _, key := range *body.OffsetActivities {
+			if _, ok := catalogActivity(key); !ok {
+				apiError(c, http.StatusBadRequest, "offset_activities contains unknown activity: "+key)
+				return
+			}
+		}
+	}
+
+	// If any snapshotted target (budget, activity level, schedule, macros, meal budgets,
+	// exercise target) is changing, snapshot the current values into
+	// calorie_config_history before overwriting them.
+	// This lets the progress endpoint resolve the correct targets for any historical date.
+	historyWritten := false
+	if body.touchesConfigHistory() {
 		cur, err := queryOne[calorieLogUserSettings](h.db, c,
 			"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
 			pgx.NamedArgs{"userID": userID})
@@ -83,22 +398,11 @@ func (h *Handler) patchUserSettings(c *gin.Context) {
 			yesterday := time.Now().UTC().Truncate(24 * time.Hour).AddDate(0, 0, -1)
 			log.Printf("[patchUserSettings] writing config history for user %d: valid_until=%s budget=%d activity=%v",
 				userID, yesterday.Format("2006-01-02"), cur.CalorieBudget, cur.ActivityLevel)
-			_, histErr := h.db.Exec(c,
-				`INSERT INTO calorie_config_history (user_id, valid_until, calorie_budget, activity_level)
-				 VALUES (@userID, @validUntil, @calorieBudget, @activityLevel)
-				 ON CONFLICT (user_id, valid_until) DO UPDATE
-				   SET calorie_budget  = EXCLUDED.calorie_budget,
-				       activity_level = EXCLUDED.activity_level`,
-				pgx.NamedArgs{
-					"userID":        userID,
-					"validUntil":    yesterday.Format("2006-01-02"),
-					"calorieBudget": cur.CalorieBudget,
-					"activityLevel": cur.ActivityLevel,
-				})
-			if histErr != nil {
+			if histErr := h.writeConfigHistory(c, userID, yesterday.Format("2006-01-02"), &cur); histErr != nil {
 				// Non-fatal: log and continue — history is best-effort
 				log.Printf("[patchUserSettings] failed to write config history for user %d: %v", userID, histErr)
 			} else {
+				historyWritten = true
 				log.Printf("[patchUserSettings] config history written for user %d", userID)
 			}
 		}
@@ -184,6 +488,72 @@ func (h *Handler) patchUserSettings(c *gin.Context) {
 		setClauses = append(setClauses, "setup_complete = @setupComplete")
 		args["setupComplete"] = *body.SetupComplete
 	}
+	if body.BudgetSchedule != nil {
+		setClauses = append(setClauses, "budget_schedule = @budgetSchedule::jsonb")
+		args["budgetSchedule"] = scheduleArg
+	}
+	if body.ProteinTargetMode != nil {
+		setClauses = append(setClauses, "protein_target_mode = @proteinTargetMode")
+		args["proteinTargetMode"] = *body.ProteinTargetMode
+	}
+	if body.ProteinTargetValue != nil {
+		setClauses = append(setClauses, "protein_target_value = @proteinTargetValue")
+		args["proteinTargetValue"] = *body.ProteinTargetValue
+	}
+	if body.CarbsTargetMode != nil {
+		setClauses = append(setClauses, "carbs_target_mode = @carbsTargetMode")
+		args["carbsTargetMode"] = *body.CarbsTargetMode
+	}
+	if body.CarbsTargetValue != nil {
+		setClauses = append(setClauses, "carbs_target_value = @carbsTargetValue")
+		args["carbsTargetValue"] = *body.CarbsTargetValue
+	}
+	if body.FatTargetMode != nil {
+		setClauses = append(setClauses, "fat_target_mode = @fatTargetMode")
+		args["fatTargetMode"] = *body.FatTargetMode
+	}
+	if body.FatTargetValue != nil {
+		setClauses = append(setClauses, "fat_target_value = @fatTargetValue")
+		args["fatTargetValue"] = *body.FatTargetValue
+	}
+
+	if body.OffsetActivities != nil {
+		setClauses = append(setClauses, "offset_activities = @offsetActivities")
+		if len(*body.OffsetActivities) == 0 {
+			args["offsetActivities"] = nil
+		} else {
+			args["offsetActivities"] = *body.OffsetActivities
+		}
+	}
+	if body.FastingTargetHours != nil {
+		setClauses = append(setClauses, "fasting_target_hours = @fastingTargetHours")
+		if *body.FastingTargetHours == 0 {
+			args["fastingTargetHours"] = nil
+		} else {
+			args["fastingTargetHours"] = *body.FastingTargetHours
+		}
+	}
+	if body.WaterTargetML != nil {
+		setClauses = append(setClauses, "water_target_ml = @waterTargetML")
+		if *body.WaterTargetML == 0 {
+			args["waterTargetML"] = nil
+		} else {
+			args["waterTargetML"] = *body.WaterTargetML
+		}
+	}
+
+	if body.CompleteDayRule != nil {
+		setClauses = append(setClauses, "complete_day_rule = @completeDayRule")
+		args["completeDayRule"] = *body.CompleteDayRule
+	}
+	if body.CompleteDayMeals != nil {
+		setClauses = append(setClauses, "complete_day_meals = @completeDayMeals")
+		if len(*body.CompleteDayMeals) == 0 {
+			args["completeDayMeals"] = nil
+		} else {
+			args["completeDayMeals"] = *body.CompleteDayMeals
+		}
+	}
 
 	if len(setClauses) == 0 {
 		apiError(c, http.StatusBadRequest, "no fields to update")
@@ -213,24 +583,16 @@ func (h *Handler) patchUserSettings(c *gin.Context) {
 				log.Printf("[patchUserSettings] auto-budget update failed for user %d: %v", userID, err)
 			} else {
 				s = updated
-				// Record history when the auto-computed budget actually changed.
-				if s.CalorieBudget != oldBudget {
+				// Record history when the auto-computed budget actually changed. Skip it
+				// when a snapshot was already written above — that one holds the
+				// pre-patch targets, which are what was in effect until yesterday.
+				if s.CalorieBudget != oldBudget && !historyWritten {
 					yesterday := time.Now().UTC().Truncate(24 * time.Hour).AddDate(0, 0, -1)
 					log.Printf("[patchUserSettings] auto-budget changed for user %d (%d → %d), writing history valid_until=%s",
 						userID, oldBudget, s.CalorieBudget, yesterday.Format("2006-01-02"))
-					_, histErr := h.db.Exec(c,
-						`INSERT INTO calorie_config_history (user_id, valid_until, calorie_budget, activity_level)
-						 VALUES (@userID, @validUntil, @calorieBudget, @activityLevel)
-						 ON CONFLICT (user_id, valid_until) DO UPDATE
-						   SET calorie_budget  = EXCLUDED.calorie_budget,
-						       activity_level = EXCLUDED.activity_level`,
-						pgx.NamedArgs{
-							"userID":        userID,
-							"validUntil":    yesterday.Format("2006-01-02"),
-							"calorieBudget": oldBudget,
-							"activityLevel": s.ActivityLevel,
-						})
-					if histErr != nil {
+					snap := s
+					snap.CalorieBudget = oldBudget
+					if histErr := h.writeConfigHistory(c, userID, yesterday.Format("2006-01-02"), &snap); histErr != nil {
 						log.Printf("[patchUserSettings] failed to write auto-budget history for user %d: %v", userID, histErr)
 					}
 				}
@@ -238,7 +600,21 @@ func (h *Handler) patchUserSettings(c *gin.Context) {
 		}
 	}
 
+	// Derived macro targets depend on the (possibly auto-updated) budget.
+	h.refreshMacroTargets(c, &s)
+
 	populateComputedTDEE(&s)
+	populateWaterTarget(&s)
 
 	c.JSON(http.StatusOK, s)
 }
+
+// rawJSONArg converts a nullable JSONB value into a query argument. Passing the
+// JSON as a string (cast with ::jsonb in SQL) keeps it text under the simple
+// query protocol; nil stays SQL NULL.
+func rawJSONArg(raw *json.RawMessage) interface{} {
+	if raw == nil {
+		return nil
+	}
+	return string(*raw)
+}
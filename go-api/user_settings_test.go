@@ -61,9 +61,9 @@ func TestShouldRecordConfigHistory_ActivityLevelFromNil(t *testing.T) {
 }
 
 // TestShouldRecordConfigHistory_UnrelatedField verifies that patching an
-// unrelated field (e.g. protein_target_g) does not trigger a history record.
+// unrelated field (e.g. units) does not trigger a history record.
 func TestShouldRecordConfigHistory_UnrelatedField(t *testing.T) {
-	body := patchUserSettingsRequest{ProteinTargetG: intPtr(150)}
+	body := patchUserSettingsRequest{Units: strPtr("metric")}
 	cur := calorieLogUserSettings{CalorieBudget: 2300}
 	if shouldRecordConfigHistory(body, &cur) {
 		t.Error("expected false when only unrelated field patched, got true")
@@ -83,3 +83,135 @@ func TestShouldRecordConfigHistory_BothChanging(t *testing.T) {
 		t.Error("expected true when both budget and activity_level change, got false")
 	}
 }
+
+// TestShouldRecordConfigHistory_ScheduleChanged verifies that replacing the weekday
+// budget schedule triggers a history record, while re-sending the same schedule
+// in a different order does not.
+func TestShouldRecordConfigHistory_ScheduleChanged(t *testing.T) {
+	cur := calorieLogUserSettings{
+		CalorieBudget: 2000,
+		BudgetSchedule: scheduleJSON(
+			budgetScheduleDay{Weekday: 0, CalorieBudget: intPtr(2400)},
+			budgetScheduleDay{Weekday: 5, CalorieBudget: intPtr(1800)},
+		),
+	}
+	changed := patchUserSettingsRequest{BudgetSchedule: scheduleJSON(budgetScheduleDay{Weekday: 0, CalorieBudget: intPtr(2500)})}
+	if !shouldRecordConfigHistory(changed, &cur) {
+		t.Error("expected true when budget_schedule changes, got false")
+	}
+	reordered := patchUserSettingsRequest{BudgetSchedule: scheduleJSON(
+		budgetScheduleDay{Weekday: 5, CalorieBudget: intPtr(1800)},
+		budgetScheduleDay{Weekday: 0, CalorieBudget: intPtr(2400)},
+	)}
+	if shouldRecordConfigHistory(reordered, &cur) {
+		t.Error("expected false when budget_schedule is unchanged, got true")
+	}
+}
+
+// TestValidateBudgetSchedule verifies weekday range, duplicate, and value checks.
+func TestValidateBudgetSchedule(t *testing.T) {
+	cases := []struct {
+		name    string
+		days    []budgetScheduleDay
+		wantErr bool
+	}{
+		{"valid", []budgetScheduleDay{{Weekday: 0, CalorieBudget: intPtr(2400)}, {Weekday: 6}}, false},
+		{"weekday out of range", []budgetScheduleDay{{Weekday: 7}}, true},
+		{"duplicate weekday", []budgetScheduleDay{{Weekday: 2}, {Weekday: 2}}, true},
+		{"zero budget", []budgetScheduleDay{{Weekday: 1, CalorieBudget: intPtr(0)}}, true},
+		{"negative macro", []budgetScheduleDay{{Weekday: 1, FatTargetG: intPtr(-5)}}, true},
+	}
+	for _, tc := range cases {
+		if err := validateBudgetSchedule(tc.days); (err != nil) != tc.wantErr {
+			t.Errorf("%s: wantErr=%v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
+
+// TestShouldRecordConfigHistory_MacroTargets verifies that macro target grams,
+// mode, and value changes trigger a history record, and unchanged values don't.
+func TestShouldRecordConfigHistory_MacroTargets(t *testing.T) {
+	pct := 30.0
+	cur := calorieLogUserSettings{
+		ProteinTargetG:    150,
+		ProteinTargetMode: "grams",
+		CarbsTargetMode:   "grams",
+		FatTargetMode:     "percent",
+		FatTargetValue:    &pct,
+	}
+	newPct := 25.0
+	samePct := 30.0
+	cases := []struct {
+		name string
+		body patchUserSettingsRequest
+		want bool
+	}{
+		{"protein grams changed", patchUserSettingsRequest{ProteinTargetG: intPtr(160)}, true},
+		{"protein grams same", patchUserSettingsRequest{ProteinTargetG: intPtr(150)}, false},
+		{"carbs mode changed", patchUserSettingsRequest{CarbsTargetMode: strPtr("percent"), CarbsTargetValue: &newPct}, true},
+		{"fat value changed", patchUserSettingsRequest{FatTargetValue: &newPct}, true},
+		{"fat value same", patchUserSettingsRequest{FatTargetMode: strPtr("percent"), FatTargetValue: &samePct}, false},
+	}
+	for _, tc := range cases {
+		if got := shouldRecordConfigHistory(tc.body, &cur); got != tc.want {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestValidateEffectiveMacroTargets verifies that patched values are checked
+// against the stored mode and that percent targets can't exceed 100% together.
+func TestValidateEffectiveMacroTargets(t *testing.T) {
+	f64 := func(v float64) *float64 { return &v }
+	cur := calorieLogUserSettings{
+		ProteinTargetMode:  "percent",
+		ProteinTargetValue: f64(30),
+		CarbsTargetMode:    "percent",
+		CarbsTargetValue:   f64(40),
+		FatTargetMode:      "grams",
+	}
+	cases := []struct {
+		name    string
+		body    patchUserSettingsRequest
+		wantErr bool
+	}{
+		{"value only, stored percent mode", patchUserSettingsRequest{ProteinTargetValue: f64(150)}, true},
+		{"value only, within range", patchUserSettingsRequest{ProteinTargetValue: f64(35)}, false},
+		{"fat percent fits", patchUserSettingsRequest{FatTargetMode: strPtr("percent"), FatTargetValue: f64(30)}, false},
+		{"fat percent exceeds total", patchUserSettingsRequest{FatTargetMode: strPtr("percent"), FatTargetValue: f64(35)}, true},
+		{"switch protein to g_per_lb", patchUserSettingsRequest{ProteinTargetMode: strPtr("g_per_lb"), ProteinTargetValue: f64(1)}, false},
+		{"g_per_lb with stored percent value", patchUserSettingsRequest{CarbsTargetMode: strPtr("g_per_lb")}, true},
+	}
+	for _, tc := range cases {
+		protein, carbs, fat := settingsMacroSpecs(&cur)
+		err := validateEffectiveMacroTargets(
+			patchMacroSpec(protein, tc.body.ProteinTargetMode, tc.body.ProteinTargetValue),
+			patchMacroSpec(carbs, tc.body.CarbsTargetMode, tc.body.CarbsTargetValue),
+			patchMacroSpec(fat, tc.body.FatTargetMode, tc.body.FatTargetValue),
+		)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: wantErr=%v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
+
+// TestShouldRecordConfigHistory_MealBudgets verifies that per-meal budget and
+// exercise target changes are recorded in history like the daily budget.
+func TestShouldRecordConfigHistory_MealBudgets(t *testing.T) {
+	cur := calorieLogUserSettings{BreakfastBudget: 500, SnackBudget: 200, ExerciseTargetCalories: 300}
+	cases := []struct {
+		name string
+		body patchUserSettingsRequest
+		want bool
+	}{
+		{"breakfast changed", patchUserSettingsRequest{BreakfastBudget: intPtr(450)}, true},
+		{"breakfast same", patchUserSettingsRequest{BreakfastBudget: intPtr(500)}, false},
+		{"snack same", patchUserSettingsRequest{SnackBudget: intPtr(200)}, false},
+		{"exercise target changed", patchUserSettingsRequest{ExerciseTargetCalories: intPtr(400)}, true},
+	}
+	for _, tc := range cases {
+		if got := shouldRecordConfigHistory(tc.body, &cur); got != tc.want {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
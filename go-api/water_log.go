@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// mlPerFlOz converts US fluid ounces to ml.
+const mlPerFlOz = 29.5735
+
+// waterQuickAdds are the one-tap amounts offered per display unit.
+var waterQuickAdds = map[string][]float64{
+	"oz": {8, 12, 16, 24},
+	"ml": {250, 330, 500, 750},
+}
+
+// waterUnit returns the display unit for a units setting.
+func waterUnit(units string) string {
+	if units == "metric" {
+		return "ml"
+	}
+	return "oz"
+}
+
+// toML converts an amount in unit (ml | oz) to whole ml.
+func toML(amount float64, unit string) int {
+	if unit == "oz" {
+		return int(math.Round(amount * mlPerFlOz))
+	}
+	return int(math.Round(amount))
+}
+
+// fromML converts ml to unit (ml | oz), oz rounded to one decimal.
+func fromML(ml int, unit string) float64 {
+	if unit == "oz" {
+		return math.Round(float64(ml)/mlPerFlOz*10) / 10
+	}
+	return float64(ml)
+}
+
+// waterTargetToML converts a settings water_target to ml. unit is the
+// request's water_target_unit; nil uses the display unit for units.
+func waterTargetToML(target float64, unit *string, units string) (int, error) {
+	u := waterUnit(units)
+	if unit != nil {
+		u = *unit
+	}
+	if u != "ml" && u != "oz" {
+		return 0, errors.New("water_target_unit must be ml or oz")
+	}
+	return toML(target, u), nil
+}
+
+// populateWaterTarget fills the display-unit water target fields on s.
+func populateWaterTarget(s *calorieLogUserSettings) {
+	s.WaterUnit = waterUnit(s.Units)
+	if s.WaterTargetML != nil {
+		target := fromML(*s.WaterTargetML, s.WaterUnit)
+		s.WaterTarget = &target
+	}
+}
+
+// waterQuickAdd is a one-tap amount in the user's unit, with its ml value.
+type waterQuickAdd struct {
+	Amount   float64 `json:"amount"`
+	Unit     string  `json:"unit"`
+	AmountML int     `json:"amount_ml"`
+}
+
+// waterTotals is one day's hydration against the target. Totals are in ml;
+// Total and Target repeat them in the user's display Unit.
+type waterTotals struct {
+	LoggedML    int      `json:"logged_ml"`     // water_log entries
+	FromItemsML int      `json:"from_items_ml"` // beverage items with hydration_ml
+	TotalML     int      `json:"total_ml"`
+	TargetML    *int     `json:"target_ml"`
+	RemainingML *int     `json:"remaining_ml"` // never negative; nil without a target
+	MetTarget   bool     `json:"met_target"`
+	Unit        string   `json:"unit"`
+	Total       float64  `json:"total"`
+	Target      *float64 `json:"target"`
+}
+
+// waterDayRow is a per-day hydration total from waterTotalsByDate.
+type waterDayRow struct {
+	Date        DateOnly `db:"date"`
+	LoggedML    int      `db:"logged_ml"`
+	FromItemsML int      `db:"from_items_ml"`
+}
+
+// newWaterTotals builds a day's totals against the target in the given units.
+func newWaterTotals(loggedML, fromItemsML int, targetML *int, units string) waterTotals {
+	unit := waterUnit(units)
+	t := waterTotals{
+		LoggedML:    loggedML,
+		FromItemsML: fromItemsML,
+		TotalML:     loggedML + fromItemsML,
+		TargetML:    targetML,
+		Unit:        unit,
+	}
+	t.Total = fromML(t.TotalML, unit)
+	if targetML != nil {
+		remaining := max(*targetML-t.TotalML, 0)
+		target := fromML(*targetML, unit)
+		t.RemainingML, t.Target = &remaining, &target
+		t.MetTarget = remaining == 0
+	}
+	return t
+}
+
+// waterTotalsByDate sums water_log entries and beverage items per day in
+// [start, end]. Days with neither are absent from the map.
+func (h *Handler) waterTotalsByDate(c *gin.Context, userID int, start, end string) (map[string]waterDayRow, error) {
+	rows, err := queryMany[waterDayRow](h.db, c,
+		`SELECT date, SUM(logged_ml) AS logged_ml, SUM(from_items_ml) AS from_items_ml
+		 FROM (
+			SELECT date, amount_ml AS logged_ml, 0 AS from_items_ml
+			FROM water_log
+			WHERE user_id = @userID AND date >= @start AND date <= @end
+			UNION ALL
+			SELECT date, 0, hydration_ml
+			FROM calorie_log_items
+			WHERE user_id = @userID AND date >= @start AND date <= @end AND hydration_ml IS NOT NULL
+		 ) w
+		 GROUP BY date`,
+		pgx.NamedArgs{"userID": userID, "start": start, "end": end})
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]waterDayRow, len(rows))
+	for _, r := range rows {
+		byDate[r.Date.Format("2006-01-02")] = r
+	}
+	return byDate, nil
+}
+
+// waterDayResponse is the response for GET /api/water-log.
+type waterDayResponse struct {
+	Date      string          `json:"date"`
+	Entries   []waterEntry    `json:"entries"`
+	Totals    waterTotals     `json:"totals"`
+	QuickAdds []waterQuickAdd `json:"quick_adds"`
+}
+
+// getWaterLog returns a day's water entries, totals, and quick-add amounts.
+// GET /api/water-log?date=YYYY-MM-DD (defaults to today).
+func (h *Handler) getWaterLog(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	settings, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch settings")
+		return
+	}
+
+	entries, err := queryMany[waterEntry](h.db, c,
+		`SELECT * FROM water_log WHERE user_id = @userID AND date = @date
+		 ORDER BY consumed_at NULLS LAST, created_at`,
+		pgx.NamedArgs{"userID": userID, "date": date})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch water log")
+		return
+	}
+	if entries == nil {
+		entries = []waterEntry{}
+	}
+
+	totals, err := h.waterTotalsByDate(c, userID, date, date)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to fetch water totals")
+		return
+	}
+	day := totals[date]
+
+	unit := waterUnit(settings.Units)
+	quickAdds := make([]waterQuickAdd, 0, 4)
+	for _, amount := range waterQuickAdds[unit] {
+		quickAdds = append(quickAdds, waterQuickAdd{Amount: amount, Unit: unit, AmountML: toML(amount, unit)})
+	}
+
+	c.JSON(http.StatusOK, waterDayResponse{
+		Date:      date,
+		Entries:   entries,
+		Totals:    newWaterTotals(day.LoggedML, day.FromItemsML, settings.WaterTargetML, settings.Units),
+		QuickAdds: quickAdds,
+	})
+}
+
+// createWaterEntry logs an amount of water.
+// POST /api/water-log. Body: { "date"?, "amount": 16, "unit"?: "oz"|"ml", "consumed_at"?: "HH:MM" }.
+// unit defaults to the user's units setting (oz for "us", ml for "metric").
+func (h *Handler) createWaterEntry(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var body struct {
+		Date       string  `json:"date"`
+		Amount     float64 `json:"amount"`
+		Unit       string  `json:"unit"`
+		ConsumedAt *string `json:"consumed_at"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Date == "" {
+		body.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", body.Date); err != nil {
+		apiError(c, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+	if body.ConsumedAt != nil {
+		if _, err := parseClockTime(*body.ConsumedAt); err != nil {
+			apiError(c, http.StatusBadRequest, "invalid consumed_at, expected HH:MM")
+			return
+		}
+	}
+	if body.Unit == "" {
+		settings, err := queryOne[calorieLogUserSettings](h.db, c,
+			"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+			pgx.NamedArgs{"userID": userID})
+		if err != nil {
+			apiError(c, http.StatusInternalServerError, "failed to fetch settings")
+			return
+		}
+		body.Unit = waterUnit(settings.Units)
+	}
+	if body.Unit != "ml" && body.Unit != "oz" {
+		apiError(c, http.StatusBadRequest, "unit must be ml or oz")
+		return
+	}
+	amountML := toML(body.Amount, body.Unit)
+	if amountML <= 0 || amountML > 10000 {
+		apiError(c, http.StatusBadRequest, "amount must be between 1 ml and 10 l")
+		return
+	}
+
+	entry, err := queryOne[waterEntry](h.db, c,
+		`INSERT INTO water_log (user_id, date, amount_ml, consumed_at)
+		 VALUES (@userID, @date, @amountML, @consumedAt::time)
+		 RETURNING *`,
+		pgx.NamedArgs{"userID": userID, "date": body.Date, "amountML": amountML, "consumedAt": body.ConsumedAt})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to create water entry")
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// deleteWaterEntry removes a water entry. Returns 204 on success.
+// DELETE /api/water-log/:id.
+func (h *Handler) deleteWaterEntry(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	tag, err := h.db.Exec(c,
+		"DELETE FROM water_log WHERE id = @id AND user_id = @userID",
+		pgx.NamedArgs{"id": id, "userID": userID})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, "failed to delete water entry")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		apiError(c, http.StatusNotFound, "water entry not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
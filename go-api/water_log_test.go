@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWaterUnitConversion(t *testing.T) {
+	if got := toML(16, "oz"); got != 473 {
+		t.Errorf("16 oz: got %d ml, want 473", got)
+	}
+	if got := toML(250, "ml"); got != 250 {
+		t.Errorf("250 ml: got %d", got)
+	}
+	if got := fromML(473, "oz"); got != 16 {
+		t.Errorf("473 ml: got %v oz, want 16", got)
+	}
+	if waterUnit("us") != "oz" || waterUnit("metric") != "ml" || waterUnit("") != "oz" {
+		t.Error("unexpected display units")
+	}
+}
+
+func TestNewWaterTotals(t *testing.T) {
+	target := 2000
+	got := newWaterTotals(1500, 250, &target, "metric")
+	if got.TotalML != 1750 || *got.RemainingML != 250 || got.MetTarget || got.Unit != "ml" || got.Total != 1750 {
+		t.Errorf("under target: %+v", got)
+	}
+
+	got = newWaterTotals(2100, 0, &target, "us")
+	if *got.RemainingML != 0 || !got.MetTarget || got.Unit != "oz" || got.Total != 71 || *got.Target != 67.6 {
+		t.Errorf("over target: %+v (target %v)", got, *got.Target)
+	}
+
+	got = newWaterTotals(500, 0, nil, "us")
+	if got.TargetML != nil || got.RemainingML != nil || got.MetTarget {
+		t.Errorf("no target: %+v", got)
+	}
+}
+
+func TestWaterTargetToML(t *testing.T) {
+	if got, err := waterTargetToML(64, nil, "us"); err != nil || got != 1893 {
+		t.Errorf("64 oz for us: got %d, %v", got, err)
+	}
+	if got, err := waterTargetToML(2000, nil, "metric"); err != nil || got != 2000 {
+		t.Errorf("2000 ml for metric: got %d, %v", got, err)
+	}
+	if got, err := waterTargetToML(2000, strPtr("ml"), "us"); err != nil || got != 2000 {
+		t.Errorf("explicit ml for us: got %d, %v", got, err)
+	}
+	if _, err := waterTargetToML(8, strPtr("cups"), "us"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
+
+func TestPopulateWaterTarget(t *testing.T) {
+	s := calorieLogUserSettings{Units: "us", WaterTargetML: intPtr(1893)}
+	populateWaterTarget(&s)
+	if s.WaterUnit != "oz" || s.WaterTarget == nil || *s.WaterTarget != 64 {
+		t.Errorf("us: got %v %v", s.WaterTarget, s.WaterUnit)
+	}
+
+	s = calorieLogUserSettings{Units: "metric"}
+	populateWaterTarget(&s)
+	if s.WaterUnit != "ml" || s.WaterTarget != nil {
+		t.Errorf("no target: got %v %v", s.WaterTarget, s.WaterUnit)
+	}
+}
+
+func TestCreateWaterEntry_Validation(t *testing.T) {
+	h := Handler{}
+	cases := []struct{ name, body string }{
+		{"bad unit", `{"amount":8,"unit":"cups"}`},
+		{"zero amount", `{"amount":0,"unit":"ml"}`},
+		{"too much", `{"amount":400,"unit":"oz"}`},
+		{"bad date", `{"date":"today","amount":8,"unit":"oz"}`},
+		{"bad time", `{"amount":8,"unit":"oz","consumed_at":"noon"}`},
+	}
+	for _, tc := range cases {
+		if w := doBulkRequest(h.createWaterEntry, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", tc.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPatchUserSettings_WaterTargetValidation(t *testing.T) {
+	h := Handler{}
+	cases := []struct{ name, body string }{
+		{"both fields", `{"water_target":64,"water_target_ml":2000}`},
+		{"bad unit", `{"water_target":8,"water_target_unit":"cups"}`},
+		{"too much", `{"water_target":400,"water_target_unit":"oz"}`},
+		{"negative", `{"water_target":-1,"units":"metric"}`},
+	}
+	for _, tc := range cases {
+		if w := doBulkRequest(h.patchUserSettings, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", tc.name, w.Code, w.Body.String())
+		}
+	}
+}
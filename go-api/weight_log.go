@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -81,7 +82,7 @@ func (h *Handler) upsertWeightEntry(c *gin.Context) {
 	entry, err := queryOne[weightEntry](h.db, c,
 		`INSERT INTO weight_log (user_id, date, weight_lbs)
 		 VALUES (@userID, @date, @weightLBS)
-		 ON CONFLICT (user_id, date) DO UPDATE SET weight_lbs = EXCLUDED.weight_lbs
+		 ON CONFLICT (user_id, date) DO UPDATE SET weight_lbs = EXCLUDED.weight_lbs, source = 'manual'
 		 RETURNING *`,
 		pgx.NamedArgs{"userID": userID, "date": body.Date, "weightLBS": body.WeightLBS})
 	if err != nil {
@@ -89,6 +90,13 @@ func (h *Handler) upsertWeightEntry(c *gin.Context) {
 		return
 	}
 
+	// g_per_lb macro targets follow the latest weight — re-resolve them (best-effort).
+	if settings, err := queryOne[calorieLogUserSettings](h.db, c,
+		"SELECT * FROM calorie_log_user_settings WHERE user_id = @userID",
+		pgx.NamedArgs{"userID": userID}); err == nil {
+		h.refreshMacroTargets(c, &settings)
+	}
+
 	c.JSON(http.StatusCreated, entry)
 }
 
@@ -121,7 +129,8 @@ func (h *Handler) updateWeightEntry(c *gin.Context) {
 	entry, err := queryOne[weightEntry](h.db, c,
 		`UPDATE weight_log SET
 			date       = COALESCE(@date, date),
-			weight_lbs = COALESCE(@weightLBS, weight_lbs)
+			weight_lbs = COALESCE(@weightLBS, weight_lbs),
+			source     = 'manual'
 		 WHERE id = @id AND user_id = @userID
 		 RETURNING *`,
 		pgx.NamedArgs{"id": id, "userID": userID, "date": body.Date, "weightLBS": body.WeightLBS})
@@ -160,3 +169,22 @@ func (h *Handler) deleteWeightEntry(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// latestWeightLBS returns the user's most recent weight_log entry, falling back to
+// the settings weight when nothing has been logged. ok=false when neither exists.
+func (h *Handler) latestWeightLBS(c *gin.Context, userID int, fallback *float64) (weight float64, ok bool) {
+	var w float64
+	err := h.db.QueryRow(c,
+		`SELECT weight_lbs FROM weight_log WHERE user_id = @userID ORDER BY date DESC LIMIT 1`,
+		pgx.NamedArgs{"userID": userID}).Scan(&w)
+	if err == nil {
+		return w, true
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("[latestWeightLBS] query error for user %d: %v", userID, err)
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return 0, false
+}
@@ -1,4 +1,4 @@
-export type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, Recipe, RecipeListItem, RecipeIngredient, RecipeTool, RecipeStep, RecipeDetail, RecipeIngredientInput, RecipeToolInput, RecipeStepInput, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalTag, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalMentalStateBar, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput } from './types'
+export type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, Recipe, RecipeListItem, RecipeListResponse, RecipeIngredient, RecipeTool, RecipeStep, StepIngredient, StepTool, RecipeDetail, RecipeIngredientInput, RecipeToolInput, RecipeStepInput, StepIngredientInput, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalTag, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalMentalStateBar, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput } from './types'
 export { EMOTION_TAGS, CONDITION_TAGS, ENTRY_TYPE_TAGS } from './types'
 export { todayString, getMondayOf, shiftWeek, formatWeekRange, dayLabel, dayNumber } from './utils/dates'
 export { ITEM_TYPES, FOOD_UNITS, EXERCISE_UNITS, UNIT_LABELS, MEAL_PLAN_MEAL_TYPES } from './constants'
@@ -18,6 +18,10 @@ export interface CalorieLogItem {
   recipe_id: number | null
   // Set when this item was logged from a meal plan entry; null for manually-added items.
   meal_plan_entry_id: number | null
+  // Where the numbers came from (manual, ai, food_db, …) and how sure they are (1–5).
+  // Optional on create: the server infers the source and a default confidence.
+  source?: string
+  confidence?: number | null
   created_at: string
   updated_at: string
 }
@@ -175,9 +179,23 @@ export interface Recipe {
 export interface RecipeListItem extends Recipe {
   step_count: number
   total_timer_seconds: number
+  tags: string[]
+  // Cooking history; avg_rating is over rated cooks only.
+  last_cooked_on: string | null
+  times_cooked: number
+  avg_rating: number | null
+}
+
+// RecipeListResponse is the paginated response from GET /api/recipes.
+// has_more signals the client that another page is available.
+export interface RecipeListResponse {
+  recipes: RecipeListItem[]
+  has_more: boolean
 }
 
-// RecipeIngredient mirrors the recipe_ingredients DB row.
+// RecipeIngredient mirrors the recipe_ingredients DB row. food_id links a saved
+// food for computed nutrition; grams is the weight of qty/uom when it can't be
+// converted automatically. sub_recipe_id links another recipe instead of a food.
 export interface RecipeIngredient {
   id: number
   recipe_id: number
@@ -186,6 +204,9 @@ export interface RecipeIngredient {
   uom: string | null
   note: string | null
   sort_order: number
+  food_id: number | null
+  grams: number | null
+  sub_recipe_id: number | null
 }
 
 // RecipeTool mirrors the recipe_tools DB row.
@@ -206,6 +227,26 @@ export interface RecipeStep {
   timer_seconds: number | null
   meanwhile_text: string | null
   sort_order: number
+  // Ingredients and tools the step uses. Missing on steps from revisions
+  // recorded before steps had references.
+  ingredients?: StepIngredient[]
+  tools?: StepTool[]
+}
+
+// StepIngredient is an ingredient a step uses, with the amount used in that
+// step. qty is null when the step uses all of it.
+export interface StepIngredient {
+  ingredient_id: number
+  name: string
+  qty: number | null
+  uom: string | null
+  qty_text?: string
+}
+
+// StepTool is a tool a step uses.
+export interface StepTool {
+  tool_id: number
+  name: string
 }
 
 // RecipeDetail is the full recipe response from GET /api/recipes/:id — the base
@@ -224,6 +265,11 @@ export interface RecipeIngredientInput {
   uom: string | null
   note: string | null
   sort_order: number
+  // Sent back unchanged so a save keeps the ingredient's food or recipe link —
+  // the server replaces the whole ingredient list.
+  food_id?: number | null
+  grams?: number | null
+  sub_recipe_id?: number | null
 }
 
 export interface RecipeToolInput {
@@ -237,6 +283,15 @@ export interface RecipeStepInput {
   timer_seconds: number | null
   meanwhile_text: string | null
   sort_order: number
+  // 0-based positions in the recipe's ingredients and tools lists.
+  ingredients?: StepIngredientInput[] | null
+  tools?: number[] | null
+}
+
+export interface StepIngredientInput {
+  ingredient: number
+  qty: number | null
+  uom: string | null
 }
 
 // CreateRecipeInput is the body for POST /api/recipes.
@@ -256,7 +311,9 @@ export interface CreateRecipeInput {
 }
 
 // UpdateRecipeInput is the body for PUT /api/recipes/:id — all fields optional.
-export type UpdateRecipeInput = Partial<CreateRecipeInput>
+// ai_prompt is sent when saving an ai-modify draft, so the revision is
+// attributed to the AI.
+export type UpdateRecipeInput = Partial<CreateRecipeInput> & { ai_prompt?: string }
 
 /* ─── Habits ─────────────────────────────────────────────────────────────── */
 
@@ -1,7 +1,7 @@
 // API service layer — all backend calls go through request() which handles
 // auth headers, 401 redirects, and consistent error extraction.
 
-import type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, RecipeListItem, RecipeDetail, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput } from './types'
+import type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, RecipeListItem, RecipeListResponse, RecipeDetail, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput } from './types'
 
 // Re-export types so existing imports from api.ts keep working.
 export type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, RecipeListItem, RecipeDetail, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput }
@@ -152,8 +152,14 @@ export function deleteFavorite(id: number) {
 /* ─── Recipe API ──────────────────────────────────────────────────── */
 
 // fetchRecipes returns all recipes for the current user, ordered by last updated.
-export function fetchRecipes() {
-  return request<RecipeListItem[]>('/api/recipes')
+// The list endpoint is paginated; pages are fetched until has_more is false.
+export async function fetchRecipes() {
+  const recipes: RecipeListItem[] = []
+  for (let offset = 0; ; offset += 200) {
+    const page = await request<RecipeListResponse>(`/api/recipes?limit=200&offset=${offset}`)
+    recipes.push(...page.recipes)
+    if (!page.has_more) return recipes
+  }
 }
 
 // fetchRecipe returns the full detail for a single recipe (includes ingredients, tools, steps).
@@ -198,7 +204,7 @@ export function generateRecipe(prompt: string) {
 
 // aiModifyRecipe asks OpenAI to apply a modification to the current recipe.
 // The server does NOT save the result — the caller applies it to the local draft
-// and must call updateRecipe to persist.
+// and must call updateRecipe to persist, passing the prompt as ai_prompt.
 export function aiModifyRecipe(id: number, prompt: string) {
   return request<CreateRecipeInput>(`/api/recipes/${id}/ai-modify`, {
     method: 'POST',
@@ -31,6 +31,9 @@ interface Props {
     protein_g: number | null
     carbs_g: number | null
     fat_g: number | null
+    // Set when the values came from an applied AI suggestion.
+    source?: 'ai'
+    confidence?: number
   }) => void
   favorites: CalorieLogFavorite[]
   onManageFavorites: () => void
@@ -56,6 +59,9 @@ export default function InlineAddRow({ mealType, isOpen, onOpen, onClose, onAdd,
   // Track which fields the user has manually edited so Apply doesn't overwrite them.
   // State (not ref) because dirty fields affect rendered displaySuggestion output.
   const [dirtyFields, setDirtyFields] = useState<Set<string>>(new Set())
+  // The applied AI suggestion's confidence, sent with the item so it's logged
+  // as an AI estimate. Cleared when a favorite fills the row instead.
+  const [aiConfidence, setAiConfidence] = useState<number | null>(null)
 
   const { state: suggestionState, dismiss: dismissSuggestion, markApplied } = useSuggestion(name, mealType)
 
@@ -92,6 +98,7 @@ export default function InlineAddRow({ mealType, isOpen, onOpen, onClose, onAdd,
     setName(''); setQty('1'); setUom('each')
     setCalories(''); setProtein(''); setCarbs(''); setFat('')
     setDirtyFields(new Set())
+    setAiConfidence(null)
     setShowFavorites(false)
     onClose()
   }
@@ -110,6 +117,7 @@ export default function InlineAddRow({ mealType, isOpen, onOpen, onClose, onAdd,
     setFat(scaled.fat_g != null ? String(scaled.fat_g) : '')
     // Mark all fields dirty so AI suggestion won't overwrite the filled values
     setDirtyFields(new Set(['name', 'qty', 'uom', 'calories', 'protein', 'carbs', 'fat']))
+    setAiConfidence(null)
     setShowFavorites(false)
   }
 
@@ -123,6 +131,7 @@ export default function InlineAddRow({ mealType, isOpen, onOpen, onClose, onAdd,
       protein_g: !isExercise && protein ? parseFloat(protein) : null,
       carbs_g: !isExercise && carbs ? parseFloat(carbs) : null,
       fat_g: !isExercise && fat ? parseFloat(fat) : null,
+      ...(aiConfidence != null && { source: 'ai' as const, confidence: aiConfidence }),
     })
     handleClose()
   }
@@ -139,6 +148,7 @@ export default function InlineAddRow({ mealType, isOpen, onOpen, onClose, onAdd,
       if (!dirtyFields.has('carbs')) setCarbs(suggestion.carbs_g.toString())
       if (!dirtyFields.has('fat')) setFat(suggestion.fat_g.toString())
     }
+    setAiConfidence(suggestion.confidence)
     markApplied(suggestion.item_name)
   }
 
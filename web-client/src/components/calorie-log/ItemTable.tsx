@@ -38,6 +38,7 @@ interface Props {
   onInlineAdd: (type: string, fields: {
     name: string; qty: number | null; uom: string | null; calories: number
     protein_g: number | null; carbs_g: number | null; fat_g: number | null
+    source?: 'ai'; confidence?: number
   }) => void
   onUpdateItem: (id: number, field: string, value: unknown) => Promise<boolean>
   onItemAction: (item: CalorieLogItem, position: { x: number; y: number }) => void
@@ -216,6 +217,7 @@ function MealSection({ type, items, editing, editValue, flashKey, skipBlurRef,
   onInlineAdd: (fields: {
     name: string; qty: number | null; uom: string | null; calories: number
     protein_g: number | null; carbs_g: number | null; fat_g: number | null
+    source?: 'ai'; confidence?: number
   }) => void
   isAddOpen: boolean
   onAddOpen: () => void
@@ -27,11 +27,15 @@ const mockRecipes = [
     updated_at: '2026-01-01T00:00:00Z',
     step_count: 2,
     total_timer_seconds: 300,
+    tags: [],
+    last_cooked_on: null,
+    times_cooked: 0,
+    avg_rating: null,
   },
 ]
 
 const server = setupServer(
-  http.get('/api/recipes', () => HttpResponse.json(mockRecipes)),
+  http.get('/api/recipes', () => HttpResponse.json({ recipes: mockRecipes, has_more: false })),
   http.get('/api/calorie-log/favorites', () => HttpResponse.json([])),
   // Silence AI suggestion requests so they don't produce unhandled warnings
   http.post('/api/calorie-log/suggest', () => HttpResponse.json({ error: 'unrecognized' })),
@@ -14,8 +14,9 @@ interface Props {
   onClose: () => void
   mode: 'modify' | 'copy'
   recipe: RecipeDetail
-  // Called with the AI-generated draft. Parent merges it into its local state.
-  onResult: (draft: CreateRecipeInput) => void
+  // Called with the AI-generated draft and the prompt that produced it. Parent
+  // merges the draft into its local state.
+  onResult: (draft: CreateRecipeInput, prompt: string) => void
 }
 
 export default function AIModifySheet({ open, onClose, mode, recipe, onResult }: Props) {
@@ -45,7 +46,7 @@ export default function AIModifySheet({ open, onClose, mode, recipe, onResult }:
       const draft = mode === 'modify'
         ? await aiModifyRecipe(recipe.id, prompt.trim())
         : await aiCopyRecipe(recipe.id, prompt.trim())
-      onResult(draft)
+      onResult(draft, prompt.trim())
       onClose()
     } catch (err) {
       setError(err instanceof Error ? err.message : 'AI request failed')
@@ -0,0 +1,66 @@
+import { describe, it, expect, vi } from 'vitest'
+import { render, screen, fireEvent, waitFor } from '@testing-library/react'
+import AIModifySheet from '../AIModifySheet'
+import type { RecipeDetail, CreateRecipeInput } from '../../../types'
+
+/* ─── Mocks ──────────────────────────────────────────────────────────── */
+
+// vi.mock is hoisted above the imports, so the draft it returns must be too.
+const aiDraft = vi.hoisted((): CreateRecipeInput => ({
+  name: 'Vegan Pasta',
+  emoji: '🍝',
+  category: 'dinner',
+  notes: null,
+  servings: 1,
+  calories: 350,
+  protein_g: 12,
+  carbs_g: 60,
+  fat_g: 8,
+  ingredients: [],
+  tools: [],
+  steps: [],
+}))
+
+vi.mock('../../../api', () => ({
+  aiModifyRecipe: vi.fn().mockResolvedValue(aiDraft),
+  aiCopyRecipe: vi.fn().mockResolvedValue(aiDraft),
+}))
+
+import { aiModifyRecipe } from '../../../api'
+const mockModify = aiModifyRecipe as ReturnType<typeof vi.fn>
+
+/* ─── Fixtures ───────────────────────────────────────────────────────── */
+
+const recipe: RecipeDetail = {
+  id: 1,
+  user_id: 1,
+  name: 'Test Pasta',
+  emoji: '🍝',
+  category: 'dinner',
+  notes: null,
+  servings: 1,
+  calories: 400,
+  protein_g: 20,
+  carbs_g: 60,
+  fat_g: 10,
+  created_at: '2026-01-01T00:00:00Z',
+  updated_at: '2026-01-01T00:00:00Z',
+  ingredients: [],
+  tools: [],
+  steps: [],
+}
+
+/* ─── Tests ──────────────────────────────────────────────────────────── */
+
+describe('AIModifySheet', () => {
+  it('passes the prompt along with the draft so the save can send ai_prompt', async () => {
+    const onResult = vi.fn()
+    render(<AIModifySheet open onClose={vi.fn()} mode="modify" recipe={recipe} onResult={onResult} />)
+
+    fireEvent.change(screen.getByRole('textbox'), { target: { value: '  make it vegan  ' } })
+    fireEvent.click(screen.getByRole('button', { name: /Apply Changes/ }))
+
+    await waitFor(() => expect(onResult).toHaveBeenCalledWith(aiDraft, 'make it vegan'))
+    expect(mockModify).toHaveBeenCalledWith(1, 'make it vegan')
+  })
+})
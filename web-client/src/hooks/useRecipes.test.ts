@@ -11,19 +11,21 @@ const mockRecipes: RecipeListItem[] = [
     id: 1, user_id: 1, name: 'Pumpkin Pie', category: 'dessert',
     emoji: '🎃', servings: 8, calories: 320, protein_g: 6, carbs_g: 45, fat_g: 13,
     created_at: '2026-01-01T00:00:00Z', updated_at: '2026-01-01T00:00:00Z',
-    step_count: 5, total_timer_seconds: 3900,
+    step_count: 5, total_timer_seconds: 3900, tags: [],
+    last_cooked_on: null, times_cooked: 0, avg_rating: null,
   },
   {
     id: 2, user_id: 1, name: 'Chicken Stir-Fry', category: 'dinner',
     emoji: '🥘', servings: 4, calories: 450, protein_g: 38, carbs_g: 30, fat_g: 14,
     created_at: '2026-01-02T00:00:00Z', updated_at: '2026-01-02T00:00:00Z',
-    step_count: 3, total_timer_seconds: 1200,
+    step_count: 3, total_timer_seconds: 1200, tags: [],
+    last_cooked_on: null, times_cooked: 0, avg_rating: null,
   },
 ]
 
 // MSW intercepts fetch at the network level so real hook logic runs
 const server = setupServer(
-  http.get('/api/recipes', () => HttpResponse.json(mockRecipes))
+  http.get('/api/recipes', () => HttpResponse.json({ recipes: mockRecipes, has_more: false }))
 )
 
 beforeAll(() => server.listen({ onUnhandledRequest: 'error' }))
@@ -64,13 +66,14 @@ describe('useRecipes', () => {
         id: 3, user_id: 1, name: 'New Recipe', category: 'lunch',
         emoji: null, servings: 2, calories: null, protein_g: null, carbs_g: null, fat_g: null,
         created_at: '2026-01-03T00:00:00Z', updated_at: '2026-01-03T00:00:00Z',
-        step_count: 1, total_timer_seconds: 0,
+        step_count: 1, total_timer_seconds: 0, tags: [],
+        last_cooked_on: null, times_cooked: 0, avg_rating: null,
       },
     ]
     server.use(
       http.get('/api/recipes', () => {
         callCount++
-        return HttpResponse.json(callCount === 1 ? mockRecipes : secondList)
+        return HttpResponse.json({ recipes: callCount === 1 ? mockRecipes : secondList, has_more: false })
       })
     )
 
@@ -174,6 +174,7 @@ export default function CalorieLog() {
   const handleInlineAdd = async (type: string, fields: {
     name: string; qty: number | null; uom: string | null; calories: number
     protein_g: number | null; carbs_g: number | null; fat_g: number | null
+    source?: 'ai'; confidence?: number
   }) => {
     // Validate type to prevent silent cast of invalid values to the union type.
     if (!ITEM_TYPES.includes(type as CalorieLogItem['type'])) {
@@ -186,6 +187,7 @@ export default function CalorieLog() {
         calories: fields.calories, qty: fields.qty,
         uom: fields.uom as CalorieLogItem['uom'],
         protein_g: fields.protein_g, carbs_g: fields.carbs_g, fat_g: fields.fat_g,
+        source: fields.source, confidence: fields.confidence,
       })
       loadSummary()
     } catch {
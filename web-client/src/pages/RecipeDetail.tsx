@@ -91,12 +91,39 @@ function recipeToEdit(r: RecipeDetailType): Draft {
     protein_g:  r.protein_g != null ? String(r.protein_g) : '',
     carbs_g:    r.carbs_g   != null ? String(r.carbs_g)   : '',
     fat_g:      r.fat_g     != null ? String(r.fat_g)     : '',
-    ingredients: r.ingredients.map(i => ({ name: i.name, qty: i.qty, uom: i.uom, note: i.note, sort_order: i.sort_order })),
+    ingredients: r.ingredients.map(i => ({
+      name: i.name, qty: i.qty, uom: i.uom, note: i.note, sort_order: i.sort_order,
+      food_id: i.food_id, grams: i.grams, sub_recipe_id: i.sub_recipe_id,
+    })),
     tools:       r.tools.map(t => ({ name: t.name, sort_order: t.sort_order })),
-    steps:       r.steps.map(s => ({ type: s.type, text: s.text, timer_seconds: s.timer_seconds, meanwhile_text: s.meanwhile_text, sort_order: s.sort_order })),
+    steps:       r.steps.map(s => ({
+      type: s.type, text: s.text, timer_seconds: s.timer_seconds, meanwhile_text: s.meanwhile_text, sort_order: s.sort_order,
+      // Step references go by position in the ingredient and tool lists.
+      ingredients: (s.ingredients ?? []).map(ref => ({
+        ingredient: r.ingredients.findIndex(i => i.id === ref.ingredient_id), qty: ref.qty, uom: ref.uom,
+      })).filter(ref => ref.ingredient >= 0),
+      tools: (s.tools ?? []).map(ref => r.tools.findIndex(t => t.id === ref.tool_id)).filter(i => i >= 0),
+    })),
   }
 }
 
+// Drop step references to the ingredient or tool removed at position i and
+// shift the ones after it down, so they keep pointing at the same item.
+const shiftPosition = (p: number, i: number) => (p > i ? p - 1 : p)
+
+function removeStepIngredientRefs(steps: RecipeStepInput[], i: number): RecipeStepInput[] {
+  return steps.map(s => ({
+    ...s,
+    ingredients: (s.ingredients ?? [])
+      .filter(ref => ref.ingredient !== i)
+      .map(ref => ({ ...ref, ingredient: shiftPosition(ref.ingredient, i) })),
+  }))
+}
+
+function removeStepToolRefs(steps: RecipeStepInput[], i: number): RecipeStepInput[] {
+  return steps.map(s => ({ ...s, tools: (s.tools ?? []).filter(p => p !== i).map(p => shiftPosition(p, i)) }))
+}
+
 function draftToInput(d: Draft): CreateRecipeInput {
   return {
     name:        d.name,
@@ -108,13 +135,30 @@ function draftToInput(d: Draft): CreateRecipeInput {
     protein_g:   d.protein_g !== '' ? Number(d.protein_g) : null,
     carbs_g:     d.carbs_g   !== '' ? Number(d.carbs_g)   : null,
     fat_g:       d.fat_g     !== '' ? Number(d.fat_g)     : null,
-    ingredients: d.ingredients,
+    // Links (food_id, grams, sub_recipe_id) ride along on each ingredient so a save keeps them.
+    ingredients: d.ingredients.map(i => ({
+      name: i.name, qty: i.qty, uom: i.uom, note: i.note, sort_order: i.sort_order,
+      food_id: i.food_id ?? null, grams: i.grams ?? null, sub_recipe_id: i.sub_recipe_id ?? null,
+    })),
     tools:       d.tools,
     steps:       d.steps,
   }
 }
 
 // Merge an AI draft response into the current draft (keeps the existing id)
+// The AI doesn't see food or recipe links; keep them on ingredients it left
+// alone (same name, qty, and uom), as the server does for ai-modify ?save=true.
+function keepIngredientLinks(current: RecipeIngredientInput[], ai: RecipeIngredientInput[]): RecipeIngredientInput[] {
+  const key = (i: RecipeIngredientInput) => `${i.name.trim().toLowerCase()}|${i.qty}|${i.uom}`
+  const linked = new Map(current.filter(i => i.food_id != null || i.sub_recipe_id != null).map(i => [key(i), i]))
+  return ai.map(i => {
+    const old = linked.get(key(i))
+    return old && i.food_id == null && i.sub_recipe_id == null
+      ? { ...i, food_id: old.food_id, grams: old.grams, sub_recipe_id: old.sub_recipe_id }
+      : i
+  })
+}
+
 function mergeAIDraft(current: Draft, ai: CreateRecipeInput, clearId = false): Draft {
   return {
     id:          clearId ? null : current.id,
@@ -127,7 +171,7 @@ function mergeAIDraft(current: Draft, ai: CreateRecipeInput, clearId = false): D
     protein_g:   ai.protein_g  != null ? String(ai.protein_g) : current.protein_g,
     carbs_g:     ai.carbs_g    != null ? String(ai.carbs_g)   : current.carbs_g,
     fat_g:       ai.fat_g      != null ? String(ai.fat_g)     : current.fat_g,
-    ingredients: ai.ingredients ?? current.ingredients,
+    ingredients: ai.ingredients ? keepIngredientLinks(current.ingredients, ai.ingredients) : current.ingredients,
     tools:       ai.tools       ?? current.tools,
     steps:       ai.steps       ?? current.steps,
   }
@@ -255,6 +299,9 @@ export default function RecipeDetail() {
   const [saving,  setSaving]  = useState(false)
   const [saveErr, setSaveErr] = useState('')
   const [showDeleteConfirm, setShowDeleteConfirm] = useState(false)
+  // Prompts of the AI modifications applied to the draft since the last save,
+  // sent as ai_prompt so the saved revision is attributed to the AI.
+  const [aiPrompts, setAIPrompts] = useState<string[]>([])
 
   // Sheet state
   const [logSheetOpen,    setLogSheetOpen]    = useState(false)
@@ -275,6 +322,7 @@ export default function RecipeDetail() {
   const enterEdit = () => {
     if (recipe) setDraft(recipeToEdit(recipe))
     setDirty(false)
+    setAIPrompts([])
     setSaveErr('')
     setMode('edit')
   }
@@ -283,6 +331,7 @@ export default function RecipeDetail() {
     if (dirty && !confirm('Discard unsaved changes?')) return
     setMode('view')
     setDirty(false)
+    setAIPrompts([])
   }
 
   const updateDraft = useCallback(<K extends keyof Draft>(key: K, value: Draft[K]) => {
@@ -307,11 +356,15 @@ export default function RecipeDetail() {
         navigate(`/recipes/${created.id}`, { replace: true })
       } else {
         // Update
-        const updated = await updateRecipe(draft.id, input)
+        const updated = await updateRecipe(draft.id, {
+          ...input,
+          ...(aiPrompts.length > 0 && { ai_prompt: aiPrompts.join('\n') }),
+        })
         setRecipe(updated)
         setDraft(recipeToEdit(updated))
         setMode('view')
         setDirty(false)
+        setAIPrompts([])
       }
     } catch (err) {
       setSaveErr(err instanceof Error ? err.message : 'Save failed')
@@ -371,7 +424,11 @@ export default function RecipeDetail() {
   }
 
   const removeIngredient = (i: number) => {
-    setDraft(prev => ({ ...prev, ingredients: prev.ingredients.filter((_, idx) => idx !== i) }))
+    setDraft(prev => ({
+      ...prev,
+      ingredients: prev.ingredients.filter((_, idx) => idx !== i),
+      steps:       removeStepIngredientRefs(prev.steps, i),
+    }))
     setDirty(true)
   }
 
@@ -385,7 +442,11 @@ export default function RecipeDetail() {
   }
 
   const removeTool = (i: number) => {
-    setDraft(prev => ({ ...prev, tools: prev.tools.filter((_, idx) => idx !== i) }))
+    setDraft(prev => ({
+      ...prev,
+      tools: prev.tools.filter((_, idx) => idx !== i),
+      steps: removeStepToolRefs(prev.steps, i),
+    }))
     setDirty(true)
   }
 
@@ -889,8 +950,9 @@ export default function RecipeDetail() {
             onClose={() => setAIModifyOpen(false)}
             mode="modify"
             recipe={recipe}
-            onResult={aiDraft => {
+            onResult={(aiDraft, prompt) => {
               setDraft(prev => mergeAIDraft(prev, aiDraft, false))
+              setAIPrompts(prev => [...prev, prompt])
               setDirty(true)
             }}
           />
@@ -148,6 +148,35 @@ function StepBadge({ step, index }: { step: RecipeStep; index: number }) {
   )
 }
 
+/* ─── StepNeeds ────────────────────────────────────────────────────── */
+
+// "You need" card listing the ingredients (with the amount for this step)
+// and tools a step uses. Renders nothing for steps without references.
+function StepNeeds({ step }: { step: RecipeStep }) {
+  const ingredients = step.ingredients ?? []
+  const tools = step.tools ?? []
+  if (ingredients.length === 0 && tools.length === 0) return null
+
+  return (
+    <div className="mb-5 bg-gray-50 border border-gray-100 rounded-2xl p-4">
+      <p className="text-gray-500 text-xs font-semibold mb-2">You need</p>
+      <ul className="space-y-1">
+        {ingredients.map(ing => {
+          const qty = ing.qty != null ? `${ing.qty_text ?? ing.qty}${ing.uom ? ` ${ing.uom}` : ''} ` : ''
+          return (
+            <li key={`i${ing.ingredient_id}`} className="text-sm text-gray-800">
+              {qty && <span className="font-medium">{qty}</span>}{ing.name}
+            </li>
+          )
+        })}
+        {tools.map(tool => (
+          <li key={`t${tool.tool_id}`} className="text-sm text-gray-500">{tool.name}</li>
+        ))}
+      </ul>
+    </div>
+  )
+}
+
 /* ─── TimerPanel ────────────────────────────────────────────────────── */
 
 interface TimerPanelProps {
@@ -363,6 +392,8 @@ export default function RecipeExecution() {
           <StepBadge step={step} index={stepIndex} />
         </div>
 
+        <StepNeeds step={step} />
+
         {step.type === 'instruction' ? (
           /* ── Instruction step ──────────────────────────────────────── */
           <p className="text-gray-900 text-2xl leading-relaxed font-light">
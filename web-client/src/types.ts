@@ -1,3 +1,3 @@
 // Types live in packages/shared — re-exported here so existing import paths still work.
-export type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, Recipe, RecipeListItem, RecipeIngredient, RecipeTool, RecipeStep, RecipeDetail, RecipeIngredientInput, RecipeToolInput, RecipeStepInput, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalTag, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalMentalStateBar, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput } from '@stride/shared'
+export type { AISuggestion, CalorieLogItem, CalorieLogUserSettings, DailySummary, WeekDaySummary, WeekSummaryResponse, WeightEntry, ProgressStats, ProgressResponse, CalorieLogFavorite, Recipe, RecipeListItem, RecipeListResponse, RecipeIngredient, RecipeTool, RecipeStep, StepIngredient, StepTool, RecipeDetail, RecipeIngredientInput, RecipeToolInput, RecipeStepInput, StepIngredientInput, CreateRecipeInput, UpdateRecipeInput, Habit, HabitLog, HabitWithLog, HabitWeekEntry, CreateHabitInput, UpdateHabitInput, JournalTag, JournalEntry, JournalSummaryResponse, JournalSummaryRange, JournalCalendarDay, JournalMentalStateBar, JournalTagDay, CreateJournalEntryInput, UpdateJournalEntryInput, Task, TaskListResponse, CreateTaskInput, UpdateTaskInput, CompleteTaskResponse, MealPlanEntry, CreateMealPlanEntryInput, UpdateMealPlanEntryInput, CopyWeekInput } from '@stride/shared'
 export { EMOTION_TAGS, CONDITION_TAGS, ENTRY_TYPE_TAGS } from '@stride/shared'